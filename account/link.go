@@ -0,0 +1,68 @@
+// Package account covers the person behind the courier, customer and admin profiles:
+// one User can hold several profiles and switch between them.
+package account
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/entity"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrPhoneLinkedToOtherAccount is returned when a registration's phone belongs to a
+	// user signed in with a different Firebase UID.
+	ErrPhoneLinkedToOtherAccount = errors.New("phone is registered to another account")
+	// ErrPhoneNotVerified is returned when a registration's phone belongs to an existing
+	// user but the caller has not proven they control it.
+	ErrPhoneNotVerified = errors.New("phone is registered to another account; verify the phone to add a profile")
+)
+
+// UserStore is the part of a profile repository needed to link a registration to an
+// existing user.
+type UserStore interface {
+	GetUserByFirebaseUID(ctx context.Context, uid string) (*entity.User, error)
+	GetUserByPhone(ctx context.Context, phone string) (*entity.User, error)
+	StoreUser(ctx context.Context, u *entity.User) (*entity.User, error)
+	SetUserFirebaseUID(ctx context.Context, userID uuid.UUID, uid string) error
+}
+
+// FindOrCreateUser returns the user a new profile should attach to: the one with u's
+// Firebase UID, else the one with u's phone, else u itself once stored. u's Firebase UID
+// must come from a verified ID token. A phone match is only reused when phoneVerified
+// (the caller proved the phone by OTP or by an ID token for that number) and the user
+// has no other Firebase UID (it then takes u's), so a phone number in a request body
+// never grants access to someone else's account.
+func FindOrCreateUser(ctx context.Context, store UserStore, u *entity.User, phoneVerified bool) (*entity.User, error) {
+	if u.FirebaseUID != nil {
+		existing, err := store.GetUserByFirebaseUID(ctx, *u.FirebaseUID)
+		if err == nil {
+			return existing, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+	existing, err := store.GetUserByPhone(ctx, u.Phone)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return store.StoreUser(ctx, u)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !phoneVerified {
+		return nil, ErrPhoneNotVerified
+	}
+	if u.FirebaseUID != nil {
+		if existing.FirebaseUID != nil {
+			return nil, ErrPhoneLinkedToOtherAccount
+		}
+		if err := store.SetUserFirebaseUID(ctx, existing.ID, *u.FirebaseUID); err != nil {
+			return nil, err
+		}
+		existing.FirebaseUID = u.FirebaseUID
+	}
+	return existing, nil
+}
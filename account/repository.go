@@ -0,0 +1,39 @@
+package account
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/entity"
+)
+
+// Repository reads a user's data for export and anonymizes it on deletion.
+type Repository interface {
+	GetUserByID(ctx context.Context, id uuid.UUID) (*entity.User, error)
+	GetCourierByUserID(ctx context.Context, userID uuid.UUID) (*entity.Courier, error)
+	GetCustomerByUserID(ctx context.Context, userID uuid.UUID) (*entity.Customer, error)
+	HasAdminProfile(ctx context.Context, userID uuid.UUID) (bool, error)
+	ListOrdersForCustomer(ctx context.Context, customerID uuid.UUID) ([]entity.Order, error)
+	ListOrdersForCourier(ctx context.Context, courierID uuid.UUID) ([]entity.Order, error)
+	ListAssignmentAttempts(ctx context.Context, courierID uuid.UUID) ([]entity.OrderAssignmentAttempt, error)
+	ListGuarantyPayments(ctx context.Context, courierID uuid.UUID) ([]entity.GuarantyPayment, error)
+	ListCourierDocuments(ctx context.Context, courierID uuid.UUID) ([]entity.CourierDocument, error)
+	ListChatMessages(ctx context.Context, senderIDs []uuid.UUID) ([]entity.ChatMessage, error)
+	ListSupportTickets(ctx context.Context, openerIDs []uuid.UUID) ([]entity.SupportTicket, error)
+	// ListTicketMessages returns the whole conversation, support replies included, of the
+	// tickets the profiles opened.
+	ListTicketMessages(ctx context.Context, openerIDs []uuid.UUID) ([]entity.TicketMessage, error)
+	ListSessions(ctx context.Context, userID uuid.UUID) ([]entity.AuthSession, error)
+	// CountActiveOrders counts orders in progress where the user is customer or courier.
+	CountActiveOrders(ctx context.Context, userID uuid.UUID) (int64, error)
+
+	CreateDeletionRequest(ctx context.Context, r *entity.AccountDeletionRequest) error
+	// GetPendingDeletion returns the user's deletion request that is neither canceled nor completed.
+	GetPendingDeletion(ctx context.Context, userID uuid.UUID) (*entity.AccountDeletionRequest, error)
+	CancelDeletion(ctx context.Context, id uuid.UUID, at time.Time) error
+	ListDueDeletions(ctx context.Context, now time.Time, limit int) ([]entity.AccountDeletionRequest, error)
+	// Anonymize scrubs the user's personal data and completes the request in one transaction.
+	// It returns the blob keys of deleted uploads for the caller to remove from storage.
+	Anonymize(ctx context.Context, r *entity.AccountDeletionRequest, at time.Time) ([]string, error)
+}
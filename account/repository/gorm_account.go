@@ -0,0 +1,344 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/account"
+	"github.com/mikios34/delivery-backend/audit"
+	"github.com/mikios34/delivery-backend/entity"
+	"gorm.io/gorm"
+)
+
+// finishedStatuses are the order states that no longer need the user's data.
+var finishedStatuses = []entity.OrderStatus{
+	entity.OrderNoNearbyDriver,
+	entity.OrderDelivered,
+	entity.OrderCanceledByCustomer,
+	entity.OrderCanceledByCourier,
+	entity.OrderCanceledByAdmin,
+	entity.OrderDeclined,
+}
+
+type GormAccountRepo struct {
+	db *gorm.DB
+}
+
+func NewGormAccountRepo(db *gorm.DB) account.Repository {
+	return &GormAccountRepo{db: db}
+}
+
+func (r *GormAccountRepo) GetUserByID(ctx context.Context, id uuid.UUID) (*entity.User, error) {
+	var u entity.User
+	if err := r.db.WithContext(ctx).First(&u, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (r *GormAccountRepo) GetCourierByUserID(ctx context.Context, userID uuid.UUID) (*entity.Courier, error) {
+	var c entity.Courier
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&c).Error; err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *GormAccountRepo) GetCustomerByUserID(ctx context.Context, userID uuid.UUID) (*entity.Customer, error) {
+	var c entity.Customer
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&c).Error; err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *GormAccountRepo) HasAdminProfile(ctx context.Context, userID uuid.UUID) (bool, error) {
+	var n int64
+	if err := r.db.WithContext(ctx).Model(&entity.Admin{}).Where("user_id = ?", userID).Count(&n).Error; err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (r *GormAccountRepo) ListOrdersForCustomer(ctx context.Context, customerID uuid.UUID) ([]entity.Order, error) {
+	var list []entity.Order
+	if err := r.db.WithContext(ctx).Where("customer_id = ?", customerID).Order("created_at").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *GormAccountRepo) ListOrdersForCourier(ctx context.Context, courierID uuid.UUID) ([]entity.Order, error) {
+	var list []entity.Order
+	if err := r.db.WithContext(ctx).Where("assigned_courier = ?", courierID).Order("created_at").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *GormAccountRepo) ListAssignmentAttempts(ctx context.Context, courierID uuid.UUID) ([]entity.OrderAssignmentAttempt, error) {
+	var list []entity.OrderAssignmentAttempt
+	if err := r.db.WithContext(ctx).Where("courier_id = ?", courierID).Order("created_at").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *GormAccountRepo) ListGuarantyPayments(ctx context.Context, courierID uuid.UUID) ([]entity.GuarantyPayment, error) {
+	var list []entity.GuarantyPayment
+	if err := r.db.WithContext(ctx).Where("courier_id = ?", courierID).Order("created_at").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *GormAccountRepo) ListCourierDocuments(ctx context.Context, courierID uuid.UUID) ([]entity.CourierDocument, error) {
+	var list []entity.CourierDocument
+	if err := r.db.WithContext(ctx).Where("courier_id = ?", courierID).Order("created_at").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *GormAccountRepo) ListChatMessages(ctx context.Context, senderIDs []uuid.UUID) ([]entity.ChatMessage, error) {
+	list := []entity.ChatMessage{}
+	if len(senderIDs) == 0 {
+		return list, nil
+	}
+	if err := r.db.WithContext(ctx).Where("sender_id IN ?", senderIDs).Order("created_at").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *GormAccountRepo) ListSupportTickets(ctx context.Context, openerIDs []uuid.UUID) ([]entity.SupportTicket, error) {
+	list := []entity.SupportTicket{}
+	if len(openerIDs) == 0 {
+		return list, nil
+	}
+	if err := r.db.WithContext(ctx).Where("opener_id IN ?", openerIDs).Order("created_at").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *GormAccountRepo) ListTicketMessages(ctx context.Context, openerIDs []uuid.UUID) ([]entity.TicketMessage, error) {
+	list := []entity.TicketMessage{}
+	if len(openerIDs) == 0 {
+		return list, nil
+	}
+	tickets := r.db.WithContext(ctx).Model(&entity.SupportTicket{}).Select("id").Where("opener_id IN ?", openerIDs)
+	if err := r.db.WithContext(ctx).Preload("Attachments").Where("ticket_id IN (?)", tickets).Order("created_at").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *GormAccountRepo) ListSessions(ctx context.Context, userID uuid.UUID) ([]entity.AuthSession, error) {
+	var list []entity.AuthSession
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *GormAccountRepo) CountActiveOrders(ctx context.Context, userID uuid.UUID) (int64, error) {
+	db := r.db.WithContext(ctx)
+	customerIDs := db.Model(&entity.Customer{}).Select("id").Where("user_id = ?", userID)
+	courierIDs := db.Model(&entity.Courier{}).Select("id").Where("user_id = ?", userID)
+	var n int64
+	err := db.Model(&entity.Order{}).
+		Where("customer_id IN (?) OR assigned_courier IN (?)", customerIDs, courierIDs).
+		Where("status NOT IN ?", finishedStatuses).
+		Count(&n).Error
+	return n, err
+}
+
+func (r *GormAccountRepo) CreateDeletionRequest(ctx context.Context, req *entity.AccountDeletionRequest) error {
+	return r.db.WithContext(ctx).Create(req).Error
+}
+
+func (r *GormAccountRepo) GetPendingDeletion(ctx context.Context, userID uuid.UUID) (*entity.AccountDeletionRequest, error) {
+	var req entity.AccountDeletionRequest
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND canceled_at IS NULL AND completed_at IS NULL", userID).
+		Order("created_at DESC").
+		First(&req).Error
+	if err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+func (r *GormAccountRepo) CancelDeletion(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&entity.AccountDeletionRequest{}).
+		Where("id = ? AND canceled_at IS NULL AND completed_at IS NULL", id).
+		Update("canceled_at", at).Error
+}
+
+func (r *GormAccountRepo) ListDueDeletions(ctx context.Context, now time.Time, limit int) ([]entity.AccountDeletionRequest, error) {
+	var list []entity.AccountDeletionRequest
+	err := r.db.WithContext(ctx).
+		Where("scheduled_for <= ? AND canceled_at IS NULL AND completed_at IS NULL", now).
+		Order("scheduled_for").
+		Limit(limit).
+		Find(&list).Error
+	return list, err
+}
+
+// Anonymize overwrites names, phone numbers, addresses, coordinates, chat and ticket text, and
+// removes login identifiers. Orders and guaranty payments keep their amounts and states
+// for bookkeeping; UpdateColumns leaves their timestamps untouched.
+func (r *GormAccountRepo) Anonymize(ctx context.Context, req *entity.AccountDeletionRequest, at time.Time) ([]string, error) {
+	var blobKeys []string
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var user entity.User
+		if err := tx.First(&user, "id = ?", req.UserID).Error; err != nil {
+			return err
+		}
+		var customerIDs, courierIDs []uuid.UUID
+		if err := tx.Model(&entity.Customer{}).Where("user_id = ?", user.ID).Pluck("id", &customerIDs).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&entity.Courier{}).Where("user_id = ?", user.ID).Pluck("id", &courierIDs).Error; err != nil {
+			return err
+		}
+
+		if len(customerIDs) > 0 {
+			if err := tx.Model(&entity.Order{}).Where("customer_id IN ?", customerIDs).UpdateColumns(map[string]interface{}{
+				"receiver_phone":  "",
+				"pickup_address":  "",
+				"pickup_lat":      nil,
+				"pickup_lng":      nil,
+				"dropoff_address": "",
+				"dropoff_lat":     nil,
+				"dropoff_lng":     nil,
+			}).Error; err != nil {
+				return err
+			}
+			// Public tracking links would otherwise still resolve to the (now empty) orders.
+			orderIDs := tx.Model(&entity.Order{}).Select("id").Where("customer_id IN ?", customerIDs)
+			if err := tx.Where("order_id IN (?)", orderIDs).Delete(&entity.OrderTrackingLink{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&entity.Customer{}).Where("id IN ?", customerIDs).UpdateColumns(map[string]interface{}{
+				"profile_picture": nil,
+				"active":          false,
+			}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("id IN ?", customerIDs).Delete(&entity.Customer{}).Error; err != nil {
+				return err
+			}
+			// Support notes are about the person; they go with the profile.
+			if err := tx.Where("customer_id IN ?", customerIDs).Delete(&entity.CustomerNote{}).Error; err != nil {
+				return err
+			}
+		}
+		if len(courierIDs) > 0 {
+			if err := tx.Model(&entity.Courier{}).Where("id IN ?", courierIDs).UpdateColumns(map[string]interface{}{
+				"vehicle_details":     "",
+				"latitude":            nil,
+				"longitude":           nil,
+				"location_updated_at": nil,
+				"active":              false,
+				"available":           false,
+			}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("id IN ?", courierIDs).Delete(&entity.Courier{}).Error; err != nil {
+				return err
+			}
+			// Identity documents go for good, including ones already replaced.
+			if err := tx.Unscoped().Model(&entity.CourierDocument{}).Where("courier_id IN ?", courierIDs).
+				Pluck("blob_key", &blobKeys).Error; err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Where("courier_id IN ?", courierIDs).Delete(&entity.CourierDocument{}).Error; err != nil {
+				return err
+			}
+		}
+		senders := append(append([]uuid.UUID{}, customerIDs...), courierIDs...)
+		if len(senders) > 0 {
+			if err := tx.Model(&entity.ChatMessage{}).Where("sender_id IN ?", senders).
+				UpdateColumn("body", "[deleted]").Error; err != nil {
+				return err
+			}
+			// Tickets stay for the refund history; their text and images go.
+			if err := tx.Model(&entity.TicketMessage{}).Where("author_id IN ?", senders).
+				UpdateColumn("body", "[deleted]").Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&entity.SupportTicket{}).Where("opener_id IN ?", senders).
+				UpdateColumn("subject", "[deleted]").Error; err != nil {
+				return err
+			}
+			// Only images the user sent; support's own evidence stays with the ticket.
+			ownMessages := tx.Model(&entity.TicketMessage{}).Select("id").Where("author_id IN ?", senders)
+			var imageKeys []string
+			if err := tx.Model(&entity.TicketAttachment{}).Where("message_id IN (?)", ownMessages).
+				Pluck("blob_key", &imageKeys).Error; err != nil {
+				return err
+			}
+			blobKeys = append(blobKeys, imageKeys...)
+			if err := tx.Where("message_id IN (?)", ownMessages).Delete(&entity.TicketAttachment{}).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&entity.AuthSession{}).Where("user_id = ?", user.ID).UpdateColumns(map[string]interface{}{
+			"device_name": "",
+			"user_agent":  "",
+			"ip":          "",
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&entity.AuthSession{}).Where("user_id = ? AND revoked_at IS NULL", user.ID).UpdateColumns(map[string]interface{}{
+			"revoked_at":     at,
+			"revoked_reason": "account_deleted",
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("phone = ?", user.Phone).Delete(&entity.OTPCode{}).Error; err != nil {
+			return err
+		}
+		// Audit entries stay as security records, without where the user acted from and
+		// without snapshots of their profiles, which hold names, phones and vehicle details.
+		if err := tx.Model(&entity.AuditLog{}).Where("actor_user_id = ?", user.ID.String()).UpdateColumns(map[string]interface{}{
+			"ip":         "",
+			"user_agent": "",
+		}).Error; err != nil {
+			return err
+		}
+		targets := []string{user.ID.String()}
+		for _, id := range senders {
+			targets = append(targets, id.String())
+		}
+		if err := tx.Model(&entity.AuditLog{}).
+			Where("target_type IN ? AND target_id IN ?", []string{audit.TargetUser, audit.TargetCourier, audit.TargetCustomer}, targets).
+			UpdateColumns(map[string]interface{}{"before": nil, "after": nil}).Error; err != nil {
+			return err
+		}
+		// The phone column is not null; keep a unique placeholder so it can't match a login.
+		if err := tx.Model(&entity.User{}).Where("id = ?", user.ID).UpdateColumns(map[string]interface{}{
+			"first_name":      "Deleted",
+			"last_name":       "User",
+			"phone":           "deleted:" + user.ID.String(),
+			"firebase_uid":    nil,
+			"phone_verified":  false,
+			"profile_picture": nil,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&entity.User{}, "id = ?", user.ID).Error; err != nil {
+			return err
+		}
+		return tx.Model(&entity.AccountDeletionRequest{}).Where("id = ?", req.ID).
+			UpdateColumn("completed_at", at).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return blobKeys, nil
+}
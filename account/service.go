@@ -0,0 +1,67 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/entity"
+)
+
+var (
+	// ErrDeletionPending is returned when a deletion was already requested.
+	ErrDeletionPending = errors.New("account deletion already requested")
+	// ErrNoPendingDeletion is returned when there is no deletion to look up or cancel.
+	ErrNoPendingDeletion = errors.New("no pending account deletion")
+	// ErrActiveOrders is returned while the user still has orders in progress.
+	ErrActiveOrders = errors.New("account has orders in progress")
+	// ErrAdminAccount is returned for users holding an admin profile; a super admin
+	// removes those instead.
+	ErrAdminAccount = errors.New("admin accounts cannot be deleted from the app")
+)
+
+// Export is everything stored about a user, as handed out by GET /account/export.
+type Export struct {
+	ExportedAt time.Time        `json:"exported_at"`
+	User       entity.User      `json:"user"`
+	Courier    *entity.Courier  `json:"courier,omitempty"`
+	Customer   *entity.Customer `json:"customer,omitempty"`
+	// Orders placed as a customer.
+	Orders []entity.Order `json:"orders"`
+	// CourierOrders are orders assigned to the user as a courier.
+	CourierOrders      []CourierOrder                  `json:"courier_orders"`
+	AssignmentAttempts []entity.OrderAssignmentAttempt `json:"assignment_attempts"`
+	GuarantyPayments   []entity.GuarantyPayment        `json:"guaranty_payments"`
+	CourierDocuments   []entity.CourierDocument        `json:"courier_documents"`
+	ChatMessages       []entity.ChatMessage            `json:"chat_messages"`
+	SupportTickets     []entity.SupportTicket          `json:"support_tickets"`
+	TicketMessages     []entity.TicketMessage          `json:"ticket_messages"`
+	Sessions           []entity.AuthSession            `json:"sessions"`
+	DeletionRequest    *entity.AccountDeletionRequest  `json:"deletion_request,omitempty"`
+}
+
+// CourierOrder is an order delivered by the user, without the customer's receiver phone,
+// addresses or coordinates: those are the customer's personal data, not the courier's.
+type CourierOrder struct {
+	ID          uuid.UUID          `json:"id"`
+	Status      entity.OrderStatus `json:"status"`
+	PriceCents  int64              `json:"price_cents"`
+	CreatedAt   time.Time          `json:"created_at"`
+	AcceptedAt  *time.Time         `json:"accepted_at,omitempty"`
+	PickedUpAt  *time.Time         `json:"picked_up_at,omitempty"`
+	DeliveredAt *time.Time         `json:"delivered_at,omitempty"`
+	CanceledAt  *time.Time         `json:"canceled_at,omitempty"`
+}
+
+// Service exports and deletes a user's personal data.
+type Service interface {
+	Export(ctx context.Context, userID uuid.UUID) (*Export, error)
+	// RequestDeletion schedules anonymization after the cooling-off period.
+	RequestDeletion(ctx context.Context, userID uuid.UUID, reason string) (*entity.AccountDeletionRequest, error)
+	GetDeletion(ctx context.Context, userID uuid.UUID) (*entity.AccountDeletionRequest, error)
+	CancelDeletion(ctx context.Context, userID uuid.UUID) error
+	// ProcessDueDeletions anonymizes every account whose cooling-off period ended
+	// before now and returns how many were processed.
+	ProcessDueDeletions(ctx context.Context, now time.Time) (int, error)
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/account"
+	"github.com/mikios34/delivery-backend/blob"
+	"github.com/mikios34/delivery-backend/entity"
+	"gorm.io/gorm"
+)
+
+const (
+	// coolingOff is how long a deletion request can still be canceled.
+	coolingOff = 14 * 24 * time.Hour
+	// dueBatch bounds how many accounts one ProcessDueDeletions call anonymizes.
+	dueBatch = 100
+)
+
+// accountService implements account.Service.
+type accountService struct {
+	repo  account.Repository
+	blobs blob.Store
+}
+
+// NewAccountService constructs an account.Service backed by the provided repository.
+// blobs removes uploaded files of deleted accounts; nil leaves them in place.
+func NewAccountService(repo account.Repository, blobs blob.Store) account.Service {
+	return &accountService{repo: repo, blobs: blobs}
+}
+
+func (s *accountService) Export(ctx context.Context, userID uuid.UUID) (*account.Export, error) {
+	u, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	out := &account.Export{
+		ExportedAt:         time.Now().UTC(),
+		User:               *u,
+		Orders:             []entity.Order{},
+		CourierOrders:      []account.CourierOrder{},
+		AssignmentAttempts: []entity.OrderAssignmentAttempt{},
+		GuarantyPayments:   []entity.GuarantyPayment{},
+		CourierDocuments:   []entity.CourierDocument{},
+	}
+	var senders []uuid.UUID
+	if c, err := s.repo.GetCustomerByUserID(ctx, userID); err == nil {
+		out.Customer = c
+		senders = append(senders, c.ID)
+		if out.Orders, err = s.repo.ListOrdersForCustomer(ctx, c.ID); err != nil {
+			return nil, err
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if c, err := s.repo.GetCourierByUserID(ctx, userID); err == nil {
+		out.Courier = c
+		senders = append(senders, c.ID)
+		orders, err := s.repo.ListOrdersForCourier(ctx, c.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range orders {
+			out.CourierOrders = append(out.CourierOrders, account.CourierOrder{
+				ID: o.ID, Status: o.Status, PriceCents: o.EstimatedPriceCents, CreatedAt: o.CreatedAt,
+				AcceptedAt: o.AcceptedAt, PickedUpAt: o.PickedUpAt, DeliveredAt: o.DeliveredAt, CanceledAt: o.CanceledAt,
+			})
+		}
+		if out.AssignmentAttempts, err = s.repo.ListAssignmentAttempts(ctx, c.ID); err != nil {
+			return nil, err
+		}
+		if out.GuarantyPayments, err = s.repo.ListGuarantyPayments(ctx, c.ID); err != nil {
+			return nil, err
+		}
+		if out.CourierDocuments, err = s.repo.ListCourierDocuments(ctx, c.ID); err != nil {
+			return nil, err
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if out.ChatMessages, err = s.repo.ListChatMessages(ctx, senders); err != nil {
+		return nil, err
+	}
+	if out.SupportTickets, err = s.repo.ListSupportTickets(ctx, senders); err != nil {
+		return nil, err
+	}
+	if out.TicketMessages, err = s.repo.ListTicketMessages(ctx, senders); err != nil {
+		return nil, err
+	}
+	if out.Sessions, err = s.repo.ListSessions(ctx, userID); err != nil {
+		return nil, err
+	}
+	if req, err := s.repo.GetPendingDeletion(ctx, userID); err == nil {
+		out.DeletionRequest = req
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *accountService) RequestDeletion(ctx context.Context, userID uuid.UUID, reason string) (*entity.AccountDeletionRequest, error) {
+	isAdmin, err := s.repo.HasAdminProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if isAdmin {
+		return nil, account.ErrAdminAccount
+	}
+	if _, err := s.repo.GetPendingDeletion(ctx, userID); err == nil {
+		return nil, account.ErrDeletionPending
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	active, err := s.repo.CountActiveOrders(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if active > 0 {
+		return nil, account.ErrActiveOrders
+	}
+	req := &entity.AccountDeletionRequest{
+		UserID:       userID,
+		Reason:       strings.TrimSpace(reason),
+		ScheduledFor: time.Now().Add(coolingOff),
+	}
+	if err := s.repo.CreateDeletionRequest(ctx, req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func (s *accountService) GetDeletion(ctx context.Context, userID uuid.UUID) (*entity.AccountDeletionRequest, error) {
+	req, err := s.repo.GetPendingDeletion(ctx, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, account.ErrNoPendingDeletion
+	}
+	return req, err
+}
+
+func (s *accountService) CancelDeletion(ctx context.Context, userID uuid.UUID) error {
+	req, err := s.GetDeletion(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return s.repo.CancelDeletion(ctx, req.ID, time.Now())
+}
+
+// ProcessDueDeletions anonymizes due accounts one by one. Accounts that picked up an
+// active order during the cooling-off period are retried on a later run.
+func (s *accountService) ProcessDueDeletions(ctx context.Context, now time.Time) (int, error) {
+	due, err := s.repo.ListDueDeletions(ctx, now, dueBatch)
+	if err != nil {
+		return 0, err
+	}
+	done := 0
+	for i := range due {
+		req := &due[i]
+		active, err := s.repo.CountActiveOrders(ctx, req.UserID)
+		if err != nil {
+			return done, err
+		}
+		if active > 0 {
+			log.Printf("account deletion %s: user %s has %d active orders; postponed", req.ID, req.UserID, active)
+			continue
+		}
+		keys, err := s.repo.Anonymize(ctx, req, now)
+		if err != nil {
+			return done, err
+		}
+		if s.blobs != nil {
+			for _, k := range keys {
+				if err := s.blobs.Delete(ctx, k); err != nil {
+					log.Printf("account deletion %s: delete blob %s: %v", req.ID, k, err)
+				}
+			}
+		}
+		done++
+	}
+	return done, nil
+}
@@ -0,0 +1,70 @@
+package admin
+
+import "github.com/mikios34/delivery-backend/entity"
+
+// Permission names a privileged capability checked by middleware.RequirePermission.
+type Permission string
+
+const (
+	PermManageAdmins    Permission = "admins.manage"
+	PermViewOrders      Permission = "orders.view"
+	PermManageOrders    Permission = "orders.manage"
+	PermViewCouriers    Permission = "couriers.view"
+	PermManageCouriers  Permission = "couriers.manage"
+	PermViewCustomers   Permission = "customers.view"
+	PermManageCustomers Permission = "customers.manage"
+	PermManageCatalog   Permission = "catalog.manage"
+	PermViewAnalytics   Permission = "analytics.view"
+	PermIssueRefunds    Permission = "refunds.issue"
+	PermManageSupport   Permission = "support.manage"
+	PermAnnounce        Permission = "announcements.manage"
+	PermViewAudit       Permission = "audit.view"
+
+	// PermImpersonate mints read-only tokens acting as a customer or courier;
+	// PermImpersonateWrite also allows tokens that may change data.
+	PermImpersonate      Permission = "users.impersonate"
+	PermImpersonateWrite Permission = "users.impersonate_write"
+)
+
+// rolePermissions is the permission set granted to each admin role.
+// super_admin is handled separately and holds every permission; it is the only role
+// that may impersonate users.
+var rolePermissions = map[entity.AdminRole][]Permission{
+	entity.AdminRoleNone: {},
+	entity.AdminRoleDispatcher: {
+		PermViewOrders, PermManageOrders,
+		PermViewCouriers, PermManageCouriers,
+		PermViewCustomers, PermAnnounce,
+	},
+	entity.AdminRoleFinance: {
+		PermViewOrders, PermViewCustomers,
+		PermViewAnalytics, PermIssueRefunds, PermManageCatalog,
+	},
+	entity.AdminRoleSupport: {
+		PermViewOrders, PermViewCouriers,
+		PermViewCustomers, PermManageCustomers,
+		PermManageSupport, PermAnnounce,
+	},
+}
+
+// ValidRole reports whether role is a known admin role.
+func ValidRole(role entity.AdminRole) bool {
+	if role == entity.AdminRoleSuperAdmin {
+		return true
+	}
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// HasPermission reports whether the admin role grants perm.
+func HasPermission(role entity.AdminRole, perm Permission) bool {
+	if role == entity.AdminRoleSuperAdmin {
+		return true
+	}
+	for _, p := range rolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
@@ -12,5 +12,13 @@ type AdminRepository interface {
 	StoreUser(ctx context.Context, u *entity.User) (*entity.User, error)
 	StoreAdmin(ctx context.Context, a *entity.Admin) (*entity.Admin, error)
 	GetAdminByID(ctx context.Context, id uuid.UUID) (*entity.Admin, error)
-	PhoneExists(ctx context.Context, phone string) (bool, error)
+	GetUserByPhone(ctx context.Context, phone string) (*entity.User, error)
+	GetUserByFirebaseUID(ctx context.Context, uid string) (*entity.User, error)
+	SetUserFirebaseUID(ctx context.Context, userID uuid.UUID, uid string) error
+	GetAdminByUserID(ctx context.Context, userID uuid.UUID) (*entity.Admin, error)
+	// GetAdminByPhone finds the admin profile whose user has the given phone.
+	GetAdminByPhone(ctx context.Context, phone string) (*entity.Admin, error)
+	ListAdmins(ctx context.Context) ([]AdminView, error)
+	UpdateAdmin(ctx context.Context, id uuid.UUID, fields map[string]interface{}) error
+	CountActiveSuperAdmins(ctx context.Context) (int64, error)
 }
@@ -40,10 +40,65 @@ func (r *GormAdminRepo) GetAdminByID(ctx context.Context, id uuid.UUID) (*entity
 	return &a, nil
 }
 
-func (r *GormAdminRepo) PhoneExists(ctx context.Context, phone string) (bool, error) {
-	var count int64
-	if err := r.db.WithContext(ctx).Model(&entity.User{}).Where("phone = ? AND role = ?", phone, "admin").Count(&count).Error; err != nil {
-		return false, err
+func (r *GormAdminRepo) GetUserByPhone(ctx context.Context, phone string) (*entity.User, error) {
+	var u entity.User
+	if err := r.db.WithContext(ctx).Where("phone = ?", phone).Order("created_at").First(&u).Error; err != nil {
+		return nil, err
 	}
-	return count > 0, nil
+	return &u, nil
+}
+
+func (r *GormAdminRepo) GetUserByFirebaseUID(ctx context.Context, uid string) (*entity.User, error) {
+	var u entity.User
+	if err := r.db.WithContext(ctx).Where("firebase_uid = ?", uid).First(&u).Error; err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (r *GormAdminRepo) SetUserFirebaseUID(ctx context.Context, userID uuid.UUID, uid string) error {
+	return r.db.WithContext(ctx).Model(&entity.User{}).Where("id = ?", userID).Update("firebase_uid", uid).Error
+}
+
+func (r *GormAdminRepo) GetAdminByUserID(ctx context.Context, userID uuid.UUID) (*entity.Admin, error) {
+	var a entity.Admin
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&a).Error; err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *GormAdminRepo) GetAdminByPhone(ctx context.Context, phone string) (*entity.Admin, error) {
+	var a entity.Admin
+	err := r.db.WithContext(ctx).
+		Joins("JOIN users ON users.id = admins.user_id AND users.deleted_at IS NULL").
+		Where("users.phone = ?", phone).
+		First(&a).Error
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *GormAdminRepo) ListAdmins(ctx context.Context) ([]adminpkg.AdminView, error) {
+	var out []adminpkg.AdminView
+	err := r.db.WithContext(ctx).Table("admins").
+		Select("admins.*, users.first_name, users.last_name, users.phone").
+		Joins("JOIN users ON users.id = admins.user_id").
+		Where("admins.deleted_at IS NULL").
+		Order("admins.created_at ASC").
+		Scan(&out).Error
+	return out, err
+}
+
+func (r *GormAdminRepo) UpdateAdmin(ctx context.Context, id uuid.UUID, fields map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&entity.Admin{}).Where("id = ?", id).Updates(fields).Error
+}
+
+func (r *GormAdminRepo) CountActiveSuperAdmins(ctx context.Context) (int64, error) {
+	var n int64
+	err := r.db.WithContext(ctx).Model(&entity.Admin{}).
+		Where("role = ? AND active = ?", entity.AdminRoleSuperAdmin, true).
+		Count(&n).Error
+	return n, err
 }
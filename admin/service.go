@@ -2,19 +2,51 @@ package admin
 
 import (
 	"context"
+	"errors"
 
+	"github.com/google/uuid"
 	"github.com/mikios34/delivery-backend/entity"
 )
 
+var (
+	// ErrPhoneExists is returned when registering a second admin with the same phone.
+	ErrPhoneExists = errors.New("admin with this phone already exists")
+	// ErrInvalidRole is returned for unknown admin roles.
+	ErrInvalidRole = errors.New("invalid admin role")
+	// ErrLastSuperAdmin is returned when a change would leave no active super admin.
+	ErrLastSuperAdmin = errors.New("cannot demote or deactivate the last active super admin")
+)
+
 // RegisterAdminRequest carries the data required to register an admin.
 type RegisterAdminRequest struct {
 	FirstName   string
 	LastName    string
 	Phone       string
 	FirebaseUID string
+	Role        entity.AdminRole
+}
+
+// UpdateAdminRequest changes an admin's role and/or active flag; nil fields are left as is.
+type UpdateAdminRequest struct {
+	Role   *entity.AdminRole
+	Active *bool
+}
+
+// AdminView is an admin profile with the owning user's name and phone.
+type AdminView struct {
+	entity.Admin
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Phone     string `json:"phone"`
 }
 
 // AdminService exposes admin-related business operations.
 type AdminService interface {
 	RegisterAdmin(ctx context.Context, req RegisterAdminRequest) (*entity.Admin, error)
+	ListAdmins(ctx context.Context) ([]AdminView, error)
+	GetAdmin(ctx context.Context, id uuid.UUID) (*entity.Admin, error)
+	UpdateAdmin(ctx context.Context, id uuid.UUID, req UpdateAdminRequest) (*entity.Admin, error)
+	// BootstrapSuperAdmin creates a super admin, or promotes the admin with that phone.
+	// The bool reports whether a new admin was created.
+	BootstrapSuperAdmin(ctx context.Context, req RegisterAdminRequest) (*entity.Admin, bool, error)
 }
@@ -4,8 +4,11 @@ import (
 	"context"
 	"errors"
 
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/account"
 	adminpkg "github.com/mikios34/delivery-backend/admin"
 	"github.com/mikios34/delivery-backend/entity"
+	"gorm.io/gorm"
 )
 
 // adminService implements AdminService.
@@ -18,18 +21,13 @@ func NewAdminService(repo adminpkg.AdminRepository) adminpkg.AdminService {
 	return &adminService{repo: repo}
 }
 
-// RegisterAdmin creates a base User with role "admin" and an Admin profile.
+// RegisterAdmin attaches an Admin profile to the user with this phone/Firebase UID,
+// creating the user (primary role "admin") if there is none.
 func (s *adminService) RegisterAdmin(ctx context.Context, req adminpkg.RegisterAdminRequest) (*entity.Admin, error) {
-	// check phone uniqueness among admins
-	exists, err := s.repo.PhoneExists(ctx, req.Phone)
-	if err != nil {
-		return nil, err
+	if !adminpkg.ValidRole(req.Role) {
+		return nil, adminpkg.ErrInvalidRole
 	}
-	if exists {
-		return nil, errors.New("admin with this phone already exists")
-	}
-
-	// create user
+	// find or create the user; an existing courier/customer account gains an admin profile
 	u := &entity.User{
 		FirstName:     req.FirstName,
 		LastName:      req.LastName,
@@ -41,16 +39,84 @@ func (s *adminService) RegisterAdmin(ctx context.Context, req adminpkg.RegisterA
 		uid := req.FirebaseUID
 		u.FirebaseUID = &uid
 	}
-	createdUser, err := s.repo.StoreUser(ctx, u)
+	// Admins are added by a super admin (or the bootstrap command), who vouches for the phone.
+	createdUser, err := account.FindOrCreateUser(ctx, s.repo, u, true)
 	if err != nil {
 		return nil, err
 	}
+	if _, err := s.repo.GetAdminByUserID(ctx, createdUser.ID); err == nil {
+		return nil, adminpkg.ErrPhoneExists
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
 
 	// create admin profile
-	a := &entity.Admin{UserID: createdUser.ID, Active: true}
+	a := &entity.Admin{UserID: createdUser.ID, Role: req.Role, Active: true}
 	createdAdmin, err := s.repo.StoreAdmin(ctx, a)
 	if err != nil {
 		return nil, err
 	}
 	return createdAdmin, nil
 }
+
+func (s *adminService) GetAdmin(ctx context.Context, id uuid.UUID) (*entity.Admin, error) {
+	return s.repo.GetAdminByID(ctx, id)
+}
+
+func (s *adminService) ListAdmins(ctx context.Context) ([]adminpkg.AdminView, error) {
+	return s.repo.ListAdmins(ctx)
+}
+
+// UpdateAdmin changes role/active, refusing to leave the system without a super admin.
+func (s *adminService) UpdateAdmin(ctx context.Context, id uuid.UUID, req adminpkg.UpdateAdminRequest) (*entity.Admin, error) {
+	a, err := s.repo.GetAdminByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]interface{}{}
+	if req.Role != nil {
+		if !adminpkg.ValidRole(*req.Role) {
+			return nil, adminpkg.ErrInvalidRole
+		}
+		fields["role"] = *req.Role
+	}
+	if req.Active != nil {
+		fields["active"] = *req.Active
+	}
+	if len(fields) == 0 {
+		return a, nil
+	}
+	losesSuper := a.Role == entity.AdminRoleSuperAdmin && a.Active &&
+		((req.Role != nil && *req.Role != entity.AdminRoleSuperAdmin) || (req.Active != nil && !*req.Active))
+	if losesSuper {
+		n, err := s.repo.CountActiveSuperAdmins(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if n <= 1 {
+			return nil, adminpkg.ErrLastSuperAdmin
+		}
+	}
+	if err := s.repo.UpdateAdmin(ctx, id, fields); err != nil {
+		return nil, err
+	}
+	return s.repo.GetAdminByID(ctx, id)
+}
+
+func (s *adminService) BootstrapSuperAdmin(ctx context.Context, req adminpkg.RegisterAdminRequest) (*entity.Admin, bool, error) {
+	existing, err := s.repo.GetAdminByPhone(ctx, req.Phone)
+	if err == nil {
+		fields := map[string]interface{}{"role": entity.AdminRoleSuperAdmin, "active": true}
+		if err := s.repo.UpdateAdmin(ctx, existing.ID, fields); err != nil {
+			return nil, false, err
+		}
+		a, err := s.repo.GetAdminByID(ctx, existing.ID)
+		return a, false, err
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, err
+	}
+	req.Role = entity.AdminRoleSuperAdmin
+	a, err := s.RegisterAdmin(ctx, req)
+	return a, err == nil, err
+}
@@ -0,0 +1,35 @@
+package analytics
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/entity"
+)
+
+// Query selects orders created in [From, To) with their pickup inside Zone, if set.
+// Period is "day" or "week" to group by period, or empty for a single row over the range
+// (its PeriodStart is left zero).
+type Query struct {
+	From   time.Time
+	To     time.Time
+	Period Granularity
+	Zone   *entity.Zone
+}
+
+// Repository specifies the report queries and zone storage. Report rows come back in
+// period order; rates and per-courier figures are left to the service.
+type Repository interface {
+	OrderStats(ctx context.Context, q Query) ([]KPIs, error)
+	OfferStats(ctx context.Context, q Query) ([]OfferStats, error)
+	RevenueByVehicleType(ctx context.Context, q Query) ([]RevenueLine, error)
+	RevenueByOrderType(ctx context.Context, q Query) ([]RevenueLine, error)
+
+	ListZones(ctx context.Context) ([]entity.Zone, error)
+	GetZone(ctx context.Context, id uuid.UUID) (*entity.Zone, error)
+	// ZoneNameTaken matches case-insensitively; exclude is the zone being updated.
+	ZoneNameTaken(ctx context.Context, name string, exclude uuid.UUID) (bool, error)
+	CreateZone(ctx context.Context, z *entity.Zone) error
+	UpdateZone(ctx context.Context, id uuid.UUID, fields map[string]interface{}) error
+}
@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/analytics"
+	"github.com/mikios34/delivery-backend/entity"
+	"gorm.io/gorm"
+)
+
+// GormAnalyticsRepo implements analytics.Repository using GORM.
+type GormAnalyticsRepo struct{ db *gorm.DB }
+
+func NewGormAnalyticsRepo(db *gorm.DB) analytics.Repository { return &GormAnalyticsRepo{db: db} }
+
+// period returns the period column and GROUP BY clause for q. Periods are UTC.
+func period(q analytics.Query) (column, groupBy string) {
+	switch q.Period {
+	case analytics.Daily, analytics.Weekly:
+		return "date_trunc('" + string(q.Period) + "', o.created_at AT TIME ZONE 'UTC') AS period_start", " GROUP BY 1"
+	}
+	return "NULL::timestamp AS period_start", ""
+}
+
+// where filters orders (as o) by creation time and zone.
+func where(q analytics.Query) (string, []interface{}) {
+	sql := " WHERE o.deleted_at IS NULL AND o.created_at >= ? AND o.created_at < ?"
+	args := []interface{}{q.From, q.To}
+	if z := q.Zone; z != nil {
+		sql += " AND o.pickup_lat BETWEEN ? AND ? AND o.pickup_lng BETWEEN ? AND ?"
+		args = append(args, z.MinLat, z.MaxLat, z.MinLng, z.MaxLng)
+	}
+	return sql, args
+}
+
+// median is the median seconds from order creation to the timestamp expression.
+func median(at, name string) string {
+	return "percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM " + at + " - o.created_at)) AS " + name
+}
+
+type orderStatsRow struct {
+	PeriodStart        *time.Time
+	Created            int64
+	Accepted           int64
+	Delivered          int64
+	CanceledByCustomer int64
+	CanceledByCourier  int64
+	CanceledByAdmin    int64
+	NoDriver           int64
+	RevenueCents       int64
+	ToAssign           *float64
+	ToAccept           *float64
+	ToPickup           *float64
+	ToDeliver          *float64
+	CouriersDelivering int64
+	BusyHours          float64
+}
+
+func (r *GormAnalyticsRepo) OrderStats(ctx context.Context, q analytics.Query) ([]analytics.KPIs, error) {
+	col, groupBy := period(q)
+	cond, args := where(q)
+	sql := `SELECT ` + col + `,
+		COUNT(*) AS created,
+		COUNT(*) FILTER (WHERE o.accepted_at IS NOT NULL) AS accepted,
+		COUNT(*) FILTER (WHERE o.status = 'delivered') AS delivered,
+		COUNT(*) FILTER (WHERE o.status = 'canceled_by_customer') AS canceled_by_customer,
+		COUNT(*) FILTER (WHERE o.status = 'canceled_by_courier') AS canceled_by_courier,
+		COUNT(*) FILTER (WHERE o.status = 'canceled_by_admin') AS canceled_by_admin,
+		COUNT(*) FILTER (WHERE o.no_nearby_driver_at IS NOT NULL OR o.status = 'no_nearby_driver') AS no_driver,
+		COALESCE(SUM(o.estimated_price_cents) FILTER (WHERE o.status = 'delivered'), 0) AS revenue_cents,
+		` + median("fa.first_at", "to_assign") + `,
+		` + median("o.accepted_at", "to_accept") + `,
+		` + median("o.picked_up_at", "to_pickup") + `,
+		` + median("o.delivered_at", "to_deliver") + `,
+		COUNT(DISTINCT o.assigned_courier) FILTER (WHERE o.status = 'delivered') AS couriers_delivering,
+		COALESCE(SUM(EXTRACT(EPOCH FROM o.delivered_at - o.accepted_at)) FILTER (WHERE o.status = 'delivered'), 0) / 3600 AS busy_hours
+		FROM orders o
+		LEFT JOIN LATERAL (
+			SELECT MIN(a.created_at) AS first_at FROM order_assignment_attempts a
+			WHERE a.order_id = o.id AND a.deleted_at IS NULL
+		) fa ON TRUE` + cond + groupBy + ` ORDER BY 1`
+	var rows []orderStatsRow
+	if err := r.db.WithContext(ctx).Raw(sql, args...).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make([]analytics.KPIs, 0, len(rows))
+	for _, row := range rows {
+		k := analytics.KPIs{
+			OrdersCreated: row.Created,
+			Accepted:      row.Accepted,
+			Delivered:     row.Delivered,
+			Canceled:      analytics.Cancellations{ByCustomer: row.CanceledByCustomer, ByCourier: row.CanceledByCourier, ByAdmin: row.CanceledByAdmin},
+			NoDriver:      row.NoDriver,
+			MedianSeconds: analytics.Medians{ToAssign: row.ToAssign, ToAccept: row.ToAccept, ToPickup: row.ToPickup, ToDeliver: row.ToDeliver},
+			RevenueCents:  row.RevenueCents,
+			Couriers:      analytics.Utilization{CouriersDelivering: row.CouriersDelivering, BusyHours: row.BusyHours},
+		}
+		if row.PeriodStart != nil {
+			k.PeriodStart = *row.PeriodStart
+		}
+		out = append(out, k)
+	}
+	return out, nil
+}
+
+type offerStatsRow struct {
+	PeriodStart     *time.Time
+	Offers          int64
+	CouriersOffered int64
+}
+
+func (r *GormAnalyticsRepo) OfferStats(ctx context.Context, q analytics.Query) ([]analytics.OfferStats, error) {
+	col, groupBy := period(q)
+	cond, args := where(q)
+	sql := `SELECT ` + col + `, COUNT(*) AS offers, COUNT(DISTINCT a.courier_id) AS couriers_offered
+		FROM orders o
+		JOIN order_assignment_attempts a ON a.order_id = o.id AND a.deleted_at IS NULL` + cond + groupBy + ` ORDER BY 1`
+	var rows []offerStatsRow
+	if err := r.db.WithContext(ctx).Raw(sql, args...).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make([]analytics.OfferStats, 0, len(rows))
+	for _, row := range rows {
+		s := analytics.OfferStats{Offers: row.Offers, CouriersOffered: row.CouriersOffered}
+		if row.PeriodStart != nil {
+			s.PeriodStart = *row.PeriodStart
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+func (r *GormAnalyticsRepo) RevenueByVehicleType(ctx context.Context, q analytics.Query) ([]analytics.RevenueLine, error) {
+	return r.revenueBy(ctx, q, "o.vehicle_type_id", "vehicle_types")
+}
+
+func (r *GormAnalyticsRepo) RevenueByOrderType(ctx context.Context, q analytics.Query) ([]analytics.RevenueLine, error) {
+	return r.revenueBy(ctx, q, "o.type_id", "order_types")
+}
+
+// revenueBy sums delivered orders per value of key, named from table. Orders whose type
+// was removed keep their id with an empty name.
+func (r *GormAnalyticsRepo) revenueBy(ctx context.Context, q analytics.Query, key, table string) ([]analytics.RevenueLine, error) {
+	col, _ := period(q)
+	cond, args := where(q)
+	groupBy := " GROUP BY " + key + ", t.name"
+	if q.Period != "" {
+		groupBy = " GROUP BY 1, " + key + ", t.name"
+	}
+	sql := `SELECT ` + col + `, ` + key + ` AS id, COALESCE(t.name, '') AS name,
+		COUNT(*) AS orders, COALESCE(SUM(o.estimated_price_cents), 0) AS revenue_cents
+		FROM orders o
+		LEFT JOIN ` + table + ` t ON t.id = ` + key + cond + ` AND o.status = 'delivered'` + groupBy +
+		` ORDER BY 1, revenue_cents DESC`
+	var rows []struct {
+		PeriodStart  *time.Time
+		ID           uuid.UUID
+		Name         string
+		Orders       int64
+		RevenueCents int64
+	}
+	if err := r.db.WithContext(ctx).Raw(sql, args...).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make([]analytics.RevenueLine, 0, len(rows))
+	for _, row := range rows {
+		l := analytics.RevenueLine{ID: row.ID, Name: row.Name, Orders: row.Orders, RevenueCents: row.RevenueCents}
+		if row.PeriodStart != nil {
+			l.PeriodStart = *row.PeriodStart
+		}
+		out = append(out, l)
+	}
+	return out, nil
+}
+
+func (r *GormAnalyticsRepo) ListZones(ctx context.Context) ([]entity.Zone, error) {
+	var list []entity.Zone
+	if err := r.db.WithContext(ctx).Order("name ASC").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *GormAnalyticsRepo) GetZone(ctx context.Context, id uuid.UUID) (*entity.Zone, error) {
+	var z entity.Zone
+	if err := r.db.WithContext(ctx).First(&z, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &z, nil
+}
+
+func (r *GormAnalyticsRepo) ZoneNameTaken(ctx context.Context, name string, exclude uuid.UUID) (bool, error) {
+	var n int64
+	q := r.db.WithContext(ctx).Model(&entity.Zone{}).Where("LOWER(name) = LOWER(?)", name)
+	if exclude != uuid.Nil {
+		q = q.Where("id <> ?", exclude)
+	}
+	if err := q.Count(&n).Error; err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (r *GormAnalyticsRepo) CreateZone(ctx context.Context, z *entity.Zone) error {
+	return r.db.WithContext(ctx).Create(z).Error
+}
+
+func (r *GormAnalyticsRepo) UpdateZone(ctx context.Context, id uuid.UUID, fields map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&entity.Zone{}).Where("id = ?", id).Updates(fields).Error
+}
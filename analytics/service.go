@@ -0,0 +1,157 @@
+// Package analytics computes operational KPIs from the orders and assignment attempts
+// tables, and manages the zones reports can be filtered by.
+package analytics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/entity"
+)
+
+var (
+	// ErrInvalidRange is returned when from is not before to or the range is too long.
+	ErrInvalidRange = errors.New("from must be before to and the range at most 366 days")
+	// ErrInvalidGranularity is returned for granularities other than day and week.
+	ErrInvalidGranularity = errors.New("granularity must be day or week")
+	// ErrNameRequired is returned when a zone name is blank.
+	ErrNameRequired = errors.New("name is required")
+	// ErrDuplicateZone is returned when another zone has the same name.
+	ErrDuplicateZone = errors.New("a zone with this name already exists")
+	// ErrInvalidBounds is returned for coordinates out of range or min not below max.
+	ErrInvalidBounds = errors.New("zone bounds must be valid coordinates with min below max")
+)
+
+// MaxRange is the longest period a report may cover.
+const MaxRange = 366 * 24 * time.Hour
+
+// Granularity is the length of the periods a report is split into.
+type Granularity string
+
+const (
+	Daily  Granularity = "day"
+	Weekly Granularity = "week" // weeks start on Monday
+)
+
+// Filter selects the orders a report covers: those created in [From, To) whose pickup lies
+// in the zone, when ZoneID is set. Periods are in UTC.
+type Filter struct {
+	From        time.Time
+	To          time.Time
+	Granularity Granularity
+	ZoneID      *uuid.UUID
+}
+
+// Cancellations counts canceled orders by who canceled them.
+type Cancellations struct {
+	ByCustomer int64 `json:"by_customer"`
+	ByCourier  int64 `json:"by_courier"`
+	ByAdmin    int64 `json:"by_admin"`
+}
+
+// Medians are median seconds from order creation to each stage. A nil value means no
+// order in the period reached the stage.
+type Medians struct {
+	ToAssign  *float64 `json:"to_assign"`
+	ToAccept  *float64 `json:"to_accept"`
+	ToPickup  *float64 `json:"to_pickup"`
+	ToDeliver *float64 `json:"to_deliver"`
+}
+
+// Utilization describes how the courier fleet was used.
+type Utilization struct {
+	// CouriersOffered is the number of distinct couriers offered an order.
+	CouriersOffered int64 `json:"couriers_offered"`
+	// Offers is the number of assignment attempts.
+	Offers int64 `json:"offers"`
+	// AcceptanceRate is accepted orders over offers.
+	AcceptanceRate float64 `json:"acceptance_rate"`
+	// CouriersDelivering is the number of distinct couriers that delivered an order.
+	CouriersDelivering   int64   `json:"couriers_delivering"`
+	DeliveriesPerCourier float64 `json:"deliveries_per_courier"`
+	// BusyHours sums the time from accept to delivery of delivered orders.
+	BusyHours float64 `json:"busy_hours"`
+}
+
+// KPIs are the aggregates of one period, or of the whole report.
+type KPIs struct {
+	PeriodStart   time.Time     `json:"period_start"`
+	OrdersCreated int64         `json:"orders_created"`
+	Accepted      int64         `json:"accepted"`
+	Delivered     int64         `json:"delivered"`
+	Canceled      Cancellations `json:"canceled"`
+	// NoDriver counts orders dispatch found no courier for at least once.
+	NoDriver      int64       `json:"no_driver"`
+	NoDriverRate  float64     `json:"no_driver_rate"`
+	MedianSeconds Medians     `json:"median_seconds"`
+	RevenueCents  int64       `json:"revenue_cents"` // estimated price of delivered orders
+	Couriers      Utilization `json:"couriers"`
+}
+
+// OfferStats are the assignment attempts of orders created in one period.
+type OfferStats struct {
+	PeriodStart     time.Time
+	Offers          int64
+	CouriersOffered int64
+}
+
+// RevenueLine is the delivered orders of one vehicle or order type in one period.
+type RevenueLine struct {
+	PeriodStart  time.Time `json:"period_start"`
+	ID           uuid.UUID `json:"id"`
+	Name         string    `json:"name"`
+	Orders       int64     `json:"orders"`
+	RevenueCents int64     `json:"revenue_cents"`
+}
+
+// Report is the KPIs per period plus totals for the whole filter.
+type Report struct {
+	From        time.Time   `json:"from"`
+	To          time.Time   `json:"to"`
+	Granularity Granularity `json:"granularity"`
+	ZoneID      *uuid.UUID  `json:"zone_id,omitempty"`
+	Periods     []KPIs      `json:"periods"`
+	Totals      KPIs        `json:"totals"`
+}
+
+// RevenueReport breaks delivered revenue down by vehicle type and order type per period.
+type RevenueReport struct {
+	From          time.Time     `json:"from"`
+	To            time.Time     `json:"to"`
+	Granularity   Granularity   `json:"granularity"`
+	ZoneID        *uuid.UUID    `json:"zone_id,omitempty"`
+	ByVehicleType []RevenueLine `json:"by_vehicle_type"`
+	ByOrderType   []RevenueLine `json:"by_order_type"`
+}
+
+// ZoneRequest creates a zone.
+type ZoneRequest struct {
+	Name   string
+	MinLat float64
+	MinLng float64
+	MaxLat float64
+	MaxLng float64
+}
+
+// UpdateZoneRequest changes a zone; nil fields are left as is.
+type UpdateZoneRequest struct {
+	Name   *string
+	MinLat *float64
+	MinLng *float64
+	MaxLat *float64
+	MaxLng *float64
+	Active *bool
+}
+
+// Service exposes reports and zone administration.
+type Service interface {
+	KPIs(ctx context.Context, f Filter) (*Report, error)
+	Revenue(ctx context.Context, f Filter) (*RevenueReport, error)
+
+	ListZones(ctx context.Context) ([]entity.Zone, error)
+	GetZone(ctx context.Context, id uuid.UUID) (*entity.Zone, error)
+	CreateZone(ctx context.Context, req ZoneRequest) (*entity.Zone, error)
+	UpdateZone(ctx context.Context, id uuid.UUID, req UpdateZoneRequest) (*entity.Zone, error)
+}
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/analytics"
+	"github.com/mikios34/delivery-backend/entity"
+)
+
+// analyticsService implements analytics.Service.
+type analyticsService struct {
+	repo analytics.Repository
+}
+
+// NewAnalyticsService constructs an analytics.Service backed by the provided repository.
+func NewAnalyticsService(repo analytics.Repository) analytics.Service {
+	return &analyticsService{repo: repo}
+}
+
+func (s *analyticsService) KPIs(ctx context.Context, f analytics.Filter) (*analytics.Report, error) {
+	q, err := s.query(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	periods, err := s.kpis(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	q.Period = ""
+	totals, err := s.kpis(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	rep := &analytics.Report{From: f.From, To: f.To, Granularity: f.Granularity, ZoneID: f.ZoneID, Periods: periods}
+	if len(totals) > 0 {
+		rep.Totals = totals[0]
+	}
+	rep.Totals.PeriodStart = f.From
+	return rep, nil
+}
+
+// kpis merges order and offer stats per period and derives the rates.
+func (s *analyticsService) kpis(ctx context.Context, q analytics.Query) ([]analytics.KPIs, error) {
+	list, err := s.repo.OrderStats(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	offers, err := s.repo.OfferStats(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	byPeriod := make(map[int64]analytics.OfferStats, len(offers))
+	for _, o := range offers {
+		byPeriod[o.PeriodStart.Unix()] = o
+	}
+	for i := range list {
+		k := &list[i]
+		if o, ok := byPeriod[k.PeriodStart.Unix()]; ok {
+			k.Couriers.Offers = o.Offers
+			k.Couriers.CouriersOffered = o.CouriersOffered
+		}
+		k.NoDriverRate = ratio(k.NoDriver, k.OrdersCreated)
+		k.Couriers.AcceptanceRate = ratio(k.Accepted, k.Couriers.Offers)
+		k.Couriers.DeliveriesPerCourier = ratio(k.Delivered, k.Couriers.CouriersDelivering)
+	}
+	return list, nil
+}
+
+func (s *analyticsService) Revenue(ctx context.Context, f analytics.Filter) (*analytics.RevenueReport, error) {
+	q, err := s.query(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	byVehicle, err := s.repo.RevenueByVehicleType(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	byType, err := s.repo.RevenueByOrderType(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	return &analytics.RevenueReport{
+		From: f.From, To: f.To, Granularity: f.Granularity, ZoneID: f.ZoneID,
+		ByVehicleType: byVehicle, ByOrderType: byType,
+	}, nil
+}
+
+// query validates the filter and resolves its zone.
+func (s *analyticsService) query(ctx context.Context, f analytics.Filter) (analytics.Query, error) {
+	if f.Granularity != analytics.Daily && f.Granularity != analytics.Weekly {
+		return analytics.Query{}, analytics.ErrInvalidGranularity
+	}
+	if !f.From.Before(f.To) || f.To.Sub(f.From) > analytics.MaxRange {
+		return analytics.Query{}, analytics.ErrInvalidRange
+	}
+	q := analytics.Query{From: f.From, To: f.To, Period: f.Granularity}
+	if f.ZoneID != nil {
+		z, err := s.repo.GetZone(ctx, *f.ZoneID)
+		if err != nil {
+			return analytics.Query{}, err
+		}
+		q.Zone = z
+	}
+	return q, nil
+}
+
+func ratio(n, d int64) float64 {
+	if d == 0 {
+		return 0
+	}
+	return float64(n) / float64(d)
+}
+
+func (s *analyticsService) ListZones(ctx context.Context) ([]entity.Zone, error) {
+	return s.repo.ListZones(ctx)
+}
+
+func (s *analyticsService) GetZone(ctx context.Context, id uuid.UUID) (*entity.Zone, error) {
+	return s.repo.GetZone(ctx, id)
+}
+
+func (s *analyticsService) CreateZone(ctx context.Context, req analytics.ZoneRequest) (*entity.Zone, error) {
+	z := &entity.Zone{
+		Name: strings.TrimSpace(req.Name), Active: true,
+		MinLat: req.MinLat, MinLng: req.MinLng, MaxLat: req.MaxLat, MaxLng: req.MaxLng,
+	}
+	if err := s.checkZone(ctx, z); err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateZone(ctx, z); err != nil {
+		return nil, err
+	}
+	return z, nil
+}
+
+func (s *analyticsService) UpdateZone(ctx context.Context, id uuid.UUID, req analytics.UpdateZoneRequest) (*entity.Zone, error) {
+	z, err := s.repo.GetZone(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]interface{}{}
+	if req.Name != nil {
+		z.Name = strings.TrimSpace(*req.Name)
+		fields["name"] = z.Name
+	}
+	for _, f := range []struct {
+		col string
+		in  *float64
+		out *float64
+	}{
+		{"min_lat", req.MinLat, &z.MinLat}, {"min_lng", req.MinLng, &z.MinLng},
+		{"max_lat", req.MaxLat, &z.MaxLat}, {"max_lng", req.MaxLng, &z.MaxLng},
+	} {
+		if f.in != nil {
+			*f.out = *f.in
+			fields[f.col] = *f.in
+		}
+	}
+	if req.Active != nil {
+		z.Active = *req.Active
+		fields["active"] = z.Active
+	}
+	if len(fields) == 0 {
+		return z, nil
+	}
+	if err := s.checkZone(ctx, z); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateZone(ctx, id, fields); err != nil {
+		return nil, err
+	}
+	return s.repo.GetZone(ctx, id)
+}
+
+// checkZone validates the zone as it will be stored.
+func (s *analyticsService) checkZone(ctx context.Context, z *entity.Zone) error {
+	if z.Name == "" {
+		return analytics.ErrNameRequired
+	}
+	if z.MinLat < -90 || z.MaxLat > 90 || z.MinLng < -180 || z.MaxLng > 180 ||
+		z.MinLat >= z.MaxLat || z.MinLng >= z.MaxLng {
+		return analytics.ErrInvalidBounds
+	}
+	taken, err := s.repo.ZoneNameTaken(ctx, z.Name, z.ID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return analytics.ErrDuplicateZone
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package announcement
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/entity"
+)
+
+// Repository specifies announcement persistence and recipient matching.
+type Repository interface {
+	Create(ctx context.Context, a *entity.Announcement) error
+	Get(ctx context.Context, id uuid.UUID) (*entity.Announcement, error)
+	List(ctx context.Context, limit, offset int) ([]AnnouncementView, int64, error)
+	SetExpiry(ctx context.Context, id uuid.UUID, at time.Time) error
+	ZoneExists(ctx context.Context, id uuid.UUID) (bool, error)
+	// Unread returns announcements unexpired at now that target r and that r has not
+	// acknowledged, newest first.
+	Unread(ctx context.Context, r Recipient, now time.Time, limit int) ([]entity.Announcement, error)
+	// MatchingCouriers returns the couriers among ids that are inside a's zone (by last
+	// known location) and drive its vehicle type, when those are set.
+	MatchingCouriers(ctx context.Context, a *entity.Announcement, ids []uuid.UUID) ([]uuid.UUID, error)
+	// Ack inserts the acknowledgement unless it already exists.
+	Ack(ctx context.Context, ack *entity.AnnouncementAck) error
+}
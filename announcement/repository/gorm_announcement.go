@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/announcement"
+	"github.com/mikios34/delivery-backend/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormAnnouncementRepo implements announcement.Repository using GORM.
+type GormAnnouncementRepo struct{ db *gorm.DB }
+
+func NewGormAnnouncementRepo(db *gorm.DB) announcement.Repository {
+	return &GormAnnouncementRepo{db: db}
+}
+
+// inZone matches a courier (as c) whose last known location lies inside zone z.
+const inZone = "c.latitude BETWEEN z.min_lat AND z.max_lat AND c.longitude BETWEEN z.min_lng AND z.max_lng"
+
+func (r *GormAnnouncementRepo) Create(ctx context.Context, a *entity.Announcement) error {
+	return r.db.WithContext(ctx).Create(a).Error
+}
+
+func (r *GormAnnouncementRepo) Get(ctx context.Context, id uuid.UUID) (*entity.Announcement, error) {
+	var a entity.Announcement
+	if err := r.db.WithContext(ctx).First(&a, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *GormAnnouncementRepo) List(ctx context.Context, limit, offset int) ([]announcement.AnnouncementView, int64, error) {
+	var total int64
+	if err := r.db.WithContext(ctx).Model(&entity.Announcement{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var out []announcement.AnnouncementView
+	err := r.db.WithContext(ctx).Table("announcements a").
+		Select("a.*, (SELECT COUNT(*) FROM announcement_acks k WHERE k.announcement_id = a.id) AS acks").
+		Where("a.deleted_at IS NULL").
+		Order("a.created_at DESC").
+		Limit(limit).Offset(offset).
+		Scan(&out).Error
+	return out, total, err
+}
+
+func (r *GormAnnouncementRepo) SetExpiry(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&entity.Announcement{}).Where("id = ?", id).Update("expires_at", at).Error
+}
+
+func (r *GormAnnouncementRepo) ZoneExists(ctx context.Context, id uuid.UUID) (bool, error) {
+	var n int64
+	if err := r.db.WithContext(ctx).Model(&entity.Zone{}).Where("id = ?", id).Count(&n).Error; err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (r *GormAnnouncementRepo) Unread(ctx context.Context, rcpt announcement.Recipient, now time.Time, limit int) ([]entity.Announcement, error) {
+	q := r.db.WithContext(ctx).Table("announcements a").Select("a.*").
+		Where("a.deleted_at IS NULL AND (a.expires_at IS NULL OR a.expires_at > ?)", now).
+		Where("a.audience IN ?", []string{rcpt.Role, string(entity.AudienceAll)}).
+		Where("NOT EXISTS (SELECT 1 FROM announcement_acks k WHERE k.announcement_id = a.id AND k.recipient_role = ? AND k.recipient_id = ?)", rcpt.Role, rcpt.ID)
+	if rcpt.Role == announcement.RoleCourier {
+		q = q.Joins("JOIN couriers c ON c.id = ?", rcpt.ID).
+			Where("(a.vehicle_type = '' OR a.vehicle_type = c.primary_vehicle)").
+			Where("(a.zone_id IS NULL OR EXISTS (SELECT 1 FROM zones z WHERE z.id = a.zone_id AND " + inZone + "))")
+	} else {
+		q = q.Where("a.zone_id IS NULL AND a.vehicle_type = ''")
+	}
+	var out []entity.Announcement
+	err := q.Order("a.created_at DESC").Limit(limit).Scan(&out).Error
+	return out, err
+}
+
+func (r *GormAnnouncementRepo) MatchingCouriers(ctx context.Context, a *entity.Announcement, ids []uuid.UUID) ([]uuid.UUID, error) {
+	q := r.db.WithContext(ctx).Table("couriers c").Where("c.id IN ? AND c.deleted_at IS NULL", ids)
+	if a.VehicleType != "" {
+		q = q.Where("c.primary_vehicle = ?", a.VehicleType)
+	}
+	if a.ZoneID != nil {
+		q = q.Joins("JOIN zones z ON z.id = ?", *a.ZoneID).Where(inZone)
+	}
+	var out []uuid.UUID
+	err := q.Pluck("c.id", &out).Error
+	return out, err
+}
+
+func (r *GormAnnouncementRepo) Ack(ctx context.Context, ack *entity.AnnouncementAck) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(ack).Error
+}
@@ -0,0 +1,90 @@
+// Package announcement broadcasts operations messages ("heavy rain, surge active",
+// planned maintenance) to couriers and customers. Announcements are pushed live to
+// connected recipients and kept until they expire so clients can fetch the unread ones.
+package announcement
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/entity"
+)
+
+const (
+	RoleCourier  = "courier"
+	RoleCustomer = "customer"
+)
+
+// Live events pushed through the realtime hub.
+const (
+	// EventPublished carries the announcement.
+	EventPublished = "announcement"
+	// EventExpired carries { id } when an admin ends an announcement early.
+	EventExpired = "announcement.expired"
+)
+
+// MaxUnread caps the announcements returned to a recipient at once.
+const MaxUnread = 50
+
+var (
+	ErrTitleRequired = errors.New("title is required")
+	ErrBodyRequired  = errors.New("body is required")
+	// ErrInvalidAudience is returned for an audience other than courier, customer or all.
+	ErrInvalidAudience = errors.New("audience must be courier, customer or all")
+	// ErrCourierTargetOnly is returned when zone_id or vehicle_type is set for customers;
+	// customers have no known position or vehicle.
+	ErrCourierTargetOnly = errors.New("zone_id and vehicle_type can only target couriers")
+	ErrInvalidVehicle    = errors.New("unknown vehicle_type")
+	ErrUnknownZone       = errors.New("zone not found")
+	ErrInvalidExpiry     = errors.New("expires_at must be in the future")
+	ErrAlreadyExpired    = errors.New("announcement has already expired")
+)
+
+// Recipient identifies the courier or customer reading announcements.
+type Recipient struct {
+	Role string    // "courier" or "customer"
+	ID   uuid.UUID // courier or customer profile id
+}
+
+// CreateRequest describes a new announcement. CreatedBy is the admin id.
+type CreateRequest struct {
+	Title       string
+	Body        string
+	Audience    entity.AnnouncementAudience
+	ZoneID      *uuid.UUID
+	VehicleType entity.VehicleType
+	ExpiresAt   *time.Time
+	CreatedBy   uuid.UUID
+}
+
+// AnnouncementView is an announcement with how many recipients acknowledged it.
+type AnnouncementView struct {
+	entity.Announcement
+	Acks int64 `json:"acks"`
+}
+
+// Notifier delivers live events; the realtime hub implements it.
+type Notifier interface {
+	OnlineCouriers() []string
+	OnlineCustomers() []string
+	Notify(courierID string, event string, payload any) error
+	NotifyCustomer(customerID string, event string, payload any) error
+}
+
+// Service publishes announcements and tracks who acknowledged them.
+type Service interface {
+	// Create stores the announcement and pushes it to the connected recipients it targets.
+	// It also returns how many recipients were reached live.
+	Create(ctx context.Context, req CreateRequest) (*entity.Announcement, int, error)
+	// List returns announcements newest first, expired ones included.
+	List(ctx context.Context, limit, offset int) ([]AnnouncementView, int64, error)
+	// Expire ends an announcement now and tells connected recipients to drop it.
+	Expire(ctx context.Context, id uuid.UUID) (*entity.Announcement, error)
+	// Unread returns unexpired announcements targeting r that r has not acknowledged,
+	// newest first. Couriers are matched on their current zone and vehicle.
+	Unread(ctx context.Context, r Recipient) ([]entity.Announcement, error)
+	// Ack marks an announcement as read by r; acknowledging twice is harmless.
+	Ack(ctx context.Context, id uuid.UUID, r Recipient) error
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/announcement"
+	"github.com/mikios34/delivery-backend/entity"
+	"gorm.io/gorm"
+)
+
+// announcementService implements announcement.Service.
+type announcementService struct {
+	repo     announcement.Repository
+	notifier announcement.Notifier
+}
+
+// NewAnnouncementService constructs an announcement.Service; notifier pushes live events.
+func NewAnnouncementService(repo announcement.Repository, notifier announcement.Notifier) announcement.Service {
+	return &announcementService{repo: repo, notifier: notifier}
+}
+
+var vehicleTypes = map[entity.VehicleType]bool{
+	entity.VehicleBike: true, entity.VehicleMotor: true, entity.VehicleCar: true,
+	entity.VehicleBicycle: true, entity.VehicleTaxi: true, entity.VehicleBus: true,
+	entity.VehicleTrain: true, entity.VehicleWalker: true, entity.VehicleOther: true,
+}
+
+func (s *announcementService) Create(ctx context.Context, req announcement.CreateRequest) (*entity.Announcement, int, error) {
+	a := &entity.Announcement{
+		Title:       strings.TrimSpace(req.Title),
+		Body:        strings.TrimSpace(req.Body),
+		Audience:    req.Audience,
+		ZoneID:      req.ZoneID,
+		VehicleType: req.VehicleType,
+		ExpiresAt:   req.ExpiresAt,
+		CreatedBy:   req.CreatedBy,
+	}
+	if a.Title == "" {
+		return nil, 0, announcement.ErrTitleRequired
+	}
+	if a.Body == "" {
+		return nil, 0, announcement.ErrBodyRequired
+	}
+	switch a.Audience {
+	case entity.AudienceCouriers, entity.AudienceCustomers, entity.AudienceAll:
+	default:
+		return nil, 0, announcement.ErrInvalidAudience
+	}
+	if (a.ZoneID != nil || a.VehicleType != "") && a.Audience != entity.AudienceCouriers {
+		return nil, 0, announcement.ErrCourierTargetOnly
+	}
+	if a.VehicleType != "" && !vehicleTypes[a.VehicleType] {
+		return nil, 0, announcement.ErrInvalidVehicle
+	}
+	if a.ExpiresAt != nil && !a.ExpiresAt.After(time.Now()) {
+		return nil, 0, announcement.ErrInvalidExpiry
+	}
+	if a.ZoneID != nil {
+		ok, err := s.repo.ZoneExists(ctx, *a.ZoneID)
+		if err != nil {
+			return nil, 0, err
+		}
+		if !ok {
+			return nil, 0, announcement.ErrUnknownZone
+		}
+	}
+	if err := s.repo.Create(ctx, a); err != nil {
+		return nil, 0, err
+	}
+	return a, s.push(ctx, a, announcement.EventPublished, a), nil
+}
+
+func (s *announcementService) List(ctx context.Context, limit, offset int) ([]announcement.AnnouncementView, int64, error) {
+	return s.repo.List(ctx, limit, offset)
+}
+
+func (s *announcementService) Expire(ctx context.Context, id uuid.UUID) (*entity.Announcement, error) {
+	a, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if a.ExpiresAt != nil && !a.ExpiresAt.After(now) {
+		return nil, announcement.ErrAlreadyExpired
+	}
+	if err := s.repo.SetExpiry(ctx, id, now); err != nil {
+		return nil, err
+	}
+	a.ExpiresAt = &now
+	s.push(ctx, a, announcement.EventExpired, struct {
+		ID uuid.UUID `json:"id"`
+	}{a.ID})
+	return a, nil
+}
+
+func (s *announcementService) Unread(ctx context.Context, r announcement.Recipient) ([]entity.Announcement, error) {
+	list, err := s.repo.Unread(ctx, r, time.Now(), announcement.MaxUnread)
+	if err != nil {
+		return nil, err
+	}
+	if list == nil {
+		list = []entity.Announcement{}
+	}
+	return list, nil
+}
+
+func (s *announcementService) Ack(ctx context.Context, id uuid.UUID, r announcement.Recipient) error {
+	a, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	// Announcements a recipient was never shown read as missing. Couriers are checked
+	// against their current position, so one acknowledged after leaving the zone 404s.
+	switch {
+	case a.Audience != entity.AudienceAll && string(a.Audience) != r.Role:
+		return gorm.ErrRecordNotFound
+	case r.Role == announcement.RoleCourier:
+		ids, err := s.repo.MatchingCouriers(ctx, a, []uuid.UUID{r.ID})
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return gorm.ErrRecordNotFound
+		}
+	}
+	return s.repo.Ack(ctx, &entity.AnnouncementAck{AnnouncementID: id, RecipientRole: r.Role, RecipientID: r.ID, AckedAt: time.Now()})
+}
+
+// push sends event to the connected recipients a targets and returns how many it reached.
+// Delivery is best effort: the announcement stays fetchable through Unread.
+func (s *announcementService) push(ctx context.Context, a *entity.Announcement, event string, payload any) int {
+	if s.notifier == nil {
+		return 0
+	}
+	sent := 0
+	if a.Audience != entity.AudienceCustomers {
+		var ids []uuid.UUID
+		for _, raw := range s.notifier.OnlineCouriers() {
+			if id, err := uuid.Parse(raw); err == nil {
+				ids = append(ids, id)
+			}
+		}
+		if len(ids) > 0 && (a.ZoneID != nil || a.VehicleType != "") {
+			matched, err := s.repo.MatchingCouriers(ctx, a, ids)
+			if err != nil {
+				log.Printf("announcement %s: match couriers: %v", a.ID, err)
+			}
+			ids = matched
+		}
+		for _, id := range ids {
+			if s.notifier.Notify(id.String(), event, payload) == nil {
+				sent++
+			}
+		}
+	}
+	if a.Audience != entity.AudienceCouriers {
+		for _, id := range s.notifier.OnlineCustomers() {
+			if s.notifier.NotifyCustomer(id, event, payload) == nil {
+				sent++
+			}
+		}
+	}
+	return sent
+}
@@ -0,0 +1,14 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/mikios34/delivery-backend/entity"
+)
+
+// Repository specifies audit log persistence.
+type Repository interface {
+	CreateLog(ctx context.Context, l *entity.AuditLog) error
+	// ListLogs returns matching logs newest first plus the total match count.
+	ListLogs(ctx context.Context, f Filter) ([]entity.AuditLog, int64, error)
+}
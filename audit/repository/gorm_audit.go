@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/mikios34/delivery-backend/audit"
+	"github.com/mikios34/delivery-backend/entity"
+	"gorm.io/gorm"
+)
+
+// GormAuditRepo implements audit.Repository using GORM.
+type GormAuditRepo struct {
+	db *gorm.DB
+}
+
+// NewGormAuditRepo constructs a new GormAuditRepo.
+func NewGormAuditRepo(db *gorm.DB) audit.Repository {
+	return &GormAuditRepo{db: db}
+}
+
+func (r *GormAuditRepo) CreateLog(ctx context.Context, l *entity.AuditLog) error {
+	return r.db.WithContext(ctx).Create(l).Error
+}
+
+func (r *GormAuditRepo) ListLogs(ctx context.Context, f audit.Filter) ([]entity.AuditLog, int64, error) {
+	q := r.db.WithContext(ctx).Model(&entity.AuditLog{})
+	if f.ActorID != "" {
+		q = q.Where("actor_id = ? OR actor_user_id = ?", f.ActorID, f.ActorID)
+	}
+	if f.ActorRole != "" {
+		q = q.Where("actor_role = ?", f.ActorRole)
+	}
+	if f.Action != "" {
+		q = q.Where("action = ?", f.Action)
+	}
+	if f.TargetType != "" {
+		q = q.Where("target_type = ?", f.TargetType)
+	}
+	if f.TargetID != "" {
+		q = q.Where("target_id = ?", f.TargetID)
+	}
+	if f.ImpersonatorID != "" {
+		q = q.Where("impersonator_admin_id = ?", f.ImpersonatorID)
+	}
+	if f.TokenID != "" {
+		q = q.Where("after->>'token_id' = ?", f.TokenID)
+	}
+	if f.From != nil {
+		q = q.Where("created_at >= ?", *f.From)
+	}
+	if f.To != nil {
+		q = q.Where("created_at < ?", *f.To)
+	}
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var out []entity.AuditLog
+	err := q.Order("created_at DESC").Limit(f.Limit).Offset(f.Offset).Find(&out).Error
+	return out, total, err
+}
@@ -0,0 +1,130 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/mikios34/delivery-backend/entity"
+)
+
+// Actions recorded by the API.
+const (
+	ActionOrderStatusChanged         = "order.status_changed"
+	ActionOrderCanceled              = "order.canceled"
+	ActionOrderAssigned              = "order.assigned"
+	ActionOrderRedispatched          = "order.redispatched"
+	ActionCourierRegistered          = "courier.registered"
+	ActionCourierAvailabilityChanged = "courier.availability_changed"
+	ActionCourierReviewed            = "courier.reviewed"
+	ActionCourierDocumentUploaded    = "courier.document_uploaded"
+	ActionAdminCreated               = "admin.created"
+	ActionAdminUpdated               = "admin.updated"
+	ActionAccountDeletionRequested   = "account.deletion_requested"
+	ActionAccountDeletionCanceled    = "account.deletion_canceled"
+	ActionCatalogCreated             = "catalog.created"
+	ActionCatalogUpdated             = "catalog.updated"
+	ActionCatalogReordered           = "catalog.reordered"
+	ActionZoneCreated                = "zone.created"
+	ActionZoneUpdated                = "zone.updated"
+	ActionCustomerSuspended          = "customer.suspended"
+	ActionCustomerReactivated        = "customer.reactivated"
+	ActionCustomerNoteAdded          = "customer.note_added"
+	ActionAnnouncementCreated        = "announcement.created"
+	ActionAnnouncementExpired        = "announcement.expired"
+	ActionTicketAssigned             = "ticket.assigned"
+	ActionTicketResolved             = "ticket.resolved"
+	ActionTicketAdjustmentIssued     = "ticket.adjustment_issued"
+	ActionImpersonationStarted       = "impersonation.started"
+	ActionImpersonationRevoked       = "impersonation.revoked"
+	// ActionImpersonationRequest is recorded for every request made with an impersonation token.
+	ActionImpersonationRequest = "impersonation.request"
+)
+
+// Target types.
+const (
+	TargetOrder    = "order"
+	TargetCourier  = "courier"
+	TargetCustomer = "customer"
+	TargetAdmin    = "admin"
+	TargetUser     = "user"
+	TargetTicket   = "ticket"
+	// Catalog entries.
+	TargetOrderType      = "order_type"
+	TargetVehicleType    = "vehicle_type"
+	TargetGuarantyOption = "guaranty_option"
+	TargetZone           = "zone"
+	TargetAnnouncement   = "announcement"
+	TargetImpersonation  = "impersonation"
+)
+
+// Actor identifies who performed an action.
+type Actor struct {
+	UserID string
+	Role   string
+	// ID is the role profile id (courier_id, customer_id or admin_id).
+	ID string
+	// ImpersonatorID is the admin id when the actor is being impersonated.
+	ImpersonatorID string
+}
+
+// RequestMeta describes the request an action came from.
+type RequestMeta struct {
+	IP        string
+	UserAgent string
+	Method    string
+	Path      string
+	RequestID string
+}
+
+type ctxKey struct{}
+
+type ctxValue struct {
+	actor Actor
+	meta  RequestMeta
+}
+
+// WithActor attaches the acting principal and request metadata to ctx for Record.
+func WithActor(ctx context.Context, actor Actor, meta RequestMeta) context.Context {
+	return context.WithValue(ctx, ctxKey{}, ctxValue{actor: actor, meta: meta})
+}
+
+// FromContext returns the actor and request metadata attached with WithActor.
+func FromContext(ctx context.Context) (Actor, RequestMeta, bool) {
+	v, ok := ctx.Value(ctxKey{}).(ctxValue)
+	return v.actor, v.meta, ok
+}
+
+// Entry is one audited action; Before/After are marshaled to JSON snapshots.
+type Entry struct {
+	Action     string
+	TargetType string
+	TargetID   string
+	Before     any
+	After      any
+}
+
+// Filter narrows audit log queries; zero values are ignored.
+type Filter struct {
+	ActorID    string
+	ActorRole  string
+	Action     string
+	TargetType string
+	TargetID   string
+	// ImpersonatorID matches actions taken through that admin's impersonation tokens.
+	ImpersonatorID string
+	// TokenID matches entries whose after snapshot records this token_id, such as
+	// impersonation.started.
+	TokenID string
+	From    *time.Time
+	To      *time.Time
+	Limit   int
+	Offset  int
+}
+
+// Service records and queries the audit trail.
+type Service interface {
+	// Record stores the entry with the actor and request metadata found in ctx.
+	// Failures are logged, never returned: the audited change has already happened.
+	Record(ctx context.Context, e Entry)
+	List(ctx context.Context, f Filter) ([]entity.AuditLog, int64, error)
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/mikios34/delivery-backend/audit"
+	"github.com/mikios34/delivery-backend/entity"
+)
+
+// auditService implements audit.Service.
+type auditService struct {
+	repo audit.Repository
+}
+
+// NewAuditService constructs an audit.Service backed by the repository.
+func NewAuditService(repo audit.Repository) audit.Service {
+	return &auditService{repo: repo}
+}
+
+func (s *auditService) Record(ctx context.Context, e audit.Entry) {
+	actor, meta, _ := audit.FromContext(ctx)
+	l := &entity.AuditLog{
+		ActorUserID: actor.UserID,
+		ActorRole:   actor.Role,
+		ActorID:     actor.ID,
+		Action:      e.Action,
+		TargetType:  e.TargetType,
+		TargetID:    e.TargetID,
+		Before:      snapshot(e.Before),
+		After:       snapshot(e.After),
+		IP:          meta.IP,
+		UserAgent:   meta.UserAgent,
+		Method:      meta.Method,
+		Path:        meta.Path,
+		RequestID:   meta.RequestID,
+	}
+	l.ImpersonatorAdminID = actor.ImpersonatorID
+	if l.ActorRole == "" {
+		l.ActorRole = "system"
+	}
+	// The request context may be about to expire; audit writes get their own deadline.
+	wctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	if err := s.repo.CreateLog(wctx, l); err != nil {
+		log.Printf("audit: failed to record %s on %s/%s: %v", e.Action, e.TargetType, e.TargetID, err)
+	}
+}
+
+func (s *auditService) List(ctx context.Context, f audit.Filter) ([]entity.AuditLog, int64, error) {
+	return s.repo.ListLogs(ctx, f)
+}
+
+func snapshot(v any) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return raw
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 // Claims carries standard and custom claims for our tokens.
@@ -13,38 +14,63 @@ type Claims struct {
 	CourierID  string `json:"courier_id,omitempty"`
 	CustomerID string `json:"customer_id,omitempty"`
 	AdminID    string `json:"admin_id,omitempty"`
-	TokenType  string `json:"token_type"` // "access" or "refresh"
+	AdminRole  string `json:"admin_role,omitempty"` // RBAC role, admin tokens only
+	TokenType  string `json:"token_type"`           // "access" or "refresh"
+	// SessionID is the refresh token family the token belongs to.
+	SessionID string `json:"sid,omitempty"`
+	// ImpersonatorAdminID is set on impersonation tokens: the admin acting as this principal.
+	ImpersonatorAdminID string `json:"imp_admin_id,omitempty"`
+	ImpersonationScope  string `json:"imp_scope,omitempty"` // "read" or "write"
 	jwt.RegisteredClaims
 }
 
-// SignJWT creates a signed JWT containing the role and profile identifiers.
-func SignJWT(secret string, principal *Principal, ttl time.Duration, tokenType string) (string, error) {
-	claims := Claims{
+// SignJWT creates a JWT containing the role and profile identifiers, signed with the
+// default keyset's active key (its kid goes in the header).
+// Each token gets a unique jti (returned in the claims) so it can be tracked and revoked.
+func SignJWT(principal *Principal, ttl time.Duration, tokenType string) (string, *Claims, error) {
+	now := time.Now()
+	claims := &Claims{
 		UserID:     principal.UserID,
 		Role:       principal.Role,
 		CourierID:  principal.CourierID,
 		CustomerID: principal.CustomerID,
 		AdminID:    principal.AdminID,
+		AdminRole:  principal.AdminRole,
 		TokenType:  tokenType,
+		SessionID:  principal.SessionID,
+
+		ImpersonatorAdminID: principal.ImpersonatorAdminID,
+		ImpersonationScope:  principal.ImpersonationScope,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
 			Subject:   principal.UserID,
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
 			Issuer:    "delivery-backend",
 			Audience:  jwt.ClaimStrings{tokenType},
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
+	ks := DefaultKeyset()
+	if ks == nil {
+		return "", nil, ErrNoSigningKey
+	}
+	signed, err := ks.sign(claims)
+	if err != nil {
+		return "", nil, err
+	}
+	return signed, claims, nil
 }
 
-// ParseAndValidate parses a token and validates signature and expiry.
-func ParseAndValidate(secret string, tokenString string) (*Claims, error) {
+// ParseAndValidate parses a token and validates signature (by kid against the default
+// keyset) and expiry.
+func ParseAndValidate(tokenString string) (*Claims, error) {
+	ks := DefaultKeyset()
+	if ks == nil {
+		return nil, ErrUnknownKey
+	}
 	claims := &Claims{}
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte(secret), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, claims, ks.keyFunc)
 	if err != nil {
 		return nil, err
 	}
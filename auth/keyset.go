@@ -0,0 +1,381 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// devSecret is only ever used when APP_ENV is a dev environment and nothing else is configured.
+const devSecret = "dev-insecure-secret-change-me"
+
+var (
+	// ErrNoSigningKey is returned when no key in the set is currently active for signing.
+	ErrNoSigningKey = errors.New("no active JWT signing key")
+	// ErrUnknownKey is returned when a token references a kid that is not (or no longer) trusted.
+	ErrUnknownKey = errors.New("unknown or expired JWT key")
+)
+
+// KeyConfig describes one key in the keyset file. Paths are relative to the keyset file.
+//
+// A key signs new tokens from ActiveFrom until a newer key becomes active, and keeps
+// verifying tokens until ExpiresAt. Give it an ExpiresAt at least one refresh token
+// lifetime (30 days) after its successor's ActiveFrom so existing sessions survive rotation.
+type KeyConfig struct {
+	KID            string    `json:"kid"`
+	Alg            string    `json:"alg"` // RS256 or EdDSA
+	PrivateKeyFile string    `json:"private_key_file,omitempty"`
+	PublicKeyFile  string    `json:"public_key_file,omitempty"` // verify-only keys (e.g. retired ones)
+	ActiveFrom     time.Time `json:"active_from,omitempty"`
+	ExpiresAt      time.Time `json:"expires_at,omitempty"`
+}
+
+// KeysetConfig is the on-disk format of JWT_KEYSET_FILE.
+type KeysetConfig struct {
+	Keys []KeyConfig `json:"keys"`
+}
+
+type jwtKey struct {
+	kid        string
+	method     jwt.SigningMethod
+	signer     any // private key (or HMAC secret); nil for verify-only keys
+	verifier   any // public key (or HMAC secret)
+	activeFrom time.Time
+	expiresAt  time.Time
+}
+
+func (k *jwtKey) usable(now time.Time) bool {
+	return k.expiresAt.IsZero() || now.Before(k.expiresAt)
+}
+
+// Keyset holds the keys used to sign and verify our JWTs. It is safe for concurrent use
+// and can be reloaded from disk while serving.
+type Keyset struct {
+	path string
+	// legacy verifies HS256 tokens without a kid, minted before asymmetric keys were introduced,
+	// until legacyUntil (no cutoff when zero, which only the dev keyset uses).
+	legacy      []byte
+	legacyUntil time.Time
+	// legacyLogged is the unix time legacy verification was last logged, to log it once a minute.
+	legacyLogged atomic.Int64
+
+	mu   sync.RWMutex
+	keys []*jwtKey
+}
+
+var defaultKeyset atomic.Pointer[Keyset]
+
+// SetDefaultKeyset installs the keyset used by SignJWT and ParseAndValidate.
+func SetDefaultKeyset(ks *Keyset) { defaultKeyset.Store(ks) }
+
+// DefaultKeyset returns the keyset installed with SetDefaultKeyset (nil if none).
+func DefaultKeyset() *Keyset { return defaultKeyset.Load() }
+
+// IsDevMode reports whether APP_ENV names a local development environment.
+func IsDevMode() bool {
+	switch os.Getenv("APP_ENV") {
+	case "dev", "development", "local":
+		return true
+	}
+	return false
+}
+
+// KeysetFromEnv builds the keyset from the environment:
+//   - JWT_KEYSET_FILE: JSON KeysetConfig with RS256/EdDSA keys (required outside dev mode).
+//   - JWT_SECRET: with a keyset, only verifies legacy HS256 tokens, and only until
+//     JWT_LEGACY_UNTIL (RFC 3339, required alongside it); in dev mode without a keyset it
+//     is the HS256 signing secret (falling back to a built-in dev secret).
+func KeysetFromEnv() (*Keyset, error) {
+	secret := os.Getenv("JWT_SECRET")
+	if path := os.Getenv("JWT_KEYSET_FILE"); path != "" {
+		ks := &Keyset{path: path}
+		if secret != "" {
+			until, err := time.Parse(time.RFC3339, os.Getenv("JWT_LEGACY_UNTIL"))
+			if err != nil {
+				return nil, errors.New("JWT_SECRET with a keyset needs JWT_LEGACY_UNTIL (RFC 3339), after which legacy HS256 tokens are rejected")
+			}
+			if time.Now().Before(until) {
+				ks.legacy, ks.legacyUntil = []byte(secret), until
+			} else {
+				log.Printf("JWT_LEGACY_UNTIL (%s) has passed; legacy HS256 tokens are rejected, unset JWT_SECRET", until.Format(time.RFC3339))
+			}
+		}
+		if err := ks.Reload(); err != nil {
+			return nil, err
+		}
+		return ks, nil
+	}
+	if !IsDevMode() {
+		return nil, errors.New("JWT_KEYSET_FILE is not set; HS256 signing is only allowed with APP_ENV=dev")
+	}
+	if secret == "" {
+		secret = devSecret
+	}
+	log.Println("warning: signing JWTs with HS256 (dev mode); configure JWT_KEYSET_FILE for production")
+	return &Keyset{
+		legacy: []byte(secret),
+		keys:   []*jwtKey{{kid: "dev", method: jwt.SigningMethodHS256, signer: []byte(secret), verifier: []byte(secret)}},
+	}, nil
+}
+
+// Reload re-reads the keyset file. On error the current keys stay in place.
+func (ks *Keyset) Reload() error {
+	if ks.path == "" {
+		return nil
+	}
+	raw, err := os.ReadFile(ks.path)
+	if err != nil {
+		return fmt.Errorf("read keyset: %w", err)
+	}
+	var cfg KeysetConfig
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return fmt.Errorf("parse keyset: %w", err)
+	}
+	dir := filepath.Dir(ks.path)
+	keys := make([]*jwtKey, 0, len(cfg.Keys))
+	seen := map[string]bool{}
+	canSign := false
+	for _, kc := range cfg.Keys {
+		if kc.KID == "" {
+			return errors.New("keyset: every key needs a kid")
+		}
+		if seen[kc.KID] {
+			return fmt.Errorf("keyset: duplicate kid %q", kc.KID)
+		}
+		seen[kc.KID] = true
+		k, err := loadKey(dir, kc)
+		if err != nil {
+			return fmt.Errorf("keyset: key %q: %w", kc.KID, err)
+		}
+		if k.signer != nil {
+			canSign = true
+		}
+		keys = append(keys, k)
+	}
+	if !canSign {
+		return errors.New("keyset: no key has a private_key_file")
+	}
+	ks.mu.Lock()
+	ks.keys = keys
+	ks.mu.Unlock()
+	return nil
+}
+
+// WatchFile reloads the keyset every interval so rotated keys are picked up without a restart.
+func (ks *Keyset) WatchFile(interval time.Duration) {
+	if ks.path == "" || interval <= 0 {
+		return
+	}
+	go func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for range t.C {
+			if err := ks.Reload(); err != nil {
+				log.Printf("jwt keyset reload failed (keeping current keys): %v", err)
+			}
+		}
+	}()
+}
+
+// signingKey picks the most recently activated key that can sign right now.
+func (ks *Keyset) signingKey(now time.Time) *jwtKey {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	var best *jwtKey
+	for _, k := range ks.keys {
+		if k.signer == nil || !k.usable(now) || now.Before(k.activeFrom) {
+			continue
+		}
+		if best == nil || k.activeFrom.After(best.activeFrom) {
+			best = k
+		}
+	}
+	return best
+}
+
+func (ks *Keyset) sign(claims jwt.Claims) (string, error) {
+	k := ks.signingKey(time.Now())
+	if k == nil {
+		return "", ErrNoSigningKey
+	}
+	token := jwt.NewWithClaims(k.method, claims)
+	token.Header["kid"] = k.kid
+	return token.SignedString(k.signer)
+}
+
+func (ks *Keyset) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	now := time.Now()
+	if kid == "" {
+		if ks.legacy == nil || token.Method != jwt.SigningMethodHS256 {
+			return nil, ErrUnknownKey
+		}
+		if !ks.legacyUntil.IsZero() {
+			if !now.Before(ks.legacyUntil) {
+				return nil, ErrUnknownKey
+			}
+			if last := ks.legacyLogged.Load(); now.Unix()-last >= 60 && ks.legacyLogged.CompareAndSwap(last, now.Unix()) {
+				log.Printf("jwt: accepted a legacy HS256 token without kid; legacy verification ends %s", ks.legacyUntil.Format(time.RFC3339))
+			}
+		}
+		return ks.legacy, nil
+	}
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	for _, k := range ks.keys {
+		if k.kid != kid {
+			continue
+		}
+		if !k.usable(now) {
+			return nil, ErrUnknownKey
+		}
+		if token.Method.Alg() != k.method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method %s for kid %q", token.Method.Alg(), kid)
+		}
+		return k.verifier, nil
+	}
+	return nil, ErrUnknownKey
+}
+
+// JWK is a single public key in JWKS format (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS is the public document served at /.well-known/jwks.json.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS lists the public keys other services should trust: every asymmetric key that has
+// not expired, including ones scheduled to activate later so verifiers can pre-fetch them.
+func (ks *Keyset) JWKS() JWKS {
+	now := time.Now()
+	out := JWKS{Keys: []JWK{}}
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	for _, k := range ks.keys {
+		if !k.usable(now) {
+			continue
+		}
+		switch pub := k.verifier.(type) {
+		case *rsa.PublicKey:
+			out.Keys = append(out.Keys, JWK{
+				Kty: "RSA", Kid: k.kid, Alg: k.method.Alg(), Use: "sig",
+				N: b64(pub.N.Bytes()),
+				E: b64(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			out.Keys = append(out.Keys, JWK{
+				Kty: "OKP", Kid: k.kid, Alg: k.method.Alg(), Use: "sig",
+				Crv: "Ed25519", X: b64(pub),
+			})
+		}
+	}
+	return out
+}
+
+func b64(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+func loadKey(dir string, kc KeyConfig) (*jwtKey, error) {
+	k := &jwtKey{kid: kc.KID, activeFrom: kc.ActiveFrom, expiresAt: kc.ExpiresAt}
+	switch kc.Alg {
+	case "RS256":
+		k.method = jwt.SigningMethodRS256
+	case "EdDSA":
+		k.method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported alg %q (use RS256 or EdDSA)", kc.Alg)
+	}
+
+	switch {
+	case kc.PrivateKeyFile != "":
+		block, err := readPEM(dir, kc.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		priv, err := parsePrivateKey(block)
+		if err != nil {
+			return nil, err
+		}
+		k.signer = priv
+		k.verifier = priv.Public()
+	case kc.PublicKeyFile != "":
+		block, err := readPEM(dir, kc.PublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		k.verifier = pub
+	default:
+		return nil, errors.New("private_key_file or public_key_file is required")
+	}
+
+	switch k.verifier.(type) {
+	case *rsa.PublicKey:
+		if kc.Alg != "RS256" {
+			return nil, errors.New("RSA key configured with non-RS256 alg")
+		}
+	case ed25519.PublicKey:
+		if kc.Alg != "EdDSA" {
+			return nil, errors.New("Ed25519 key configured with non-EdDSA alg")
+		}
+	default:
+		return nil, errors.New("unsupported key type")
+	}
+	return k, nil
+}
+
+func readPEM(dir, name string) (*pem.Block, error) {
+	if !filepath.IsAbs(name) {
+		name = filepath.Join(dir, name)
+	}
+	raw, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM block found", name)
+	}
+	return block, nil
+}
+
+func parsePrivateKey(block *pem.Block) (crypto.Signer, error) {
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, errors.New("unsupported private key type")
+		}
+		return signer, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, errors.New("private key must be PKCS#8 (RSA or Ed25519) or PKCS#1 RSA")
+}
@@ -0,0 +1,276 @@
+package auth
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// testKey is a generated key written to a PEM file next to the keyset.
+type testKey struct {
+	file string
+	priv crypto.Signer
+}
+
+func writeTestKey(t *testing.T, dir, name string, priv crypto.Signer) testKey {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := name + ".pem"
+	if err := os.WriteFile(filepath.Join(dir, file), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return testKey{file: file, priv: priv}
+}
+
+func newEdKey(t *testing.T, dir, name string) testKey {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return writeTestKey(t, dir, name, priv)
+}
+
+func newRSAKey(t *testing.T, dir, name string) testKey {
+	t.Helper()
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return writeTestKey(t, dir, name, priv)
+}
+
+// writeKeyset writes the keyset file and returns its path.
+func writeKeyset(t *testing.T, dir string, keys ...KeyConfig) string {
+	t.Helper()
+	raw, err := json.Marshal(KeysetConfig{Keys: keys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "keyset.json")
+	if err := os.WriteFile(path, raw, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// keysetFromFile loads path the way the server does, without a legacy secret.
+func keysetFromFile(t *testing.T, path string) *Keyset {
+	t.Helper()
+	t.Setenv("JWT_KEYSET_FILE", path)
+	t.Setenv("JWT_SECRET", "")
+	t.Setenv("JWT_LEGACY_UNTIL", "")
+	ks, err := KeysetFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ks
+}
+
+func signTest(t *testing.T, ks *Keyset) (string, string) {
+	t.Helper()
+	signed, err := ks.sign(&Claims{UserID: "u", RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tok, _, err := jwt.NewParser().ParseUnverified(signed, &Claims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	kid, _ := tok.Header["kid"].(string)
+	return signed, kid
+}
+
+func verifyTest(ks *Keyset, signed string) error {
+	_, err := jwt.ParseWithClaims(signed, &Claims{}, ks.keyFunc)
+	return err
+}
+
+func TestKeysetRotation(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	oldKey, newKey := newEdKey(t, dir, "old"), newRSAKey(t, dir, "new")
+	old := KeyConfig{KID: "old", Alg: "EdDSA", PrivateKeyFile: oldKey.file, ActiveFrom: now.Add(-48 * time.Hour)}
+	next := KeyConfig{KID: "new", Alg: "RS256", PrivateKeyFile: newKey.file, ActiveFrom: now.Add(time.Hour)}
+	path := writeKeyset(t, dir, old, next)
+	ks := keysetFromFile(t, path)
+
+	// The successor is not active yet: the old key keeps signing.
+	oldToken, kid := signTest(t, ks)
+	if kid != "old" {
+		t.Fatalf("kid = %q before rotation, want old", kid)
+	}
+
+	// Once the successor is active it signs, and the old key still verifies (overlap).
+	next.ActiveFrom = now.Add(-time.Minute)
+	writeKeyset(t, dir, old, next)
+	if err := ks.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	newToken, kid := signTest(t, ks)
+	if kid != "new" {
+		t.Fatalf("kid = %q after rotation, want new", kid)
+	}
+	for name, tok := range map[string]string{"old": oldToken, "new": newToken} {
+		if err := verifyTest(ks, tok); err != nil {
+			t.Errorf("%s token during overlap: %v", name, err)
+		}
+	}
+
+	// After the old key expires its tokens are rejected.
+	old.ExpiresAt = now.Add(-time.Second)
+	writeKeyset(t, dir, old, next)
+	if err := ks.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyTest(ks, oldToken); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("expired key err = %v, want ErrUnknownKey", err)
+	}
+	if err := verifyTest(ks, newToken); err != nil {
+		t.Errorf("new token after expiry of the old key: %v", err)
+	}
+}
+
+func TestKeysetRejectsUnknownKidAndAlgSwap(t *testing.T) {
+	dir := t.TempDir()
+	key := newEdKey(t, dir, "k1")
+	ks := keysetFromFile(t, writeKeyset(t, dir, KeyConfig{KID: "k1", Alg: "EdDSA", PrivateKeyFile: key.file}))
+
+	other := jwt.NewWithClaims(jwt.SigningMethodEdDSA, &Claims{})
+	other.Header["kid"] = "k2"
+	signed, err := other.SignedString(key.priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyTest(ks, signed); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("unknown kid err = %v, want ErrUnknownKey", err)
+	}
+
+	// An HS256 token naming an asymmetric kid must not be checked with that key.
+	swapped := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{})
+	swapped.Header["kid"] = "k1"
+	signed, err = swapped.SignedString([]byte("guess"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyTest(ks, signed); err == nil {
+		t.Error("HS256 token with an EdDSA kid was accepted")
+	}
+}
+
+func TestKeysetJWKS(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	rsaKey, edKey, retired := newRSAKey(t, dir, "rsa"), newEdKey(t, dir, "ed"), newEdKey(t, dir, "retired")
+	ks := keysetFromFile(t, writeKeyset(t, dir,
+		KeyConfig{KID: "rsa", Alg: "RS256", PrivateKeyFile: rsaKey.file},
+		KeyConfig{KID: "ed", Alg: "EdDSA", PrivateKeyFile: edKey.file, ActiveFrom: now.Add(time.Hour)},
+		KeyConfig{KID: "retired", Alg: "EdDSA", PrivateKeyFile: retired.file, ExpiresAt: now.Add(-time.Hour)},
+	))
+
+	raw, err := json.Marshal(ks.JWKS())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(raw, []byte(`"d"`)) {
+		t.Fatal("JWKS contains private key material")
+	}
+	var doc JWKS
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]JWK{}
+	for _, k := range doc.Keys {
+		got[k.Kid] = k
+	}
+	if len(got) != 2 {
+		t.Fatalf("keys = %v, want rsa and the not-yet-active ed", raw)
+	}
+	if _, ok := got["retired"]; ok {
+		t.Error("expired key is published")
+	}
+
+	r := got["rsa"]
+	pub := rsaKey.priv.Public().(*rsa.PublicKey)
+	n, _ := base64.RawURLEncoding.DecodeString(r.N)
+	e, _ := base64.RawURLEncoding.DecodeString(r.E)
+	if r.Kty != "RSA" || r.Alg != "RS256" || r.Use != "sig" || new(big.Int).SetBytes(n).Cmp(pub.N) != 0 || int(new(big.Int).SetBytes(e).Int64()) != pub.E {
+		t.Errorf("rsa jwk = %+v does not match the key", r)
+	}
+
+	ed := got["ed"]
+	x, _ := base64.RawURLEncoding.DecodeString(ed.X)
+	if ed.Kty != "OKP" || ed.Crv != "Ed25519" || ed.Alg != "EdDSA" || !bytes.Equal(x, edKey.priv.Public().(ed25519.PublicKey)) {
+		t.Errorf("ed jwk = %+v does not match the key", ed)
+	}
+}
+
+func TestKeysetLegacyCutoff(t *testing.T) {
+	dir := t.TempDir()
+	key := newEdKey(t, dir, "k1")
+	path := writeKeyset(t, dir, KeyConfig{KID: "k1", Alg: "EdDSA", PrivateKeyFile: key.file})
+	legacy, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{UserID: "u"}).SignedString([]byte("old-secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("JWT_KEYSET_FILE", path)
+	t.Setenv("JWT_SECRET", "old-secret")
+	t.Setenv("JWT_LEGACY_UNTIL", "")
+	if _, err := KeysetFromEnv(); err == nil {
+		t.Error("JWT_SECRET with a keyset but no JWT_LEGACY_UNTIL was accepted")
+	}
+
+	tests := []struct {
+		name   string
+		until  time.Time
+		accept bool
+	}{
+		{"before the cutoff", time.Now().Add(time.Hour), true},
+		{"after the cutoff", time.Now().Add(-time.Hour), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("JWT_LEGACY_UNTIL", tt.until.Format(time.RFC3339))
+			ks, err := KeysetFromEnv()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := verifyTest(ks, legacy); (err == nil) != tt.accept {
+				t.Errorf("legacy token err = %v, accept = %v", err, tt.accept)
+			}
+			// A keyset never signs with the legacy secret.
+			if _, kid := signTest(t, ks); kid != "k1" {
+				t.Errorf("signed with kid %q, want k1", kid)
+			}
+		})
+	}
+
+	// The cutoff is also enforced on a running server, not only at startup.
+	t.Setenv("JWT_LEGACY_UNTIL", time.Now().Add(time.Hour).Format(time.RFC3339))
+	ks, err := KeysetFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks.legacyUntil = time.Now().Add(-time.Second)
+	if err := verifyTest(ks, legacy); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("legacy token past the cutoff err = %v, want ErrUnknownKey", err)
+	}
+}
@@ -2,6 +2,7 @@ package auth
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/mikios34/delivery-backend/entity"
@@ -11,8 +12,28 @@ import (
 type Repository interface {
 	GetUserByPhone(ctx context.Context, phone string) (*entity.User, error)
 	GetUserByFirebaseUID(ctx context.Context, uid string) (*entity.User, error)
+	GetUserByID(ctx context.Context, id uuid.UUID) (*entity.User, error)
 
 	GetCourierByUserID(ctx context.Context, userID uuid.UUID) (*entity.Courier, error)
 	GetCustomerByUserID(ctx context.Context, userID uuid.UUID) (*entity.Customer, error)
 	GetAdminByUserID(ctx context.Context, userID uuid.UUID) (*entity.Admin, error)
+	GetAdminByID(ctx context.Context, id uuid.UUID) (*entity.Admin, error)
+	GetCourierByID(ctx context.Context, id uuid.UUID) (*entity.Courier, error)
+	GetCustomerByID(ctx context.Context, id uuid.UUID) (*entity.Customer, error)
+
+	// Refresh token sessions
+	CreateSession(ctx context.Context, s *entity.AuthSession) (*entity.AuthSession, error)
+	GetSessionByJTI(ctx context.Context, jti string) (*entity.AuthSession, error)
+	// MarkSessionRotated spends a session; it reports false if it was already rotated or revoked.
+	MarkSessionRotated(ctx context.Context, id uuid.UUID, at time.Time) (bool, error)
+	// RevokeFamily revokes every live token in the family owned by userID and returns how many were revoked.
+	RevokeFamily(ctx context.Context, userID, familyID uuid.UUID, reason string) (int64, error)
+	// ListActiveSessions returns the current (unrotated, unrevoked, unexpired) token of each family.
+	ListActiveSessions(ctx context.Context, userID uuid.UUID) ([]entity.AuthSession, error)
+	// IsFamilyRevoked reports whether the session family has been revoked.
+	IsFamilyRevoked(ctx context.Context, familyID uuid.UUID) (bool, error)
+
+	// Access token denylist
+	DenyToken(ctx context.Context, jti string, expiresAt time.Time) error
+	IsTokenDenied(ctx context.Context, jti string) (bool, error)
 }
@@ -2,11 +2,13 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	authpkg "github.com/mikios34/delivery-backend/auth"
 	"github.com/mikios34/delivery-backend/entity"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type GormAuthRepo struct {
@@ -33,6 +35,14 @@ func (r *GormAuthRepo) GetUserByFirebaseUID(ctx context.Context, uid string) (*e
 	return &u, nil
 }
 
+func (r *GormAuthRepo) GetUserByID(ctx context.Context, id uuid.UUID) (*entity.User, error) {
+	var u entity.User
+	if err := r.db.WithContext(ctx).First(&u, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
 func (r *GormAuthRepo) GetCourierByUserID(ctx context.Context, userID uuid.UUID) (*entity.Courier, error) {
 	var c entity.Courier
 	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&c).Error; err != nil {
@@ -56,3 +66,89 @@ func (r *GormAuthRepo) GetAdminByUserID(ctx context.Context, userID uuid.UUID) (
 	}
 	return &a, nil
 }
+
+func (r *GormAuthRepo) GetAdminByID(ctx context.Context, id uuid.UUID) (*entity.Admin, error) {
+	var a entity.Admin
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&a).Error; err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *GormAuthRepo) GetCourierByID(ctx context.Context, id uuid.UUID) (*entity.Courier, error) {
+	var c entity.Courier
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&c).Error; err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *GormAuthRepo) GetCustomerByID(ctx context.Context, id uuid.UUID) (*entity.Customer, error) {
+	var c entity.Customer
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&c).Error; err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *GormAuthRepo) CreateSession(ctx context.Context, s *entity.AuthSession) (*entity.AuthSession, error) {
+	if err := r.db.WithContext(ctx).Create(s).Error; err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (r *GormAuthRepo) GetSessionByJTI(ctx context.Context, jti string) (*entity.AuthSession, error) {
+	var s entity.AuthSession
+	if err := r.db.WithContext(ctx).Where("jti = ?", jti).First(&s).Error; err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *GormAuthRepo) MarkSessionRotated(ctx context.Context, id uuid.UUID, at time.Time) (bool, error) {
+	// Conditional update so two concurrent refreshes with the same token cannot both succeed.
+	res := r.db.WithContext(ctx).Model(&entity.AuthSession{}).
+		Where("id = ? AND rotated_at IS NULL AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{"rotated_at": at, "last_used_at": at})
+	return res.RowsAffected == 1, res.Error
+}
+
+func (r *GormAuthRepo) RevokeFamily(ctx context.Context, userID, familyID uuid.UUID, reason string) (int64, error) {
+	res := r.db.WithContext(ctx).Model(&entity.AuthSession{}).
+		Where("user_id = ? AND family_id = ? AND revoked_at IS NULL", userID, familyID).
+		Updates(map[string]interface{}{"revoked_at": time.Now(), "revoked_reason": reason})
+	return res.RowsAffected, res.Error
+}
+
+func (r *GormAuthRepo) ListActiveSessions(ctx context.Context, userID uuid.UUID) ([]entity.AuthSession, error) {
+	var list []entity.AuthSession
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ? AND rotated_at IS NULL AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_used_at DESC").
+		Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *GormAuthRepo) IsFamilyRevoked(ctx context.Context, familyID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&entity.AuthSession{}).
+		Where("family_id = ? AND revoked_at IS NOT NULL", familyID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *GormAuthRepo) DenyToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	rec := &entity.RevokedToken{JTI: jti, ExpiresAt: expiresAt}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(rec).Error
+}
+
+func (r *GormAuthRepo) IsTokenDenied(ctx context.Context, jti string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&entity.RevokedToken{}).
+		Where("jti = ? AND expires_at > ?", jti, time.Now()).
+		Count(&count).Error
+	return count > 0, err
+}
@@ -2,23 +2,85 @@ package auth
 
 import (
 	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/entity"
+)
+
+var (
+	// ErrInvalidRefreshToken is returned for unknown, expired or revoked refresh tokens.
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// ErrRefreshTokenReused is returned when an already-rotated refresh token is presented;
+	// the whole session family is revoked as it has likely been stolen.
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected; session revoked")
+	// ErrPhoneNotVerified is returned for phone-only logins without a verified OTP code.
+	ErrPhoneNotVerified = errors.New("phone login requires a verified one-time code")
+	// ErrSessionNotFound is returned when revoking a session the user does not own.
+	ErrSessionNotFound = errors.New("session not found")
+	// ErrRoleNotHeld is returned when signing in as (or switching to) a role the user has
+	// no profile for.
+	ErrRoleNotHeld = errors.New("user has no profile for this role")
+	// ErrInvalidImpersonation is returned for impersonation targets other than a customer
+	// or courier profile.
+	ErrInvalidImpersonation = errors.New("only customer and courier profiles can be impersonated")
+)
+
+// Impersonation tokens are access tokens of their own type, so they are never mistaken
+// for a sign-in; they have no refresh token and no session.
+const (
+	TokenImpersonation = "impersonation"
+	// ScopeRead tokens are rejected by every endpoint that changes data.
+	ScopeRead = "read"
+	// ScopeWrite tokens may change data, except on endpoints that refuse impersonation outright.
+	ScopeWrite = "write"
+)
+
+// Impersonation token lifetimes.
+const (
+	DefaultImpersonationTTL = 15 * time.Minute
+	MaxImpersonationTTL     = time.Hour
 )
 
+// DeviceInfo describes the client a session was opened from.
+type DeviceInfo struct {
+	Name      string
+	UserAgent string
+	IP        string
+}
+
 // LoginRequest supports two modes: phone or firebase_uid. One must be provided.
 type LoginRequest struct {
-	Phone       string
+	Phone string
+	// FirebaseUID must come from a verified Firebase ID token, never from the request body.
 	FirebaseUID string
+	// PhoneVerified must be set by the caller once the phone was proven via OTP;
+	// phone-only logins are rejected without it.
+	PhoneVerified bool
+	// Role selects which of the user's profiles the tokens are scoped to; empty picks
+	// the primary role (users.role) or the first profile the user holds.
+	Role   string
+	Device DeviceInfo
 }
 
 type Principal struct {
 	UserID string `json:"user_id"`
-	Role   string `json:"-"` // used for JWT only; not returned in response
+	Role   string `json:"role"` // the role the tokens are scoped to
+	// Roles lists every role the user holds a profile for (see POST /auth/switch-role).
+	Roles []string `json:"roles,omitempty"`
 	// Optional: attach specific profile IDs based on role
 	CourierID    string `json:"courier_id,omitempty"`
 	CustomerID   string `json:"customer_id,omitempty"`
 	AdminID      string `json:"admin_id,omitempty"`
+	AdminRole    string `json:"admin_role,omitempty"`
 	Token        string `json:"token"`
 	RefreshToken string `json:"refresh_token"`
+	// SessionID identifies the refresh token family (see GET /sessions).
+	SessionID string `json:"session_id,omitempty"`
+	// Set on impersonation tokens only.
+	ImpersonatorAdminID string `json:"impersonator_admin_id,omitempty"`
+	ImpersonationScope  string `json:"impersonation_scope,omitempty"`
 	// User profile details included in login response for convenience
 	FirstName      string  `json:"first_name"`
 	LastName       string  `json:"last_name"`
@@ -26,8 +88,45 @@ type Principal struct {
 	ProfilePicture *string `json:"profile_picture,omitempty"`
 }
 
-// Service provides login/auth operations (no password; trusts Firebase UID or phone verified externally).
+// ImpersonationRequest asks for a token acting as a customer or courier profile.
+type ImpersonationRequest struct {
+	AdminID   uuid.UUID
+	Role      string // "customer" or "courier"
+	ProfileID uuid.UUID
+	Scope     string
+	TTL       time.Duration
+}
+
+// Impersonation is a minted impersonation token. Principal describes who it acts as and
+// carries no tokens itself.
+type Impersonation struct {
+	Token     string     `json:"token"`
+	TokenID   string     `json:"token_id"` // jti, used to revoke the token
+	Scope     string     `json:"scope"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	Principal *Principal `json:"principal"`
+}
+
+// Service provides login/auth operations (no password; trusts a verified Firebase UID or an OTP-verified phone).
 type Service interface {
 	Login(ctx context.Context, req LoginRequest) (*Principal, error)
-	Refresh(ctx context.Context, refreshToken string) (*Principal, error)
+	// Refresh rotates the refresh token: the presented token is spent and a new pair is issued.
+	Refresh(ctx context.Context, refreshToken string, device DeviceInfo) (*Principal, error)
+	// Issue signs a fresh access/refresh pair for the principal and opens a new session.
+	Issue(ctx context.Context, p *Principal, device DeviceInfo) error
+
+	// SwitchRole signs the user in as another role they hold, in a new session, and
+	// revokes the session the caller was signed in with.
+	SwitchRole(ctx context.Context, userID, sessionID uuid.UUID, role string, device DeviceInfo) (*Principal, error)
+
+	// Logout revokes the session (refresh token family) the caller is signed in with.
+	Logout(ctx context.Context, userID, sessionID uuid.UUID) error
+	// RevokeAccessToken denylists a single access token until its expiry.
+	RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error
+	ListSessions(ctx context.Context, userID uuid.UUID) ([]entity.AuthSession, error)
+	RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error
+
+	// Impersonate signs a token acting as the profile on behalf of an admin. The caller
+	// checks the admin's permissions for the requested scope.
+	Impersonate(ctx context.Context, req ImpersonationRequest) (*Impersonation, error)
 }
@@ -2,12 +2,20 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
-	"os"
 	"time"
 
+	"github.com/google/uuid"
 	authpkg "github.com/mikios34/delivery-backend/auth"
 	"github.com/mikios34/delivery-backend/entity"
+	"gorm.io/gorm"
+)
+
+const (
+	accessTTL  = 15 * time.Minute
+	refreshTTL = 30 * 24 * time.Hour
 )
 
 type authService struct {
@@ -18,10 +26,18 @@ func NewAuthService(repo authpkg.Repository) authpkg.Service {
 	return &authService{repo: repo}
 }
 
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 func (s *authService) Login(ctx context.Context, req authpkg.LoginRequest) (*authpkg.Principal, error) {
 	if req.FirebaseUID == "" && req.Phone == "" {
 		return nil, errors.New("either firebase_uid or phone is required")
 	}
+	if req.FirebaseUID == "" && !req.PhoneVerified {
+		return nil, authpkg.ErrPhoneNotVerified
+	}
 
 	var user *entity.User
 	var err error
@@ -34,66 +50,169 @@ func (s *authService) Login(ctx context.Context, req authpkg.LoginRequest) (*aut
 		return nil, err
 	}
 
+	p, err := s.principalFor(ctx, user, req.Role)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Issue(ctx, p, req.Device); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// principalFor scopes a principal to one of the user's profiles. An empty role picks the
+// primary role when its profile exists, else the first profile found.
+func (s *authService) principalFor(ctx context.Context, user *entity.User, role string) (*authpkg.Principal, error) {
 	p := &authpkg.Principal{
 		UserID:         user.ID.String(),
-		Role:           user.Role,
 		FirstName:      user.FirstName,
 		LastName:       user.LastName,
 		Phone:          user.Phone,
 		ProfilePicture: user.ProfilePicture,
 	}
-	switch user.Role {
+	var courierProfile *entity.Courier
+	var customerProfile *entity.Customer
+	var adminProfile *entity.Admin
+	if c, err := s.repo.GetCourierByUserID(ctx, user.ID); err == nil {
+		courierProfile = c
+		p.Roles = append(p.Roles, "courier")
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if c, err := s.repo.GetCustomerByUserID(ctx, user.ID); err == nil {
+		customerProfile = c
+		p.Roles = append(p.Roles, "customer")
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if a, err := s.repo.GetAdminByUserID(ctx, user.ID); err == nil {
+		adminProfile = a
+		p.Roles = append(p.Roles, "admin")
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if role == "" {
+		role = user.Role
+		if !holds(p.Roles, role) && len(p.Roles) > 0 {
+			role = p.Roles[0]
+		}
+	}
+	if !holds(p.Roles, role) {
+		return nil, authpkg.ErrRoleNotHeld
+	}
+	p.Role = role
+	switch role {
 	case "courier":
-		c, err := s.repo.GetCourierByUserID(ctx, user.ID)
-		if err == nil {
-			p.CourierID = c.ID.String()
+		if !courierProfile.Active {
+			return nil, authpkg.ErrProfileInactive
 		}
+		p.CourierID = courierProfile.ID.String()
 	case "customer":
-		c, err := s.repo.GetCustomerByUserID(ctx, user.ID)
-		if err == nil {
-			p.CustomerID = c.ID.String()
+		if customerProfile.SuspendedAt != nil {
+			return nil, authpkg.ErrProfileSuspended
+		}
+		if !customerProfile.Active {
+			return nil, authpkg.ErrProfileInactive
 		}
+		p.CustomerID = customerProfile.ID.String()
 	case "admin":
-		a, err := s.repo.GetAdminByUserID(ctx, user.ID)
-		if err == nil {
-			p.AdminID = a.ID.String()
+		if !adminProfile.Active {
+			return nil, authpkg.ErrProfileInactive
+		}
+		p.AdminID = adminProfile.ID.String()
+		p.AdminRole = string(adminProfile.Role)
+	}
+	return p, nil
+}
+
+func holds(roles []string, role string) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
 		}
 	}
+	return false
+}
+
+// Issue opens a new session family for the principal.
+func (s *authService) Issue(ctx context.Context, p *authpkg.Principal, device authpkg.DeviceInfo) error {
+	return s.issue(ctx, p, uuid.New(), time.Now(), device)
+}
 
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		secret = "dev-insecure-secret-change-me"
+// issue signs an access/refresh pair in the given family and persists the refresh token.
+func (s *authService) issue(ctx context.Context, p *authpkg.Principal, familyID uuid.UUID, signedInAt time.Time, device authpkg.DeviceInfo) error {
+	userID, err := uuid.Parse(p.UserID)
+	if err != nil {
+		return err
 	}
-	accessTTL := 15 * time.Minute
-	refreshTTL := 30 * 24 * time.Hour
-	token, err := authpkg.SignJWT(secret, p, accessTTL, "access")
+	p.SessionID = familyID.String()
+	token, _, err := authpkg.SignJWT(p, accessTTL, "access")
 	if err != nil {
-		return nil, err
+		return err
 	}
-	p.Token = token
-	refresh, err := authpkg.SignJWT(secret, p, refreshTTL, "refresh")
+	refresh, claims, err := authpkg.SignJWT(p, refreshTTL, "refresh")
 	if err != nil {
-		return nil, err
+		return err
+	}
+	now := time.Now()
+	sess := &entity.AuthSession{
+		FamilyID:   familyID,
+		UserID:     userID,
+		Role:       p.Role,
+		JTI:        claims.ID,
+		TokenHash:  hashToken(refresh),
+		DeviceName: device.Name,
+		UserAgent:  device.UserAgent,
+		IP:         device.IP,
+		SignedInAt: signedInAt,
+		LastUsedAt: now,
+		ExpiresAt:  claims.ExpiresAt.Time,
+	}
+	if _, err := s.repo.CreateSession(ctx, sess); err != nil {
+		return err
 	}
+	p.Token = token
 	p.RefreshToken = refresh
-	return p, nil
+	return nil
 }
 
-func (s *authService) Refresh(ctx context.Context, refreshToken string) (*authpkg.Principal, error) {
+func (s *authService) Refresh(ctx context.Context, refreshToken string, device authpkg.DeviceInfo) (*authpkg.Principal, error) {
 	if refreshToken == "" {
 		return nil, errors.New("missing refresh token")
 	}
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		secret = "dev-insecure-secret-change-me"
-	}
-	claims, err := authpkg.ParseAndValidate(secret, refreshToken)
+	claims, err := authpkg.ParseAndValidate(refreshToken)
 	if err != nil {
 		return nil, err
 	}
 	if claims.TokenType != "refresh" {
 		return nil, errors.New("invalid token type")
 	}
+	sess, err := s.repo.GetSessionByJTI(ctx, claims.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, authpkg.ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+	if sess.TokenHash != hashToken(refreshToken) || sess.RevokedAt != nil {
+		return nil, authpkg.ErrInvalidRefreshToken
+	}
+	// A rotated token being presented again means two parties hold it: kill the family.
+	if sess.RotatedAt != nil {
+		_, _ = s.repo.RevokeFamily(ctx, sess.UserID, sess.FamilyID, "reuse_detected")
+		return nil, authpkg.ErrRefreshTokenReused
+	}
+	ok, err := s.repo.MarkSessionRotated(ctx, sess.ID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		// Lost a race with a concurrent refresh using the same token.
+		_, _ = s.repo.RevokeFamily(ctx, sess.UserID, sess.FamilyID, "reuse_detected")
+		return nil, authpkg.ErrRefreshTokenReused
+	}
+
 	// Build principal from claims
 	p := &authpkg.Principal{
 		UserID:     claims.UserID,
@@ -102,14 +221,117 @@ func (s *authService) Refresh(ctx context.Context, refreshToken string) (*authpk
 		CustomerID: claims.CustomerID,
 		AdminID:    claims.AdminID,
 	}
-	accessTTL := 15 * time.Minute
-	token, err := authpkg.SignJWT(secret, p, accessTTL, "access")
+	// Admin roles can change during a session; always sign the current one.
+	if p.Role == "admin" {
+		a, err := s.repo.GetAdminByUserID(ctx, sess.UserID)
+		if err != nil {
+			return nil, err
+		}
+		p.AdminID = a.ID.String()
+		p.AdminRole = string(a.Role)
+	}
+	// Keep the device label from sign-in unless the client sends a new one.
+	if device.Name == "" {
+		device.Name = sess.DeviceName
+	}
+	if err := s.issue(ctx, p, sess.FamilyID, sess.SignedInAt, device); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (s *authService) SwitchRole(ctx context.Context, userID, sessionID uuid.UUID, role string, device authpkg.DeviceInfo) (*authpkg.Principal, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
-	p.Token = token
-	// Return same refresh token or issue a new one (rotation optional)
-	// For simplicity, return the same refresh token
-	p.RefreshToken = refreshToken
+	p, err := s.principalFor(ctx, user, role)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Issue(ctx, p, device); err != nil {
+		return nil, err
+	}
+	// The old session's tokens carry the previous role; retire them.
+	if _, err := s.repo.RevokeFamily(ctx, userID, sessionID, "role_switched"); err != nil {
+		return nil, err
+	}
 	return p, nil
 }
+
+func (s *authService) Logout(ctx context.Context, userID, sessionID uuid.UUID) error {
+	_, err := s.repo.RevokeFamily(ctx, userID, sessionID, "logout")
+	return err
+}
+
+func (s *authService) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	if jti == "" {
+		return nil
+	}
+	return s.repo.DenyToken(ctx, jti, expiresAt)
+}
+
+func (s *authService) ListSessions(ctx context.Context, userID uuid.UUID) ([]entity.AuthSession, error) {
+	return s.repo.ListActiveSessions(ctx, userID)
+}
+
+func (s *authService) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	n, err := s.repo.RevokeFamily(ctx, userID, sessionID, "revoked_by_user")
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return authpkg.ErrSessionNotFound
+	}
+	return nil
+}
+
+func (s *authService) Impersonate(ctx context.Context, req authpkg.ImpersonationRequest) (*authpkg.Impersonation, error) {
+	if req.Scope != authpkg.ScopeRead && req.Scope != authpkg.ScopeWrite {
+		req.Scope = authpkg.ScopeRead
+	}
+	if req.TTL <= 0 {
+		req.TTL = authpkg.DefaultImpersonationTTL
+	}
+	if req.TTL > authpkg.MaxImpersonationTTL {
+		req.TTL = authpkg.MaxImpersonationTTL
+	}
+	var userID uuid.UUID
+	switch req.Role {
+	case "courier":
+		c, err := s.repo.GetCourierByID(ctx, req.ProfileID)
+		if err != nil {
+			return nil, err
+		}
+		userID = c.UserID
+	case "customer":
+		c, err := s.repo.GetCustomerByID(ctx, req.ProfileID)
+		if err != nil {
+			return nil, err
+		}
+		userID = c.UserID
+	default:
+		return nil, authpkg.ErrInvalidImpersonation
+	}
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	p, err := s.principalFor(ctx, user, req.Role)
+	if err != nil {
+		return nil, err
+	}
+	p.ImpersonatorAdminID = req.AdminID.String()
+	p.ImpersonationScope = req.Scope
+	token, claims, err := authpkg.SignJWT(p, req.TTL, authpkg.TokenImpersonation)
+	if err != nil {
+		return nil, err
+	}
+	return &authpkg.Impersonation{
+		Token:     token,
+		TokenID:   claims.ID,
+		Scope:     req.Scope,
+		ExpiresAt: claims.ExpiresAt.Time,
+		Principal: p,
+	}, nil
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	authpkg "github.com/mikios34/delivery-backend/auth"
+	"github.com/mikios34/delivery-backend/entity"
+	"gorm.io/gorm"
+)
+
+// memSessions is an in-memory auth.Repository covering refresh token sessions; any other
+// method panics through the nil embedded interface.
+type memSessions struct {
+	authpkg.Repository
+	byJTI map[string]*entity.AuthSession
+}
+
+func (r *memSessions) CreateSession(_ context.Context, s *entity.AuthSession) (*entity.AuthSession, error) {
+	s.ID = uuid.New()
+	r.byJTI[s.JTI] = s
+	return s, nil
+}
+
+func (r *memSessions) GetSessionByJTI(_ context.Context, jti string) (*entity.AuthSession, error) {
+	s, ok := r.byJTI[jti]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	cp := *s
+	return &cp, nil
+}
+
+func (r *memSessions) MarkSessionRotated(_ context.Context, id uuid.UUID, at time.Time) (bool, error) {
+	for _, s := range r.byJTI {
+		if s.ID == id {
+			if s.RotatedAt != nil || s.RevokedAt != nil {
+				return false, nil
+			}
+			s.RotatedAt = &at
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memSessions) RevokeFamily(_ context.Context, userID, familyID uuid.UUID, reason string) (int64, error) {
+	var n int64
+	now := time.Now()
+	for _, s := range r.byJTI {
+		if s.UserID == userID && s.FamilyID == familyID && s.RevokedAt == nil {
+			s.RevokedAt, s.RevokedReason = &now, reason
+			n++
+		}
+	}
+	return n, nil
+}
+
+// newRefreshFixture signs in one customer twice, giving two independent session families.
+func newRefreshFixture(t *testing.T) (*memSessions, authpkg.Service, *authpkg.Principal, *authpkg.Principal) {
+	t.Helper()
+	t.Setenv("APP_ENV", "dev")
+	t.Setenv("JWT_SECRET", "test-secret")
+	t.Setenv("JWT_KEYSET_FILE", "")
+	ks, err := authpkg.KeysetFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	authpkg.SetDefaultKeyset(ks)
+
+	repo := &memSessions{byJTI: map[string]*entity.AuthSession{}}
+	svc := NewAuthService(repo)
+	userID, customerID := uuid.NewString(), uuid.NewString()
+	var ps []*authpkg.Principal
+	for i := 0; i < 2; i++ {
+		p := &authpkg.Principal{UserID: userID, Role: "customer", CustomerID: customerID}
+		if err := svc.Issue(context.Background(), p, authpkg.DeviceInfo{Name: "phone"}); err != nil {
+			t.Fatal(err)
+		}
+		ps = append(ps, p)
+	}
+	return repo, svc, ps[0], ps[1]
+}
+
+func TestRefreshRotates(t *testing.T) {
+	_, svc, first, _ := newRefreshFixture(t)
+	ctx := context.Background()
+
+	next, err := svc.Refresh(ctx, first.RefreshToken, authpkg.DeviceInfo{})
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if next.RefreshToken == first.RefreshToken {
+		t.Fatal("refresh returned the same refresh token")
+	}
+	if next.SessionID != first.SessionID {
+		t.Errorf("session = %s, want the family %s", next.SessionID, first.SessionID)
+	}
+	if _, err := svc.Refresh(ctx, next.RefreshToken, authpkg.DeviceInfo{}); err != nil {
+		t.Errorf("refresh with the rotated-in token: %v", err)
+	}
+}
+
+func TestRefreshReuseRevokesFamily(t *testing.T) {
+	repo, svc, first, other := newRefreshFixture(t)
+	ctx := context.Background()
+
+	next, err := svc.Refresh(ctx, first.RefreshToken, authpkg.DeviceInfo{})
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	// A second party replays the spent token.
+	if _, err := svc.Refresh(ctx, first.RefreshToken, authpkg.DeviceInfo{}); !errors.Is(err, authpkg.ErrRefreshTokenReused) {
+		t.Fatalf("replay err = %v, want ErrRefreshTokenReused", err)
+	}
+	// The legitimate holder's newer token died with the family.
+	if _, err := svc.Refresh(ctx, next.RefreshToken, authpkg.DeviceInfo{}); !errors.Is(err, authpkg.ErrInvalidRefreshToken) {
+		t.Errorf("newest token err = %v, want ErrInvalidRefreshToken", err)
+	}
+	for _, s := range repo.byJTI {
+		if s.FamilyID.String() == first.SessionID && s.RevokedAt == nil && s.RotatedAt == nil {
+			t.Errorf("session %s in the reused family is still live", s.JTI)
+		}
+	}
+	// Other sign-ins of the same user are untouched.
+	if _, err := svc.Refresh(ctx, other.RefreshToken, authpkg.DeviceInfo{}); err != nil {
+		t.Errorf("refresh in another family: %v", err)
+	}
+}
+
+func TestRefreshRejectsAccessAndUnknownTokens(t *testing.T) {
+	repo, svc, first, _ := newRefreshFixture(t)
+	ctx := context.Background()
+
+	if _, err := svc.Refresh(ctx, first.Token, authpkg.DeviceInfo{}); err == nil {
+		t.Error("an access token was accepted as a refresh token")
+	}
+	for jti := range repo.byJTI {
+		delete(repo.byJTI, jti)
+	}
+	if _, err := svc.Refresh(ctx, first.RefreshToken, authpkg.DeviceInfo{}); !errors.Is(err, authpkg.ErrInvalidRefreshToken) {
+		t.Errorf("unknown session err = %v, want ErrInvalidRefreshToken", err)
+	}
+}
@@ -0,0 +1,209 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	adminpkg "github.com/mikios34/delivery-backend/admin"
+	"gorm.io/gorm"
+)
+
+// Principal rejection codes returned to clients alongside a 401.
+const (
+	CodeTokenRevoked       = "token_revoked"
+	CodeSessionRevoked     = "session_revoked"
+	CodePrincipalNotFound  = "principal_not_found"
+	CodeProfileInactive    = "profile_inactive"
+	CodeProfileSuspended   = "profile_suspended"
+	CodeRoleChanged        = "role_changed"
+	CodeImpersonationEnded = "impersonation_ended"
+)
+
+// PrincipalError is returned when a validly signed token must nevertheless be rejected.
+type PrincipalError struct {
+	Code    string
+	Message string
+}
+
+func (e *PrincipalError) Error() string { return e.Message }
+
+var (
+	ErrTokenRevoked       = &PrincipalError{Code: CodeTokenRevoked, Message: "token has been revoked"}
+	ErrSessionRevoked     = &PrincipalError{Code: CodeSessionRevoked, Message: "session has been revoked"}
+	ErrPrincipalNotFound  = &PrincipalError{Code: CodePrincipalNotFound, Message: "user or profile no longer exists"}
+	ErrProfileInactive    = &PrincipalError{Code: CodeProfileInactive, Message: "profile is deactivated"}
+	ErrProfileSuspended   = &PrincipalError{Code: CodeProfileSuspended, Message: "account is suspended; contact support"}
+	ErrRoleChanged        = &PrincipalError{Code: CodeRoleChanged, Message: "admin role changed; refresh the token"}
+	ErrImpersonationEnded = &PrincipalError{Code: CodeImpersonationEnded, Message: "impersonating admin is no longer permitted"}
+)
+
+// maxCacheEntries bounds the validator cache; expired entries are swept when it fills up.
+const maxCacheEntries = 10000
+
+type cacheEntry struct {
+	err     error
+	expires time.Time
+}
+
+// PrincipalValidator re-checks token principals against the database: the user still exists,
+// the role profile is still active, and neither the token nor its session was revoked.
+// Results are cached per token for a short TTL so hot clients do not hit the DB per request.
+type PrincipalValidator struct {
+	repo Repository
+	ttl  time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cacheEntry
+}
+
+// NewPrincipalValidator constructs a validator caching decisions for ttl.
+func NewPrincipalValidator(repo Repository, ttl time.Duration) *PrincipalValidator {
+	return &PrincipalValidator{repo: repo, ttl: ttl, cache: make(map[string]cacheEntry)}
+}
+
+// ValidatePrincipal returns nil when the principal may proceed, a *PrincipalError when the
+// token must be rejected, or another error if validation itself failed.
+func (v *PrincipalValidator) ValidatePrincipal(ctx context.Context, claims *Claims) error {
+	key := claims.ID
+	if key == "" {
+		// Tokens minted before jti existed: cache by identity instead.
+		key = claims.UserID + "|" + claims.Role + "|" + claims.CourierID + claims.CustomerID + claims.AdminID
+	}
+	now := time.Now()
+	v.mu.Lock()
+	if e, ok := v.cache[key]; ok && now.Before(e.expires) {
+		v.mu.Unlock()
+		return e.err
+	}
+	v.mu.Unlock()
+
+	err := v.check(ctx, claims)
+	var perr *PrincipalError
+	// Only cache definitive answers; transient DB errors are retried on the next request.
+	if err == nil || errors.As(err, &perr) {
+		v.mu.Lock()
+		if len(v.cache) >= maxCacheEntries {
+			for k, e := range v.cache {
+				if now.After(e.expires) {
+					delete(v.cache, k)
+				}
+			}
+		}
+		v.cache[key] = cacheEntry{err: err, expires: now.Add(v.ttl)}
+		v.mu.Unlock()
+	}
+	return err
+}
+
+func (v *PrincipalValidator) check(ctx context.Context, claims *Claims) error {
+	if claims.ID != "" {
+		denied, err := v.repo.IsTokenDenied(ctx, claims.ID)
+		if err != nil {
+			return err
+		}
+		if denied {
+			return ErrTokenRevoked
+		}
+	}
+	if sid, err := uuid.Parse(claims.SessionID); err == nil {
+		revoked, err := v.repo.IsFamilyRevoked(ctx, sid)
+		if err != nil {
+			return err
+		}
+		if revoked {
+			return ErrSessionRevoked
+		}
+	}
+
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return ErrPrincipalNotFound
+	}
+	if _, err := v.repo.GetUserByID(ctx, userID); err != nil {
+		return notFoundOr(err)
+	}
+
+	var profileID string
+	var active bool
+	switch claims.Role {
+	case "courier":
+		c, err := v.repo.GetCourierByUserID(ctx, userID)
+		if err != nil {
+			return notFoundOr(err)
+		}
+		profileID, active = c.ID.String(), c.Active
+		if claims.CourierID != "" && claims.CourierID != profileID {
+			return ErrPrincipalNotFound
+		}
+	case "customer":
+		c, err := v.repo.GetCustomerByUserID(ctx, userID)
+		if err != nil {
+			return notFoundOr(err)
+		}
+		profileID, active = c.ID.String(), c.Active
+		if claims.CustomerID != "" && claims.CustomerID != profileID {
+			return ErrPrincipalNotFound
+		}
+		if c.SuspendedAt != nil {
+			return ErrProfileSuspended
+		}
+	case "admin":
+		a, err := v.repo.GetAdminByUserID(ctx, userID)
+		if err != nil {
+			return notFoundOr(err)
+		}
+		profileID, active = a.ID.String(), a.Active
+		if claims.AdminID != "" && claims.AdminID != profileID {
+			return ErrPrincipalNotFound
+		}
+		if active && claims.AdminRole != string(a.Role) {
+			return ErrRoleChanged
+		}
+	default:
+		return ErrPrincipalNotFound
+	}
+	if !active {
+		return ErrProfileInactive
+	}
+	if claims.ImpersonatorAdminID != "" {
+		return v.checkImpersonator(ctx, claims)
+	}
+	return nil
+}
+
+// checkImpersonator ends an impersonation token once its admin is deactivated or loses
+// the permission for the token's scope.
+func (v *PrincipalValidator) checkImpersonator(ctx context.Context, claims *Claims) error {
+	if claims.Role != "courier" && claims.Role != "customer" {
+		return ErrImpersonationEnded
+	}
+	adminID, err := uuid.Parse(claims.ImpersonatorAdminID)
+	if err != nil {
+		return ErrImpersonationEnded
+	}
+	a, err := v.repo.GetAdminByID(ctx, adminID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrImpersonationEnded
+	}
+	if err != nil {
+		return err
+	}
+	perm := adminpkg.PermImpersonate
+	if claims.ImpersonationScope == ScopeWrite {
+		perm = adminpkg.PermImpersonateWrite
+	}
+	if !a.Active || !adminpkg.HasPermission(a.Role, perm) {
+		return ErrImpersonationEnded
+	}
+	return nil
+}
+
+func notFoundOr(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrPrincipalNotFound
+	}
+	return err
+}
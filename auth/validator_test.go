@@ -0,0 +1,233 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/entity"
+	"gorm.io/gorm"
+)
+
+// memPrincipals is an in-memory Repository covering what the validator reads; any other
+// method panics through the nil embedded interface.
+type memPrincipals struct {
+	Repository
+	users     map[uuid.UUID]bool
+	couriers  map[uuid.UUID]*entity.Courier // by user id
+	customers map[uuid.UUID]*entity.Customer
+	admins    map[uuid.UUID]*entity.Admin
+	denied    map[string]bool
+	revoked   map[uuid.UUID]bool
+	// fail makes every lookup return a transient error.
+	fail error
+	// reads counts IsTokenDenied calls, i.e. uncached validations.
+	reads int
+}
+
+func newMemPrincipals() *memPrincipals {
+	return &memPrincipals{
+		users:     map[uuid.UUID]bool{},
+		couriers:  map[uuid.UUID]*entity.Courier{},
+		customers: map[uuid.UUID]*entity.Customer{},
+		admins:    map[uuid.UUID]*entity.Admin{},
+		denied:    map[string]bool{},
+		revoked:   map[uuid.UUID]bool{},
+	}
+}
+
+func (r *memPrincipals) IsTokenDenied(_ context.Context, jti string) (bool, error) {
+	r.reads++
+	return r.denied[jti], r.fail
+}
+
+func (r *memPrincipals) IsFamilyRevoked(_ context.Context, id uuid.UUID) (bool, error) {
+	return r.revoked[id], r.fail
+}
+
+func (r *memPrincipals) GetUserByID(_ context.Context, id uuid.UUID) (*entity.User, error) {
+	if !r.users[id] {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &entity.User{ID: id}, nil
+}
+
+func (r *memPrincipals) GetCourierByUserID(_ context.Context, id uuid.UUID) (*entity.Courier, error) {
+	if c, ok := r.couriers[id]; ok {
+		return c, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memPrincipals) GetCustomerByUserID(_ context.Context, id uuid.UUID) (*entity.Customer, error) {
+	if c, ok := r.customers[id]; ok {
+		return c, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memPrincipals) GetAdminByUserID(_ context.Context, id uuid.UUID) (*entity.Admin, error) {
+	if a, ok := r.admins[id]; ok {
+		return a, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memPrincipals) GetAdminByID(_ context.Context, id uuid.UUID) (*entity.Admin, error) {
+	for _, a := range r.admins {
+		if a.ID == id {
+			return a, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// principalFixture holds one live user of each role.
+type principalFixture struct {
+	repo     *memPrincipals
+	courier  *Claims
+	customer *Claims
+	admin    *Claims
+}
+
+func newPrincipalFixture() *principalFixture {
+	r := newMemPrincipals()
+	claimsFor := func(role string) (*Claims, uuid.UUID) {
+		userID := uuid.New()
+		r.users[userID] = true
+		c := &Claims{UserID: userID.String(), Role: role, SessionID: uuid.NewString()}
+		c.ID = uuid.NewString()
+		return c, userID
+	}
+	f := &principalFixture{repo: r}
+	var uid uuid.UUID
+	f.courier, uid = claimsFor("courier")
+	r.couriers[uid] = &entity.Courier{ID: uuid.New(), UserID: uid, Active: true}
+	f.courier.CourierID = r.couriers[uid].ID.String()
+	f.customer, uid = claimsFor("customer")
+	r.customers[uid] = &entity.Customer{ID: uuid.New(), UserID: uid, Active: true}
+	f.customer.CustomerID = r.customers[uid].ID.String()
+	f.admin, uid = claimsFor("admin")
+	r.admins[uid] = &entity.Admin{ID: uuid.New(), UserID: uid, Role: entity.AdminRoleSupport, Active: true}
+	f.admin.AdminID, f.admin.AdminRole = r.admins[uid].ID.String(), string(entity.AdminRoleSupport)
+	return f
+}
+
+func userOf(c *Claims) uuid.UUID { return uuid.MustParse(c.UserID) }
+
+func TestValidatePrincipal(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims func(f *principalFixture) *Claims
+		want   error
+	}{
+		{"live courier", func(f *principalFixture) *Claims { return f.courier }, nil},
+		{"live customer", func(f *principalFixture) *Claims { return f.customer }, nil},
+		{"live admin", func(f *principalFixture) *Claims { return f.admin }, nil},
+		{"denylisted token", func(f *principalFixture) *Claims {
+			f.repo.denied[f.courier.ID] = true
+			return f.courier
+		}, ErrTokenRevoked},
+		{"revoked session", func(f *principalFixture) *Claims {
+			f.repo.revoked[uuid.MustParse(f.customer.SessionID)] = true
+			return f.customer
+		}, ErrSessionRevoked},
+		{"deleted user", func(f *principalFixture) *Claims {
+			delete(f.repo.users, userOf(f.courier))
+			return f.courier
+		}, ErrPrincipalNotFound},
+		{"courier profile of another user", func(f *principalFixture) *Claims {
+			f.courier.CourierID = uuid.NewString()
+			return f.courier
+		}, ErrPrincipalNotFound},
+		{"deactivated courier", func(f *principalFixture) *Claims {
+			f.repo.couriers[userOf(f.courier)].Active = false
+			return f.courier
+		}, ErrProfileInactive},
+		{"deactivated customer", func(f *principalFixture) *Claims {
+			f.repo.customers[userOf(f.customer)].Active = false
+			return f.customer
+		}, ErrProfileInactive},
+		{"suspended customer", func(f *principalFixture) *Claims {
+			now := time.Now()
+			f.repo.customers[userOf(f.customer)].SuspendedAt = &now
+			return f.customer
+		}, ErrProfileSuspended},
+		{"deactivated admin", func(f *principalFixture) *Claims {
+			f.repo.admins[userOf(f.admin)].Active = false
+			return f.admin
+		}, ErrProfileInactive},
+		{"admin role changed", func(f *principalFixture) *Claims {
+			f.repo.admins[userOf(f.admin)].Role = entity.AdminRoleFinance
+			return f.admin
+		}, ErrRoleChanged},
+		{"impersonation by a permitted admin", func(f *principalFixture) *Claims {
+			f.repo.admins[userOf(f.admin)].Role = entity.AdminRoleSuperAdmin
+			f.customer.ImpersonatorAdminID, f.customer.ImpersonationScope = f.admin.AdminID, ScopeRead
+			return f.customer
+		}, nil},
+		{"impersonation after the admin lost the permission", func(f *principalFixture) *Claims {
+			f.repo.admins[userOf(f.admin)].Role = entity.AdminRoleFinance
+			f.customer.ImpersonatorAdminID, f.customer.ImpersonationScope = f.admin.AdminID, ScopeRead
+			return f.customer
+		}, ErrImpersonationEnded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newPrincipalFixture()
+			claims := tt.claims(f)
+			err := NewPrincipalValidator(f.repo, time.Minute).ValidatePrincipal(context.Background(), claims)
+			if err != tt.want {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatePrincipalCodesAreDistinct(t *testing.T) {
+	seen := map[string]bool{}
+	for _, e := range []*PrincipalError{
+		ErrTokenRevoked, ErrSessionRevoked, ErrPrincipalNotFound, ErrProfileInactive,
+		ErrProfileSuspended, ErrRoleChanged, ErrImpersonationEnded,
+	} {
+		if e.Code == "" || seen[e.Code] {
+			t.Errorf("code %q is empty or reused", e.Code)
+		}
+		seen[e.Code] = true
+	}
+}
+
+func TestValidatePrincipalCache(t *testing.T) {
+	f := newPrincipalFixture()
+	ctx := context.Background()
+
+	v := NewPrincipalValidator(f.repo, time.Minute)
+	if err := v.ValidatePrincipal(ctx, f.courier); err != nil {
+		t.Fatal(err)
+	}
+	f.repo.denied[f.courier.ID] = true
+	if err := v.ValidatePrincipal(ctx, f.courier); err != nil {
+		t.Errorf("within the TTL err = %v, want the cached nil", err)
+	}
+	if f.repo.reads != 1 {
+		t.Errorf("repository read %d times, want 1", f.repo.reads)
+	}
+
+	// A zero TTL never serves from the cache, so the denylist applies at once.
+	if err := NewPrincipalValidator(f.repo, 0).ValidatePrincipal(ctx, f.courier); err != ErrTokenRevoked {
+		t.Errorf("err = %v, want ErrTokenRevoked", err)
+	}
+
+	// Transient failures are not cached.
+	v = NewPrincipalValidator(f.repo, time.Minute)
+	f.repo.fail = errors.New("db down")
+	if err := v.ValidatePrincipal(ctx, f.customer); err == nil {
+		t.Fatal("expected the repository error")
+	}
+	f.repo.fail = nil
+	if err := v.ValidatePrincipal(ctx, f.customer); err != nil {
+		t.Errorf("after recovery err = %v, want nil", err)
+	}
+}
@@ -0,0 +1,20 @@
+// Package blob stores uploaded files (courier documents, attachments) by key.
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrNotFound is returned for keys that were never stored or were deleted.
+var ErrNotFound = errors.New("blob not found")
+
+// Store saves and serves opaque file contents. Keys are slash-separated paths chosen
+// by the caller, e.g. "couriers/<courier_id>/<document_id>".
+type Store interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the blob; deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+}
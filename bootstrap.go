@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	adminpkg "github.com/mikios34/delivery-backend/admin"
+	adminrepo "github.com/mikios34/delivery-backend/admin/repository"
+	adminsvc "github.com/mikios34/delivery-backend/admin/service"
+)
+
+// runBootstrapAdmin creates the first super admin (or promotes an existing admin by phone),
+// since creating admins over the API already requires one.
+//
+//	go run . bootstrap-admin -phone +251900000000 -first-name Ada -last-name Admin
+func runBootstrapAdmin(args []string) {
+	fs := flag.NewFlagSet("bootstrap-admin", flag.ExitOnError)
+	phone := fs.String("phone", "", "admin phone number (required)")
+	firstName := fs.String("first-name", "", "first name (required for a new admin)")
+	lastName := fs.String("last-name", "", "last name (required for a new admin)")
+	firebaseUID := fs.String("firebase-uid", "", "firebase uid (optional)")
+	_ = fs.Parse(args)
+	if *phone == "" {
+		fs.Usage()
+		log.Fatal("bootstrap-admin: -phone is required")
+	}
+
+	db := setupDatabase()
+	svc := adminsvc.NewAdminService(adminrepo.NewGormAdminRepo(db))
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	a, created, err := svc.BootstrapSuperAdmin(ctx, adminpkg.RegisterAdminRequest{
+		FirstName:   *firstName,
+		LastName:    *lastName,
+		Phone:       *phone,
+		FirebaseUID: *firebaseUID,
+	})
+	if err != nil {
+		log.Fatal("bootstrap-admin: ", err)
+	}
+	if created {
+		fmt.Printf("created super admin %s (user %s)\n", a.ID, a.UserID)
+	} else {
+		fmt.Printf("promoted admin %s (user %s) to super admin\n", a.ID, a.UserID)
+	}
+}
@@ -0,0 +1,37 @@
+package catalog
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/entity"
+)
+
+// Repository specifies catalog database operations. Names are matched case-insensitively;
+// exclude is the entry being updated (uuid.Nil when creating).
+type Repository interface {
+	ListOrderTypes(ctx context.Context) ([]entity.OrderType, error)
+	GetOrderType(ctx context.Context, id uuid.UUID) (*entity.OrderType, error)
+	OrderTypeNameTaken(ctx context.Context, name string, exclude uuid.UUID) (bool, error)
+	CreateOrderType(ctx context.Context, t *entity.OrderType) error
+	UpdateOrderType(ctx context.Context, id uuid.UUID, fields map[string]interface{}) error
+	ReorderOrderTypes(ctx context.Context, ids []uuid.UUID) error
+
+	ListVehicleTypes(ctx context.Context) ([]entity.VehicleTypeConfig, error)
+	GetVehicleType(ctx context.Context, id uuid.UUID) (*entity.VehicleTypeConfig, error)
+	VehicleCodeTaken(ctx context.Context, code string) (bool, error)
+	// CreateVehicleType stores the vehicle type with its first pricing version.
+	CreateVehicleType(ctx context.Context, vt *entity.VehicleTypeConfig, by *uuid.UUID) error
+	// UpdateVehicleType applies fields and, when pricing is set, adds the next pricing
+	// version and points the vehicle type at it, in one transaction.
+	UpdateVehicleType(ctx context.Context, id uuid.UUID, fields map[string]interface{}, pricing *entity.VehiclePricingVersion) error
+	ReorderVehicleTypes(ctx context.Context, ids []uuid.UUID) error
+	ListPricingVersions(ctx context.Context, vehicleTypeID uuid.UUID) ([]entity.VehiclePricingVersion, error)
+
+	ListGuarantyOptions(ctx context.Context) ([]entity.GuarantyOption, error)
+	GetGuarantyOption(ctx context.Context, id uuid.UUID) (*entity.GuarantyOption, error)
+	GuarantyLabelTaken(ctx context.Context, label string, exclude uuid.UUID) (bool, error)
+	CreateGuarantyOption(ctx context.Context, g *entity.GuarantyOption) error
+	UpdateGuarantyOption(ctx context.Context, id uuid.UUID, fields map[string]interface{}) error
+	ReorderGuarantyOptions(ctx context.Context, ids []uuid.UUID) error
+}
@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/catalog"
+	"github.com/mikios34/delivery-backend/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormCatalogRepo implements catalog.Repository using GORM.
+type GormCatalogRepo struct{ db *gorm.DB }
+
+func NewGormCatalogRepo(db *gorm.DB) catalog.Repository { return &GormCatalogRepo{db: db} }
+
+// displayOrder sorts catalog rows as shown to users.
+const displayOrder = "sort_order ASC, created_at ASC"
+
+func (r *GormCatalogRepo) ListOrderTypes(ctx context.Context) ([]entity.OrderType, error) {
+	var list []entity.OrderType
+	if err := r.db.WithContext(ctx).Order(displayOrder).Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *GormCatalogRepo) GetOrderType(ctx context.Context, id uuid.UUID) (*entity.OrderType, error) {
+	var t entity.OrderType
+	if err := r.db.WithContext(ctx).First(&t, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *GormCatalogRepo) OrderTypeNameTaken(ctx context.Context, name string, exclude uuid.UUID) (bool, error) {
+	return r.taken(ctx, &entity.OrderType{}, "name", name, exclude)
+}
+
+func (r *GormCatalogRepo) CreateOrderType(ctx context.Context, t *entity.OrderType) error {
+	return r.createLast(ctx, &entity.OrderType{}, t, &t.SortOrder)
+}
+
+func (r *GormCatalogRepo) UpdateOrderType(ctx context.Context, id uuid.UUID, fields map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&entity.OrderType{}).Where("id = ?", id).Updates(fields).Error
+}
+
+func (r *GormCatalogRepo) ReorderOrderTypes(ctx context.Context, ids []uuid.UUID) error {
+	return r.reorder(ctx, &entity.OrderType{}, ids)
+}
+
+func (r *GormCatalogRepo) ListVehicleTypes(ctx context.Context) ([]entity.VehicleTypeConfig, error) {
+	var list []entity.VehicleTypeConfig
+	if err := r.db.WithContext(ctx).Order(displayOrder).Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *GormCatalogRepo) GetVehicleType(ctx context.Context, id uuid.UUID) (*entity.VehicleTypeConfig, error) {
+	var vt entity.VehicleTypeConfig
+	if err := r.db.WithContext(ctx).First(&vt, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &vt, nil
+}
+
+func (r *GormCatalogRepo) VehicleCodeTaken(ctx context.Context, code string) (bool, error) {
+	return r.taken(ctx, &entity.VehicleTypeConfig{}, "code", code, uuid.Nil)
+}
+
+func (r *GormCatalogRepo) CreateVehicleType(ctx context.Context, vt *entity.VehicleTypeConfig, by *uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		txRepo := &GormCatalogRepo{db: tx}
+		if err := txRepo.createLast(ctx, &entity.VehicleTypeConfig{}, vt, &vt.SortOrder); err != nil {
+			return err
+		}
+		v := pricingOf(vt)
+		v.Version = 1
+		v.CreatedBy = by
+		if err := tx.Create(v).Error; err != nil {
+			return err
+		}
+		vt.PricingVersionID = &v.ID
+		return tx.Model(vt).Update("pricing_version_id", v.ID).Error
+	})
+}
+
+func (r *GormCatalogRepo) UpdateVehicleType(ctx context.Context, id uuid.UUID, fields map[string]interface{}, pricing *entity.VehiclePricingVersion) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if pricing != nil {
+			// Lock the vehicle type so concurrent edits get consecutive version numbers.
+			var vt entity.VehicleTypeConfig
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&vt, "id = ?", id).Error; err != nil {
+				return err
+			}
+			var last int
+			if err := tx.Model(&entity.VehiclePricingVersion{}).Where("vehicle_type_id = ?", id).
+				Select("COALESCE(MAX(version), 0)").Scan(&last).Error; err != nil {
+				return err
+			}
+			pricing.VehicleTypeID = id
+			pricing.Version = last + 1
+			if err := tx.Create(pricing).Error; err != nil {
+				return err
+			}
+			fields["pricing_version_id"] = pricing.ID
+		}
+		if len(fields) == 0 {
+			return nil
+		}
+		return tx.Model(&entity.VehicleTypeConfig{}).Where("id = ?", id).Updates(fields).Error
+	})
+}
+
+func (r *GormCatalogRepo) ReorderVehicleTypes(ctx context.Context, ids []uuid.UUID) error {
+	return r.reorder(ctx, &entity.VehicleTypeConfig{}, ids)
+}
+
+func (r *GormCatalogRepo) ListPricingVersions(ctx context.Context, vehicleTypeID uuid.UUID) ([]entity.VehiclePricingVersion, error) {
+	var list []entity.VehiclePricingVersion
+	if err := r.db.WithContext(ctx).Where("vehicle_type_id = ?", vehicleTypeID).
+		Order("version DESC").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *GormCatalogRepo) ListGuarantyOptions(ctx context.Context) ([]entity.GuarantyOption, error) {
+	var list []entity.GuarantyOption
+	if err := r.db.WithContext(ctx).Order(displayOrder).Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *GormCatalogRepo) GetGuarantyOption(ctx context.Context, id uuid.UUID) (*entity.GuarantyOption, error) {
+	var g entity.GuarantyOption
+	if err := r.db.WithContext(ctx).First(&g, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+func (r *GormCatalogRepo) GuarantyLabelTaken(ctx context.Context, label string, exclude uuid.UUID) (bool, error) {
+	return r.taken(ctx, &entity.GuarantyOption{}, "label", label, exclude)
+}
+
+func (r *GormCatalogRepo) CreateGuarantyOption(ctx context.Context, g *entity.GuarantyOption) error {
+	return r.createLast(ctx, &entity.GuarantyOption{}, g, &g.SortOrder)
+}
+
+func (r *GormCatalogRepo) UpdateGuarantyOption(ctx context.Context, id uuid.UUID, fields map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&entity.GuarantyOption{}).Where("id = ?", id).Updates(fields).Error
+}
+
+func (r *GormCatalogRepo) ReorderGuarantyOptions(ctx context.Context, ids []uuid.UUID) error {
+	return r.reorder(ctx, &entity.GuarantyOption{}, ids)
+}
+
+// taken reports whether another row (soft-deleted ones included, since the unique
+// indexes cover them) already uses value in column, ignoring case.
+func (r *GormCatalogRepo) taken(ctx context.Context, model interface{}, column, value string, exclude uuid.UUID) (bool, error) {
+	var n int64
+	q := r.db.WithContext(ctx).Unscoped().Model(model).Where("LOWER("+column+") = LOWER(?)", value)
+	if exclude != uuid.Nil {
+		q = q.Where("id <> ?", exclude)
+	}
+	if err := q.Count(&n).Error; err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// createLast inserts row at the end of its list.
+func (r *GormCatalogRepo) createLast(ctx context.Context, model, row interface{}, sortOrder *int) error {
+	var last int
+	if err := r.db.WithContext(ctx).Model(model).Select("COALESCE(MAX(sort_order), 0)").Scan(&last).Error; err != nil {
+		return err
+	}
+	*sortOrder = last + 1
+	return r.db.WithContext(ctx).Create(row).Error
+}
+
+// reorder numbers the rows 1..n in the order of ids.
+func (r *GormCatalogRepo) reorder(ctx context.Context, model interface{}, ids []uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i, id := range ids {
+			if err := tx.Model(model).Where("id = ?", id).Update("sort_order", i+1).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func pricingOf(vt *entity.VehicleTypeConfig) *entity.VehiclePricingVersion {
+	return &entity.VehiclePricingVersion{
+		VehicleTypeID: vt.ID,
+		BaseFare:      vt.BaseFare,
+		PerKm:         vt.PerKm,
+		PerMinute:     vt.PerMinute,
+		AvgSpeedKmh:   vt.AvgSpeedKmh,
+		MinimumFare:   vt.MinimumFare,
+		BookingFee:    vt.BookingFee,
+	}
+}
@@ -0,0 +1,103 @@
+// Package catalog manages the admin-editable lists customers and couriers pick from:
+// order types, vehicle types with their pricing, and courier guaranty options.
+package catalog
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/entity"
+)
+
+var (
+	// ErrNameRequired is returned when a name or label is blank.
+	ErrNameRequired = errors.New("name is required")
+	// ErrDuplicate is returned when a name or code is already used by another entry.
+	ErrDuplicate = errors.New("an entry with this name or code already exists")
+	// ErrInvalidCode is returned for vehicle type codes outside [a-z0-9_], 1-32 characters.
+	ErrInvalidCode = errors.New("code must be 1-32 lowercase letters, digits or underscores")
+	// ErrNegativeAmount is returned for fares or amounts below zero.
+	ErrNegativeAmount = errors.New("fares and amounts must not be negative")
+	// ErrInvalidSpeed is returned when the average speed used for time estimates is not positive.
+	ErrInvalidSpeed = errors.New("avg_speed_kmh must be greater than 0")
+	// ErrInvalidReorder is returned when a reorder list isn't exactly the catalog's ids.
+	ErrInvalidReorder = errors.New("ids must list every entry exactly once")
+)
+
+// Pricing holds the fare fields of a vehicle type.
+type Pricing struct {
+	BaseFare    float64 `json:"base_fare"`
+	PerKm       float64 `json:"per_km"`
+	PerMinute   float64 `json:"per_minute"`
+	AvgSpeedKmh float64 `json:"avg_speed_kmh"`
+	MinimumFare float64 `json:"minimum_fare"`
+	BookingFee  float64 `json:"booking_fee"`
+}
+
+// VehicleTypeRequest creates a vehicle type.
+type VehicleTypeRequest struct {
+	Code    string
+	Name    string
+	Pricing Pricing
+	// AdminID is recorded on the first pricing version.
+	AdminID *uuid.UUID
+}
+
+// UpdateVehicleTypeRequest changes a vehicle type; nil fields are left as is. Any fare
+// change creates a new pricing version. The code can't be changed.
+type UpdateVehicleTypeRequest struct {
+	Name        *string
+	Active      *bool
+	BaseFare    *float64
+	PerKm       *float64
+	PerMinute   *float64
+	AvgSpeedKmh *float64
+	MinimumFare *float64
+	BookingFee  *float64
+	AdminID     *uuid.UUID
+}
+
+// UpdateOrderTypeRequest changes an order type; nil fields are left as is.
+type UpdateOrderTypeRequest struct {
+	Name   *string
+	Active *bool
+}
+
+// GuarantyOptionRequest creates a guaranty option.
+type GuarantyOptionRequest struct {
+	Label       string
+	AmountCents int64
+}
+
+// UpdateGuarantyOptionRequest changes a guaranty option; nil fields are left as is.
+// Payments already recorded keep the amount they were created with.
+type UpdateGuarantyOptionRequest struct {
+	Label       *string
+	AmountCents *int64
+	Active      *bool
+}
+
+// Service exposes catalog administration. List methods include inactive entries and
+// return them in display order.
+type Service interface {
+	ListOrderTypes(ctx context.Context) ([]entity.OrderType, error)
+	GetOrderType(ctx context.Context, id uuid.UUID) (*entity.OrderType, error)
+	CreateOrderType(ctx context.Context, name string) (*entity.OrderType, error)
+	UpdateOrderType(ctx context.Context, id uuid.UUID, req UpdateOrderTypeRequest) (*entity.OrderType, error)
+	ReorderOrderTypes(ctx context.Context, ids []uuid.UUID) ([]entity.OrderType, error)
+
+	ListVehicleTypes(ctx context.Context) ([]entity.VehicleTypeConfig, error)
+	GetVehicleType(ctx context.Context, id uuid.UUID) (*entity.VehicleTypeConfig, error)
+	CreateVehicleType(ctx context.Context, req VehicleTypeRequest) (*entity.VehicleTypeConfig, error)
+	UpdateVehicleType(ctx context.Context, id uuid.UUID, req UpdateVehicleTypeRequest) (*entity.VehicleTypeConfig, error)
+	ReorderVehicleTypes(ctx context.Context, ids []uuid.UUID) ([]entity.VehicleTypeConfig, error)
+	// ListPricingVersions returns a vehicle type's pricing history, newest first.
+	ListPricingVersions(ctx context.Context, vehicleTypeID uuid.UUID) ([]entity.VehiclePricingVersion, error)
+
+	ListGuarantyOptions(ctx context.Context) ([]entity.GuarantyOption, error)
+	GetGuarantyOption(ctx context.Context, id uuid.UUID) (*entity.GuarantyOption, error)
+	CreateGuarantyOption(ctx context.Context, req GuarantyOptionRequest) (*entity.GuarantyOption, error)
+	UpdateGuarantyOption(ctx context.Context, id uuid.UUID, req UpdateGuarantyOptionRequest) (*entity.GuarantyOption, error)
+	ReorderGuarantyOptions(ctx context.Context, ids []uuid.UUID) ([]entity.GuarantyOption, error)
+}
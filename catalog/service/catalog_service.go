@@ -0,0 +1,338 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/catalog"
+	"github.com/mikios34/delivery-backend/entity"
+)
+
+var vehicleCodePattern = regexp.MustCompile(`^[a-z0-9_]{1,32}$`)
+
+// catalogService implements catalog.Service.
+type catalogService struct {
+	repo catalog.Repository
+}
+
+// NewCatalogService constructs a catalog.Service backed by the provided repository.
+func NewCatalogService(repo catalog.Repository) catalog.Service {
+	return &catalogService{repo: repo}
+}
+
+func (s *catalogService) ListOrderTypes(ctx context.Context) ([]entity.OrderType, error) {
+	return s.repo.ListOrderTypes(ctx)
+}
+
+func (s *catalogService) GetOrderType(ctx context.Context, id uuid.UUID) (*entity.OrderType, error) {
+	return s.repo.GetOrderType(ctx, id)
+}
+
+func (s *catalogService) CreateOrderType(ctx context.Context, name string) (*entity.OrderType, error) {
+	name = strings.TrimSpace(name)
+	if err := s.checkOrderTypeName(ctx, name, uuid.Nil); err != nil {
+		return nil, err
+	}
+	t := &entity.OrderType{Name: name, Active: true}
+	if err := s.repo.CreateOrderType(ctx, t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (s *catalogService) UpdateOrderType(ctx context.Context, id uuid.UUID, req catalog.UpdateOrderTypeRequest) (*entity.OrderType, error) {
+	t, err := s.repo.GetOrderType(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]interface{}{}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if err := s.checkOrderTypeName(ctx, name, id); err != nil {
+			return nil, err
+		}
+		fields["name"] = name
+	}
+	if req.Active != nil {
+		fields["active"] = *req.Active
+	}
+	if len(fields) == 0 {
+		return t, nil
+	}
+	if err := s.repo.UpdateOrderType(ctx, id, fields); err != nil {
+		return nil, err
+	}
+	return s.repo.GetOrderType(ctx, id)
+}
+
+func (s *catalogService) ReorderOrderTypes(ctx context.Context, ids []uuid.UUID) ([]entity.OrderType, error) {
+	list, err := s.repo.ListOrderTypes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	current := make([]uuid.UUID, len(list))
+	for i := range list {
+		current[i] = list[i].ID
+	}
+	if !samePermutation(current, ids) {
+		return nil, catalog.ErrInvalidReorder
+	}
+	if err := s.repo.ReorderOrderTypes(ctx, ids); err != nil {
+		return nil, err
+	}
+	return s.repo.ListOrderTypes(ctx)
+}
+
+func (s *catalogService) ListVehicleTypes(ctx context.Context) ([]entity.VehicleTypeConfig, error) {
+	return s.repo.ListVehicleTypes(ctx)
+}
+
+func (s *catalogService) GetVehicleType(ctx context.Context, id uuid.UUID) (*entity.VehicleTypeConfig, error) {
+	return s.repo.GetVehicleType(ctx, id)
+}
+
+func (s *catalogService) CreateVehicleType(ctx context.Context, req catalog.VehicleTypeRequest) (*entity.VehicleTypeConfig, error) {
+	code := strings.TrimSpace(req.Code)
+	name := strings.TrimSpace(req.Name)
+	if !vehicleCodePattern.MatchString(code) {
+		return nil, catalog.ErrInvalidCode
+	}
+	if name == "" {
+		return nil, catalog.ErrNameRequired
+	}
+	if err := validatePricing(req.Pricing); err != nil {
+		return nil, err
+	}
+	taken, err := s.repo.VehicleCodeTaken(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if taken {
+		return nil, catalog.ErrDuplicate
+	}
+	p := req.Pricing
+	vt := &entity.VehicleTypeConfig{
+		Code: code, Name: name, Active: true,
+		BaseFare: p.BaseFare, PerKm: p.PerKm, PerMinute: p.PerMinute,
+		AvgSpeedKmh: p.AvgSpeedKmh, MinimumFare: p.MinimumFare, BookingFee: p.BookingFee,
+	}
+	if err := s.repo.CreateVehicleType(ctx, vt, req.AdminID); err != nil {
+		return nil, err
+	}
+	return vt, nil
+}
+
+func (s *catalogService) UpdateVehicleType(ctx context.Context, id uuid.UUID, req catalog.UpdateVehicleTypeRequest) (*entity.VehicleTypeConfig, error) {
+	vt, err := s.repo.GetVehicleType(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]interface{}{}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, catalog.ErrNameRequired
+		}
+		fields["name"] = name
+	}
+	if req.Active != nil {
+		fields["active"] = *req.Active
+	}
+
+	cur := catalog.Pricing{
+		BaseFare: vt.BaseFare, PerKm: vt.PerKm, PerMinute: vt.PerMinute,
+		AvgSpeedKmh: vt.AvgSpeedKmh, MinimumFare: vt.MinimumFare, BookingFee: vt.BookingFee,
+	}
+	next := cur
+	for _, f := range []struct {
+		in  *float64
+		out *float64
+	}{
+		{req.BaseFare, &next.BaseFare}, {req.PerKm, &next.PerKm}, {req.PerMinute, &next.PerMinute},
+		{req.AvgSpeedKmh, &next.AvgSpeedKmh}, {req.MinimumFare, &next.MinimumFare}, {req.BookingFee, &next.BookingFee},
+	} {
+		if f.in != nil {
+			*f.out = *f.in
+		}
+	}
+	var version *entity.VehiclePricingVersion
+	// A legacy row without a version gets one even if the fares are unchanged.
+	if next != cur || vt.PricingVersionID == nil {
+		if err := validatePricing(next); err != nil {
+			return nil, err
+		}
+		fields["base_fare"] = next.BaseFare
+		fields["per_km"] = next.PerKm
+		fields["per_minute"] = next.PerMinute
+		fields["avg_speed_kmh"] = next.AvgSpeedKmh
+		fields["minimum_fare"] = next.MinimumFare
+		fields["booking_fee"] = next.BookingFee
+		version = &entity.VehiclePricingVersion{
+			BaseFare: next.BaseFare, PerKm: next.PerKm, PerMinute: next.PerMinute,
+			AvgSpeedKmh: next.AvgSpeedKmh, MinimumFare: next.MinimumFare, BookingFee: next.BookingFee,
+			CreatedBy: req.AdminID,
+		}
+	}
+	if len(fields) == 0 {
+		return vt, nil
+	}
+	if err := s.repo.UpdateVehicleType(ctx, id, fields, version); err != nil {
+		return nil, err
+	}
+	return s.repo.GetVehicleType(ctx, id)
+}
+
+func (s *catalogService) ReorderVehicleTypes(ctx context.Context, ids []uuid.UUID) ([]entity.VehicleTypeConfig, error) {
+	list, err := s.repo.ListVehicleTypes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	current := make([]uuid.UUID, len(list))
+	for i := range list {
+		current[i] = list[i].ID
+	}
+	if !samePermutation(current, ids) {
+		return nil, catalog.ErrInvalidReorder
+	}
+	if err := s.repo.ReorderVehicleTypes(ctx, ids); err != nil {
+		return nil, err
+	}
+	return s.repo.ListVehicleTypes(ctx)
+}
+
+func (s *catalogService) ListPricingVersions(ctx context.Context, vehicleTypeID uuid.UUID) ([]entity.VehiclePricingVersion, error) {
+	if _, err := s.repo.GetVehicleType(ctx, vehicleTypeID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListPricingVersions(ctx, vehicleTypeID)
+}
+
+func (s *catalogService) ListGuarantyOptions(ctx context.Context) ([]entity.GuarantyOption, error) {
+	return s.repo.ListGuarantyOptions(ctx)
+}
+
+func (s *catalogService) GetGuarantyOption(ctx context.Context, id uuid.UUID) (*entity.GuarantyOption, error) {
+	return s.repo.GetGuarantyOption(ctx, id)
+}
+
+func (s *catalogService) CreateGuarantyOption(ctx context.Context, req catalog.GuarantyOptionRequest) (*entity.GuarantyOption, error) {
+	label := strings.TrimSpace(req.Label)
+	if err := s.checkGuarantyLabel(ctx, label, uuid.Nil); err != nil {
+		return nil, err
+	}
+	if req.AmountCents < 0 {
+		return nil, catalog.ErrNegativeAmount
+	}
+	g := &entity.GuarantyOption{Label: label, AmountCents: req.AmountCents, Active: true}
+	if err := s.repo.CreateGuarantyOption(ctx, g); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+func (s *catalogService) UpdateGuarantyOption(ctx context.Context, id uuid.UUID, req catalog.UpdateGuarantyOptionRequest) (*entity.GuarantyOption, error) {
+	g, err := s.repo.GetGuarantyOption(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]interface{}{}
+	if req.Label != nil {
+		label := strings.TrimSpace(*req.Label)
+		if err := s.checkGuarantyLabel(ctx, label, id); err != nil {
+			return nil, err
+		}
+		fields["label"] = label
+	}
+	if req.AmountCents != nil {
+		if *req.AmountCents < 0 {
+			return nil, catalog.ErrNegativeAmount
+		}
+		fields["amount_cents"] = *req.AmountCents
+	}
+	if req.Active != nil {
+		fields["active"] = *req.Active
+	}
+	if len(fields) == 0 {
+		return g, nil
+	}
+	if err := s.repo.UpdateGuarantyOption(ctx, id, fields); err != nil {
+		return nil, err
+	}
+	return s.repo.GetGuarantyOption(ctx, id)
+}
+
+func (s *catalogService) ReorderGuarantyOptions(ctx context.Context, ids []uuid.UUID) ([]entity.GuarantyOption, error) {
+	list, err := s.repo.ListGuarantyOptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	current := make([]uuid.UUID, len(list))
+	for i := range list {
+		current[i] = list[i].ID
+	}
+	if !samePermutation(current, ids) {
+		return nil, catalog.ErrInvalidReorder
+	}
+	if err := s.repo.ReorderGuarantyOptions(ctx, ids); err != nil {
+		return nil, err
+	}
+	return s.repo.ListGuarantyOptions(ctx)
+}
+
+func (s *catalogService) checkOrderTypeName(ctx context.Context, name string, exclude uuid.UUID) error {
+	if name == "" {
+		return catalog.ErrNameRequired
+	}
+	taken, err := s.repo.OrderTypeNameTaken(ctx, name, exclude)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return catalog.ErrDuplicate
+	}
+	return nil
+}
+
+func (s *catalogService) checkGuarantyLabel(ctx context.Context, label string, exclude uuid.UUID) error {
+	if label == "" {
+		return catalog.ErrNameRequired
+	}
+	taken, err := s.repo.GuarantyLabelTaken(ctx, label, exclude)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return catalog.ErrDuplicate
+	}
+	return nil
+}
+
+func validatePricing(p catalog.Pricing) error {
+	if p.BaseFare < 0 || p.PerKm < 0 || p.PerMinute < 0 || p.MinimumFare < 0 || p.BookingFee < 0 {
+		return catalog.ErrNegativeAmount
+	}
+	if p.AvgSpeedKmh <= 0 {
+		return catalog.ErrInvalidSpeed
+	}
+	return nil
+}
+
+// samePermutation reports whether ids contains exactly the ids in current, each once.
+func samePermutation(current, ids []uuid.UUID) bool {
+	if len(current) != len(ids) {
+		return false
+	}
+	want := make(map[uuid.UUID]bool, len(current))
+	for _, id := range current {
+		want[id] = true
+	}
+	for _, id := range ids {
+		if !want[id] {
+			return false
+		}
+		delete(want, id)
+	}
+	return true
+}
@@ -0,0 +1,19 @@
+package chat
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/entity"
+)
+
+// Repository specifies chat message persistence.
+type Repository interface {
+	CreateMessage(ctx context.Context, m *entity.ChatMessage) (*entity.ChatMessage, error)
+	// ListMessages returns messages for the order oldest first. When before is non-nil only
+	// messages created before it are returned (the newest `limit` of them).
+	ListMessages(ctx context.Context, orderID uuid.UUID, before *time.Time, limit int) ([]entity.ChatMessage, error)
+	// MarkRead sets read_at on unread messages in the order that were not sent by readerRole.
+	MarkRead(ctx context.Context, orderID uuid.UUID, readerRole string, at time.Time) (int64, error)
+}
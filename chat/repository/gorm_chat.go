@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/chat"
+	"github.com/mikios34/delivery-backend/entity"
+	"gorm.io/gorm"
+)
+
+// GormChatRepo implements chat.Repository using GORM.
+type GormChatRepo struct {
+	db *gorm.DB
+}
+
+func NewGormChatRepo(db *gorm.DB) chat.Repository {
+	return &GormChatRepo{db: db}
+}
+
+func (r *GormChatRepo) CreateMessage(ctx context.Context, m *entity.ChatMessage) (*entity.ChatMessage, error) {
+	if err := r.db.WithContext(ctx).Create(m).Error; err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (r *GormChatRepo) ListMessages(ctx context.Context, orderID uuid.UUID, before *time.Time, limit int) ([]entity.ChatMessage, error) {
+	var list []entity.ChatMessage
+	q := r.db.WithContext(ctx).Where("order_id = ?", orderID)
+	if before != nil {
+		q = q.Where("created_at < ?", *before)
+	}
+	// Take the newest page, then flip it so callers get chronological order.
+	q = q.Order("created_at DESC")
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+	if err := q.Find(&list).Error; err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(list)-1; i < j; i, j = i+1, j-1 {
+		list[i], list[j] = list[j], list[i]
+	}
+	return list, nil
+}
+
+func (r *GormChatRepo) MarkRead(ctx context.Context, orderID uuid.UUID, readerRole string, at time.Time) (int64, error) {
+	res := r.db.WithContext(ctx).Model(&entity.ChatMessage{}).
+		Where("order_id = ? AND sender_role <> ? AND read_at IS NULL", orderID, readerRole).
+		Update("read_at", at)
+	return res.RowsAffected, res.Error
+}
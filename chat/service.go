@@ -0,0 +1,48 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/entity"
+)
+
+const (
+	RoleCustomer = "customer"
+	RoleCourier  = "courier"
+)
+
+var (
+	// ErrNotParticipant is returned when the caller is not the order's customer or assigned courier.
+	ErrNotParticipant = errors.New("forbidden: not a participant of this order")
+	// ErrThreadNotOpen is returned when messaging before the courier has accepted the order.
+	ErrThreadNotOpen = errors.New("chat opens once a courier accepts the order")
+	// ErrThreadClosed is returned when messaging after the order was delivered or canceled.
+	ErrThreadClosed = errors.New("chat is closed for this order")
+	// ErrEmptyMessage is returned for blank or oversized message bodies.
+	ErrEmptyMessage = errors.New("message body must be 1-2000 characters")
+)
+
+// Participant identifies who is acting on a thread.
+type Participant struct {
+	Role string    // "customer" or "courier"
+	ID   uuid.UUID // customer or courier profile id
+}
+
+// Thread is the message list for an order together with its open/closed state.
+type Thread struct {
+	Order    *entity.Order        `json:"-"`
+	Open     bool                 `json:"open"`
+	Closed   bool                 `json:"closed"`
+	Messages []entity.ChatMessage `json:"messages"`
+}
+
+// Service exposes order chat operations.
+type Service interface {
+	List(ctx context.Context, orderID uuid.UUID, who Participant, before *time.Time, limit int) (*Thread, error)
+	Send(ctx context.Context, orderID uuid.UUID, who Participant, body string) (*entity.ChatMessage, *entity.Order, error)
+	// MarkRead marks the other party's messages as read and returns the order and count updated.
+	MarkRead(ctx context.Context, orderID uuid.UUID, who Participant) (*entity.Order, int64, time.Time, error)
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/chat"
+	"github.com/mikios34/delivery-backend/entity"
+	orderpkg "github.com/mikios34/delivery-backend/order"
+)
+
+const maxBodyLen = 2000
+
+// chatService implements chat.Service.
+type chatService struct {
+	repo   chat.Repository
+	orders orderpkg.Repository
+}
+
+// NewChatService constructs a chat.Service backed by the chat and order repositories.
+func NewChatService(repo chat.Repository, orders orderpkg.Repository) chat.Service {
+	return &chatService{repo: repo, orders: orders}
+}
+
+func (s *chatService) List(ctx context.Context, orderID uuid.UUID, who chat.Participant, before *time.Time, limit int) (*chat.Thread, error) {
+	ord, err := s.authorize(ctx, orderID, who)
+	if err != nil {
+		return nil, err
+	}
+	msgs, err := s.repo.ListMessages(ctx, orderID, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	return &chat.Thread{Order: ord, Open: isOpen(ord.Status), Closed: isClosed(ord.Status), Messages: msgs}, nil
+}
+
+func (s *chatService) Send(ctx context.Context, orderID uuid.UUID, who chat.Participant, body string) (*entity.ChatMessage, *entity.Order, error) {
+	body = strings.TrimSpace(body)
+	if body == "" || utf8.RuneCountInString(body) > maxBodyLen {
+		return nil, nil, chat.ErrEmptyMessage
+	}
+	ord, err := s.authorize(ctx, orderID, who)
+	if err != nil {
+		return nil, nil, err
+	}
+	if isClosed(ord.Status) {
+		return nil, nil, chat.ErrThreadClosed
+	}
+	if !isOpen(ord.Status) {
+		return nil, nil, chat.ErrThreadNotOpen
+	}
+	m := &entity.ChatMessage{OrderID: orderID, SenderRole: who.Role, SenderID: who.ID, Body: body}
+	created, err := s.repo.CreateMessage(ctx, m)
+	if err != nil {
+		return nil, nil, err
+	}
+	return created, ord, nil
+}
+
+func (s *chatService) MarkRead(ctx context.Context, orderID uuid.UUID, who chat.Participant) (*entity.Order, int64, time.Time, error) {
+	ord, err := s.authorize(ctx, orderID, who)
+	if err != nil {
+		return nil, 0, time.Time{}, err
+	}
+	now := time.Now()
+	n, err := s.repo.MarkRead(ctx, orderID, who.Role, now)
+	if err != nil {
+		return nil, 0, time.Time{}, err
+	}
+	return ord, n, now, nil
+}
+
+// authorize loads the order and checks the caller is its customer or currently assigned courier.
+func (s *chatService) authorize(ctx context.Context, orderID uuid.UUID, who chat.Participant) (*entity.Order, error) {
+	ord, err := s.orders.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	switch who.Role {
+	case chat.RoleCustomer:
+		if ord.CustomerID == who.ID {
+			return ord, nil
+		}
+	case chat.RoleCourier:
+		if ord.AssignedCourier != nil && *ord.AssignedCourier == who.ID {
+			return ord, nil
+		}
+	}
+	return nil, chat.ErrNotParticipant
+}
+
+// isOpen reports whether messages can be exchanged: the courier has accepted and the order is in progress.
+func isOpen(status entity.OrderStatus) bool {
+	switch status {
+	case entity.OrderAccepted, entity.OrderArrived, entity.OrderPickedUp:
+		return true
+	}
+	return false
+}
+
+// isClosed reports whether the thread is permanently closed.
+func isClosed(status entity.OrderStatus) bool {
+	switch status {
+	case entity.OrderDelivered, entity.OrderCanceledByCustomer, entity.OrderCanceledByCourier, entity.OrderCanceledByAdmin, entity.OrderNoNearbyDriver:
+		return true
+	}
+	return false
+}
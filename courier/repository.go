@@ -15,10 +15,22 @@ type CourierRepository interface {
 	GetCourierByUserID(ctx context.Context, userID uuid.UUID) (*entity.Courier, error)
 	GetUserByID(ctx context.Context, id uuid.UUID) (*entity.User, error)
 	GetUserByCourierID(ctx context.Context, courierID uuid.UUID) (*entity.User, error)
-	PhoneExists(ctx context.Context, phone string) (bool, error)
+	GetUserByPhone(ctx context.Context, phone string) (*entity.User, error)
+	GetUserByFirebaseUID(ctx context.Context, uid string) (*entity.User, error)
+	SetUserFirebaseUID(ctx context.Context, userID uuid.UUID, uid string) error
 	ListGuarantyOptions(ctx context.Context) ([]entity.GuarantyOption, error)
 	CreateGuarantyPayment(ctx context.Context, gp *entity.GuarantyPayment) (*entity.GuarantyPayment, error)
 	UpdateAvailability(ctx context.Context, courierID uuid.UUID, available bool) error
 	UpdateLocation(ctx context.Context, courierID uuid.UUID, lat, lng *float64) error
+	// ListAvailableCouriersNear returns approved, active, available couriers without an active order.
 	ListAvailableCouriersNear(ctx context.Context, centerLat, centerLng, radiusKm float64, limit int) ([]entity.Courier, error)
+
+	// Onboarding
+	StoreDocument(ctx context.Context, d *entity.CourierDocument) error
+	// ReplaceDocuments soft-deletes the courier's documents of a kind and returns them.
+	ReplaceDocuments(ctx context.Context, courierID uuid.UUID, kind entity.CourierDocumentKind) ([]entity.CourierDocument, error)
+	ListDocuments(ctx context.Context, courierID uuid.UUID) ([]entity.CourierDocument, error)
+	GetDocument(ctx context.Context, id uuid.UUID) (*entity.CourierDocument, error)
+	UpdateOnboarding(ctx context.Context, courierID uuid.UUID, fields map[string]interface{}) error
+	ListCouriersByOnboarding(ctx context.Context, status entity.CourierOnboardingStatus, limit, offset int) ([]CourierView, int64, error)
 }
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/mikios34/delivery-backend/courier"
@@ -69,17 +70,29 @@ func (r *GormCourierRepo) GetUserByCourierID(ctx context.Context, courierID uuid
 	return &u, nil
 }
 
-func (r *GormCourierRepo) PhoneExists(ctx context.Context, phone string) (bool, error) {
-	var count int64
-	if err := r.db.WithContext(ctx).Model(&entity.User{}).Where("phone = ? AND role = ?", phone, "courier").Count(&count).Error; err != nil {
-		return false, err
+func (r *GormCourierRepo) GetUserByPhone(ctx context.Context, phone string) (*entity.User, error) {
+	var u entity.User
+	if err := r.db.WithContext(ctx).Where("phone = ?", phone).Order("created_at").First(&u).Error; err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (r *GormCourierRepo) GetUserByFirebaseUID(ctx context.Context, uid string) (*entity.User, error) {
+	var u entity.User
+	if err := r.db.WithContext(ctx).Where("firebase_uid = ?", uid).First(&u).Error; err != nil {
+		return nil, err
 	}
-	return count > 0, nil
+	return &u, nil
+}
+
+func (r *GormCourierRepo) SetUserFirebaseUID(ctx context.Context, userID uuid.UUID, uid string) error {
+	return r.db.WithContext(ctx).Model(&entity.User{}).Where("id = ?", userID).Update("firebase_uid", uid).Error
 }
 
 func (r *GormCourierRepo) ListGuarantyOptions(ctx context.Context) ([]entity.GuarantyOption, error) {
 	var opts []entity.GuarantyOption
-	if err := r.db.WithContext(ctx).Where("active = ?", true).Find(&opts).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("active = ?", true).Order("sort_order, created_at").Find(&opts).Error; err != nil {
 		return nil, err
 	}
 	return opts, nil
@@ -98,8 +111,9 @@ func (r *GormCourierRepo) UpdateAvailability(ctx context.Context, courierID uuid
 
 func (r *GormCourierRepo) UpdateLocation(ctx context.Context, courierID uuid.UUID, lat, lng *float64) error {
 	updates := map[string]interface{}{
-		"latitude":  lat,
-		"longitude": lng,
+		"latitude":            lat,
+		"longitude":           lng,
+		"location_updated_at": time.Now(),
 	}
 	return r.db.WithContext(ctx).Model(&entity.Courier{}).Where("id = ?", courierID).Updates(updates).Error
 }
@@ -116,10 +130,11 @@ func (r *GormCourierRepo) ListAvailableCouriersNear(ctx context.Context, centerL
 	// Exclude couriers with an active order (assigned/accepted/arrived/picked_up)
 	sql := `
 		SELECT id, user_id, has_vehicle, primary_vehicle, vehicle_details,
-		       guaranty_option_id, guaranty_paid, active, available,
+		       guaranty_option_id, guaranty_paid, active, available, onboarding_status,
 		       latitude, longitude, created_at, updated_at, deleted_at
 		FROM couriers c
-		WHERE c.available = TRUE AND c.active = TRUE AND c.latitude IS NOT NULL AND c.longitude IS NOT NULL
+		WHERE c.available = TRUE AND c.active = TRUE AND c.onboarding_status = 'approved'
+		  AND c.latitude IS NOT NULL AND c.longitude IS NOT NULL
 		  AND NOT EXISTS (
 		    SELECT 1 FROM orders o
 		    WHERE o.assigned_courier = c.id
@@ -136,3 +151,66 @@ func (r *GormCourierRepo) ListAvailableCouriersNear(ctx context.Context, centerL
 	}
 	return list, nil
 }
+
+func (r *GormCourierRepo) StoreDocument(ctx context.Context, d *entity.CourierDocument) error {
+	return r.db.WithContext(ctx).Create(d).Error
+}
+
+func (r *GormCourierRepo) ReplaceDocuments(ctx context.Context, courierID uuid.UUID, kind entity.CourierDocumentKind) ([]entity.CourierDocument, error) {
+	var old []entity.CourierDocument
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("courier_id = ? AND kind = ?", courierID, kind).Find(&old).Error; err != nil {
+			return err
+		}
+		if len(old) == 0 {
+			return nil
+		}
+		return tx.Where("courier_id = ? AND kind = ?", courierID, kind).Delete(&entity.CourierDocument{}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return old, nil
+}
+
+func (r *GormCourierRepo) ListDocuments(ctx context.Context, courierID uuid.UUID) ([]entity.CourierDocument, error) {
+	var list []entity.CourierDocument
+	if err := r.db.WithContext(ctx).Where("courier_id = ?", courierID).Order("created_at").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *GormCourierRepo) GetDocument(ctx context.Context, id uuid.UUID) (*entity.CourierDocument, error) {
+	var d entity.CourierDocument
+	if err := r.db.WithContext(ctx).First(&d, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (r *GormCourierRepo) UpdateOnboarding(ctx context.Context, courierID uuid.UUID, fields map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&entity.Courier{}).Where("id = ?", courierID).Updates(fields).Error
+}
+
+func (r *GormCourierRepo) ListCouriersByOnboarding(ctx context.Context, status entity.CourierOnboardingStatus, limit, offset int) ([]courier.CourierView, int64, error) {
+	base := func() *gorm.DB {
+		q := r.db.WithContext(ctx).Table("couriers").Where("couriers.deleted_at IS NULL")
+		if status != "" {
+			q = q.Where("couriers.onboarding_status = ?", status)
+		}
+		return q
+	}
+	var total int64
+	if err := base().Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var out []courier.CourierView
+	err := base().
+		Select("couriers.*, users.first_name, users.last_name, users.phone").
+		Joins("JOIN users ON users.id = couriers.user_id").
+		Order("couriers.created_at ASC").
+		Limit(limit).Offset(offset).
+		Scan(&out).Error
+	return out, total, err
+}
@@ -2,18 +2,83 @@ package courier
 
 import (
 	"context"
+	"errors"
+	"io"
 
 	"github.com/google/uuid"
 	"github.com/mikios34/delivery-backend/entity"
 )
 
+var (
+	// ErrNotApproved is returned when a courier that has not passed review tries to go available.
+	ErrNotApproved = errors.New("courier is not approved yet")
+	// ErrInvalidDocumentKind is returned for unknown document kinds.
+	ErrInvalidDocumentKind = errors.New("invalid document kind")
+	// ErrUnsupportedDocument is returned for files that are too large or not an image/PDF.
+	ErrUnsupportedDocument = errors.New("document must be a JPEG, PNG or PDF up to 10 MB")
+	// ErrDocumentStoreUnavailable is returned when no blob store is configured.
+	ErrDocumentStoreUnavailable = errors.New("document storage not configured")
+	// ErrInvalidReview is returned for unknown decisions or transitions the current status doesn't allow.
+	ErrInvalidReview = errors.New("review decision not allowed in the current onboarding status")
+	// ErrMissingDocuments is returned when approving a courier without the required documents.
+	ErrMissingDocuments = errors.New("required documents are missing")
+	// ErrNotesRequired is returned when rejecting or suspending without notes.
+	ErrNotesRequired = errors.New("notes are required to reject or suspend")
+)
+
+// Review decisions an admin can take on a courier.
+const (
+	DecisionApprove = "approve"
+	DecisionReject  = "reject"
+	DecisionSuspend = "suspend"
+)
+
+// UploadDocumentRequest carries one verification file.
+type UploadDocumentRequest struct {
+	CourierID   uuid.UUID
+	Kind        entity.CourierDocumentKind
+	FileName    string
+	ContentType string
+	Size        int64
+	Content     io.Reader
+}
+
+// ReviewRequest is an admin decision on a courier's onboarding.
+type ReviewRequest struct {
+	CourierID uuid.UUID
+	AdminID   uuid.UUID
+	Decision  string
+	Notes     string
+}
+
+// Onboarding summarizes a courier's verification state.
+type Onboarding struct {
+	Status      entity.CourierOnboardingStatus `json:"onboarding_status"`
+	ReviewNotes string                         `json:"review_notes,omitempty"`
+	Documents   []entity.CourierDocument       `json:"documents"`
+	// Missing lists required document kinds not uploaded yet.
+	Missing []entity.CourierDocumentKind `json:"missing"`
+}
+
+// CourierView is a courier profile with the owning user's name and phone.
+type CourierView struct {
+	entity.Courier
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Phone     string `json:"phone"`
+}
+
 // RegisterCourierRequest carries the data required to register a courier.
 // The handler is expected to verify Firebase phone auth and provide the FirebaseUID before calling the service.
 type RegisterCourierRequest struct {
-	FirstName        string
-	LastName         string
-	Phone            string
-	FirebaseUID      string
+	FirstName string
+	LastName  string
+	Phone     string
+	// FirebaseUID comes from a verified ID token; empty when the phone was proven by OTP.
+	FirebaseUID string
+	// PhoneVerified is set once the caller proved they control Phone; an existing user
+	// with that phone is only reused when it is.
+	PhoneVerified    bool
 	HasVehicle       bool
 	PrimaryVehicle   entity.VehicleType
 	VehicleDetails   string
@@ -25,6 +90,17 @@ type RegisterCourierRequest struct {
 type CourierService interface {
 	RegisterCourier(ctx context.Context, req RegisterCourierRequest) (*entity.Courier, error)
 	ListGuarantyOptions(ctx context.Context) ([]entity.GuarantyOption, error)
+	GetCourier(ctx context.Context, courierID uuid.UUID) (*entity.Courier, error)
 	SetAvailability(ctx context.Context, courierID uuid.UUID, available bool) error
 	UpdateLocation(ctx context.Context, courierID uuid.UUID, lat, lng *float64) error
+
+	// UploadDocument stores a verification file, replacing an earlier one of the same kind.
+	UploadDocument(ctx context.Context, req UploadDocumentRequest) (*entity.CourierDocument, error)
+	GetOnboarding(ctx context.Context, courierID uuid.UUID) (*Onboarding, error)
+	// OpenDocument returns a document of the courier and its contents; the caller closes the reader.
+	OpenDocument(ctx context.Context, courierID, documentID uuid.UUID) (*entity.CourierDocument, io.ReadCloser, error)
+	// ListCouriers pages couriers with the given onboarding status (all when empty), oldest first.
+	ListCouriers(ctx context.Context, status entity.CourierOnboardingStatus, limit, offset int) ([]CourierView, int64, error)
+	// Review approves, rejects or suspends a courier.
+	Review(ctx context.Context, req ReviewRequest) (*entity.Courier, error)
 }
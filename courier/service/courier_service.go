@@ -1,48 +1,58 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/account"
+	"github.com/mikios34/delivery-backend/blob"
 	"github.com/mikios34/delivery-backend/courier"
 	"github.com/mikios34/delivery-backend/entity"
+	"gorm.io/gorm"
 )
 
 // courierService implements CourierService.
 type courierService struct {
 	repo courier.CourierRepository
+	docs blob.Store
 }
 
+// maxDocumentBytes caps a single verification upload.
+const maxDocumentBytes = 10 << 20
+
+// documentTypes are the sniffed content types accepted for verification documents.
+var documentTypes = map[string]bool{"image/jpeg": true, "image/png": true, "application/pdf": true}
+
 // NewCourierService constructs a CourierService backed by the provided repository.
-func NewCourierService(repo courier.CourierRepository) courier.CourierService {
-	return &courierService{repo: repo}
+// docs stores verification documents; uploads fail when it is nil.
+func NewCourierService(repo courier.CourierRepository, docs blob.Store) courier.CourierService {
+	return &courierService{repo: repo, docs: docs}
 }
 
 func (s *courierService) ListGuarantyOptions(ctx context.Context) ([]entity.GuarantyOption, error) {
 	return s.repo.ListGuarantyOptions(ctx)
 }
 
-// RegisterCourier performs validations and persists User, Courier and GuarantyPayment.
+// RegisterCourier performs validations and persists User (unless the caller already has
+// one), Courier and GuarantyPayment.
 // This implementation is sequential (calls repository methods). If you need atomic DB transactions,
 // we can extend the repository to expose transaction support and update this method accordingly.
 func (s *courierService) RegisterCourier(ctx context.Context, req courier.RegisterCourierRequest) (*entity.Courier, error) {
-	// check phone uniqueness
-	exists, err := s.repo.PhoneExists(ctx, req.Phone)
-	if err != nil {
-		return nil, err
-	}
-	if exists {
-		return nil, errors.New("courier with this phone already exists")
-	}
-
-	// create user
+	// find or create the user; an existing customer/admin account gains a courier profile
 	u := &entity.User{
 		FirstName:     req.FirstName,
 		LastName:      req.LastName,
 		Phone:         req.Phone,
 		Role:          "courier",
-		PhoneVerified: true,
+		PhoneVerified: req.PhoneVerified,
 	}
 	if req.FirebaseUID != "" {
 		uid := req.FirebaseUID
@@ -52,10 +62,15 @@ func (s *courierService) RegisterCourier(ctx context.Context, req courier.Regist
 		pp := req.ProfilePicture
 		u.ProfilePicture = &pp
 	}
-	createdUser, err := s.repo.StoreUser(ctx, u)
+	createdUser, err := account.FindOrCreateUser(ctx, s.repo, u, req.PhoneVerified)
 	if err != nil {
 		return nil, err
 	}
+	if _, err := s.repo.GetCourierByUserID(ctx, createdUser.ID); err == nil {
+		return nil, errors.New("courier with this phone already exists")
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
 
 	// confirm guaranty option exists & active
 	opts, err := s.repo.ListGuarantyOptions(ctx)
@@ -83,6 +98,7 @@ func (s *courierService) RegisterCourier(ctx context.Context, req courier.Regist
 		GuarantyOptionID: &selected.ID,
 		GuarantyPaid:     false,
 		Active:           true,
+		OnboardingStatus: entity.CourierPendingReview,
 	}
 	createdCourier, err := s.repo.StoreCourier(ctx, c)
 	if err != nil {
@@ -104,10 +120,212 @@ func (s *courierService) RegisterCourier(ctx context.Context, req courier.Regist
 	return createdCourier, nil
 }
 
+func (s *courierService) GetCourier(ctx context.Context, courierID uuid.UUID) (*entity.Courier, error) {
+	return s.repo.GetCourierByID(ctx, courierID)
+}
+
 func (s *courierService) SetAvailability(ctx context.Context, courierID uuid.UUID, available bool) error {
+	if available {
+		c, err := s.repo.GetCourierByID(ctx, courierID)
+		if err != nil {
+			return err
+		}
+		if c.OnboardingStatus != entity.CourierApproved {
+			return courier.ErrNotApproved
+		}
+	}
 	return s.repo.UpdateAvailability(ctx, courierID, available)
 }
 
 func (s *courierService) UpdateLocation(ctx context.Context, courierID uuid.UUID, lat, lng *float64) error {
 	return s.repo.UpdateLocation(ctx, courierID, lat, lng)
 }
+
+func (s *courierService) UploadDocument(ctx context.Context, req courier.UploadDocumentRequest) (*entity.CourierDocument, error) {
+	switch req.Kind {
+	case entity.DocumentID, entity.DocumentDriverLicense, entity.DocumentVehicleRegistration:
+	default:
+		return nil, courier.ErrInvalidDocumentKind
+	}
+	if s.docs == nil {
+		return nil, courier.ErrDocumentStoreUnavailable
+	}
+	if req.Size > maxDocumentBytes {
+		return nil, courier.ErrUnsupportedDocument
+	}
+	c, err := s.repo.GetCourierByID(ctx, req.CourierID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Trust the bytes rather than the client's Content-Type header.
+	head := make([]byte, 512)
+	n, err := io.ReadFull(req.Content, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	head = head[:n]
+	contentType := http.DetectContentType(head)
+	if !documentTypes[contentType] {
+		return nil, courier.ErrUnsupportedDocument
+	}
+	// Read one byte past the cap so oversized bodies with a lying size are caught.
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(io.LimitReader(io.MultiReader(bytes.NewReader(head), req.Content), maxDocumentBytes+1)); err != nil {
+		return nil, err
+	}
+	if buf.Len() > maxDocumentBytes {
+		return nil, courier.ErrUnsupportedDocument
+	}
+
+	doc := &entity.CourierDocument{
+		ID:          uuid.New(),
+		CourierID:   c.ID,
+		Kind:        req.Kind,
+		FileName:    strings.TrimSpace(req.FileName),
+		ContentType: contentType,
+		SizeBytes:   int64(buf.Len()),
+	}
+	doc.BlobKey = fmt.Sprintf("couriers/%s/%s", c.ID, doc.ID)
+	if err := s.docs.Put(ctx, doc.BlobKey, &buf); err != nil {
+		return nil, err
+	}
+	old, err := s.repo.ReplaceDocuments(ctx, c.ID, req.Kind)
+	if err != nil {
+		_ = s.docs.Delete(ctx, doc.BlobKey)
+		return nil, err
+	}
+	if err := s.repo.StoreDocument(ctx, doc); err != nil {
+		_ = s.docs.Delete(ctx, doc.BlobKey)
+		return nil, err
+	}
+	for _, d := range old {
+		if err := s.docs.Delete(ctx, d.BlobKey); err != nil {
+			log.Printf("courier %s: delete replaced document %s: %v", c.ID, d.ID, err)
+		}
+	}
+
+	// A rejected courier who sends new documents goes back into the review queue.
+	if c.OnboardingStatus == entity.CourierRejected {
+		if err := s.repo.UpdateOnboarding(ctx, c.ID, map[string]interface{}{"onboarding_status": entity.CourierPendingReview}); err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+func (s *courierService) GetOnboarding(ctx context.Context, courierID uuid.UUID) (*courier.Onboarding, error) {
+	c, err := s.repo.GetCourierByID(ctx, courierID)
+	if err != nil {
+		return nil, err
+	}
+	docs, err := s.repo.ListDocuments(ctx, courierID)
+	if err != nil {
+		return nil, err
+	}
+	if docs == nil {
+		docs = []entity.CourierDocument{}
+	}
+	return &courier.Onboarding{
+		Status:      c.OnboardingStatus,
+		ReviewNotes: c.ReviewNotes,
+		Documents:   docs,
+		Missing:     missingDocuments(c, docs),
+	}, nil
+}
+
+func (s *courierService) OpenDocument(ctx context.Context, courierID, documentID uuid.UUID) (*entity.CourierDocument, io.ReadCloser, error) {
+	if s.docs == nil {
+		return nil, nil, courier.ErrDocumentStoreUnavailable
+	}
+	doc, err := s.repo.GetDocument(ctx, documentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if doc.CourierID != courierID {
+		return nil, nil, gorm.ErrRecordNotFound
+	}
+	rc, err := s.docs.Open(ctx, doc.BlobKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return doc, rc, nil
+}
+
+func (s *courierService) ListCouriers(ctx context.Context, status entity.CourierOnboardingStatus, limit, offset int) ([]courier.CourierView, int64, error) {
+	return s.repo.ListCouriersByOnboarding(ctx, status, limit, offset)
+}
+
+func (s *courierService) Review(ctx context.Context, req courier.ReviewRequest) (*entity.Courier, error) {
+	c, err := s.repo.GetCourierByID(ctx, req.CourierID)
+	if err != nil {
+		return nil, err
+	}
+	notes := strings.TrimSpace(req.Notes)
+	var next entity.CourierOnboardingStatus
+	switch req.Decision {
+	case courier.DecisionApprove:
+		if c.OnboardingStatus == entity.CourierApproved {
+			return nil, courier.ErrInvalidReview
+		}
+		docs, err := s.repo.ListDocuments(ctx, c.ID)
+		if err != nil {
+			return nil, err
+		}
+		if len(missingDocuments(c, docs)) > 0 {
+			return nil, courier.ErrMissingDocuments
+		}
+		next = entity.CourierApproved
+	case courier.DecisionReject:
+		if c.OnboardingStatus != entity.CourierPendingReview {
+			return nil, courier.ErrInvalidReview
+		}
+		next = entity.CourierRejected
+	case courier.DecisionSuspend:
+		if c.OnboardingStatus != entity.CourierApproved {
+			return nil, courier.ErrInvalidReview
+		}
+		next = entity.CourierSuspended
+	default:
+		return nil, courier.ErrInvalidReview
+	}
+	if next != entity.CourierApproved && notes == "" {
+		return nil, courier.ErrNotesRequired
+	}
+
+	now := time.Now().UTC()
+	fields := map[string]interface{}{
+		"onboarding_status": next,
+		"review_notes":      notes,
+		"reviewed_at":       now,
+		"reviewed_by":       req.AdminID,
+	}
+	if next != entity.CourierApproved {
+		// Take the courier out of dispatch immediately; an active order stays with them.
+		fields["available"] = false
+	}
+	if err := s.repo.UpdateOnboarding(ctx, c.ID, fields); err != nil {
+		return nil, err
+	}
+	return s.repo.GetCourierByID(ctx, c.ID)
+}
+
+// missingDocuments lists required kinds without an upload. Couriers with a vehicle
+// also need a driver license and vehicle registration.
+func missingDocuments(c *entity.Courier, docs []entity.CourierDocument) []entity.CourierDocumentKind {
+	required := []entity.CourierDocumentKind{entity.DocumentID}
+	if c.HasVehicle {
+		required = append(required, entity.DocumentDriverLicense, entity.DocumentVehicleRegistration)
+	}
+	have := make(map[entity.CourierDocumentKind]bool, len(docs))
+	for _, d := range docs {
+		have[d.Kind] = true
+	}
+	missing := []entity.CourierDocumentKind{}
+	for _, k := range required {
+		if !have[k] {
+			missing = append(missing, k)
+		}
+	}
+	return missing
+}
@@ -13,5 +13,15 @@ type CustomerRepository interface {
 	StoreCustomer(ctx context.Context, c *entity.Customer) (*entity.Customer, error)
 	GetCustomerByID(ctx context.Context, id uuid.UUID) (*entity.Customer, error)
 	GetUserByID(ctx context.Context, id uuid.UUID) (*entity.User, error)
-	PhoneExists(ctx context.Context, phone string) (bool, error)
+	GetUserByPhone(ctx context.Context, phone string) (*entity.User, error)
+	GetUserByFirebaseUID(ctx context.Context, uid string) (*entity.User, error)
+	SetUserFirebaseUID(ctx context.Context, userID uuid.UUID, uid string) error
+	GetCustomerByUserID(ctx context.Context, userID uuid.UUID) (*entity.Customer, error)
+
+	SearchCustomers(ctx context.Context, f CustomerFilter) ([]CustomerView, int64, error)
+	GetCustomerView(ctx context.Context, id uuid.UUID) (*CustomerView, error)
+	// UpdateSuspension applies fields and stores the history note in one transaction.
+	UpdateSuspension(ctx context.Context, id uuid.UUID, fields map[string]interface{}, note *entity.CustomerNote) error
+	CreateNote(ctx context.Context, n *entity.CustomerNote) error
+	ListNotes(ctx context.Context, customerID uuid.UUID) ([]entity.CustomerNote, error)
 }
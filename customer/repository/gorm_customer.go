@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"strings"
 
 	"github.com/google/uuid"
 	customerpkg "github.com/mikios34/delivery-backend/customer"
@@ -48,10 +49,102 @@ func (r *GormCustomerRepo) GetUserByID(ctx context.Context, id uuid.UUID) (*enti
 	return &u, nil
 }
 
-func (r *GormCustomerRepo) PhoneExists(ctx context.Context, phone string) (bool, error) {
-	var count int64
-	if err := r.db.WithContext(ctx).Model(&entity.User{}).Where("phone = ? AND role = ?", phone, "customer").Count(&count).Error; err != nil {
-		return false, err
+func (r *GormCustomerRepo) GetUserByPhone(ctx context.Context, phone string) (*entity.User, error) {
+	var u entity.User
+	if err := r.db.WithContext(ctx).Where("phone = ?", phone).Order("created_at").First(&u).Error; err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (r *GormCustomerRepo) GetUserByFirebaseUID(ctx context.Context, uid string) (*entity.User, error) {
+	var u entity.User
+	if err := r.db.WithContext(ctx).Where("firebase_uid = ?", uid).First(&u).Error; err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (r *GormCustomerRepo) SetUserFirebaseUID(ctx context.Context, userID uuid.UUID, uid string) error {
+	return r.db.WithContext(ctx).Model(&entity.User{}).Where("id = ?", userID).Update("firebase_uid", uid).Error
+}
+
+func (r *GormCustomerRepo) GetCustomerByUserID(ctx context.Context, userID uuid.UUID) (*entity.Customer, error) {
+	var c entity.Customer
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&c).Error; err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// customers selects non-deleted customers joined with their users.
+func (r *GormCustomerRepo) customers(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx).Table("customers").
+		Joins("JOIN users ON users.id = customers.user_id").
+		Where("customers.deleted_at IS NULL")
+}
+
+func (r *GormCustomerRepo) SearchCustomers(ctx context.Context, f customerpkg.CustomerFilter) ([]customerpkg.CustomerView, int64, error) {
+	base := func() *gorm.DB {
+		q := r.customers(ctx)
+		switch f.Status {
+		case customerpkg.StatusActive:
+			q = q.Where("customers.suspended_at IS NULL")
+		case customerpkg.StatusSuspended:
+			q = q.Where("customers.suspended_at IS NOT NULL")
+		}
+		if f.Query != "" {
+			like := "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(f.Query) + "%"
+			q = q.Where("users.first_name ILIKE ? OR users.last_name ILIKE ? OR users.phone ILIKE ?", like, like, like)
+		}
+		return q
+	}
+	var total int64
+	if err := base().Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var out []customerpkg.CustomerView
+	err := base().
+		Select("customers.*, users.first_name, users.last_name, users.phone").
+		Order("customers.created_at DESC").
+		Limit(f.Limit).Offset(f.Offset).
+		Scan(&out).Error
+	return out, total, err
+}
+
+func (r *GormCustomerRepo) GetCustomerView(ctx context.Context, id uuid.UUID) (*customerpkg.CustomerView, error) {
+	var out []customerpkg.CustomerView
+	err := r.customers(ctx).
+		Select("customers.*, users.first_name, users.last_name, users.phone").
+		Where("customers.id = ?", id).
+		Limit(1).
+		Scan(&out).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &out[0], nil
+}
+
+func (r *GormCustomerRepo) UpdateSuspension(ctx context.Context, id uuid.UUID, fields map[string]interface{}, note *entity.CustomerNote) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&entity.Customer{}).Where("id = ?", id).Updates(fields).Error; err != nil {
+			return err
+		}
+		return tx.Create(note).Error
+	})
+}
+
+func (r *GormCustomerRepo) CreateNote(ctx context.Context, n *entity.CustomerNote) error {
+	return r.db.WithContext(ctx).Create(n).Error
+}
+
+func (r *GormCustomerRepo) ListNotes(ctx context.Context, customerID uuid.UUID) ([]entity.CustomerNote, error) {
+	var list []entity.CustomerNote
+	if err := r.db.WithContext(ctx).Where("customer_id = ?", customerID).Order("created_at DESC").Find(&list).Error; err != nil {
+		return nil, err
 	}
-	return count > 0, nil
+	return list, nil
 }
@@ -2,20 +2,71 @@ package customer
 
 import (
 	"context"
+	"errors"
 
+	"github.com/google/uuid"
 	"github.com/mikios34/delivery-backend/entity"
 )
 
+var (
+	// ErrReasonRequired is returned when suspending or reactivating without a reason.
+	ErrReasonRequired = errors.New("reason is required")
+	// ErrNoteRequired is returned for a blank support note.
+	ErrNoteRequired = errors.New("note body is required")
+	// ErrAlreadySuspended is returned when suspending a suspended customer.
+	ErrAlreadySuspended = errors.New("customer is already suspended")
+	// ErrNotSuspended is returned when reactivating a customer that isn't suspended.
+	ErrNotSuspended = errors.New("customer is not suspended")
+)
+
+// Customer status filters for ListCustomers.
+const (
+	StatusActive    = "active"
+	StatusSuspended = "suspended"
+)
+
+// CustomerView is a customer profile with the owning user's name and phone.
+type CustomerView struct {
+	entity.Customer
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Phone     string `json:"phone"`
+}
+
+// CustomerFilter narrows admin customer lists; zero values are ignored.
+type CustomerFilter struct {
+	// Query matches first name, last name or phone (case-insensitive substring).
+	Query  string
+	Status string // StatusActive or StatusSuspended
+	Limit  int
+	Offset int
+}
+
 // RegisterCustomerRequest carries the data required to register a customer.
 type RegisterCustomerRequest struct {
-	FirstName      string
-	LastName       string
-	Phone          string
-	FirebaseUID    string
+	FirstName string
+	LastName  string
+	Phone     string
+	// FirebaseUID comes from a verified ID token; empty when the phone was proven by OTP.
+	FirebaseUID string
+	// PhoneVerified is set once the caller proved they control Phone; an existing user
+	// with that phone is only reused when it is.
+	PhoneVerified  bool
 	ProfilePicture *string
 }
 
 // CustomerService exposes customer-related business operations.
 type CustomerService interface {
 	RegisterCustomer(ctx context.Context, req RegisterCustomerRequest) (*entity.Customer, error)
+
+	// Admin customer management.
+	ListCustomers(ctx context.Context, f CustomerFilter) ([]CustomerView, int64, error)
+	GetCustomer(ctx context.Context, id uuid.UUID) (*CustomerView, error)
+	// Suspend blocks the customer from signing in and ordering, and records the reason.
+	Suspend(ctx context.Context, id, adminID uuid.UUID, reason string) (*CustomerView, error)
+	// Reactivate lifts a suspension; the reason goes to the customer's notes.
+	Reactivate(ctx context.Context, id, adminID uuid.UUID, reason string) (*CustomerView, error)
+	AddNote(ctx context.Context, id, adminID uuid.UUID, body string) (*entity.CustomerNote, error)
+	// ListNotes returns notes and suspension history, newest first.
+	ListNotes(ctx context.Context, id uuid.UUID) ([]entity.CustomerNote, error)
 }
@@ -3,9 +3,14 @@ package service
 import (
 	"context"
 	"errors"
+	"strings"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/account"
 	customerpkg "github.com/mikios34/delivery-backend/customer"
 	"github.com/mikios34/delivery-backend/entity"
+	"gorm.io/gorm"
 )
 
 // customerService implements CustomerService.
@@ -18,24 +23,16 @@ func NewCustomerService(repo customerpkg.CustomerRepository) customerpkg.Custome
 	return &customerService{repo: repo}
 }
 
-// RegisterCustomer creates a base User with role "customer" and a Customer profile.
+// RegisterCustomer attaches a Customer profile to the caller's user, creating the user
+// (primary role "customer") on first registration.
 func (s *customerService) RegisterCustomer(ctx context.Context, req customerpkg.RegisterCustomerRequest) (*entity.Customer, error) {
-	// check phone uniqueness among customers
-	exists, err := s.repo.PhoneExists(ctx, req.Phone)
-	if err != nil {
-		return nil, err
-	}
-	if exists {
-		return nil, errors.New("customer with this phone already exists")
-	}
-
-	// create user
+	// find or create the user; an existing courier/admin account gains a customer profile
 	u := &entity.User{
 		FirstName:     req.FirstName,
 		LastName:      req.LastName,
 		Phone:         req.Phone,
 		Role:          "customer",
-		PhoneVerified: true,
+		PhoneVerified: req.PhoneVerified,
 	}
 	if req.ProfilePicture != nil {
 		u.ProfilePicture = req.ProfilePicture
@@ -44,10 +41,15 @@ func (s *customerService) RegisterCustomer(ctx context.Context, req customerpkg.
 		uid := req.FirebaseUID
 		u.FirebaseUID = &uid
 	}
-	createdUser, err := s.repo.StoreUser(ctx, u)
+	createdUser, err := account.FindOrCreateUser(ctx, s.repo, u, req.PhoneVerified)
 	if err != nil {
 		return nil, err
 	}
+	if _, err := s.repo.GetCustomerByUserID(ctx, createdUser.ID); err == nil {
+		return nil, errors.New("customer with this phone already exists")
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
 
 	// create customer profile
 	c := &entity.Customer{UserID: createdUser.ID, Active: true}
@@ -57,3 +59,84 @@ func (s *customerService) RegisterCustomer(ctx context.Context, req customerpkg.
 	}
 	return createdCustomer, nil
 }
+
+func (s *customerService) ListCustomers(ctx context.Context, f customerpkg.CustomerFilter) ([]customerpkg.CustomerView, int64, error) {
+	f.Query = strings.TrimSpace(f.Query)
+	return s.repo.SearchCustomers(ctx, f)
+}
+
+func (s *customerService) GetCustomer(ctx context.Context, id uuid.UUID) (*customerpkg.CustomerView, error) {
+	return s.repo.GetCustomerView(ctx, id)
+}
+
+func (s *customerService) Suspend(ctx context.Context, id, adminID uuid.UUID, reason string) (*customerpkg.CustomerView, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, customerpkg.ErrReasonRequired
+	}
+	c, err := s.repo.GetCustomerView(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if c.SuspendedAt != nil {
+		return nil, customerpkg.ErrAlreadySuspended
+	}
+	fields := map[string]interface{}{
+		"active":            false,
+		"suspended_at":      time.Now(),
+		"suspension_reason": reason,
+		"suspended_by":      adminID,
+	}
+	note := &entity.CustomerNote{CustomerID: id, AdminID: adminID, Kind: entity.CustomerNoteSuspended, Body: reason}
+	if err := s.repo.UpdateSuspension(ctx, id, fields, note); err != nil {
+		return nil, err
+	}
+	return s.repo.GetCustomerView(ctx, id)
+}
+
+func (s *customerService) Reactivate(ctx context.Context, id, adminID uuid.UUID, reason string) (*customerpkg.CustomerView, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, customerpkg.ErrReasonRequired
+	}
+	c, err := s.repo.GetCustomerView(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if c.SuspendedAt == nil {
+		return nil, customerpkg.ErrNotSuspended
+	}
+	fields := map[string]interface{}{
+		"active":            true,
+		"suspended_at":      nil,
+		"suspension_reason": "",
+		"suspended_by":      nil,
+	}
+	note := &entity.CustomerNote{CustomerID: id, AdminID: adminID, Kind: entity.CustomerNoteReactivated, Body: reason}
+	if err := s.repo.UpdateSuspension(ctx, id, fields, note); err != nil {
+		return nil, err
+	}
+	return s.repo.GetCustomerView(ctx, id)
+}
+
+func (s *customerService) AddNote(ctx context.Context, id, adminID uuid.UUID, body string) (*entity.CustomerNote, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return nil, customerpkg.ErrNoteRequired
+	}
+	if _, err := s.repo.GetCustomerByID(ctx, id); err != nil {
+		return nil, err
+	}
+	n := &entity.CustomerNote{CustomerID: id, AdminID: adminID, Kind: entity.CustomerNoteText, Body: body}
+	if err := s.repo.CreateNote(ctx, n); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+func (s *customerService) ListNotes(ctx context.Context, id uuid.UUID) ([]entity.CustomerNote, error) {
+	if _, err := s.repo.GetCustomerByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.repo.ListNotes(ctx, id)
+}
@@ -0,0 +1,129 @@
+package dashboard
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/realtime"
+)
+
+// Dashboard socket events.
+const (
+	EventSnapshot = "dashboard.snapshot" // Summary plus couriers, sent on connect
+	EventOrder    = "dashboard.order"    // an order changed; data is the order
+	EventCourier  = "dashboard.courier"  // a courier changed; data is a CourierPosition
+	EventCounts   = "dashboard.counts"   // Counts after orders or couriers changed
+)
+
+// Feed streams dashboard deltas to admin sockets. Changes reported by the hub are
+// collected and flushed once per interval, so a courier sending locations every second
+// or a burst of order events costs one query per flush, not one per event.
+type Feed struct {
+	svc      Service
+	hub      *realtime.Hub
+	interval time.Duration
+
+	mu       sync.Mutex
+	orders   map[uuid.UUID]struct{}
+	couriers map[uuid.UUID]struct{}
+}
+
+// NewFeed constructs a Feed and registers it as the hub's change observer.
+func NewFeed(svc Service, hub *realtime.Hub, interval time.Duration) *Feed {
+	f := &Feed{
+		svc:      svc,
+		hub:      hub,
+		interval: interval,
+		orders:   make(map[uuid.UUID]struct{}),
+		couriers: make(map[uuid.UUID]struct{}),
+	}
+	hub.OnChange(f.observe)
+	return f
+}
+
+func (f *Feed) observe(ch realtime.Change) {
+	id, err := uuid.Parse(ch.ID)
+	if err != nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch ch.Kind {
+	case realtime.ChangeOrder:
+		f.orders[id] = struct{}{}
+	case realtime.ChangeCourier:
+		f.couriers[id] = struct{}{}
+	}
+}
+
+// Run flushes pending changes until ctx is done.
+func (f *Feed) Run(ctx context.Context) {
+	t := time.NewTicker(f.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			f.flush(ctx)
+		}
+	}
+}
+
+func (f *Feed) flush(ctx context.Context) {
+	f.mu.Lock()
+	orders, couriers := keys(f.orders), keys(f.couriers)
+	f.orders = make(map[uuid.UUID]struct{})
+	f.couriers = make(map[uuid.UUID]struct{})
+	f.mu.Unlock()
+	if len(orders)+len(couriers) == 0 || f.hub.AdminsConnected() == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	list, err := f.svc.OrdersByID(ctx, orders)
+	if err != nil {
+		log.Println("dashboard: load orders:", err)
+	}
+	for _, o := range list {
+		f.hub.BroadcastAdmins(EventOrder, o)
+		// The order's courier may have become busy or free.
+		if o.AssignedCourier != nil {
+			couriers = appendUnique(couriers, *o.AssignedCourier)
+		}
+	}
+	positions, err := f.svc.CouriersByID(ctx, couriers)
+	if err != nil {
+		log.Println("dashboard: load couriers:", err)
+	}
+	for _, p := range positions {
+		f.hub.BroadcastAdmins(EventCourier, p)
+	}
+	counts, err := f.svc.Counts(ctx)
+	if err != nil {
+		log.Println("dashboard: counts:", err)
+		return
+	}
+	f.hub.BroadcastAdmins(EventCounts, counts)
+}
+
+func keys(m map[uuid.UUID]struct{}) []uuid.UUID {
+	out := make([]uuid.UUID, 0, len(m))
+	for id := range m {
+		out = append(out, id)
+	}
+	return out
+}
+
+func appendUnique(ids []uuid.UUID, id uuid.UUID) []uuid.UUID {
+	for _, x := range ids {
+		if x == id {
+			return ids
+		}
+	}
+	return append(ids, id)
+}
@@ -0,0 +1,24 @@
+package dashboard
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/entity"
+)
+
+// Repository specifies the dashboard queries. Courier counts and lists cover active, approved
+// couriers only; lookups by id return any courier.
+type Repository interface {
+	CountOrdersByStatus(ctx context.Context, statuses []entity.OrderStatus) (map[entity.OrderStatus]int64, error)
+	CountOrdersCreatedSince(ctx context.Context, since time.Time) (map[entity.OrderStatus]int64, error)
+	ListOrdersCreatedBefore(ctx context.Context, statuses []entity.OrderStatus, before time.Time, limit int) ([]entity.Order, error)
+	ListOrdersByID(ctx context.Context, ids []uuid.UUID) ([]entity.Order, error)
+
+	// CountCouriers counts couriers; online are the ids with an open socket.
+	CountCouriers(ctx context.Context, online []uuid.UUID) (CourierCounts, error)
+	// ListCourierPositions returns couriers that are available, on an open order or in online.
+	ListCourierPositions(ctx context.Context, online []uuid.UUID) ([]CourierPosition, error)
+	ListCourierPositionsByID(ctx context.Context, ids []uuid.UUID) ([]CourierPosition, error)
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/dashboard"
+	"github.com/mikios34/delivery-backend/entity"
+	"gorm.io/gorm"
+)
+
+// GormDashboardRepo implements dashboard.Repository using GORM.
+type GormDashboardRepo struct{ db *gorm.DB }
+
+func NewGormDashboardRepo(db *gorm.DB) dashboard.Repository { return &GormDashboardRepo{db: db} }
+
+// busyOrder matches an open order a courier is working on; it expects the couriers table as c.
+const busyOrder = `SELECT 1 FROM orders o WHERE o.assigned_courier = c.id AND o.deleted_at IS NULL
+	AND o.status IN ('assigned','accepted','arrived','picked_up')`
+
+// activeOrderID selects the courier's latest open order, if any.
+const activeOrderID = `(SELECT o.id FROM orders o WHERE o.assigned_courier = c.id AND o.deleted_at IS NULL
+	AND o.status IN ('assigned','accepted','arrived','picked_up') ORDER BY o.created_at DESC LIMIT 1) AS active_order_id`
+
+type statusCount struct {
+	Status entity.OrderStatus
+	N      int64
+}
+
+func (r *GormDashboardRepo) countByStatus(q *gorm.DB) (map[entity.OrderStatus]int64, error) {
+	var rows []statusCount
+	if err := q.Select("status, COUNT(*) AS n").Group("status").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make(map[entity.OrderStatus]int64, len(rows))
+	for _, row := range rows {
+		out[row.Status] = row.N
+	}
+	return out, nil
+}
+
+func (r *GormDashboardRepo) CountOrdersByStatus(ctx context.Context, statuses []entity.OrderStatus) (map[entity.OrderStatus]int64, error) {
+	return r.countByStatus(r.db.WithContext(ctx).Model(&entity.Order{}).Where("status IN ?", statuses))
+}
+
+func (r *GormDashboardRepo) CountOrdersCreatedSince(ctx context.Context, since time.Time) (map[entity.OrderStatus]int64, error) {
+	return r.countByStatus(r.db.WithContext(ctx).Model(&entity.Order{}).Where("created_at >= ?", since))
+}
+
+func (r *GormDashboardRepo) ListOrdersCreatedBefore(ctx context.Context, statuses []entity.OrderStatus, before time.Time, limit int) ([]entity.Order, error) {
+	var list []entity.Order
+	err := r.db.WithContext(ctx).
+		Where("status IN ? AND created_at < ?", statuses, before).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&list).Error
+	return list, err
+}
+
+func (r *GormDashboardRepo) ListOrdersByID(ctx context.Context, ids []uuid.UUID) ([]entity.Order, error) {
+	var list []entity.Order
+	if err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// workingCouriers selects active, approved couriers as c.
+func (r *GormDashboardRepo) workingCouriers(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx).Table("couriers AS c").
+		Where("c.deleted_at IS NULL AND c.active = TRUE AND c.onboarding_status = ?", entity.CourierApproved)
+}
+
+func (r *GormDashboardRepo) CountCouriers(ctx context.Context, online []uuid.UUID) (dashboard.CourierCounts, error) {
+	var counts dashboard.CourierCounts
+	q := r.workingCouriers(ctx).Select(
+		"COUNT(*) FILTER (WHERE c.available) AS available, " +
+			"COUNT(*) FILTER (WHERE EXISTS (" + busyOrder + ")) AS busy")
+	if err := q.Scan(&counts).Error; err != nil {
+		return counts, err
+	}
+	if len(online) > 0 {
+		if err := r.workingCouriers(ctx).Where("c.id IN ?", online).Count(&counts.Online).Error; err != nil {
+			return counts, err
+		}
+	}
+	return counts, nil
+}
+
+// positions selects couriers with their user's name and open order, as CourierPosition rows.
+func (r *GormDashboardRepo) positions(q *gorm.DB) ([]dashboard.CourierPosition, error) {
+	var out []dashboard.CourierPosition
+	err := q.Select("c.id AS courier_id, users.first_name, users.last_name, users.phone, " +
+		"c.primary_vehicle, c.available, c.active, c.onboarding_status, " +
+		"c.latitude, c.longitude, c.location_updated_at, " + activeOrderID).
+		Joins("JOIN users ON users.id = c.user_id").
+		Order("c.location_updated_at DESC NULLS LAST").
+		Scan(&out).Error
+	return out, err
+}
+
+func (r *GormDashboardRepo) ListCourierPositions(ctx context.Context, online []uuid.UUID) ([]dashboard.CourierPosition, error) {
+	q := r.workingCouriers(ctx)
+	if len(online) > 0 {
+		q = q.Where("c.available OR c.id IN ? OR EXISTS ("+busyOrder+")", online)
+	} else {
+		q = q.Where("c.available OR EXISTS (" + busyOrder + ")")
+	}
+	return r.positions(q)
+}
+
+func (r *GormDashboardRepo) ListCourierPositionsByID(ctx context.Context, ids []uuid.UUID) ([]dashboard.CourierPosition, error) {
+	return r.positions(r.db.WithContext(ctx).Table("couriers AS c").Where("c.deleted_at IS NULL AND c.id IN ?", ids))
+}
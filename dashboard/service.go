@@ -0,0 +1,86 @@
+// Package dashboard gives dispatchers a live view of operations: order counts by status,
+// orders waiting too long for a courier, and where couriers are.
+package dashboard
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/entity"
+)
+
+// OpenStatuses are the order statuses that still need work from a courier or dispatcher.
+var OpenStatuses = []entity.OrderStatus{
+	entity.OrderPending, entity.OrderAssigned, entity.OrderAccepted, entity.OrderDeclined,
+	entity.OrderArrived, entity.OrderPickedUp, entity.OrderNoNearbyDriver,
+}
+
+// UnassignedStatuses are open orders without a courier on them.
+var UnassignedStatuses = []entity.OrderStatus{entity.OrderPending, entity.OrderDeclined, entity.OrderNoNearbyDriver}
+
+// DefaultStaleAfter is how long an unassigned order waits before it is flagged as stale.
+const DefaultStaleAfter = 10 * time.Minute
+
+// MaxStaleOrders caps the stale orders returned in a summary.
+const MaxStaleOrders = 100
+
+// Counts is the headline numbers of the dashboard.
+type Counts struct {
+	// OpenOrders counts every open order by status, however old.
+	OpenOrders map[entity.OrderStatus]int64 `json:"open_orders"`
+	// Today counts orders created since midnight UTC by status, including finished ones.
+	Today    map[entity.OrderStatus]int64 `json:"today"`
+	Couriers CourierCounts                `json:"couriers"`
+}
+
+// CourierCounts counts approved, active couriers.
+type CourierCounts struct {
+	Online    int64 `json:"online"`    // socket connected
+	Available int64 `json:"available"` // accepting orders
+	Busy      int64 `json:"busy"`      // on an open order
+}
+
+// Summary is the dashboard snapshot.
+type Summary struct {
+	Counts
+	// StaleOrders are unassigned orders created before StaleBefore, oldest first.
+	StaleOrders []entity.Order `json:"stale_orders"`
+	StaleBefore time.Time      `json:"stale_before"`
+	GeneratedAt time.Time      `json:"generated_at"`
+}
+
+// CourierPosition is a courier on the map with their last known location.
+type CourierPosition struct {
+	CourierID         uuid.UUID  `json:"courier_id"`
+	FirstName         string     `json:"first_name"`
+	LastName          string     `json:"last_name"`
+	Phone             string     `json:"phone"`
+	PrimaryVehicle    string     `json:"primary_vehicle"`
+	Available         bool       `json:"available"`
+	Online            bool       `json:"online" gorm:"-"`
+	Latitude          *float64   `json:"latitude,omitempty"`
+	Longitude         *float64   `json:"longitude,omitempty"`
+	LocationUpdatedAt *time.Time `json:"location_updated_at,omitempty"`
+	ActiveOrderID     *uuid.UUID `json:"active_order_id,omitempty"`
+	// Active and OnboardingStatus let clients drop couriers that can no longer work.
+	Active           bool                           `json:"active"`
+	OnboardingStatus entity.CourierOnboardingStatus `json:"onboarding_status"`
+}
+
+// Presence reports which couriers hold an open socket; the realtime hub implements it.
+type Presence interface {
+	OnlineCouriers() []string
+}
+
+// Service reads the dashboard.
+type Service interface {
+	// Summary returns counts and the unassigned orders older than staleAfter.
+	Summary(ctx context.Context, staleAfter time.Duration) (*Summary, error)
+	Counts(ctx context.Context) (*Counts, error)
+	// Couriers lists approved couriers that are online, available or on an order.
+	Couriers(ctx context.Context) ([]CourierPosition, error)
+	// CouriersByID returns the given couriers whatever their state, for deltas.
+	CouriersByID(ctx context.Context, ids []uuid.UUID) ([]CourierPosition, error)
+	OrdersByID(ctx context.Context, ids []uuid.UUID) ([]entity.Order, error)
+}
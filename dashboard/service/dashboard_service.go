@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/dashboard"
+	"github.com/mikios34/delivery-backend/entity"
+)
+
+// dashboardService implements dashboard.Service.
+type dashboardService struct {
+	repo     dashboard.Repository
+	presence dashboard.Presence
+}
+
+// NewDashboardService constructs a dashboard.Service; presence tells which couriers are online.
+func NewDashboardService(repo dashboard.Repository, presence dashboard.Presence) dashboard.Service {
+	return &dashboardService{repo: repo, presence: presence}
+}
+
+func (s *dashboardService) Summary(ctx context.Context, staleAfter time.Duration) (*dashboard.Summary, error) {
+	now := time.Now()
+	counts, err := s.Counts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	before := now.Add(-staleAfter)
+	stale, err := s.repo.ListOrdersCreatedBefore(ctx, dashboard.UnassignedStatuses, before, dashboard.MaxStaleOrders)
+	if err != nil {
+		return nil, err
+	}
+	if stale == nil {
+		stale = []entity.Order{}
+	}
+	return &dashboard.Summary{Counts: *counts, StaleOrders: stale, StaleBefore: before, GeneratedAt: now}, nil
+}
+
+func (s *dashboardService) Counts(ctx context.Context) (*dashboard.Counts, error) {
+	open, err := s.repo.CountOrdersByStatus(ctx, dashboard.OpenStatuses)
+	if err != nil {
+		return nil, err
+	}
+	y, m, d := time.Now().UTC().Date()
+	today, err := s.repo.CountOrdersCreatedSince(ctx, time.Date(y, m, d, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		return nil, err
+	}
+	couriers, err := s.repo.CountCouriers(ctx, s.online())
+	if err != nil {
+		return nil, err
+	}
+	return &dashboard.Counts{OpenOrders: open, Today: today, Couriers: couriers}, nil
+}
+
+func (s *dashboardService) Couriers(ctx context.Context) ([]dashboard.CourierPosition, error) {
+	online := s.online()
+	list, err := s.repo.ListCourierPositions(ctx, online)
+	if err != nil {
+		return nil, err
+	}
+	return markOnline(list, online), nil
+}
+
+func (s *dashboardService) CouriersByID(ctx context.Context, ids []uuid.UUID) ([]dashboard.CourierPosition, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	list, err := s.repo.ListCourierPositionsByID(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	return markOnline(list, s.online()), nil
+}
+
+func (s *dashboardService) OrdersByID(ctx context.Context, ids []uuid.UUID) ([]entity.Order, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	return s.repo.ListOrdersByID(ctx, ids)
+}
+
+// online returns the ids of connected couriers; ids that are not UUIDs are skipped.
+func (s *dashboardService) online() []uuid.UUID {
+	if s.presence == nil {
+		return nil
+	}
+	var ids []uuid.UUID
+	for _, raw := range s.presence.OnlineCouriers() {
+		if id, err := uuid.Parse(raw); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func markOnline(list []dashboard.CourierPosition, online []uuid.UUID) []dashboard.CourierPosition {
+	set := make(map[uuid.UUID]bool, len(online))
+	for _, id := range online {
+		set[id] = true
+	}
+	for i := range list {
+		list[i].Online = set[list[i].CourierID]
+	}
+	return list
+}
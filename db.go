@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"log"
+	"time"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -39,21 +40,50 @@ func setupDatabase() *gorm.DB {
 		&entity.User{},
 		&entity.GuarantyOption{},
 		&entity.Courier{},
+		&entity.CourierDocument{},
 		&entity.GuarantyPayment{},
 		&entity.Customer{},
+		&entity.CustomerNote{},
 		&entity.Admin{},
+		&entity.AuthSession{},
+		&entity.RevokedToken{},
+		&entity.OTPCode{},
+		&entity.RateLimitBucket{},
+		&entity.AuditLog{},
+		&entity.AccountDeletionRequest{},
 		&entity.OrderType{},
 		&entity.Order{},
 		&entity.OrderAssignmentAttempt{},
+		&entity.OrderTrackingLink{},
+		&entity.ChatMessage{},
 		&entity.VehicleTypeConfig{}, // pricing table: vehicle_types
+		&entity.VehiclePricingVersion{},
+		&entity.Zone{},
+		&entity.Announcement{},
+		&entity.AnnouncementAck{},
+		&entity.SupportTicket{},
+		&entity.TicketMessage{},
+		&entity.TicketAttachment{},
+		&entity.TicketAdjustment{},
+		&entity.OrderImport{},
+		&entity.OrderImportRow{},
 	); err != nil {
 		log.Fatal("failed to run migrations:", err)
 	}
 
+	// Accounts used to be one user per role; fold same-phone users into one.
+	if err := mergeDuplicatePhoneUsers(db); err != nil {
+		log.Println("warning: failed to merge duplicate phone users:", err)
+	}
+
 	// Optional: seed default vehicle types if none exist
 	if err := seedVehicleTypes(db); err != nil {
 		log.Println("warning: failed to seed vehicle types:", err)
 	}
+	// Orders reference pricing versions; give every vehicle type one.
+	if err := backfillPricingVersions(db); err != nil {
+		log.Println("warning: failed to backfill pricing versions:", err)
+	}
 	return db
 }
 
@@ -67,11 +97,122 @@ func seedVehicleTypes(db *gorm.DB) error {
 		return nil
 	}
 	seed := []entity.VehicleTypeConfig{
-		{Code: "bike", Name: "Bike", Active: true, BaseFare: 20, PerKm: 10, PerMinute: 0, AvgSpeedKmh: 16, MinimumFare: 35, BookingFee: 0},
-		{Code: "motorbike", Name: "Motorbike", Active: true, BaseFare: 25, PerKm: 12, PerMinute: 1.5, AvgSpeedKmh: 25, MinimumFare: 40, BookingFee: 0},
-		{Code: "car", Name: "Car", Active: true, BaseFare: 30, PerKm: 15, PerMinute: 2.0, AvgSpeedKmh: 25, MinimumFare: 50, BookingFee: 0},
+		{Code: "bike", Name: "Bike", Active: true, BaseFare: 20, PerKm: 10, PerMinute: 0, AvgSpeedKmh: 16, MinimumFare: 35, BookingFee: 0, SortOrder: 1},
+		{Code: "motorbike", Name: "Motorbike", Active: true, BaseFare: 25, PerKm: 12, PerMinute: 1.5, AvgSpeedKmh: 25, MinimumFare: 40, BookingFee: 0, SortOrder: 2},
+		{Code: "car", Name: "Car", Active: true, BaseFare: 30, PerKm: 15, PerMinute: 2.0, AvgSpeedKmh: 25, MinimumFare: 50, BookingFee: 0, SortOrder: 3},
 		// Combined public transport/taxi option
-		{Code: "transport", Name: "Transport (Taxi/Bus/Train)", Active: true, BaseFare: 35, PerKm: 18, PerMinute: 2.5, AvgSpeedKmh: 22, MinimumFare: 60, BookingFee: 0},
+		{Code: "transport", Name: "Transport (Taxi/Bus/Train)", Active: true, BaseFare: 35, PerKm: 18, PerMinute: 2.5, AvgSpeedKmh: 22, MinimumFare: 60, BookingFee: 0, SortOrder: 4},
 	}
 	return db.Create(&seed).Error
 }
+
+// backfillPricingVersions records the current fares of vehicle types that have no pricing
+// version yet (seeded rows and rows edited directly in the database before versioning).
+func backfillPricingVersions(db *gorm.DB) error {
+	var types []entity.VehicleTypeConfig
+	if err := db.Where("pricing_version_id IS NULL").Find(&types).Error; err != nil {
+		return err
+	}
+	for _, vt := range types {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var last int
+			if err := tx.Model(&entity.VehiclePricingVersion{}).Where("vehicle_type_id = ?", vt.ID).
+				Select("COALESCE(MAX(version), 0)").Scan(&last).Error; err != nil {
+				return err
+			}
+			v := entity.VehiclePricingVersion{
+				VehicleTypeID: vt.ID, Version: last + 1,
+				BaseFare: vt.BaseFare, PerKm: vt.PerKm, PerMinute: vt.PerMinute,
+				AvgSpeedKmh: vt.AvgSpeedKmh, MinimumFare: vt.MinimumFare, BookingFee: vt.BookingFee,
+			}
+			if err := tx.Create(&v).Error; err != nil {
+				return err
+			}
+			return tx.Model(&entity.VehicleTypeConfig{}).Where("id = ?", vt.ID).Update("pricing_version_id", v.ID).Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeDuplicatePhoneUsers moves the courier/customer/admin profiles of users sharing a
+// phone onto one user and soft-deletes the rest. The user with a Firebase UID (else the
+// oldest) is kept and takes over a merged user's Firebase UID when it has none. Users
+// that hold a profile type the kept user already has, or a second Firebase UID, are
+// left alone. Safe to rerun.
+func mergeDuplicatePhoneUsers(db *gorm.DB) error {
+	var phones []string
+	if err := db.Model(&entity.User{}).Group("phone").Having("COUNT(*) > 1").Pluck("phone", &phones).Error; err != nil {
+		return err
+	}
+	profiles := []interface{}{&entity.Courier{}, &entity.Customer{}, &entity.Admin{}}
+	for _, phone := range phones {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var users []entity.User
+			if err := tx.Where("phone = ?", phone).
+				Order("firebase_uid IS NULL, created_at").
+				Find(&users).Error; err != nil {
+				return err
+			}
+			keep := users[0]
+			held := map[int]bool{}
+			for i, model := range profiles {
+				var n int64
+				if err := tx.Model(model).Where("user_id = ?", keep.ID).Count(&n).Error; err != nil {
+					return err
+				}
+				held[i] = n > 0
+			}
+			for _, u := range users[1:] {
+				var moves []int
+				conflict := u.FirebaseUID != nil && keep.FirebaseUID != nil
+				for i, model := range profiles {
+					var n int64
+					if err := tx.Model(model).Where("user_id = ?", u.ID).Count(&n).Error; err != nil {
+						return err
+					}
+					if n > 0 {
+						conflict = conflict || held[i]
+						moves = append(moves, i)
+					}
+				}
+				if conflict {
+					log.Printf("merge users: phone %s: user %s conflicts with user %s; skipped", phone, u.ID, keep.ID)
+					continue
+				}
+				for _, i := range moves {
+					if err := tx.Model(profiles[i]).Where("user_id = ?", u.ID).Update("user_id", keep.ID).Error; err != nil {
+						return err
+					}
+					held[i] = true
+				}
+				// firebase_uid is unique: clear it on the merged user before handing it over.
+				if u.FirebaseUID != nil {
+					if err := tx.Model(&entity.User{}).Where("id = ?", u.ID).Update("firebase_uid", nil).Error; err != nil {
+						return err
+					}
+					if err := tx.Model(&entity.User{}).Where("id = ?", keep.ID).Update("firebase_uid", *u.FirebaseUID).Error; err != nil {
+						return err
+					}
+					keep.FirebaseUID = u.FirebaseUID
+				}
+				now := time.Now()
+				if err := tx.Model(&entity.AuthSession{}).
+					Where("user_id = ? AND revoked_at IS NULL", u.ID).
+					Updates(map[string]interface{}{"revoked_at": now, "revoked_reason": "account_merged"}).Error; err != nil {
+					return err
+				}
+				if err := tx.Delete(&entity.User{}, "id = ?", u.ID).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("phone %s: %w", phone, err)
+		}
+	}
+	return nil
+}
@@ -2,6 +2,8 @@ package dispatch
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -11,6 +13,15 @@ import (
 	"github.com/mikios34/delivery-backend/realtime"
 )
 
+var (
+	// ErrNotReassignable is returned when an order was picked up, delivered or canceled.
+	ErrNotReassignable = errors.New("order can no longer be (re)assigned")
+	// ErrCourierInactive is returned when manually assigning a deactivated courier.
+	ErrCourierInactive = errors.New("courier is not active")
+	// ErrCourierNotApproved is returned when manually assigning a courier that hasn't passed onboarding review.
+	ErrCourierNotApproved = errors.New("courier is not approved")
+)
+
 // Service defines dispatching operations.
 type Service interface {
 	// FindAndAssign picks an available courier and assigns the order (sets status to assigned).
@@ -25,6 +36,13 @@ type Service interface {
 	// It avoids offering to the declining courier and, if no alternative is available, marks
 	// the order as no_nearby_driver and notifies the customer.
 	ReassignAfterDecline(ctx context.Context, orderID uuid.UUID, declinedBy uuid.UUID) (*entity.Order, *entity.Courier, error)
+
+	// AssignTo hands the order to the chosen courier (admin override), taking it away from
+	// the current courier if any. Availability is not checked.
+	AssignTo(ctx context.Context, orderID, courierID uuid.UUID) (*entity.Order, *entity.Courier, error)
+	// Redispatch drops the current assignment and searches for another courier, like a
+	// timeout would; without one the order becomes no_nearby_driver.
+	Redispatch(ctx context.Context, orderID uuid.UUID) (*entity.Order, *entity.Courier, error)
 }
 
 type service struct {
@@ -45,7 +63,7 @@ func (s *service) FindAndAssign(ctx context.Context, orderID uuid.UUID) (*entity
 	}
 
 	// Do not assign couriers for canceled/delivered orders.
-	if ord.Status == entity.OrderCanceledByCustomer || ord.Status == entity.OrderCanceledByCourier || ord.Status == entity.OrderDelivered {
+	if ord.Status == entity.OrderCanceledByCustomer || ord.Status == entity.OrderCanceledByCourier || ord.Status == entity.OrderCanceledByAdmin || ord.Status == entity.OrderDelivered {
 		return ord, nil, nil
 	}
 
@@ -105,39 +123,43 @@ func (s *service) FindAndAssign(ctx context.Context, orderID uuid.UUID) (*entity
 		return nil, nil, err
 	}
 
-	if s.hub != nil {
-		// Notify courier with full order details
-		cap := realtime.OrderAssignedPayload{
-			OrderID:        updated.ID.String(),
-			CustomerID:     updated.CustomerID.String(),
-			PickupAddress:  updated.PickupAddress,
-			PickupLat:      updated.PickupLat,
-			PickupLng:      updated.PickupLng,
-			DropoffAddress: updated.DropoffAddress,
-			DropoffLat:     updated.DropoffLat,
-			DropoffLng:     updated.DropoffLng,
-			ReceiverPhone:  updated.ReceiverPhone,
-		}
-		_ = s.hub.Notify(chosen.ID.String(), "order.assigned", cap)
+	s.notifyAssigned(updated, chosen.ID)
+	return updated, &chosen, nil
+}
 
-		// Also notify the customer that the order is assigned with order details
-		pickupAddr := updated.PickupAddress
-		dropoffAddr := updated.DropoffAddress
-		receiverPhone := updated.ReceiverPhone
-		payload := realtime.OrderStatusPayload{
-			OrderID:        updated.ID.String(),
-			Status:         string(entity.OrderAssigned),
-			PickupAddress:  &pickupAddr,
-			PickupLat:      updated.PickupLat,
-			PickupLng:      updated.PickupLng,
-			DropoffAddress: &dropoffAddr,
-			DropoffLat:     updated.DropoffLat,
-			DropoffLng:     updated.DropoffLng,
-			ReceiverPhone:  &receiverPhone,
-		}
-		_ = s.hub.NotifyCustomer(updated.CustomerID.String(), "order.status", payload)
+// notifyAssigned sends the courier the full order and tells the customer it was (re)assigned.
+func (s *service) notifyAssigned(updated *entity.Order, courierID uuid.UUID) {
+	if s.hub == nil {
+		return
 	}
-	return updated, &chosen, nil
+	// Notify courier with full order details
+	cap := realtime.OrderAssignedPayload{
+		OrderID:        updated.ID.String(),
+		CustomerID:     updated.CustomerID.String(),
+		PickupAddress:  updated.PickupAddress,
+		PickupLat:      updated.PickupLat,
+		PickupLng:      updated.PickupLng,
+		DropoffAddress: updated.DropoffAddress,
+		DropoffLat:     updated.DropoffLat,
+		DropoffLng:     updated.DropoffLng,
+		ReceiverPhone:  updated.ReceiverPhone,
+	}
+	_ = s.hub.Notify(courierID.String(), "order.assigned", cap)
+
+	// Also notify the customer that the order is (re)assigned with order details
+	pickupAddr := updated.PickupAddress
+	dropoffAddr := updated.DropoffAddress
+	payload := realtime.OrderStatusPayload{
+		OrderID:        updated.ID.String(),
+		Status:         string(entity.OrderAssigned),
+		PickupAddress:  &pickupAddr,
+		PickupLat:      updated.PickupLat,
+		PickupLng:      updated.PickupLng,
+		DropoffAddress: &dropoffAddr,
+		DropoffLat:     updated.DropoffLat,
+		DropoffLng:     updated.DropoffLng,
+	}
+	_ = s.hub.NotifyCustomer(updated.CustomerID.String(), "order.status", payload)
 }
 
 func (s *service) Dispatch(ctx context.Context, orderID uuid.UUID) (*entity.Order, *entity.Courier, error) {
@@ -192,6 +214,89 @@ func (s *service) ReassignTimedOut(ctx context.Context, cutoff time.Time) (int,
 	return count, nil
 }
 
+// reassignable reports whether an admin may still move the order to another courier.
+func reassignable(status entity.OrderStatus) bool {
+	switch status {
+	case entity.OrderPickedUp, entity.OrderDelivered, entity.OrderCanceledByCustomer, entity.OrderCanceledByCourier, entity.OrderCanceledByAdmin:
+		return false
+	}
+	return true
+}
+
+func (s *service) AssignTo(ctx context.Context, orderID, courierID uuid.UUID) (*entity.Order, *entity.Courier, error) {
+	ord, err := s.orders.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !reassignable(ord.Status) {
+		return nil, nil, ErrNotReassignable
+	}
+	chosen, err := s.courier.GetCourierByID(ctx, courierID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("courier %s: %w", courierID, err)
+	}
+	if !chosen.Active {
+		return nil, nil, ErrCourierInactive
+	}
+	if chosen.OnboardingStatus != entity.CourierApproved {
+		return nil, nil, ErrCourierNotApproved
+	}
+	prev := ord.AssignedCourier
+	if err := s.orders.AssignCourier(ctx, ord.ID, chosen.ID); err != nil {
+		return nil, nil, err
+	}
+	if err := s.orders.UpdateOrderStatus(ctx, ord.ID, entity.OrderAssigned); err != nil {
+		return nil, nil, err
+	}
+	_ = s.orders.RecordAssignmentAttempt(ctx, ord.ID, chosen.ID)
+
+	updated, err := s.orders.GetOrderByID(ctx, ord.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if s.hub != nil && prev != nil && *prev != chosen.ID {
+		_ = s.hub.Notify(prev.String(), "order.reassigned_away", realtime.AssignmentPayload{OrderID: updated.ID.String(), CustomerID: updated.CustomerID.String()})
+	}
+	s.notifyAssigned(updated, chosen.ID)
+	return updated, chosen, nil
+}
+
+func (s *service) Redispatch(ctx context.Context, orderID uuid.UUID) (*entity.Order, *entity.Courier, error) {
+	ord, err := s.orders.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !reassignable(ord.Status) {
+		return nil, nil, ErrNotReassignable
+	}
+	prev := ord.AssignedCourier
+	if prev != nil {
+		if err := s.orders.ClearAssignment(ctx, ord.ID); err != nil {
+			return nil, nil, err
+		}
+	}
+	updated, chosen, err := s.findAndAssignExcluding(ctx, ord.ID, prev)
+	if err != nil {
+		return nil, nil, err
+	}
+	if chosen == nil {
+		if err := s.orders.MarkNoNearbyDriver(ctx, ord.ID); err != nil {
+			return nil, nil, err
+		}
+		if updated, err = s.orders.GetOrderByID(ctx, ord.ID); err != nil {
+			return nil, nil, err
+		}
+		if s.hub != nil {
+			payload := realtime.OrderStatusPayload{OrderID: updated.ID.String(), Status: string(entity.OrderNoNearbyDriver)}
+			_ = s.hub.NotifyCustomer(updated.CustomerID.String(), "order.status", payload)
+		}
+	}
+	if s.hub != nil && prev != nil {
+		_ = s.hub.Notify(prev.String(), "order.reassigned_away", realtime.AssignmentPayload{OrderID: updated.ID.String(), CustomerID: updated.CustomerID.String()})
+	}
+	return updated, chosen, nil
+}
+
 // ReassignAfterDecline attempts to reassign an order after a courier declines it.
 // If no available alternative courier is found, mark as no_nearby_driver and notify the customer.
 func (s *service) ReassignAfterDecline(ctx context.Context, orderID uuid.UUID, declinedBy uuid.UUID) (*entity.Order, *entity.Courier, error) {
@@ -277,37 +382,6 @@ func (s *service) findAndAssignExcluding(ctx context.Context, orderID uuid.UUID,
 		return nil, nil, err
 	}
 
-	if s.hub != nil {
-		// Notify courier with full order details
-		cap := realtime.OrderAssignedPayload{
-			OrderID:        updated.ID.String(),
-			CustomerID:     updated.CustomerID.String(),
-			PickupAddress:  updated.PickupAddress,
-			PickupLat:      updated.PickupLat,
-			PickupLng:      updated.PickupLng,
-			DropoffAddress: updated.DropoffAddress,
-			DropoffLat:     updated.DropoffLat,
-			DropoffLng:     updated.DropoffLng,
-			ReceiverPhone:  updated.ReceiverPhone,
-		}
-		_ = s.hub.Notify(chosen.ID.String(), "order.assigned", cap)
-
-		// Also notify the customer that the order is (re)assigned with order details
-		pickupAddr := updated.PickupAddress
-		dropoffAddr := updated.DropoffAddress
-		receiverPhone := updated.ReceiverPhone
-		payload := realtime.OrderStatusPayload{
-			OrderID:        updated.ID.String(),
-			Status:         string(entity.OrderAssigned),
-			PickupAddress:  &pickupAddr,
-			PickupLat:      updated.PickupLat,
-			PickupLng:      updated.PickupLng,
-			DropoffAddress: &dropoffAddr,
-			DropoffLat:     updated.DropoffLat,
-			DropoffLng:     updated.DropoffLng,
-			ReceiverPhone:  &receiverPhone,
-		}
-		_ = s.hub.NotifyCustomer(updated.CustomerID.String(), "order.status", payload)
-	}
+	s.notifyAssigned(updated, chosen.ID)
 	return updated, chosen, nil
 }
@@ -0,0 +1,20 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AccountDeletionRequest schedules a user's personal data for anonymization once the
+// cooling-off period ends. Canceling before ScheduledFor keeps the account.
+type AccountDeletionRequest struct {
+	ID           uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	UserID       uuid.UUID  `json:"user_id" gorm:"type:uuid;index;not null"`
+	Reason       string     `json:"reason,omitempty" gorm:"type:text"`
+	ScheduledFor time.Time  `json:"scheduled_for" gorm:"index;not null"`
+	CanceledAt   *time.Time `json:"canceled_at,omitempty"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty" gorm:"index"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
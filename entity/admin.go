@@ -7,10 +7,25 @@ import (
 	"gorm.io/gorm"
 )
 
+// AdminRole scopes what an admin may do (see admin.Permission).
+type AdminRole string
+
+const (
+	AdminRoleSuperAdmin AdminRole = "super_admin"
+	AdminRoleDispatcher AdminRole = "dispatcher"
+	AdminRoleFinance    AdminRole = "finance"
+	AdminRoleSupport    AdminRole = "support"
+	// AdminRoleNone grants no permissions; admins keep it until a super admin assigns a role.
+	AdminRoleNone AdminRole = "none"
+)
+
 // Admin represents an admin profile linked to a base User.
 type Admin struct {
-	ID        uuid.UUID      `json:"id" gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
-	UserID    uuid.UUID      `json:"user_id" gorm:"type:uuid;index;not null"`
+	ID     uuid.UUID `json:"id" gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	UserID uuid.UUID `json:"user_id" gorm:"type:uuid;index;not null"`
+	// Role defaults to none, which holds no permissions, for rows created before roles
+	// existed; a super admin assigns roles with the bootstrap-admin command or PATCH.
+	Role      AdminRole      `json:"role" gorm:"type:text;not null;default:'none';index"`
 	Active    bool           `json:"active" gorm:"default:true;index"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
@@ -0,0 +1,44 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AnnouncementAudience selects which role an announcement is shown to.
+type AnnouncementAudience string
+
+const (
+	AudienceCouriers  AnnouncementAudience = "courier"
+	AudienceCustomers AnnouncementAudience = "customer"
+	AudienceAll       AnnouncementAudience = "all"
+)
+
+// Announcement is an operations message broadcast to couriers and/or customers, e.g.
+// "heavy rain, surge active". ZoneID and VehicleType narrow a courier audience to
+// couriers currently inside the zone or driving that vehicle.
+type Announcement struct {
+	ID          uuid.UUID            `json:"id" gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	Title       string               `json:"title" gorm:"type:text;not null"`
+	Body        string               `json:"body" gorm:"type:text;not null"`
+	Audience    AnnouncementAudience `json:"audience" gorm:"type:text;index;not null"`
+	ZoneID      *uuid.UUID           `json:"zone_id,omitempty" gorm:"type:uuid;index"`
+	VehicleType VehicleType          `json:"vehicle_type,omitempty" gorm:"type:text;not null;default:''"`
+	// ExpiresAt hides the announcement from then on; nil keeps it until acknowledged.
+	ExpiresAt *time.Time     `json:"expires_at,omitempty" gorm:"index"`
+	CreatedBy uuid.UUID      `json:"created_by" gorm:"type:uuid;not null"` // admin id
+	CreatedAt time.Time      `json:"created_at" gorm:"index"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// AnnouncementAck records that a courier or customer dismissed an announcement.
+type AnnouncementAck struct {
+	AnnouncementID uuid.UUID `json:"announcement_id" gorm:"type:uuid;primaryKey"`
+	// RecipientRole is "courier" or "customer"; RecipientID is the corresponding profile id.
+	RecipientRole string    `json:"recipient_role" gorm:"type:text;primaryKey"`
+	RecipientID   uuid.UUID `json:"recipient_id" gorm:"type:uuid;primaryKey;index"`
+	AckedAt       time.Time `json:"acked_at"`
+}
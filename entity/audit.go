@@ -0,0 +1,32 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditLog records who did what to which entity, with before/after snapshots.
+type AuditLog struct {
+	ID uuid.UUID `json:"id" gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	// Actor: the authenticated user, their role and role profile id (courier/customer/admin id).
+	ActorUserID string `json:"actor_user_id,omitempty" gorm:"type:text;index"`
+	ActorRole   string `json:"actor_role" gorm:"type:text;index"`
+	ActorID     string `json:"actor_id,omitempty" gorm:"type:text;index"`
+	// ImpersonatorAdminID is set when the actor was impersonated by this admin.
+	ImpersonatorAdminID string `json:"impersonator_admin_id,omitempty" gorm:"type:text;index"`
+	// Action is a dotted verb such as "order.status_changed".
+	Action     string          `json:"action" gorm:"type:text;index;not null"`
+	TargetType string          `json:"target_type" gorm:"type:text;index:idx_audit_target;not null"`
+	TargetID   string          `json:"target_id" gorm:"type:text;index:idx_audit_target;not null"`
+	Before     json.RawMessage `json:"before,omitempty" gorm:"type:jsonb;serializer:json"`
+	After      json.RawMessage `json:"after,omitempty" gorm:"type:jsonb;serializer:json"`
+	// Request metadata
+	IP        string    `json:"ip,omitempty" gorm:"type:text"`
+	UserAgent string    `json:"user_agent,omitempty" gorm:"type:text"`
+	Method    string    `json:"method,omitempty" gorm:"type:text"`
+	Path      string    `json:"path,omitempty" gorm:"type:text"`
+	RequestID string    `json:"request_id,omitempty" gorm:"type:text"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
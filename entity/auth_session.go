@@ -0,0 +1,39 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuthSession is one issued refresh token. Every refresh rotates the token: the old row is
+// marked rotated and a new row is added to the same family. A family is what users see as a
+// "session" (one signed-in device).
+type AuthSession struct {
+	ID       uuid.UUID `json:"-" gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	FamilyID uuid.UUID `json:"id" gorm:"type:uuid;index;not null"`
+	UserID   uuid.UUID `json:"-" gorm:"type:uuid;index;not null"`
+	Role     string    `json:"role" gorm:"type:text;not null"`
+	// JTI is the refresh token's jwt id; TokenHash is the SHA-256 of the token itself.
+	JTI        string `json:"-" gorm:"type:text;uniqueIndex;not null"`
+	TokenHash  string `json:"-" gorm:"type:text;not null"`
+	DeviceName string `json:"device_name,omitempty" gorm:"type:text"`
+	UserAgent  string `json:"user_agent,omitempty" gorm:"type:text"`
+	IP         string `json:"ip,omitempty" gorm:"type:text"`
+	// SignedInAt is when the family was created (carried over on rotation).
+	SignedInAt    time.Time  `json:"signed_in_at"`
+	LastUsedAt    time.Time  `json:"last_used_at"`
+	ExpiresAt     time.Time  `json:"expires_at" gorm:"index"`
+	RotatedAt     *time.Time `json:"-"`
+	RevokedAt     *time.Time `json:"-" gorm:"index"`
+	RevokedReason string     `json:"-" gorm:"type:text"`
+	CreatedAt     time.Time  `json:"-"`
+	UpdatedAt     time.Time  `json:"-"`
+}
+
+// RevokedToken denylists an individual access token by jti until it would have expired anyway.
+type RevokedToken struct {
+	JTI       string    `json:"jti" gorm:"type:text;primaryKey"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"index;not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,22 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ChatMessage is a message in the order-scoped thread between the customer and the assigned courier.
+type ChatMessage struct {
+	ID      uuid.UUID `json:"id" gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	OrderID uuid.UUID `json:"order_id" gorm:"type:uuid;index;not null"`
+	// SenderRole is "customer" or "courier"; SenderID is the corresponding profile id.
+	SenderRole string         `json:"sender_role" gorm:"type:text;not null"`
+	SenderID   uuid.UUID      `json:"sender_id" gorm:"type:uuid;index;not null"`
+	Body       string         `json:"body" gorm:"type:text;not null"`
+	ReadAt     *time.Time     `json:"read_at,omitempty"`
+	CreatedAt  time.Time      `json:"created_at" gorm:"index"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `json:"-" gorm:"index"`
+}
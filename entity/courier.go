@@ -22,6 +22,16 @@ const (
 	VehicleOther   VehicleType = "other"
 )
 
+// CourierOnboardingStatus tracks a courier's verification.
+type CourierOnboardingStatus string
+
+const (
+	CourierPendingReview CourierOnboardingStatus = "pending_review" // registered, awaiting document review
+	CourierApproved      CourierOnboardingStatus = "approved"       // may go available and receive orders
+	CourierRejected      CourierOnboardingStatus = "rejected"       // documents refused; may re-upload
+	CourierSuspended     CourierOnboardingStatus = "suspended"      // approved before, now blocked
+)
+
 // User is a minimal auth profile used for couriers (keeps parity with existing entity.User if present).
 // If your repo already has an entity.User, you can remove/reuse it — this is intentionally minimal.
 type User struct {
@@ -48,6 +58,7 @@ type GuarantyOption struct {
 	AmountCents int64     `json:"amount_cents" gorm:"type:bigint;not null"`
 	// Currency    string         `json:"currency" gorm:"type:text;default:'USD'"`
 	Active    bool           `json:"active" gorm:"default:true;index"`
+	SortOrder int            `json:"sort_order" gorm:"not null;default:0"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
@@ -71,21 +82,27 @@ type GuarantyPayment struct {
 
 // Courier stores courier-specific data collected at registration and afterwards.
 type Courier struct {
-	ID                uuid.UUID      `json:"id" gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
-	UserID            uuid.UUID      `json:"user_id" gorm:"type:uuid;index;not null"`
-	HasVehicle        bool           `json:"has_vehicle" gorm:"default:false;index"`
-	PrimaryVehicle    VehicleType    `json:"primary_vehicle" gorm:"type:text;index"`
-	VehicleDetails    string         `json:"vehicle_details,omitempty" gorm:"type:text"`
-	GuarantyOptionID  *uuid.UUID     `json:"guaranty_option_id,omitempty" gorm:"type:uuid;index;default:null"`
-	GuarantyPaid      bool           `json:"guaranty_paid" gorm:"default:false;index"`
-	Active            bool           `json:"active" gorm:"default:true;index"`
-	Available         bool           `json:"available" gorm:"default:false;index"`
-	Latitude          *float64       `json:"latitude,omitempty" gorm:"type:double precision"`
-	Longitude         *float64       `json:"longitude,omitempty" gorm:"type:double precision"`
-	LocationUpdatedAt *time.Time     `json:"location_updated_at,omitempty"`
-	CreatedAt         time.Time      `json:"created_at"`
-	UpdatedAt         time.Time      `json:"updated_at"`
-	DeletedAt         gorm.DeletedAt `json:"-" gorm:"index"`
+	ID               uuid.UUID   `json:"id" gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	UserID           uuid.UUID   `json:"user_id" gorm:"type:uuid;index;not null"`
+	HasVehicle       bool        `json:"has_vehicle" gorm:"default:false;index"`
+	PrimaryVehicle   VehicleType `json:"primary_vehicle" gorm:"type:text;index"`
+	VehicleDetails   string      `json:"vehicle_details,omitempty" gorm:"type:text"`
+	GuarantyOptionID *uuid.UUID  `json:"guaranty_option_id,omitempty" gorm:"type:uuid;index;default:null"`
+	GuarantyPaid     bool        `json:"guaranty_paid" gorm:"default:false;index"`
+	Active           bool        `json:"active" gorm:"default:true;index"`
+	Available        bool        `json:"available" gorm:"default:false;index"`
+	// OnboardingStatus gates dispatch: only approved couriers are offered orders.
+	// Rows that predate onboarding default to approved.
+	OnboardingStatus  CourierOnboardingStatus `json:"onboarding_status" gorm:"type:text;index;not null;default:'approved'"`
+	ReviewNotes       string                  `json:"review_notes,omitempty" gorm:"type:text"`
+	ReviewedAt        *time.Time              `json:"reviewed_at,omitempty"`
+	ReviewedBy        *uuid.UUID              `json:"reviewed_by,omitempty" gorm:"type:uuid"` // admin id
+	Latitude          *float64                `json:"latitude,omitempty" gorm:"type:double precision"`
+	Longitude         *float64                `json:"longitude,omitempty" gorm:"type:double precision"`
+	LocationUpdatedAt *time.Time              `json:"location_updated_at,omitempty"`
+	CreatedAt         time.Time               `json:"created_at"`
+	UpdatedAt         time.Time               `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt          `json:"-" gorm:"index"`
 	// Relations
 	// GuarantyPayments []GuarantyPayment `json:"guaranty_payments,omitempty" gorm:"foreignKey:CourierID;constraint:OnDelete:CASCADE"`
 }
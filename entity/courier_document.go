@@ -0,0 +1,32 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CourierDocumentKind names the documents a courier uploads for verification.
+type CourierDocumentKind string
+
+const (
+	DocumentID                  CourierDocumentKind = "id_document"
+	DocumentDriverLicense       CourierDocumentKind = "driver_license"
+	DocumentVehicleRegistration CourierDocumentKind = "vehicle_registration"
+)
+
+// CourierDocument is an uploaded verification file; the bytes live in the blob store
+// under BlobKey. Uploading a kind again replaces (soft-deletes) the previous one.
+type CourierDocument struct {
+	ID          uuid.UUID           `json:"id" gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	CourierID   uuid.UUID           `json:"courier_id" gorm:"type:uuid;index;not null"`
+	Kind        CourierDocumentKind `json:"kind" gorm:"type:text;index;not null"`
+	BlobKey     string              `json:"-" gorm:"type:text;not null"`
+	FileName    string              `json:"file_name" gorm:"type:text"`
+	ContentType string              `json:"content_type" gorm:"type:text;not null"`
+	SizeBytes   int64               `json:"size_bytes" gorm:"not null"`
+	CreatedAt   time.Time           `json:"created_at"`
+	UpdatedAt   time.Time           `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt      `json:"-" gorm:"index"`
+}
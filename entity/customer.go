@@ -17,4 +17,28 @@ type Customer struct {
 	CreatedAt      time.Time      `json:"created_at"`
 	UpdatedAt      time.Time      `json:"updated_at"`
 	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Set while an admin has suspended the customer; Active is false meanwhile.
+	SuspendedAt      *time.Time `json:"suspended_at,omitempty"`
+	SuspensionReason string     `json:"suspension_reason,omitempty" gorm:"type:text"`
+	SuspendedBy      *uuid.UUID `json:"suspended_by,omitempty" gorm:"type:uuid"` // admin id
+}
+
+// CustomerNoteKind tells support notes from the suspension history.
+type CustomerNoteKind string
+
+const (
+	CustomerNoteText        CustomerNoteKind = "note"
+	CustomerNoteSuspended   CustomerNoteKind = "suspended"   // body is the reason
+	CustomerNoteReactivated CustomerNoteKind = "reactivated" // body is the reason
+)
+
+// CustomerNote is an internal note about a customer, visible to admins only.
+type CustomerNote struct {
+	ID         uuid.UUID        `json:"id" gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	CustomerID uuid.UUID        `json:"customer_id" gorm:"type:uuid;index;not null"`
+	AdminID    uuid.UUID        `json:"admin_id" gorm:"type:uuid;not null"`
+	Kind       CustomerNoteKind `json:"kind" gorm:"type:text;not null;default:'note'"`
+	Body       string           `json:"body" gorm:"type:text;not null"`
+	CreatedAt  time.Time        `json:"created_at"`
 }
@@ -12,6 +12,7 @@ type OrderType struct {
 	ID        uuid.UUID      `json:"id" gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
 	Name      string         `json:"name" gorm:"type:text;uniqueIndex;not null"`
 	Active    bool           `json:"active" gorm:"default:true;index"`
+	SortOrder int            `json:"sort_order" gorm:"not null;default:0"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
@@ -33,6 +34,7 @@ const (
 	// Cancellation states
 	OrderCanceledByCustomer OrderStatus = "canceled_by_customer" // customer canceled before completion
 	OrderCanceledByCourier  OrderStatus = "canceled_by_courier"  // courier canceled (e.g. after accept but before completion)
+	OrderCanceledByAdmin    OrderStatus = "canceled_by_admin"    // canceled by an operator (see CancelReason)
 )
 
 // Order captures a delivery request by a customer.
@@ -51,11 +53,25 @@ type Order struct {
 	DropoffLat     *float64  `json:"dropoff_lat,omitempty" gorm:"type:double precision"`
 	DropoffLng     *float64  `json:"dropoff_lng,omitempty" gorm:"type:double precision"`
 	// EstimatedPriceCents stores the pre-quote price used at creation (minor units)
-	EstimatedPriceCents int64          `json:"estimated_price_cents" gorm:"type:bigint;not null;default:0"`
-	Status              OrderStatus    `json:"status" gorm:"type:text;index;not null;default:'pending'"`
-	CreatedAt           time.Time      `json:"created_at"`
-	UpdatedAt           time.Time      `json:"updated_at"`
-	DeletedAt           gorm.DeletedAt `json:"-" gorm:"index"`
+	EstimatedPriceCents int64 `json:"estimated_price_cents" gorm:"type:bigint;not null;default:0"`
+	// ServerPriced is set when EstimatedPriceCents is the server's own quote; older orders
+	// stored the price the client sent.
+	ServerPriced bool        `json:"server_priced" gorm:"not null;default:false"`
+	Status       OrderStatus `json:"status" gorm:"type:text;index;not null;default:'pending'"`
+	// Stage timestamps for reporting; nil on orders that never reached the stage or predate them.
+	AcceptedAt  *time.Time `json:"accepted_at,omitempty"`
+	PickedUpAt  *time.Time `json:"picked_up_at,omitempty"`
+	DeliveredAt *time.Time `json:"delivered_at,omitempty" gorm:"index"`
+	CanceledAt  *time.Time `json:"canceled_at,omitempty"`
+	// NoNearbyDriverAt is the first time dispatch found no courier, even if one was found later.
+	NoNearbyDriverAt *time.Time `json:"no_nearby_driver_at,omitempty"`
+	// PricingVersionID is the vehicle pricing the order was quoted with. Nil for legacy rows.
+	PricingVersionID *uuid.UUID `json:"pricing_version_id,omitempty" gorm:"type:uuid;index;default:null"`
+	// CancelReason is set when an admin cancels the order.
+	CancelReason string         `json:"cancel_reason,omitempty" gorm:"type:text"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
 // OrderAssignmentAttempt records a courier that has been tried for an order.
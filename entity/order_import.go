@@ -0,0 +1,74 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OrderImportStatus is the stage a bulk order import is in.
+type OrderImportStatus string
+
+const (
+	ImportPending     OrderImportStatus = "pending"
+	ImportValidating  OrderImportStatus = "validating" // checking and pricing rows
+	ImportCreating    OrderImportStatus = "creating"
+	ImportDispatching OrderImportStatus = "dispatching"
+	ImportCompleted   OrderImportStatus = "completed"
+	ImportFailed      OrderImportStatus = "failed" // nothing was created (atomic mode) or creation failed
+)
+
+// ImportRowStatus is the outcome of one row of an import.
+type ImportRowStatus string
+
+const (
+	ImportRowPending ImportRowStatus = "pending"
+	ImportRowValid   ImportRowStatus = "valid"   // priced, waiting to be created
+	ImportRowInvalid ImportRowStatus = "invalid" // see Errors
+	ImportRowSkipped ImportRowStatus = "skipped" // valid, but another row failed an atomic import
+	ImportRowCreated ImportRowStatus = "created"
+)
+
+// OrderImport is a batch of orders a customer submitted at once, processed in the
+// background. The counters report progress while it runs.
+type OrderImport struct {
+	ID         uuid.UUID         `json:"id" gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	CustomerID uuid.UUID         `json:"customer_id" gorm:"type:uuid;index;not null"`
+	Format     string            `json:"format" gorm:"type:text;not null"` // "csv" or "json"
+	Atomic     bool              `json:"atomic" gorm:"not null;default:false"`
+	Status     OrderImportStatus `json:"status" gorm:"type:text;index;not null;default:'pending'"`
+	TotalRows  int               `json:"total_rows" gorm:"not null"`
+	Validated  int               `json:"validated" gorm:"not null;default:0"` // rows checked so far, valid or not
+	Invalid    int               `json:"invalid" gorm:"not null;default:0"`
+	Created    int               `json:"created" gorm:"not null;default:0"`
+	Dispatched int               `json:"dispatched" gorm:"not null;default:0"` // created orders dispatch was attempted for
+	Assigned   int               `json:"assigned" gorm:"not null;default:0"`   // of those, orders a courier was assigned to
+	// Error explains a failed import.
+	Error       string     `json:"error,omitempty" gorm:"type:text"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"index"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	// Instance identifies the API process running the import; HeartbeatAt is refreshed
+	// while it runs, so other instances can tell a live import from an abandoned one. An
+	// instance never fails its own imports as interrupted.
+	Instance    string     `json:"-" gorm:"type:text"`
+	HeartbeatAt *time.Time `json:"-" gorm:"index"`
+}
+
+// OrderImportRow is one submitted order and its result. Row numbers start at 1 and
+// follow the submitted order (for CSV, the header is not counted).
+type OrderImportRow struct {
+	ImportID  uuid.UUID       `json:"import_id" gorm:"type:uuid;primaryKey"`
+	RowNumber int             `json:"row" gorm:"primaryKey;autoIncrement:false"`
+	Status    ImportRowStatus `json:"status" gorm:"type:text;not null"`
+	Errors    []string        `json:"errors,omitempty" gorm:"type:jsonb;serializer:json"`
+	// Reference is the merchant's own identifier for the row, if they sent one.
+	Reference  string     `json:"reference,omitempty" gorm:"type:text"`
+	PriceCents int64      `json:"price_cents,omitempty"`
+	OrderID    *uuid.UUID `json:"order_id,omitempty" gorm:"type:uuid"`
+	// OrderStatus is the order's status right after dispatch (assigned or no_nearby_driver).
+	OrderStatus   OrderStatus     `json:"order_status,omitempty" gorm:"type:text"`
+	DispatchError string          `json:"dispatch_error,omitempty" gorm:"type:text"`
+	Input         json.RawMessage `json:"input" gorm:"type:jsonb;serializer:json"`
+}
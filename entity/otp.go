@@ -0,0 +1,19 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OTPCode is a one-time code sent by SMS to prove control of a phone number.
+// Only a hash of the code is stored; a newer code for the same phone supersedes older ones.
+type OTPCode struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	Phone      string     `json:"phone" gorm:"type:text;index;not null"`
+	CodeHash   string     `json:"-" gorm:"type:text;not null"`
+	Attempts   int        `json:"attempts" gorm:"not null;default:0"`
+	ExpiresAt  time.Time  `json:"expires_at" gorm:"not null"`
+	ConsumedAt *time.Time `json:"consumed_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"index"`
+}
@@ -0,0 +1,10 @@
+package entity
+
+import "time"
+
+// RateLimitBucket is a token bucket shared by all replicas (see ratelimit.Store).
+type RateLimitBucket struct {
+	Key       string    `json:"key" gorm:"type:text;primaryKey"`
+	Tokens    float64   `json:"tokens" gorm:"not null"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"index;not null;autoUpdateTime:false"`
+}
@@ -0,0 +1,97 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TicketCategory classifies what went wrong with the order.
+type TicketCategory string
+
+const (
+	TicketDamaged      TicketCategory = "damaged"
+	TicketLate         TicketCategory = "late"
+	TicketWrongAddress TicketCategory = "wrong_address"
+	TicketPayment      TicketCategory = "payment"
+	TicketOther        TicketCategory = "other"
+)
+
+// TicketStatus tracks whose turn it is on a ticket.
+type TicketStatus string
+
+const (
+	TicketOpen     TicketStatus = "open"     // waiting for support
+	TicketPending  TicketStatus = "pending"  // support replied, waiting for the opener
+	TicketResolved TicketStatus = "resolved" // closed by support; no further messages
+)
+
+// SupportTicket is a complaint or dispute a customer or courier opened about an order.
+type SupportTicket struct {
+	ID      uuid.UUID `json:"id" gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	OrderID uuid.UUID `json:"order_id" gorm:"type:uuid;index;not null"`
+	// OpenerRole is "customer" or "courier"; OpenerID is the corresponding profile id.
+	OpenerRole      string         `json:"opener_role" gorm:"type:text;not null"`
+	OpenerID        uuid.UUID      `json:"opener_id" gorm:"type:uuid;index;not null"`
+	Category        TicketCategory `json:"category" gorm:"type:text;index;not null"`
+	Subject         string         `json:"subject" gorm:"type:text;not null"`
+	Status          TicketStatus   `json:"status" gorm:"type:text;index;not null;default:'open'"`
+	AssignedAdminID *uuid.UUID     `json:"assigned_admin_id,omitempty" gorm:"type:uuid;index"`
+	Resolution      string         `json:"resolution,omitempty" gorm:"type:text"`
+	ResolvedAt      *time.Time     `json:"resolved_at,omitempty"`
+	ResolvedBy      *uuid.UUID     `json:"resolved_by,omitempty" gorm:"type:uuid"` // admin id
+	CreatedAt       time.Time      `json:"created_at" gorm:"index"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// TicketMessage is one entry in a ticket's conversation. Images are attached to a
+// message; their caption is the message body, which may then be empty.
+type TicketMessage struct {
+	ID       uuid.UUID `json:"id" gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	TicketID uuid.UUID `json:"ticket_id" gorm:"type:uuid;index;not null"`
+	// AuthorRole is "customer", "courier" or "admin"; AuthorID is the corresponding profile id.
+	AuthorRole  string             `json:"author_role" gorm:"type:text;not null"`
+	AuthorID    uuid.UUID          `json:"author_id" gorm:"type:uuid;index;not null"`
+	Body        string             `json:"body" gorm:"type:text;not null"`
+	Attachments []TicketAttachment `json:"attachments" gorm:"foreignKey:MessageID"`
+	CreatedAt   time.Time          `json:"created_at" gorm:"index"`
+}
+
+// TicketAttachment is an image sent with a ticket message; the bytes live in the blob
+// store under BlobKey.
+type TicketAttachment struct {
+	ID          uuid.UUID `json:"id" gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	TicketID    uuid.UUID `json:"ticket_id" gorm:"type:uuid;index;not null"`
+	MessageID   uuid.UUID `json:"message_id" gorm:"type:uuid;index;not null"`
+	BlobKey     string    `json:"-" gorm:"type:text;not null"`
+	FileName    string    `json:"file_name" gorm:"type:text"`
+	ContentType string    `json:"content_type" gorm:"type:text;not null"`
+	SizeBytes   int64     `json:"size_bytes" gorm:"not null"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// AdjustmentKind distinguishes money returned to the customer from other corrections.
+type AdjustmentKind string
+
+const (
+	// AdjustmentRefund returns part or all of the order price to the customer.
+	AdjustmentRefund AdjustmentKind = "refund"
+	// AdjustmentCorrection is any other signed correction for finance to settle, e.g. a
+	// courier payout fix.
+	AdjustmentCorrection AdjustmentKind = "adjustment"
+)
+
+// TicketAdjustment records a refund or adjustment an admin issued on a ticket. It is a
+// ledger entry; settling it with the payment provider happens outside this service.
+type TicketAdjustment struct {
+	ID          uuid.UUID      `json:"id" gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	TicketID    uuid.UUID      `json:"ticket_id" gorm:"type:uuid;index;not null"`
+	OrderID     uuid.UUID      `json:"order_id" gorm:"type:uuid;index;not null"`
+	Kind        AdjustmentKind `json:"kind" gorm:"type:text;not null"`
+	AmountCents int64          `json:"amount_cents" gorm:"type:bigint;not null"`
+	Reason      string         `json:"reason" gorm:"type:text;not null"`
+	IssuedBy    uuid.UUID      `json:"issued_by" gorm:"type:uuid;not null"` // admin id
+	CreatedAt   time.Time      `json:"created_at"`
+}
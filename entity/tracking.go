@@ -0,0 +1,20 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OrderTrackingLink is a shareable, expiring capability to view an order's public tracking page.
+// Only the SHA-256 hash of the token is stored; the token itself is shown once at creation.
+type OrderTrackingLink struct {
+	ID        uuid.UUID      `json:"id" gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	OrderID   uuid.UUID      `json:"order_id" gorm:"type:uuid;index;not null"`
+	TokenHash string         `json:"-" gorm:"type:text;uniqueIndex;not null"`
+	ExpiresAt time.Time      `json:"expires_at" gorm:"index;not null"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
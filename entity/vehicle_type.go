@@ -23,6 +23,27 @@ type VehicleTypeConfig struct {
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// SortOrder positions the type in customer-facing lists (ascending).
+	SortOrder int `json:"sort_order" gorm:"not null;default:0"`
+	// PricingVersionID points at the VehiclePricingVersion matching the fare fields above.
+	PricingVersionID *uuid.UUID `json:"pricing_version_id,omitempty" gorm:"type:uuid;default:null"`
 }
 
 func (VehicleTypeConfig) TableName() string { return "vehicle_types" }
+
+// VehiclePricingVersion is an immutable snapshot of a vehicle type's fares. Every pricing
+// change adds a version; orders reference the version they were quoted with.
+type VehiclePricingVersion struct {
+	ID            uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	VehicleTypeID uuid.UUID  `json:"vehicle_type_id" gorm:"type:uuid;not null;uniqueIndex:idx_vehicle_pricing_version"`
+	Version       int        `json:"version" gorm:"not null;uniqueIndex:idx_vehicle_pricing_version"`
+	BaseFare      float64    `json:"base_fare" gorm:"type:double precision;not null"`
+	PerKm         float64    `json:"per_km" gorm:"type:double precision;not null"`
+	PerMinute     float64    `json:"per_minute" gorm:"type:double precision;not null"`
+	AvgSpeedKmh   float64    `json:"avg_speed_kmh" gorm:"type:double precision;not null"`
+	MinimumFare   float64    `json:"minimum_fare" gorm:"type:double precision;not null"`
+	BookingFee    float64    `json:"booking_fee" gorm:"type:double precision;not null"`
+	CreatedBy     *uuid.UUID `json:"created_by,omitempty" gorm:"type:uuid"` // admin id; nil for seeded rows
+	CreatedAt     time.Time  `json:"created_at"`
+}
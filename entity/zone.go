@@ -0,0 +1,23 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Zone is a named rectangle of the service area used to filter reports. An order belongs
+// to a zone when its pickup point lies inside the bounds (edges included).
+type Zone struct {
+	ID        uuid.UUID      `json:"id" gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	Name      string         `json:"name" gorm:"type:text;not null"`
+	MinLat    float64        `json:"min_lat" gorm:"type:double precision;not null"`
+	MinLng    float64        `json:"min_lng" gorm:"type:double precision;not null"`
+	MaxLat    float64        `json:"max_lat" gorm:"type:double precision;not null"`
+	MaxLng    float64        `json:"max_lng" gorm:"type:double precision;not null"`
+	Active    bool           `json:"active" gorm:"default:true;index"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
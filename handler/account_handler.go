@@ -0,0 +1,144 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/account"
+	"github.com/mikios34/delivery-backend/audit"
+)
+
+// AccountHandler lets customers and couriers export or delete their personal data.
+type AccountHandler struct {
+	svc   account.Service
+	audit audit.Service
+}
+
+// NewAccountHandler constructs an AccountHandler.
+func NewAccountHandler(svc account.Service) *AccountHandler {
+	return &AccountHandler{svc: svc}
+}
+
+// WithAudit records deletion requests and cancellations in the audit trail.
+func (h *AccountHandler) WithAudit(svc audit.Service) *AccountHandler {
+	h.audit = svc
+	return h
+}
+
+// Export downloads everything stored about the caller as a JSON file.
+// GET /api/v1/account/export
+func (h *AccountHandler) Export() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(c.GetString("user_id"))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token subject"})
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+		defer cancel()
+		export, err := h.svc.Export(ctx, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to export account", "detail": err.Error()})
+			return
+		}
+		filename := fmt.Sprintf("account-%s-%s.json", userID, export.ExportedAt.Format("20060102"))
+		c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+		c.IndentedJSON(http.StatusOK, export)
+	}
+}
+
+type deletionPayload struct {
+	Reason string `json:"reason"`
+}
+
+// RequestDeletion schedules the caller's account for anonymization after the cooling-off period.
+// POST /api/v1/account/deletion
+func (h *AccountHandler) RequestDeletion() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(c.GetString("user_id"))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token subject"})
+			return
+		}
+		var p deletionPayload
+		// The body is optional.
+		if c.Request.ContentLength != 0 {
+			if err := c.ShouldBindJSON(&p); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload", "detail": err.Error()})
+				return
+			}
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		req, err := h.svc.RequestDeletion(ctx, userID, p.Reason)
+		if err != nil {
+			writeAccountError(c, "failed to request account deletion", err)
+			return
+		}
+		recordAudit(auditContext(c, ctx), h.audit, audit.Entry{
+			Action: audit.ActionAccountDeletionRequested, TargetType: audit.TargetUser, TargetID: userID.String(),
+			After: req,
+		})
+		c.JSON(http.StatusAccepted, req)
+	}
+}
+
+// GetDeletion returns the caller's pending deletion request.
+// GET /api/v1/account/deletion
+func (h *AccountHandler) GetDeletion() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(c.GetString("user_id"))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token subject"})
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		req, err := h.svc.GetDeletion(ctx, userID)
+		if err != nil {
+			writeAccountError(c, "failed to load account deletion", err)
+			return
+		}
+		c.JSON(http.StatusOK, req)
+	}
+}
+
+// CancelDeletion withdraws the caller's pending deletion request.
+// DELETE /api/v1/account/deletion
+func (h *AccountHandler) CancelDeletion() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(c.GetString("user_id"))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token subject"})
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		if err := h.svc.CancelDeletion(ctx, userID); err != nil {
+			writeAccountError(c, "failed to cancel account deletion", err)
+			return
+		}
+		recordAudit(auditContext(c, ctx), h.audit, audit.Entry{
+			Action: audit.ActionAccountDeletionCanceled, TargetType: audit.TargetUser, TargetID: userID.String(),
+		})
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func writeAccountError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, account.ErrNoPendingDeletion):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, account.ErrDeletionPending), errors.Is(err, account.ErrActiveOrders):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, account.ErrAdminAccount):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg, "detail": err.Error()})
+	}
+}
@@ -0,0 +1,264 @@
+package api
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/analytics"
+	"github.com/mikios34/delivery-backend/audit"
+	"gorm.io/gorm"
+)
+
+// AdminAnalyticsHandler serves operational reports and the zones they can be filtered by.
+type AdminAnalyticsHandler struct {
+	analytics analytics.Service
+	audit     audit.Service
+}
+
+// NewAdminAnalyticsHandler constructs an AdminAnalyticsHandler.
+func NewAdminAnalyticsHandler(svc analytics.Service) *AdminAnalyticsHandler {
+	return &AdminAnalyticsHandler{analytics: svc}
+}
+
+// WithAudit records zone changes in the audit log.
+func (h *AdminAnalyticsHandler) WithAudit(svc audit.Service) *AdminAnalyticsHandler {
+	h.audit = svc
+	return h
+}
+
+// reportDate is the date format of the from and to query parameters.
+const reportDate = "2006-01-02"
+
+// KPIs returns order, timing, revenue and courier aggregates per day or week plus totals.
+// GET /api/v1/admin/analytics/kpis?from=&to=&granularity=day|week&zone_id=&format=json|csv
+func (h *AdminAnalyticsHandler) KPIs() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		f, ok := reportFilter(c)
+		if !ok {
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+		defer cancel()
+		rep, err := h.analytics.KPIs(ctx, f)
+		if err != nil {
+			writeAnalyticsError(c, "failed to compute kpis", err)
+			return
+		}
+		if c.Query("format") != "csv" {
+			c.JSON(http.StatusOK, rep)
+			return
+		}
+		rows := [][]string{{
+			"period_start", "orders_created", "accepted", "delivered",
+			"canceled_by_customer", "canceled_by_courier", "canceled_by_admin", "no_driver", "no_driver_rate",
+			"median_to_assign_s", "median_to_accept_s", "median_to_pickup_s", "median_to_deliver_s",
+			"revenue_cents", "couriers_offered", "offers", "acceptance_rate",
+			"couriers_delivering", "deliveries_per_courier", "busy_hours",
+		}}
+		for _, k := range rep.Periods {
+			rows = append(rows, kpiRow(k.PeriodStart.Format(reportDate), k))
+		}
+		rows = append(rows, kpiRow("total", rep.Totals))
+		writeCSV(c, "kpis", f, rows)
+	}
+}
+
+// Revenue returns delivered revenue per vehicle type and per order type, per day or week.
+// GET /api/v1/admin/analytics/revenue?from=&to=&granularity=day|week&zone_id=&format=json|csv
+func (h *AdminAnalyticsHandler) Revenue() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		f, ok := reportFilter(c)
+		if !ok {
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+		defer cancel()
+		rep, err := h.analytics.Revenue(ctx, f)
+		if err != nil {
+			writeAnalyticsError(c, "failed to compute revenue", err)
+			return
+		}
+		if c.Query("format") != "csv" {
+			c.JSON(http.StatusOK, rep)
+			return
+		}
+		rows := [][]string{{"dimension", "period_start", "id", "name", "orders", "revenue_cents"}}
+		for _, set := range []struct {
+			dimension string
+			lines     []analytics.RevenueLine
+		}{{"vehicle_type", rep.ByVehicleType}, {"order_type", rep.ByOrderType}} {
+			for _, l := range set.lines {
+				rows = append(rows, []string{
+					set.dimension, l.PeriodStart.Format(reportDate), l.ID.String(), l.Name,
+					strconv.FormatInt(l.Orders, 10), strconv.FormatInt(l.RevenueCents, 10),
+				})
+			}
+		}
+		writeCSV(c, "revenue", f, rows)
+	}
+}
+
+type zonePayload struct {
+	Name   *string  `json:"name"`
+	MinLat *float64 `json:"min_lat"`
+	MinLng *float64 `json:"min_lng"`
+	MaxLat *float64 `json:"max_lat"`
+	MaxLng *float64 `json:"max_lng"`
+	Active *bool    `json:"active"`
+}
+
+// ListZones returns all zones, inactive ones included, by name.
+// GET /api/v1/admin/zones
+func (h *AdminAnalyticsHandler) ListZones() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		list, err := h.analytics.ListZones(ctx)
+		if err != nil {
+			writeAnalyticsError(c, "failed to list zones", err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"zones": list})
+	}
+}
+
+// CreateZone adds an active zone.
+// POST /api/v1/admin/zones { name, min_lat, min_lng, max_lat, max_lng }
+func (h *AdminAnalyticsHandler) CreateZone() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var p zonePayload
+		if err := c.ShouldBindJSON(&p); err != nil || p.Name == nil || p.MinLat == nil || p.MinLng == nil || p.MaxLat == nil || p.MaxLng == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "name, min_lat, min_lng, max_lat and max_lng are required"})
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		z, err := h.analytics.CreateZone(ctx, analytics.ZoneRequest{Name: *p.Name, MinLat: *p.MinLat, MinLng: *p.MinLng, MaxLat: *p.MaxLat, MaxLng: *p.MaxLng})
+		if err != nil {
+			writeAnalyticsError(c, "failed to create zone", err)
+			return
+		}
+		recordAudit(auditContext(c, ctx), h.audit, audit.Entry{Action: audit.ActionZoneCreated, TargetType: audit.TargetZone, TargetID: z.ID.String(), After: z})
+		c.JSON(http.StatusCreated, z)
+	}
+}
+
+// UpdateZone renames, moves or (de)activates a zone.
+// PATCH /api/v1/admin/zones/:id { name?, min_lat?, min_lng?, max_lat?, max_lng?, active? }
+func (h *AdminAnalyticsHandler) UpdateZone() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid zone id"})
+			return
+		}
+		var p zonePayload
+		if err := c.ShouldBindJSON(&p); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload", "detail": err.Error()})
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		before, err := h.analytics.GetZone(ctx, id)
+		if err != nil {
+			writeAnalyticsError(c, "failed to update zone", err)
+			return
+		}
+		updated, err := h.analytics.UpdateZone(ctx, id, analytics.UpdateZoneRequest{
+			Name: p.Name, MinLat: p.MinLat, MinLng: p.MinLng, MaxLat: p.MaxLat, MaxLng: p.MaxLng, Active: p.Active,
+		})
+		if err != nil {
+			writeAnalyticsError(c, "failed to update zone", err)
+			return
+		}
+		recordAudit(auditContext(c, ctx), h.audit, audit.Entry{Action: audit.ActionZoneUpdated, TargetType: audit.TargetZone, TargetID: id.String(), Before: before, After: updated})
+		c.JSON(http.StatusOK, updated)
+	}
+}
+
+// reportFilter reads from and to (inclusive UTC dates, default the last 30 days),
+// granularity (default day) and zone_id.
+func reportFilter(c *gin.Context) (analytics.Filter, bool) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	f := analytics.Filter{Granularity: analytics.Granularity(c.DefaultQuery("granularity", string(analytics.Daily)))}
+	to := today
+	if raw := c.Query("to"); raw != "" {
+		t, err := time.Parse(reportDate, raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be a date (YYYY-MM-DD)"})
+			return f, false
+		}
+		to = t
+	}
+	from := to.AddDate(0, 0, -29)
+	if raw := c.Query("from"); raw != "" {
+		t, err := time.Parse(reportDate, raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be a date (YYYY-MM-DD)"})
+			return f, false
+		}
+		from = t
+	}
+	f.From, f.To = from, to.AddDate(0, 0, 1)
+	if raw := c.Query("zone_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid zone_id"})
+			return f, false
+		}
+		f.ZoneID = &id
+	}
+	return f, true
+}
+
+func kpiRow(period string, k analytics.KPIs) []string {
+	return []string{
+		period, itoa(k.OrdersCreated), itoa(k.Accepted), itoa(k.Delivered),
+		itoa(k.Canceled.ByCustomer), itoa(k.Canceled.ByCourier), itoa(k.Canceled.ByAdmin), itoa(k.NoDriver), ftoa(k.NoDriverRate),
+		optFtoa(k.MedianSeconds.ToAssign), optFtoa(k.MedianSeconds.ToAccept), optFtoa(k.MedianSeconds.ToPickup), optFtoa(k.MedianSeconds.ToDeliver),
+		itoa(k.RevenueCents), itoa(k.Couriers.CouriersOffered), itoa(k.Couriers.Offers), ftoa(k.Couriers.AcceptanceRate),
+		itoa(k.Couriers.CouriersDelivering), ftoa(k.Couriers.DeliveriesPerCourier), ftoa(k.Couriers.BusyHours),
+	}
+}
+
+func itoa(n int64) string   { return strconv.FormatInt(n, 10) }
+func ftoa(f float64) string { return strconv.FormatFloat(f, 'f', 4, 64) }
+
+// optFtoa leaves the cell empty when no order reached the stage.
+func optFtoa(f *float64) string {
+	if f == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*f, 'f', 1, 64)
+}
+
+// writeCSV sends rows as an attachment named after the report and its date range.
+func writeCSV(c *gin.Context, report string, f analytics.Filter, rows [][]string) {
+	name := fmt.Sprintf("%s_%s_%s_%s.csv", report, f.Granularity, f.From.Format(reportDate), f.To.AddDate(0, 0, -1).Format(reportDate))
+	c.Header("Content-Disposition", `attachment; filename="`+name+`"`)
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Status(http.StatusOK)
+	w := csv.NewWriter(c.Writer)
+	_ = w.WriteAll(rows)
+}
+
+func writeAnalyticsError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+	case errors.Is(err, analytics.ErrDuplicateZone):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, analytics.ErrInvalidRange), errors.Is(err, analytics.ErrInvalidGranularity),
+		errors.Is(err, analytics.ErrNameRequired), errors.Is(err, analytics.ErrInvalidBounds):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg, "detail": err.Error()})
+	}
+}
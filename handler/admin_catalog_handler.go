@@ -0,0 +1,470 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/audit"
+	"github.com/mikios34/delivery-backend/catalog"
+	"gorm.io/gorm"
+)
+
+// AdminCatalogHandler edits order types, vehicle types (with pricing) and guaranty options.
+// Entries are deactivated rather than deleted because orders and couriers reference them.
+type AdminCatalogHandler struct {
+	catalog catalog.Service
+	audit   audit.Service
+}
+
+// NewAdminCatalogHandler constructs an AdminCatalogHandler.
+func NewAdminCatalogHandler(svc catalog.Service) *AdminCatalogHandler {
+	return &AdminCatalogHandler{catalog: svc}
+}
+
+// WithAudit records catalog changes in the audit log.
+func (h *AdminCatalogHandler) WithAudit(svc audit.Service) *AdminCatalogHandler {
+	h.audit = svc
+	return h
+}
+
+type reorderPayload struct {
+	IDs []string `json:"ids" binding:"required"`
+}
+
+type orderTypePayload struct {
+	Name   *string `json:"name"`
+	Active *bool   `json:"active"`
+}
+
+// ListOrderTypes returns all order types, inactive ones included, in display order.
+// GET /api/v1/admin/order-types
+func (h *AdminCatalogHandler) ListOrderTypes() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		list, err := h.catalog.ListOrderTypes(ctx)
+		if err != nil {
+			writeCatalogError(c, "failed to list order types", err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"order_types": list})
+	}
+}
+
+// CreateOrderType adds an active order type at the end of the list.
+// POST /api/v1/admin/order-types { name }
+func (h *AdminCatalogHandler) CreateOrderType() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var p orderTypePayload
+		if err := c.ShouldBindJSON(&p); err != nil || p.Name == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		t, err := h.catalog.CreateOrderType(ctx, *p.Name)
+		if err != nil {
+			writeCatalogError(c, "failed to create order type", err)
+			return
+		}
+		h.record(c, ctx, audit.ActionCatalogCreated, audit.TargetOrderType, t.ID.String(), nil, t)
+		c.JSON(http.StatusCreated, t)
+	}
+}
+
+// UpdateOrderType renames or (de)activates an order type.
+// PATCH /api/v1/admin/order-types/:id { name?, active? }
+func (h *AdminCatalogHandler) UpdateOrderType() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := catalogIDParam(c)
+		if !ok {
+			return
+		}
+		var p orderTypePayload
+		if err := c.ShouldBindJSON(&p); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload", "detail": err.Error()})
+			return
+		}
+		h.updateOrderType(c, id, catalog.UpdateOrderTypeRequest{Name: p.Name, Active: p.Active})
+	}
+}
+
+// DeactivateOrderType hides an order type from customers.
+// DELETE /api/v1/admin/order-types/:id
+func (h *AdminCatalogHandler) DeactivateOrderType() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := catalogIDParam(c)
+		if !ok {
+			return
+		}
+		inactive := false
+		h.updateOrderType(c, id, catalog.UpdateOrderTypeRequest{Active: &inactive})
+	}
+}
+
+func (h *AdminCatalogHandler) updateOrderType(c *gin.Context, id uuid.UUID, req catalog.UpdateOrderTypeRequest) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	before, err := h.catalog.GetOrderType(ctx, id)
+	if err != nil {
+		writeCatalogError(c, "failed to update order type", err)
+		return
+	}
+	updated, err := h.catalog.UpdateOrderType(ctx, id, req)
+	if err != nil {
+		writeCatalogError(c, "failed to update order type", err)
+		return
+	}
+	h.record(c, ctx, audit.ActionCatalogUpdated, audit.TargetOrderType, id.String(), before, updated)
+	c.JSON(http.StatusOK, updated)
+}
+
+// ReorderOrderTypes sets the display order. ids must list every order type once.
+// PUT /api/v1/admin/order-types/order { ids }
+func (h *AdminCatalogHandler) ReorderOrderTypes() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ids, ok := bindReorder(c)
+		if !ok {
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		list, err := h.catalog.ReorderOrderTypes(ctx, ids)
+		if err != nil {
+			writeCatalogError(c, "failed to reorder order types", err)
+			return
+		}
+		h.record(c, ctx, audit.ActionCatalogReordered, audit.TargetOrderType, "", nil, gin.H{"ids": ids})
+		c.JSON(http.StatusOK, gin.H{"order_types": list})
+	}
+}
+
+type vehicleTypePayload struct {
+	Code        string   `json:"code"`
+	Name        *string  `json:"name"`
+	Active      *bool    `json:"active"`
+	BaseFare    *float64 `json:"base_fare"`
+	PerKm       *float64 `json:"per_km"`
+	PerMinute   *float64 `json:"per_minute"`
+	AvgSpeedKmh *float64 `json:"avg_speed_kmh"`
+	MinimumFare *float64 `json:"minimum_fare"`
+	BookingFee  *float64 `json:"booking_fee"`
+}
+
+// ListVehicleTypes returns all vehicle types with their current pricing, in display order.
+// GET /api/v1/admin/vehicle-types
+func (h *AdminCatalogHandler) ListVehicleTypes() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		list, err := h.catalog.ListVehicleTypes(ctx)
+		if err != nil {
+			writeCatalogError(c, "failed to list vehicle types", err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"vehicle_types": list})
+	}
+}
+
+// CreateVehicleType adds a vehicle type and its first pricing version.
+// POST /api/v1/admin/vehicle-types { code, name, base_fare, per_km, per_minute, avg_speed_kmh, minimum_fare, booking_fee }
+func (h *AdminCatalogHandler) CreateVehicleType() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var p vehicleTypePayload
+		if err := c.ShouldBindJSON(&p); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload", "detail": err.Error()})
+			return
+		}
+		req := catalog.VehicleTypeRequest{Code: p.Code, AdminID: adminIDFrom(c)}
+		if p.Name != nil {
+			req.Name = *p.Name
+		}
+		for _, f := range []struct {
+			in  *float64
+			out *float64
+		}{
+			{p.BaseFare, &req.Pricing.BaseFare}, {p.PerKm, &req.Pricing.PerKm}, {p.PerMinute, &req.Pricing.PerMinute},
+			{p.AvgSpeedKmh, &req.Pricing.AvgSpeedKmh}, {p.MinimumFare, &req.Pricing.MinimumFare}, {p.BookingFee, &req.Pricing.BookingFee},
+		} {
+			if f.in != nil {
+				*f.out = *f.in
+			}
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		vt, err := h.catalog.CreateVehicleType(ctx, req)
+		if err != nil {
+			writeCatalogError(c, "failed to create vehicle type", err)
+			return
+		}
+		h.record(c, ctx, audit.ActionCatalogCreated, audit.TargetVehicleType, vt.ID.String(), nil, vt)
+		c.JSON(http.StatusCreated, vt)
+	}
+}
+
+// UpdateVehicleType changes the name, active flag or fares. Fare changes add a pricing
+// version; orders already placed keep the version they were quoted with.
+// PATCH /api/v1/admin/vehicle-types/:id
+func (h *AdminCatalogHandler) UpdateVehicleType() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := catalogIDParam(c)
+		if !ok {
+			return
+		}
+		var p vehicleTypePayload
+		if err := c.ShouldBindJSON(&p); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload", "detail": err.Error()})
+			return
+		}
+		if p.Code != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "code can't be changed"})
+			return
+		}
+		h.updateVehicleType(c, id, catalog.UpdateVehicleTypeRequest{
+			Name: p.Name, Active: p.Active,
+			BaseFare: p.BaseFare, PerKm: p.PerKm, PerMinute: p.PerMinute,
+			AvgSpeedKmh: p.AvgSpeedKmh, MinimumFare: p.MinimumFare, BookingFee: p.BookingFee,
+			AdminID: adminIDFrom(c),
+		})
+	}
+}
+
+// DeactivateVehicleType removes a vehicle type from tariff quotes.
+// DELETE /api/v1/admin/vehicle-types/:id
+func (h *AdminCatalogHandler) DeactivateVehicleType() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := catalogIDParam(c)
+		if !ok {
+			return
+		}
+		inactive := false
+		h.updateVehicleType(c, id, catalog.UpdateVehicleTypeRequest{Active: &inactive, AdminID: adminIDFrom(c)})
+	}
+}
+
+func (h *AdminCatalogHandler) updateVehicleType(c *gin.Context, id uuid.UUID, req catalog.UpdateVehicleTypeRequest) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	before, err := h.catalog.GetVehicleType(ctx, id)
+	if err != nil {
+		writeCatalogError(c, "failed to update vehicle type", err)
+		return
+	}
+	updated, err := h.catalog.UpdateVehicleType(ctx, id, req)
+	if err != nil {
+		writeCatalogError(c, "failed to update vehicle type", err)
+		return
+	}
+	h.record(c, ctx, audit.ActionCatalogUpdated, audit.TargetVehicleType, id.String(), before, updated)
+	c.JSON(http.StatusOK, updated)
+}
+
+// ReorderVehicleTypes sets the order of tariff quotes. ids must list every vehicle type once.
+// PUT /api/v1/admin/vehicle-types/order { ids }
+func (h *AdminCatalogHandler) ReorderVehicleTypes() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ids, ok := bindReorder(c)
+		if !ok {
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		list, err := h.catalog.ReorderVehicleTypes(ctx, ids)
+		if err != nil {
+			writeCatalogError(c, "failed to reorder vehicle types", err)
+			return
+		}
+		h.record(c, ctx, audit.ActionCatalogReordered, audit.TargetVehicleType, "", nil, gin.H{"ids": ids})
+		c.JSON(http.StatusOK, gin.H{"vehicle_types": list})
+	}
+}
+
+// PricingVersions lists a vehicle type's pricing history, newest first.
+// GET /api/v1/admin/vehicle-types/:id/pricing-versions
+func (h *AdminCatalogHandler) PricingVersions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := catalogIDParam(c)
+		if !ok {
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		list, err := h.catalog.ListPricingVersions(ctx, id)
+		if err != nil {
+			writeCatalogError(c, "failed to list pricing versions", err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"pricing_versions": list})
+	}
+}
+
+type guarantyOptionPayload struct {
+	Label       *string `json:"label"`
+	AmountCents *int64  `json:"amount_cents"`
+	Active      *bool   `json:"active"`
+}
+
+// ListGuarantyOptions returns all guaranty options, inactive ones included, in display order.
+// GET /api/v1/admin/guaranty-options
+func (h *AdminCatalogHandler) ListGuarantyOptions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		list, err := h.catalog.ListGuarantyOptions(ctx)
+		if err != nil {
+			writeCatalogError(c, "failed to list guaranty options", err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"guaranty_options": list})
+	}
+}
+
+// CreateGuarantyOption adds an active guaranty option at the end of the list.
+// POST /api/v1/admin/guaranty-options { label, amount_cents }
+func (h *AdminCatalogHandler) CreateGuarantyOption() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var p guarantyOptionPayload
+		if err := c.ShouldBindJSON(&p); err != nil || p.Label == nil || p.AmountCents == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "label and amount_cents are required"})
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		g, err := h.catalog.CreateGuarantyOption(ctx, catalog.GuarantyOptionRequest{Label: *p.Label, AmountCents: *p.AmountCents})
+		if err != nil {
+			writeCatalogError(c, "failed to create guaranty option", err)
+			return
+		}
+		h.record(c, ctx, audit.ActionCatalogCreated, audit.TargetGuarantyOption, g.ID.String(), nil, g)
+		c.JSON(http.StatusCreated, g)
+	}
+}
+
+// UpdateGuarantyOption changes the label, amount or active flag. Couriers who already
+// registered keep the amount recorded on their guaranty payment.
+// PATCH /api/v1/admin/guaranty-options/:id
+func (h *AdminCatalogHandler) UpdateGuarantyOption() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := catalogIDParam(c)
+		if !ok {
+			return
+		}
+		var p guarantyOptionPayload
+		if err := c.ShouldBindJSON(&p); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload", "detail": err.Error()})
+			return
+		}
+		h.updateGuarantyOption(c, id, catalog.UpdateGuarantyOptionRequest{Label: p.Label, AmountCents: p.AmountCents, Active: p.Active})
+	}
+}
+
+// DeactivateGuarantyOption hides a guaranty option from courier signup.
+// DELETE /api/v1/admin/guaranty-options/:id
+func (h *AdminCatalogHandler) DeactivateGuarantyOption() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := catalogIDParam(c)
+		if !ok {
+			return
+		}
+		inactive := false
+		h.updateGuarantyOption(c, id, catalog.UpdateGuarantyOptionRequest{Active: &inactive})
+	}
+}
+
+func (h *AdminCatalogHandler) updateGuarantyOption(c *gin.Context, id uuid.UUID, req catalog.UpdateGuarantyOptionRequest) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	before, err := h.catalog.GetGuarantyOption(ctx, id)
+	if err != nil {
+		writeCatalogError(c, "failed to update guaranty option", err)
+		return
+	}
+	updated, err := h.catalog.UpdateGuarantyOption(ctx, id, req)
+	if err != nil {
+		writeCatalogError(c, "failed to update guaranty option", err)
+		return
+	}
+	h.record(c, ctx, audit.ActionCatalogUpdated, audit.TargetGuarantyOption, id.String(), before, updated)
+	c.JSON(http.StatusOK, updated)
+}
+
+// ReorderGuarantyOptions sets the signup dropdown order. ids must list every option once.
+// PUT /api/v1/admin/guaranty-options/order { ids }
+func (h *AdminCatalogHandler) ReorderGuarantyOptions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ids, ok := bindReorder(c)
+		if !ok {
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		list, err := h.catalog.ReorderGuarantyOptions(ctx, ids)
+		if err != nil {
+			writeCatalogError(c, "failed to reorder guaranty options", err)
+			return
+		}
+		h.record(c, ctx, audit.ActionCatalogReordered, audit.TargetGuarantyOption, "", nil, gin.H{"ids": ids})
+		c.JSON(http.StatusOK, gin.H{"guaranty_options": list})
+	}
+}
+
+func (h *AdminCatalogHandler) record(c *gin.Context, ctx context.Context, action, target, id string, before, after interface{}) {
+	recordAudit(auditContext(c, ctx), h.audit, audit.Entry{
+		Action: action, TargetType: target, TargetID: id, Before: before, After: after,
+	})
+}
+
+// adminIDFrom returns the calling admin's profile id, or nil if absent.
+func adminIDFrom(c *gin.Context) *uuid.UUID {
+	id, err := uuid.Parse(c.GetString("admin_id"))
+	if err != nil {
+		return nil
+	}
+	return &id
+}
+
+func catalogIDParam(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func bindReorder(c *gin.Context) ([]uuid.UUID, bool) {
+	var p reorderPayload
+	if err := c.ShouldBindJSON(&p); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload", "detail": err.Error()})
+		return nil, false
+	}
+	ids := make([]uuid.UUID, len(p.IDs))
+	for i, raw := range p.IDs {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id " + raw})
+			return nil, false
+		}
+		ids[i] = id
+	}
+	return ids, true
+}
+
+func writeCatalogError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+	case errors.Is(err, catalog.ErrDuplicate):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, catalog.ErrNameRequired), errors.Is(err, catalog.ErrInvalidCode),
+		errors.Is(err, catalog.ErrNegativeAmount), errors.Is(err, catalog.ErrInvalidSpeed),
+		errors.Is(err, catalog.ErrInvalidReorder):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg, "detail": err.Error()})
+	}
+}
@@ -0,0 +1,195 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/audit"
+	"github.com/mikios34/delivery-backend/blob"
+	"github.com/mikios34/delivery-backend/courier"
+	"github.com/mikios34/delivery-backend/entity"
+	"gorm.io/gorm"
+)
+
+// AdminCourierHandler serves the courier onboarding review queue.
+type AdminCourierHandler struct {
+	couriers courier.CourierService
+	audit    audit.Service
+}
+
+// NewAdminCourierHandler constructs an AdminCourierHandler.
+func NewAdminCourierHandler(couriers courier.CourierService) *AdminCourierHandler {
+	return &AdminCourierHandler{couriers: couriers}
+}
+
+// WithAudit records review decisions in the audit log.
+func (h *AdminCourierHandler) WithAudit(svc audit.Service) *AdminCourierHandler {
+	h.audit = svc
+	return h
+}
+
+// onboardingPayload is pushed to the courier when an admin decides on their review.
+type onboardingPayload struct {
+	Status      entity.CourierOnboardingStatus `json:"onboarding_status"`
+	ReviewNotes string                         `json:"review_notes,omitempty"`
+}
+
+// List pages couriers, oldest first, optionally filtered by onboarding status.
+// GET /api/v1/admin/couriers?onboarding_status=pending_review&limit=&page=
+func (h *AdminCourierHandler) List() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		status := entity.CourierOnboardingStatus(c.Query("onboarding_status"))
+		switch status {
+		case "", entity.CourierPendingReview, entity.CourierApproved, entity.CourierRejected, entity.CourierSuspended:
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid onboarding_status"})
+			return
+		}
+		limit, offset, page := parsePagination(c)
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		list, total, err := h.couriers.ListCouriers(ctx, status, limit, offset)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list couriers", "detail": err.Error()})
+			return
+		}
+		if list == nil {
+			list = []courier.CourierView{}
+		}
+		resp := pageMeta(total, limit, offset, page, len(list))
+		resp["couriers"] = list
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// Get returns a courier with their onboarding documents.
+// GET /api/v1/admin/couriers/:id
+func (h *AdminCourierHandler) Get() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := courierIDParam(c)
+		if !ok {
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		cour, err := h.couriers.GetCourier(ctx, id)
+		if err != nil {
+			writeOnboardingError(c, "failed to load courier", err)
+			return
+		}
+		ob, err := h.couriers.GetOnboarding(ctx, id)
+		if err != nil {
+			writeOnboardingError(c, "failed to load onboarding", err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"courier": cour, "onboarding": ob})
+	}
+}
+
+// Document streams one of the courier's uploaded files.
+// GET /api/v1/admin/couriers/:id/documents/:docId
+func (h *AdminCourierHandler) Document() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := courierIDParam(c)
+		if !ok {
+			return
+		}
+		docID, err := uuid.Parse(c.Param("docId"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid document id"})
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+		defer cancel()
+		doc, rc, err := h.couriers.OpenDocument(ctx, id, docID)
+		if err != nil {
+			writeOnboardingError(c, "failed to open document", err)
+			return
+		}
+		defer rc.Close()
+		if doc.FileName != "" {
+			c.Header("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": doc.FileName}))
+		}
+		c.Header("Cache-Control", "private, no-store")
+		c.DataFromReader(http.StatusOK, doc.SizeBytes, doc.ContentType, rc, nil)
+	}
+}
+
+type reviewPayload struct {
+	Decision string `json:"decision" binding:"required,oneof=approve reject suspend"`
+	Notes    string `json:"notes"`
+}
+
+// Review approves, rejects or suspends a courier. Rejecting and suspending need notes,
+// which the courier sees in their onboarding status.
+// POST /api/v1/admin/couriers/:id/review
+func (h *AdminCourierHandler) Review() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := courierIDParam(c)
+		if !ok {
+			return
+		}
+		var p reviewPayload
+		if err := c.ShouldBindJSON(&p); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload", "detail": err.Error()})
+			return
+		}
+		adminID, ok := principalID(c, "admin_id")
+		if !ok {
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		before, _ := h.couriers.GetCourier(ctx, id)
+		updated, err := h.couriers.Review(ctx, courier.ReviewRequest{CourierID: id, AdminID: adminID, Decision: p.Decision, Notes: p.Notes})
+		if err != nil {
+			writeOnboardingError(c, "failed to review courier", err)
+			return
+		}
+		entry := audit.Entry{
+			Action: audit.ActionCourierReviewed, TargetType: audit.TargetCourier, TargetID: id.String(),
+			After: gin.H{"onboarding_status": updated.OnboardingStatus, "review_notes": updated.ReviewNotes, "available": updated.Available},
+		}
+		if before != nil {
+			entry.Before = gin.H{"onboarding_status": before.OnboardingStatus, "review_notes": before.ReviewNotes, "available": before.Available}
+		}
+		recordAudit(auditContext(c, ctx), h.audit, entry)
+		if hub := hubFrom(c); hub != nil {
+			_ = hub.Notify(id.String(), "courier.onboarding", onboardingPayload{Status: updated.OnboardingStatus, ReviewNotes: updated.ReviewNotes})
+			hub.CourierChanged(id.String())
+		}
+		c.JSON(http.StatusOK, updated)
+	}
+}
+
+func courierIDParam(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid courier id"})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// writeOnboardingError maps courier onboarding errors for both courier and admin endpoints.
+func writeOnboardingError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound), errors.Is(err, blob.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found", "detail": err.Error()})
+	case errors.Is(err, courier.ErrInvalidDocumentKind), errors.Is(err, courier.ErrNotesRequired):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, courier.ErrUnsupportedDocument):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+	case errors.Is(err, courier.ErrInvalidReview), errors.Is(err, courier.ErrMissingDocuments):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, courier.ErrDocumentStoreUnavailable):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg, "detail": err.Error()})
+	}
+}
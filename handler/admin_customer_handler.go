@@ -0,0 +1,242 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/audit"
+	"github.com/mikios34/delivery-backend/customer"
+	"gorm.io/gorm"
+)
+
+// AdminCustomerHandler serves customer lookup, suspension and support notes.
+type AdminCustomerHandler struct {
+	customers customer.CustomerService
+	audit     audit.Service
+}
+
+// NewAdminCustomerHandler constructs an AdminCustomerHandler.
+func NewAdminCustomerHandler(customers customer.CustomerService) *AdminCustomerHandler {
+	return &AdminCustomerHandler{customers: customers}
+}
+
+// WithAudit records suspensions and notes in the audit log.
+func (h *AdminCustomerHandler) WithAudit(svc audit.Service) *AdminCustomerHandler {
+	h.audit = svc
+	return h
+}
+
+// suspensionPayload is pushed to the customer right before their connections are closed.
+type suspensionPayload struct {
+	Reason string `json:"reason"`
+}
+
+// List pages customers, newest first, optionally searched by name or phone.
+// GET /api/v1/admin/customers?q=&status=active|suspended&limit=&page=
+func (h *AdminCustomerHandler) List() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		f := customer.CustomerFilter{Query: c.Query("q"), Status: c.Query("status")}
+		switch f.Status {
+		case "", customer.StatusActive, customer.StatusSuspended:
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid status"})
+			return
+		}
+		limit, offset, page := parsePagination(c)
+		f.Limit, f.Offset = limit, offset
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		list, total, err := h.customers.ListCustomers(ctx, f)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list customers", "detail": err.Error()})
+			return
+		}
+		if list == nil {
+			list = []customer.CustomerView{}
+		}
+		resp := pageMeta(total, limit, offset, page, len(list))
+		resp["customers"] = list
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// Get returns a customer with their notes and suspension history.
+// GET /api/v1/admin/customers/:id
+func (h *AdminCustomerHandler) Get() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := customerIDParam(c)
+		if !ok {
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		cust, err := h.customers.GetCustomer(ctx, id)
+		if err != nil {
+			writeCustomerError(c, "failed to load customer", err)
+			return
+		}
+		notes, err := h.customers.ListNotes(ctx, id)
+		if err != nil {
+			writeCustomerError(c, "failed to load notes", err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"customer": cust, "notes": notes})
+	}
+}
+
+type reasonPayload struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// Suspend blocks a customer from signing in and ordering. A connected customer gets a
+// customer.suspended event and is then disconnected.
+// POST /api/v1/admin/customers/:id/suspend { reason }
+func (h *AdminCustomerHandler) Suspend() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := customerIDParam(c)
+		if !ok {
+			return
+		}
+		var p reasonPayload
+		if err := c.ShouldBindJSON(&p); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "reason is required"})
+			return
+		}
+		adminID, ok := principalID(c, "admin_id")
+		if !ok {
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		updated, err := h.customers.Suspend(ctx, id, adminID, p.Reason)
+		if err != nil {
+			writeCustomerError(c, "failed to suspend customer", err)
+			return
+		}
+		recordAudit(auditContext(c, ctx), h.audit, audit.Entry{
+			Action: audit.ActionCustomerSuspended, TargetType: audit.TargetCustomer, TargetID: id.String(),
+			Before: gin.H{"active": true}, After: gin.H{"active": false, "reason": updated.SuspensionReason},
+		})
+		if hub := hubFrom(c); hub != nil {
+			_ = hub.NotifyCustomer(id.String(), "customer.suspended", suspensionPayload{Reason: updated.SuspensionReason})
+			hub.DisconnectCustomer(id.String())
+		}
+		c.JSON(http.StatusOK, updated)
+	}
+}
+
+// Reactivate lifts a suspension.
+// POST /api/v1/admin/customers/:id/reactivate { reason }
+func (h *AdminCustomerHandler) Reactivate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := customerIDParam(c)
+		if !ok {
+			return
+		}
+		var p reasonPayload
+		if err := c.ShouldBindJSON(&p); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "reason is required"})
+			return
+		}
+		adminID, ok := principalID(c, "admin_id")
+		if !ok {
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		before, _ := h.customers.GetCustomer(ctx, id)
+		updated, err := h.customers.Reactivate(ctx, id, adminID, p.Reason)
+		if err != nil {
+			writeCustomerError(c, "failed to reactivate customer", err)
+			return
+		}
+		entry := audit.Entry{
+			Action: audit.ActionCustomerReactivated, TargetType: audit.TargetCustomer, TargetID: id.String(),
+			After: gin.H{"active": true, "reason": p.Reason},
+		}
+		if before != nil {
+			entry.Before = gin.H{"active": false, "reason": before.SuspensionReason}
+		}
+		recordAudit(auditContext(c, ctx), h.audit, entry)
+		c.JSON(http.StatusOK, updated)
+	}
+}
+
+// Notes lists a customer's notes and suspension history, newest first.
+// GET /api/v1/admin/customers/:id/notes
+func (h *AdminCustomerHandler) Notes() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := customerIDParam(c)
+		if !ok {
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		notes, err := h.customers.ListNotes(ctx, id)
+		if err != nil {
+			writeCustomerError(c, "failed to list notes", err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"notes": notes})
+	}
+}
+
+// AddNote attaches a support note to a customer. Notes are never shown to the customer.
+// POST /api/v1/admin/customers/:id/notes { body }
+func (h *AdminCustomerHandler) AddNote() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := customerIDParam(c)
+		if !ok {
+			return
+		}
+		var p struct {
+			Body string `json:"body"`
+		}
+		if err := c.ShouldBindJSON(&p); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload", "detail": err.Error()})
+			return
+		}
+		adminID, ok := principalID(c, "admin_id")
+		if !ok {
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		note, err := h.customers.AddNote(ctx, id, adminID, p.Body)
+		if err != nil {
+			writeCustomerError(c, "failed to add note", err)
+			return
+		}
+		recordAudit(auditContext(c, ctx), h.audit, audit.Entry{
+			Action: audit.ActionCustomerNoteAdded, TargetType: audit.TargetCustomer, TargetID: id.String(),
+			After: gin.H{"note_id": note.ID},
+		})
+		c.JSON(http.StatusCreated, note)
+	}
+}
+
+func customerIDParam(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid customer id"})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func writeCustomerError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "customer not found"})
+	case errors.Is(err, customer.ErrReasonRequired), errors.Is(err, customer.ErrNoteRequired):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, customer.ErrAlreadySuspended), errors.Is(err, customer.ErrNotSuspended):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg, "detail": err.Error()})
+	}
+}
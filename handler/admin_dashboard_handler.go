@@ -0,0 +1,140 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	authpkg "github.com/mikios34/delivery-backend/auth"
+	"github.com/mikios34/delivery-backend/dashboard"
+	"github.com/mikios34/delivery-backend/realtime"
+	"github.com/mikios34/delivery-backend/realtime/protocol"
+)
+
+// AdminDashboardHandler serves the live operations dashboard for dispatchers.
+type AdminDashboardHandler struct {
+	dashboard dashboard.Service
+	hub       *realtime.Hub
+}
+
+// NewAdminDashboardHandler constructs an AdminDashboardHandler.
+func NewAdminDashboardHandler(svc dashboard.Service, hub *realtime.Hub) *AdminDashboardHandler {
+	return &AdminDashboardHandler{dashboard: svc, hub: hub}
+}
+
+// snapshotPayload is the first frame on a dashboard socket.
+type snapshotPayload struct {
+	*dashboard.Summary
+	CourierPositions []dashboard.CourierPosition `json:"courier_positions"`
+}
+
+// Summary returns order and courier counts and the unassigned orders waiting longer than
+// stale_minutes (default 10).
+// GET /api/v1/admin/dashboard?stale_minutes=
+func (h *AdminDashboardHandler) Summary() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		staleAfter, ok := staleAfterParam(c)
+		if !ok {
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		sum, err := h.dashboard.Summary(ctx, staleAfter)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load dashboard", "detail": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, sum)
+	}
+}
+
+// Couriers lists approved couriers that are online, available or on an order, with their
+// last known position.
+// GET /api/v1/admin/dashboard/couriers
+func (h *AdminDashboardHandler) Couriers() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		list, err := h.dashboard.Couriers(ctx)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list couriers", "detail": err.Error()})
+			return
+		}
+		if list == nil {
+			list = []dashboard.CourierPosition{}
+		}
+		c.JSON(http.StatusOK, gin.H{"couriers": list})
+	}
+}
+
+// Socket upgrades to a dashboard WebSocket. It sends a snapshot, then order, courier and
+// count deltas as they happen. Inbound frames are ignored.
+// GET /api/v1/ws/admin?stale_minutes=
+func (h *AdminDashboardHandler) Socket() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		staleAfter, ok := staleAfterParam(c)
+		if !ok {
+			return
+		}
+		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			return
+		}
+		// Register before loading the snapshot so no change is missed; a delta sent before
+		// the snapshot is superseded by it.
+		sock := h.hub.RegisterAdmin(conn)
+		defer sock.Close()
+		// A deactivated or downgraded admin stops receiving the stream.
+		done := make(chan struct{})
+		defer close(done)
+		go watchPrincipal(principalCheck(c), done, func(perr *authpkg.PrincipalError) {
+			_ = sock.Send(protocol.EventError, protocol.ErrorPayload{Code: perr.Code, Message: perr.Message})
+			sock.Close()
+		})
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		snap, err := h.snapshot(ctx, staleAfter)
+		cancel()
+		if err != nil {
+			_ = sock.Send(protocol.EventError, protocol.ErrorPayload{Code: protocol.CodeInternal, Message: "failed to load dashboard"})
+			return
+		}
+		if err := sock.Send(dashboard.EventSnapshot, snap); err != nil {
+			return
+		}
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (h *AdminDashboardHandler) snapshot(ctx context.Context, staleAfter time.Duration) (*snapshotPayload, error) {
+	sum, err := h.dashboard.Summary(ctx, staleAfter)
+	if err != nil {
+		return nil, err
+	}
+	couriers, err := h.dashboard.Couriers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if couriers == nil {
+		couriers = []dashboard.CourierPosition{}
+	}
+	return &snapshotPayload{Summary: sum, CourierPositions: couriers}, nil
+}
+
+func staleAfterParam(c *gin.Context) (time.Duration, bool) {
+	raw := c.Query("stale_minutes")
+	if raw == "" {
+		return dashboard.DefaultStaleAfter, true
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 1 || n > 24*60 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "stale_minutes must be between 1 and 1440"})
+		return 0, false
+	}
+	return time.Duration(n) * time.Minute, true
+}
@@ -2,18 +2,23 @@ package api
 
 import (
 	"context"
+	"errors"
 	"net/http"
-	"os"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/account"
 	adminpkg "github.com/mikios34/delivery-backend/admin"
-	authpkg "github.com/mikios34/delivery-backend/auth"
+	"github.com/mikios34/delivery-backend/audit"
+	"github.com/mikios34/delivery-backend/entity"
+	"gorm.io/gorm"
 )
 
 // AdminHandler bundles dependencies for admin-related HTTP handlers.
 type AdminHandler struct {
 	service adminpkg.AdminService
+	audit   audit.Service
 }
 
 // NewAdminHandler constructs an AdminHandler.
@@ -21,14 +26,23 @@ func NewAdminHandler(svc adminpkg.AdminService) *AdminHandler {
 	return &AdminHandler{service: svc}
 }
 
+// WithAudit records admin account changes in the audit log.
+func (h *AdminHandler) WithAudit(svc audit.Service) *AdminHandler {
+	h.audit = svc
+	return h
+}
+
 type registerAdminPayload struct {
 	FirstName   string `json:"first_name" binding:"required"`
 	LastName    string `json:"last_name" binding:"required"`
 	Phone       string `json:"phone" binding:"required"`
-	FirebaseUID string `json:"firebase_uid" binding:"required"`
+	FirebaseUID string `json:"firebase_uid"`
+	Role        string `json:"role" binding:"required"`
 }
 
-// RegisterAdmin registers an admin (creates user and admin profile).
+// RegisterAdmin creates another admin (user + admin profile) with the given role.
+// Requires the admins.manage permission; the new admin signs in on their own.
+// POST /api/v1/admin/admins
 func (h *AdminHandler) RegisterAdmin() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var p registerAdminPayload
@@ -42,40 +56,94 @@ func (h *AdminHandler) RegisterAdmin() gin.HandlerFunc {
 			LastName:    p.LastName,
 			Phone:       p.Phone,
 			FirebaseUID: p.FirebaseUID,
+			Role:        entity.AdminRole(p.Role),
 		}
 
 		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 		defer cancel()
 		createdAdmin, err := h.service.RegisterAdmin(ctx, req)
 		if err != nil {
-			switch err.Error() {
-			case "admin with this phone already exists":
-				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
-			default:
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to register admin", "detail": err.Error()})
-			}
+			writeAdminError(c, "failed to register admin", err)
 			return
 		}
+		recordAudit(auditContext(c, ctx), h.audit, audit.Entry{
+			Action: audit.ActionAdminCreated, TargetType: audit.TargetAdmin, TargetID: createdAdmin.ID.String(),
+			After: createdAdmin,
+		})
+		c.JSON(http.StatusCreated, createdAdmin)
+	}
+}
 
-		// Return principal for consistency with login response
-		principal := authpkg.Principal{
-			UserID:    createdAdmin.UserID.String(),
-			AdminID:   createdAdmin.ID.String(),
-			Role:      "admin",
-			FirstName: p.FirstName,
-			LastName:  p.LastName,
-			Phone:     p.Phone,
+// ListAdmins returns all admins with their roles.
+// GET /api/v1/admin/admins
+func (h *AdminHandler) ListAdmins() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		list, err := h.service.ListAdmins(ctx)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list admins", "detail": err.Error()})
+			return
 		}
-		secret := os.Getenv("JWT_SECRET")
-		if secret == "" {
-			secret = "dev-insecure-secret-change-me"
+		c.JSON(http.StatusOK, gin.H{"admins": list})
+	}
+}
+
+type updateAdminPayload struct {
+	Role   *string `json:"role"`
+	Active *bool   `json:"active"`
+}
+
+// UpdateAdmin changes an admin's role or active flag.
+// PATCH /api/v1/admin/admins/:id
+func (h *AdminHandler) UpdateAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid admin id"})
+			return
 		}
-		if token, err := authpkg.SignJWT(secret, &principal, 15*time.Minute, "access"); err == nil {
-			principal.Token = token
+		var p updateAdminPayload
+		if err := c.ShouldBindJSON(&p); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload", "detail": err.Error()})
+			return
 		}
-		if refresh, err := authpkg.SignJWT(secret, &principal, 30*24*time.Hour, "refresh"); err == nil {
-			principal.RefreshToken = refresh
+		req := adminpkg.UpdateAdminRequest{Active: p.Active}
+		if p.Role != nil {
+			role := entity.AdminRole(*p.Role)
+			req.Role = &role
 		}
-		c.JSON(http.StatusCreated, gin.H{"principal": principal})
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		before, err := h.service.GetAdmin(ctx, id)
+		if err != nil {
+			writeAdminError(c, "failed to update admin", err)
+			return
+		}
+		updated, err := h.service.UpdateAdmin(ctx, id, req)
+		if err != nil {
+			writeAdminError(c, "failed to update admin", err)
+			return
+		}
+		recordAudit(auditContext(c, ctx), h.audit, audit.Entry{
+			Action: audit.ActionAdminUpdated, TargetType: audit.TargetAdmin, TargetID: id.String(),
+			Before: before, After: updated,
+		})
+		c.JSON(http.StatusOK, updated)
+	}
+}
+
+func writeAdminError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, adminpkg.ErrPhoneExists), errors.Is(err, account.ErrPhoneLinkedToOtherAccount):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, adminpkg.ErrInvalidRole):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, adminpkg.ErrLastSuperAdmin):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "admin not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg, "detail": err.Error()})
 	}
 }
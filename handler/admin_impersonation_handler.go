@@ -0,0 +1,130 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	adminpkg "github.com/mikios34/delivery-backend/admin"
+	"github.com/mikios34/delivery-backend/audit"
+	authpkg "github.com/mikios34/delivery-backend/auth"
+	"github.com/mikios34/delivery-backend/entity"
+	"gorm.io/gorm"
+)
+
+// AdminImpersonationHandler mints and revokes tokens that let support act as a customer
+// or courier while debugging.
+type AdminImpersonationHandler struct {
+	auth  authpkg.Service
+	audit audit.Service
+}
+
+// NewAdminImpersonationHandler constructs an AdminImpersonationHandler.
+func NewAdminImpersonationHandler(svc authpkg.Service) *AdminImpersonationHandler {
+	return &AdminImpersonationHandler{auth: svc}
+}
+
+// WithAudit records started and revoked impersonations in the audit log.
+func (h *AdminImpersonationHandler) WithAudit(svc audit.Service) *AdminImpersonationHandler {
+	h.audit = svc
+	return h
+}
+
+type impersonationPayload struct {
+	Role       string    `json:"role" binding:"required"`
+	ProfileID  uuid.UUID `json:"profile_id" binding:"required"`
+	Reason     string    `json:"reason" binding:"required"`
+	Write      bool      `json:"write"`
+	TTLMinutes int       `json:"ttl_minutes"`
+}
+
+// Start mints an impersonation token for a customer or courier profile. Tokens are
+// read-only unless write is set, which needs users.impersonate_write.
+// POST /api/v1/admin/impersonations { role, profile_id, reason, write?, ttl_minutes? }
+func (h *AdminImpersonationHandler) Start() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var p impersonationPayload
+		if err := c.ShouldBindJSON(&p); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "role, profile_id and reason are required", "detail": err.Error()})
+			return
+		}
+		scope := authpkg.ScopeRead
+		if p.Write {
+			if !adminpkg.HasPermission(entity.AdminRole(c.GetString("admin_role")), adminpkg.PermImpersonateWrite) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "forbidden: missing permission", "permission": adminpkg.PermImpersonateWrite})
+				return
+			}
+			scope = authpkg.ScopeWrite
+		}
+		adminID, ok := principalID(c, "admin_id")
+		if !ok {
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		imp, err := h.auth.Impersonate(ctx, authpkg.ImpersonationRequest{
+			AdminID: adminID, Role: p.Role, ProfileID: p.ProfileID, Scope: scope,
+			TTL: time.Duration(p.TTLMinutes) * time.Minute,
+		})
+		if err != nil {
+			writeImpersonationError(c, "failed to start impersonation", err)
+			return
+		}
+		recordAudit(auditContext(c, ctx), h.audit, audit.Entry{
+			Action: audit.ActionImpersonationStarted, TargetType: p.Role, TargetID: p.ProfileID.String(),
+			After: gin.H{"token_id": imp.TokenID, "scope": imp.Scope, "expires_at": imp.ExpiresAt, "reason": p.Reason},
+		})
+		c.JSON(http.StatusCreated, imp)
+	}
+}
+
+// Revoke ends an impersonation token before it expires. Only ids recorded by Start are
+// accepted, so the endpoint cannot denylist arbitrary access tokens.
+// POST /api/v1/admin/impersonations/:tokenId/revoke
+func (h *AdminImpersonationHandler) Revoke() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenID, err := uuid.Parse(c.Param("tokenId"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid token id"})
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		if h.audit == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "impersonation audit not configured"})
+			return
+		}
+		_, started, err := h.audit.List(ctx, audit.Filter{Action: audit.ActionImpersonationStarted, TokenID: tokenID.String(), Limit: 1})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to look up impersonation", "detail": err.Error()})
+			return
+		}
+		if started == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "impersonation not found"})
+			return
+		}
+		// The denylist entry only has to outlive the longest possible token.
+		if err := h.auth.RevokeAccessToken(ctx, tokenID.String(), time.Now().Add(authpkg.MaxImpersonationTTL)); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke impersonation", "detail": err.Error()})
+			return
+		}
+		recordAudit(auditContext(c, ctx), h.audit, audit.Entry{
+			Action: audit.ActionImpersonationRevoked, TargetType: audit.TargetImpersonation, TargetID: tokenID.String(),
+		})
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func writeImpersonationError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "profile not found"})
+	case errors.Is(err, authpkg.ErrInvalidImpersonation):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg, "detail": err.Error()})
+	}
+}
@@ -0,0 +1,293 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/audit"
+	"github.com/mikios34/delivery-backend/courier"
+	"github.com/mikios34/delivery-backend/customer"
+	"github.com/mikios34/delivery-backend/dispatch"
+	"github.com/mikios34/delivery-backend/entity"
+	orderpkg "github.com/mikios34/delivery-backend/order"
+	"github.com/mikios34/delivery-backend/realtime"
+	"gorm.io/gorm"
+)
+
+// AdminOrderHandler lets operators search orders and force them through the lifecycle.
+type AdminOrderHandler struct {
+	orders    orderpkg.Service
+	dispatch  dispatch.Service
+	couriers  courier.CourierRepository
+	customers customer.CustomerRepository
+	audit     audit.Service
+}
+
+// NewAdminOrderHandler constructs an AdminOrderHandler.
+func NewAdminOrderHandler(orders orderpkg.Service, d dispatch.Service, couriers courier.CourierRepository, customers customer.CustomerRepository) *AdminOrderHandler {
+	return &AdminOrderHandler{orders: orders, dispatch: d, couriers: couriers, customers: customers}
+}
+
+// WithAudit records forced order changes in the audit log.
+func (h *AdminOrderHandler) WithAudit(svc audit.Service) *AdminOrderHandler {
+	h.audit = svc
+	return h
+}
+
+// orderParty is the courier or customer side of an order in the admin detail view.
+type orderParty struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    uuid.UUID `json:"user_id"`
+	FirstName string    `json:"first_name"`
+	LastName  string    `json:"last_name"`
+	Phone     string    `json:"phone"`
+	Active    bool      `json:"active"`
+	// Available is only set for couriers.
+	Available *bool `json:"available,omitempty"`
+}
+
+// Search lists orders newest first.
+// GET /api/v1/admin/orders?status=a,b&customer_id=&courier_id=&from=&to=&q=&limit=&page=
+func (h *AdminOrderHandler) Search() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var f orderpkg.SearchFilter
+		if v := c.Query("status"); v != "" {
+			for _, s := range strings.Split(v, ",") {
+				if s = strings.TrimSpace(s); s != "" {
+					f.Statuses = append(f.Statuses, entity.OrderStatus(s))
+				}
+			}
+		}
+		for key, dst := range map[string]**uuid.UUID{"customer_id": &f.CustomerID, "courier_id": &f.CourierID} {
+			if v := c.Query(key); v != "" {
+				id, err := uuid.Parse(v)
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + key})
+					return
+				}
+				*dst = &id
+			}
+		}
+		for key, dst := range map[string]**time.Time{"from": &f.From, "to": &f.To} {
+			if v := c.Query(key); v != "" {
+				t, err := time.Parse(time.RFC3339, v)
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + key + "; expected RFC3339"})
+					return
+				}
+				*dst = &t
+			}
+		}
+		f.Address = strings.TrimSpace(c.Query("q"))
+		limit, offset, page := parsePagination(c)
+		f.Limit, f.Offset = limit, offset
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		list, total, err := h.orders.SearchOrders(ctx, f)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to search orders", "detail": err.Error()})
+			return
+		}
+		resp := pageMeta(total, limit, offset, page, len(list))
+		resp["orders"] = list
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// Get returns an order with its assignment attempts and courier/customer details.
+// GET /api/v1/admin/orders/:id
+func (h *AdminOrderHandler) Get() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		oid, ok := orderIDParam(c)
+		if !ok {
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		ord, err := h.orders.GetOrder(ctx, oid)
+		if err != nil {
+			writeAdminOrderError(c, "failed to load order", err)
+			return
+		}
+		attempts, err := h.orders.ListAssignmentAttempts(ctx, oid)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load assignment attempts", "detail": err.Error()})
+			return
+		}
+		resp := gin.H{"order": ord, "assignment_attempts": attempts}
+		if cust, err := h.customers.GetCustomerByID(ctx, ord.CustomerID); err == nil {
+			p := orderParty{ID: cust.ID, UserID: cust.UserID, Active: cust.Active}
+			if u, err := h.customers.GetUserByID(ctx, cust.UserID); err == nil {
+				p.FirstName, p.LastName, p.Phone = u.FirstName, u.LastName, u.Phone
+			}
+			resp["customer"] = p
+		}
+		if ord.AssignedCourier != nil {
+			if cour, err := h.couriers.GetCourierByID(ctx, *ord.AssignedCourier); err == nil {
+				available := cour.Available
+				p := orderParty{ID: cour.ID, UserID: cour.UserID, Active: cour.Active, Available: &available}
+				if u, err := h.couriers.GetUserByID(ctx, cour.UserID); err == nil {
+					p.FirstName, p.LastName, p.Phone = u.FirstName, u.LastName, u.Phone
+				}
+				resp["courier"] = p
+			}
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+type adminAssignPayload struct {
+	CourierID string `json:"courier_id" binding:"required"`
+}
+
+// Assign hands the order to a chosen courier.
+// POST /api/v1/admin/orders/:id/assign
+func (h *AdminOrderHandler) Assign() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		oid, ok := orderIDParam(c)
+		if !ok {
+			return
+		}
+		var p adminAssignPayload
+		if err := c.ShouldBindJSON(&p); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload", "detail": err.Error()})
+			return
+		}
+		cid, err := uuid.Parse(p.CourierID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid courier_id"})
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		before, _ := h.orders.GetOrder(ctx, oid)
+		updated, _, err := h.dispatch.AssignTo(ctx, oid, cid)
+		if err != nil {
+			writeAdminOrderError(c, "failed to assign order", err)
+			return
+		}
+		h.auditOrder(c, ctx, audit.ActionOrderAssigned, before, updated)
+		c.JSON(http.StatusOK, updated)
+	}
+}
+
+// Redispatch drops the current courier and searches for another one.
+// POST /api/v1/admin/orders/:id/redispatch
+func (h *AdminOrderHandler) Redispatch() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		oid, ok := orderIDParam(c)
+		if !ok {
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		before, _ := h.orders.GetOrder(ctx, oid)
+		updated, _, err := h.dispatch.Redispatch(ctx, oid)
+		if err != nil {
+			writeAdminOrderError(c, "failed to redispatch order", err)
+			return
+		}
+		h.auditOrder(c, ctx, audit.ActionOrderRedispatched, before, updated)
+		c.JSON(http.StatusOK, updated)
+	}
+}
+
+type adminCancelPayload struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// Cancel cancels an open order with a reason, even after pickup.
+// POST /api/v1/admin/orders/:id/cancel
+func (h *AdminOrderHandler) Cancel() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		oid, ok := orderIDParam(c)
+		if !ok {
+			return
+		}
+		var p adminCancelPayload
+		if err := c.ShouldBindJSON(&p); err != nil || strings.TrimSpace(p.Reason) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "reason is required"})
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		before, _ := h.orders.GetOrder(ctx, oid)
+		updated, err := h.orders.CancelByAdmin(ctx, oid, strings.TrimSpace(p.Reason))
+		if err != nil {
+			writeAdminOrderError(c, "failed to cancel order", err)
+			return
+		}
+		h.auditOrder(c, ctx, audit.ActionOrderCanceled, before, updated)
+		notifyOrderParties(hubFrom(c), updated)
+		c.JSON(http.StatusOK, updated)
+	}
+}
+
+// Deliver marks the order delivered on the assigned courier's behalf.
+// POST /api/v1/admin/orders/:id/deliver
+func (h *AdminOrderHandler) Deliver() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		oid, ok := orderIDParam(c)
+		if !ok {
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		before, _ := h.orders.GetOrder(ctx, oid)
+		updated, err := h.orders.ForceDeliver(ctx, oid)
+		if err != nil {
+			writeAdminOrderError(c, "failed to mark order delivered", err)
+			return
+		}
+		h.auditOrder(c, ctx, audit.ActionOrderStatusChanged, before, updated)
+		notifyOrderParties(hubFrom(c), updated)
+		c.JSON(http.StatusOK, updated)
+	}
+}
+
+func (h *AdminOrderHandler) auditOrder(c *gin.Context, ctx context.Context, action string, before, after *entity.Order) {
+	recordAudit(auditContext(c, ctx), h.audit, audit.Entry{
+		Action: action, TargetType: audit.TargetOrder, TargetID: after.ID.String(),
+		Before: snapshotOrder(before), After: snapshotOrder(after),
+	})
+}
+
+// notifyOrderParties pushes the order's new status to its customer and assigned courier.
+func notifyOrderParties(hub *realtime.Hub, o *entity.Order) {
+	if hub == nil {
+		return
+	}
+	payload := realtime.OrderStatusPayload{OrderID: o.ID.String(), Status: string(o.Status)}
+	_ = hub.NotifyCustomer(o.CustomerID.String(), "order.status", payload)
+	if o.AssignedCourier != nil {
+		_ = hub.Notify(o.AssignedCourier.String(), "order.status", payload)
+	}
+}
+
+func orderIDParam(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order id"})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func writeAdminOrderError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found", "detail": err.Error()})
+	case errors.Is(err, orderpkg.ErrOrderClosed), errors.Is(err, orderpkg.ErrNoCourierAssigned),
+		errors.Is(err, dispatch.ErrNotReassignable), errors.Is(err, dispatch.ErrCourierInactive),
+		errors.Is(err, dispatch.ErrCourierNotApproved):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg, "detail": err.Error()})
+	}
+}
@@ -0,0 +1,231 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/audit"
+	"github.com/mikios34/delivery-backend/entity"
+	"github.com/mikios34/delivery-backend/support"
+)
+
+// AdminSupportHandler serves the support queue: assigning, answering and resolving tickets
+// and issuing refunds or adjustments.
+type AdminSupportHandler struct {
+	svc   support.Service
+	audit audit.Service
+}
+
+// NewAdminSupportHandler constructs an AdminSupportHandler.
+func NewAdminSupportHandler(svc support.Service) *AdminSupportHandler {
+	return &AdminSupportHandler{svc: svc}
+}
+
+// WithAudit records assignments, resolutions and adjustments in the audit log.
+func (h *AdminSupportHandler) WithAudit(svc audit.Service) *AdminSupportHandler {
+	h.audit = svc
+	return h
+}
+
+// adminParty is the admin acting on a ticket.
+func adminParty(c *gin.Context) (support.Party, bool) {
+	id, ok := principalID(c, "admin_id")
+	if !ok {
+		return support.Party{}, false
+	}
+	return support.Party{Role: support.RoleAdmin, ID: id}, true
+}
+
+// List pages tickets, most recently active first.
+// GET /api/v1/admin/tickets?status=&category=&order_id=&assigned_to=<admin id>|me|none&limit=&page=
+func (h *AdminSupportHandler) List() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, offset, page := parsePagination(c)
+		f := support.TicketFilter{
+			Status:   entity.TicketStatus(c.Query("status")),
+			Category: entity.TicketCategory(c.Query("category")),
+			Limit:    limit,
+			Offset:   offset,
+		}
+		if raw := c.Query("order_id"); raw != "" {
+			id, err := uuid.Parse(raw)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order_id"})
+				return
+			}
+			f.OrderID = &id
+		}
+		switch raw := c.Query("assigned_to"); raw {
+		case "":
+		case "none":
+			f.Unassigned = true
+		case "me":
+			f.AssignedTo = adminIDFrom(c)
+		default:
+			id, err := uuid.Parse(raw)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid assigned_to"})
+				return
+			}
+			f.AssignedTo = &id
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		list, total, err := h.svc.List(ctx, f)
+		if err != nil {
+			writeSupportError(c, "failed to list tickets", err)
+			return
+		}
+		resp := pageMeta(total, limit, offset, page, len(list))
+		resp["tickets"] = list
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// Get returns a ticket with its messages and adjustments.
+// GET /api/v1/admin/tickets/:id
+func (h *AdminSupportHandler) Get() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		who, ok := adminParty(c)
+		if !ok {
+			return
+		}
+		getTicket(c, h.svc, who)
+	}
+}
+
+// Reply answers the opener; the ticket becomes pending and is assigned to the caller if nobody has it.
+// POST /api/v1/admin/tickets/:id/messages { body }
+func (h *AdminSupportHandler) Reply() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		who, ok := adminParty(c)
+		if !ok {
+			return
+		}
+		replyTicket(c, h.svc, who)
+	}
+}
+
+// Attachment streams an image sent to a ticket.
+// GET /api/v1/admin/tickets/:id/attachments/:attachmentId
+func (h *AdminSupportHandler) Attachment() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		who, ok := adminParty(c)
+		if !ok {
+			return
+		}
+		streamAttachment(c, h.svc, who)
+	}
+}
+
+// Assign hands a ticket to an admin with support.manage, the caller when admin_id is omitted.
+// POST /api/v1/admin/tickets/:id/assign { admin_id? }
+func (h *AdminSupportHandler) Assign() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := ticketIDParam(c)
+		if !ok {
+			return
+		}
+		var p struct {
+			AdminID *uuid.UUID `json:"admin_id"`
+		}
+		if err := c.ShouldBindJSON(&p); err != nil && c.Request.ContentLength > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload", "detail": err.Error()})
+			return
+		}
+		if p.AdminID == nil {
+			me, ok := principalID(c, "admin_id")
+			if !ok {
+				return
+			}
+			p.AdminID = &me
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		t, err := h.svc.Assign(ctx, id, *p.AdminID)
+		if err != nil {
+			writeSupportError(c, "failed to assign ticket", err)
+			return
+		}
+		recordAudit(auditContext(c, ctx), h.audit, audit.Entry{
+			Action: audit.ActionTicketAssigned, TargetType: audit.TargetTicket, TargetID: id.String(),
+			After: gin.H{"assigned_admin_id": t.AssignedAdminID},
+		})
+		c.JSON(http.StatusOK, t)
+	}
+}
+
+// Resolve closes a ticket with a resolution the opener can read.
+// POST /api/v1/admin/tickets/:id/resolve { resolution }
+func (h *AdminSupportHandler) Resolve() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := ticketIDParam(c)
+		if !ok {
+			return
+		}
+		var p struct {
+			Resolution string `json:"resolution"`
+		}
+		if err := c.ShouldBindJSON(&p); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload", "detail": err.Error()})
+			return
+		}
+		adminID, ok := principalID(c, "admin_id")
+		if !ok {
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		t, err := h.svc.Resolve(ctx, id, adminID, p.Resolution)
+		if err != nil {
+			writeSupportError(c, "failed to resolve ticket", err)
+			return
+		}
+		recordAudit(auditContext(c, ctx), h.audit, audit.Entry{
+			Action: audit.ActionTicketResolved, TargetType: audit.TargetTicket, TargetID: id.String(),
+			After: gin.H{"status": t.Status, "resolution": t.Resolution},
+		})
+		c.JSON(http.StatusOK, t)
+	}
+}
+
+// Adjust issues a refund (positive, capped at the order price across the order's refunds)
+// or a signed adjustment.
+// POST /api/v1/admin/tickets/:id/adjustments { kind: refund|adjustment, amount_cents, reason }
+func (h *AdminSupportHandler) Adjust() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := ticketIDParam(c)
+		if !ok {
+			return
+		}
+		var p struct {
+			Kind        string `json:"kind" binding:"required"`
+			AmountCents int64  `json:"amount_cents"`
+			Reason      string `json:"reason"`
+		}
+		if err := c.ShouldBindJSON(&p); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload", "detail": err.Error()})
+			return
+		}
+		adminID, ok := principalID(c, "admin_id")
+		if !ok {
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		a, err := h.svc.Adjust(ctx, id, support.AdjustmentRequest{
+			Kind: entity.AdjustmentKind(p.Kind), AmountCents: p.AmountCents, Reason: p.Reason, AdminID: adminID,
+		})
+		if err != nil {
+			writeSupportError(c, "failed to issue adjustment", err)
+			return
+		}
+		recordAudit(auditContext(c, ctx), h.audit, audit.Entry{
+			Action: audit.ActionTicketAdjustmentIssued, TargetType: audit.TargetTicket, TargetID: id.String(), After: a,
+		})
+		c.JSON(http.StatusCreated, a)
+	}
+}
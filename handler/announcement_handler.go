@@ -0,0 +1,198 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/announcement"
+	"github.com/mikios34/delivery-backend/audit"
+	"github.com/mikios34/delivery-backend/entity"
+	"gorm.io/gorm"
+)
+
+// AnnouncementHandler serves announcement publishing for admins and the unread list and
+// acknowledgements for couriers and customers.
+type AnnouncementHandler struct {
+	svc   announcement.Service
+	audit audit.Service
+}
+
+// NewAnnouncementHandler constructs an AnnouncementHandler.
+func NewAnnouncementHandler(svc announcement.Service) *AnnouncementHandler {
+	return &AnnouncementHandler{svc: svc}
+}
+
+// WithAudit records published and expired announcements in the audit log.
+func (h *AnnouncementHandler) WithAudit(svc audit.Service) *AnnouncementHandler {
+	h.audit = svc
+	return h
+}
+
+// recipientFrom derives the reading courier or customer from the auth context.
+func recipientFrom(c *gin.Context) (announcement.Recipient, bool) {
+	role := c.GetString("role")
+	var idStr string
+	switch role {
+	case announcement.RoleCourier:
+		idStr = c.GetString("courier_id")
+	case announcement.RoleCustomer:
+		idStr = c.GetString("customer_id")
+	}
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "profile id missing in context"})
+		return announcement.Recipient{}, false
+	}
+	return announcement.Recipient{Role: role, ID: id}, true
+}
+
+type createAnnouncementPayload struct {
+	Title       string     `json:"title"`
+	Body        string     `json:"body"`
+	Audience    string     `json:"audience"`
+	ZoneID      *uuid.UUID `json:"zone_id"`
+	VehicleType string     `json:"vehicle_type"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+}
+
+// Create publishes an announcement and pushes it to the connected recipients it targets.
+// POST /api/v1/admin/announcements { title, body, audience, zone_id?, vehicle_type?, expires_at? }
+func (h *AnnouncementHandler) Create() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var p createAnnouncementPayload
+		if err := c.ShouldBindJSON(&p); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload", "detail": err.Error()})
+			return
+		}
+		adminID, ok := principalID(c, "admin_id")
+		if !ok {
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		a, delivered, err := h.svc.Create(ctx, announcement.CreateRequest{
+			Title:       p.Title,
+			Body:        p.Body,
+			Audience:    entity.AnnouncementAudience(p.Audience),
+			ZoneID:      p.ZoneID,
+			VehicleType: entity.VehicleType(p.VehicleType),
+			ExpiresAt:   p.ExpiresAt,
+			CreatedBy:   adminID,
+		})
+		if err != nil {
+			writeAnnouncementError(c, "failed to create announcement", err)
+			return
+		}
+		recordAudit(auditContext(c, ctx), h.audit, audit.Entry{
+			Action: audit.ActionAnnouncementCreated, TargetType: audit.TargetAnnouncement, TargetID: a.ID.String(), After: a,
+		})
+		c.JSON(http.StatusCreated, gin.H{"announcement": a, "delivered": delivered})
+	}
+}
+
+// List pages announcements newest first with their acknowledgement counts.
+// GET /api/v1/admin/announcements?limit=&page=
+func (h *AnnouncementHandler) List() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, offset, page := parsePagination(c)
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		list, total, err := h.svc.List(ctx, limit, offset)
+		if err != nil {
+			writeAnnouncementError(c, "failed to list announcements", err)
+			return
+		}
+		if list == nil {
+			list = []announcement.AnnouncementView{}
+		}
+		resp := pageMeta(total, limit, offset, page, len(list))
+		resp["announcements"] = list
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// Expire ends an announcement now; connected recipients get announcement.expired.
+// POST /api/v1/admin/announcements/:id/expire
+func (h *AnnouncementHandler) Expire() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid announcement id"})
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		a, err := h.svc.Expire(ctx, id)
+		if err != nil {
+			writeAnnouncementError(c, "failed to expire announcement", err)
+			return
+		}
+		recordAudit(auditContext(c, ctx), h.audit, audit.Entry{
+			Action: audit.ActionAnnouncementExpired, TargetType: audit.TargetAnnouncement, TargetID: id.String(),
+			After: gin.H{"expires_at": a.ExpiresAt},
+		})
+		c.JSON(http.StatusOK, a)
+	}
+}
+
+// Unread returns the caller's unexpired, unacknowledged announcements; clients call it on connect.
+// GET /api/v1/{courier|customer}/announcements
+func (h *AnnouncementHandler) Unread() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		who, ok := recipientFrom(c)
+		if !ok {
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		list, err := h.svc.Unread(ctx, who)
+		if err != nil {
+			writeAnnouncementError(c, "failed to load announcements", err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"announcements": list})
+	}
+}
+
+// Ack marks an announcement as read so it is not returned again.
+// POST /api/v1/{courier|customer}/announcements/:id/ack
+func (h *AnnouncementHandler) Ack() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		who, ok := recipientFrom(c)
+		if !ok {
+			return
+		}
+		id, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid announcement id"})
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		if err := h.svc.Ack(ctx, id, who); err != nil {
+			writeAnnouncementError(c, "failed to acknowledge announcement", err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func writeAnnouncementError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "announcement not found"})
+	case errors.Is(err, announcement.ErrAlreadyExpired):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, announcement.ErrTitleRequired), errors.Is(err, announcement.ErrBodyRequired),
+		errors.Is(err, announcement.ErrInvalidAudience), errors.Is(err, announcement.ErrCourierTargetOnly),
+		errors.Is(err, announcement.ErrInvalidVehicle), errors.Is(err, announcement.ErrUnknownZone),
+		errors.Is(err, announcement.ErrInvalidExpiry):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg, "detail": err.Error()})
+	}
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mikios34/delivery-backend/audit"
+)
+
+// auditContext attaches the authenticated caller and request metadata to ctx so
+// audit.Service.Record can attribute the action.
+func auditContext(c *gin.Context, ctx context.Context) context.Context {
+	role := c.GetString("role")
+	actor := audit.Actor{UserID: c.GetString("user_id"), Role: role, ImpersonatorID: c.GetString("impersonator_admin_id")}
+	switch role {
+	case "courier":
+		actor.ID = c.GetString("courier_id")
+	case "customer":
+		actor.ID = c.GetString("customer_id")
+	case "admin":
+		actor.ID = c.GetString("admin_id")
+	}
+	return audit.WithActor(ctx, actor, requestMeta(c))
+}
+
+// requestMeta describes the current request for audit records.
+func requestMeta(c *gin.Context) audit.RequestMeta {
+	return audit.RequestMeta{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		Method:    c.Request.Method,
+		Path:      c.FullPath(),
+		RequestID: c.GetHeader("X-Request-ID"),
+	}
+}
+
+// recordAudit records e if auditing is wired.
+func recordAudit(ctx context.Context, svc audit.Service, e audit.Entry) {
+	if svc != nil {
+		svc.Record(ctx, e)
+	}
+}
+
+// AuditHandler serves the audit trail to admins.
+type AuditHandler struct {
+	svc audit.Service
+}
+
+// NewAuditHandler constructs an AuditHandler.
+func NewAuditHandler(svc audit.Service) *AuditHandler {
+	return &AuditHandler{svc: svc}
+}
+
+// List returns audit logs newest first, filtered by actor, action, target and time range.
+// GET /api/v1/admin/audit-logs?actor_id=&actor_role=&action=&target_type=&target_id=&impersonator_id=&from=&to=&limit=&page=
+func (h *AuditHandler) List() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		f := audit.Filter{
+			ActorID:    c.Query("actor_id"),
+			ActorRole:  c.Query("actor_role"),
+			Action:     c.Query("action"),
+			TargetType: c.Query("target_type"),
+			TargetID:   c.Query("target_id"),
+
+			ImpersonatorID: c.Query("impersonator_id"),
+		}
+		for key, dst := range map[string]**time.Time{"from": &f.From, "to": &f.To} {
+			if v := c.Query(key); v != "" {
+				t, err := time.Parse(time.RFC3339, v)
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + key + "; expected RFC3339"})
+					return
+				}
+				*dst = &t
+			}
+		}
+		limit, offset, page := parsePagination(c)
+		f.Limit, f.Offset = limit, offset
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		logs, total, err := h.svc.List(ctx, f)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list audit logs", "detail": err.Error()})
+			return
+		}
+		resp := pageMeta(total, limit, offset, page, len(logs))
+		resp["logs"] = logs
+		c.JSON(http.StatusOK, resp)
+	}
+}
@@ -3,18 +3,23 @@ package api
 import (
 	"context"
 	"errors"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
 	fbAuth "firebase.google.com/go/auth"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	authpkg "github.com/mikios34/delivery-backend/auth"
+	"github.com/mikios34/delivery-backend/otp"
 	"gorm.io/gorm"
 )
 
 type AuthHandler struct {
 	service      authpkg.Service
 	firebaseAuth *fbAuth.Client
+	otp          otp.Service
 }
 
 func NewAuthHandler(svc authpkg.Service) *AuthHandler { return &AuthHandler{service: svc} }
@@ -25,9 +30,28 @@ func (h *AuthHandler) WithFirebaseAuth(client *fbAuth.Client) *AuthHandler {
 	return h
 }
 
+// WithOTP enables SMS one-time codes; phone-only login is unavailable without it.
+func (h *AuthHandler) WithOTP(svc otp.Service) *AuthHandler {
+	h.otp = svc
+	return h
+}
+
+// deviceFrom describes the calling client for session management.
+func deviceFrom(c *gin.Context, name string) authpkg.DeviceInfo {
+	return authpkg.DeviceInfo{Name: name, UserAgent: c.Request.UserAgent(), IP: c.ClientIP()}
+}
+
 type loginPayload struct {
-	Phone       string `json:"phone"`
+	Phone string `json:"phone"`
+	// IDToken is a Firebase ID token; it is verified and its UID used to sign in.
+	IDToken string `json:"id_token"`
+	// FirebaseUID is no longer accepted on its own: anyone can send a UID.
 	FirebaseUID string `json:"firebase_uid"`
+	// OTPCode is required for phone-only login (see POST /auth/otp/request).
+	OTPCode string `json:"otp_code"`
+	// Role picks which profile to sign in as when the user holds several.
+	Role       string `json:"role"`
+	DeviceName string `json:"device_name"`
 }
 
 func (h *AuthHandler) Login() gin.HandlerFunc {
@@ -37,24 +61,139 @@ func (h *AuthHandler) Login() gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload", "detail": err.Error()})
 			return
 		}
-		if p.FirebaseUID == "" && p.Phone == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "either firebase_uid or phone is required"})
+		switch {
+		case p.IDToken != "":
+			h.loginWithFirebase(c, p.IDToken, p.Role, p.DeviceName)
+		case p.FirebaseUID != "":
+			c.JSON(http.StatusBadRequest, gin.H{"error": "firebase_uid is not accepted; send the Firebase id_token instead", "code": "id_token_required"})
+		case p.Phone != "":
+			h.loginWithOTP(c, p.Phone, p.OTPCode, p.Role, p.DeviceName)
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "either id_token or phone is required"})
+		}
+	}
+}
+
+type otpRequestPayload struct {
+	Phone string `json:"phone" binding:"required"`
+}
+
+// RequestOTP sends a one-time login code by SMS.
+// POST /api/v1/auth/otp/request
+func (h *AuthHandler) RequestOTP() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if h.otp == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "otp login not configured"})
+			return
+		}
+		var p otpRequestPayload
+		if err := c.ShouldBindJSON(&p); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload", "detail": err.Error()})
 			return
 		}
-		req := authpkg.LoginRequest{Phone: p.Phone, FirebaseUID: p.FirebaseUID}
 		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 		defer cancel()
-		principal, err := h.service.Login(ctx, req)
+		challenge, err := h.otp.Request(ctx, p.Phone)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "login failed", "detail": err.Error()})
+			writeOTPError(c, err)
 			return
 		}
-		c.JSON(http.StatusOK, gin.H{"principal": principal})
+		c.JSON(http.StatusAccepted, challenge)
+	}
+}
+
+type otpVerifyPayload struct {
+	Phone      string `json:"phone" binding:"required"`
+	Code       string `json:"code" binding:"required"`
+	Role       string `json:"role"`
+	DeviceName string `json:"device_name"`
+}
+
+// VerifyOTP checks the code sent to the phone and logs the user in.
+// POST /api/v1/auth/otp/verify
+func (h *AuthHandler) VerifyOTP() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var p otpVerifyPayload
+		if err := c.ShouldBindJSON(&p); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload", "detail": err.Error()})
+			return
+		}
+		h.loginWithOTP(c, p.Phone, p.Code, p.Role, p.DeviceName)
+	}
+}
+
+// loginWithOTP consumes the phone's one-time code and, if valid, issues tokens.
+func (h *AuthHandler) loginWithOTP(c *gin.Context, phone, code, role, deviceName string) {
+	if h.otp == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "otp login not configured"})
+		return
+	}
+	if code == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": authpkg.ErrPhoneNotVerified.Error(), "code": "otp_required"})
+		return
+	}
+	// Verify and the user lookup must see the same number, however the client formatted it.
+	phone, err := otp.NormalizePhone(phone)
+	if err != nil {
+		writeOTPError(c, err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	if err := h.otp.Verify(ctx, phone, code); err != nil {
+		writeOTPError(c, err)
+		return
+	}
+	req := authpkg.LoginRequest{Phone: phone, PhoneVerified: true, Role: role, Device: deviceFrom(c, deviceName)}
+	principal, err := h.service.Login(ctx, req)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not registered"})
+			return
+		}
+		writeLoginError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"principal": principal})
+}
+
+// writeLoginError answers a failed sign-in; role selection problems get a 403 with a code.
+func writeLoginError(c *gin.Context, err error) {
+	var perr *authpkg.PrincipalError
+	switch {
+	case errors.Is(err, authpkg.ErrRoleNotHeld):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "code": "role_not_held"})
+	case errors.As(err, &perr):
+		c.JSON(http.StatusForbidden, gin.H{"error": perr.Message, "code": perr.Code})
+	default:
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "login failed", "detail": err.Error()})
+	}
+}
+
+// writeOTPError maps otp errors to responses with machine-readable codes.
+func writeOTPError(c *gin.Context, err error) {
+	var cooldown *otp.CooldownError
+	switch {
+	case errors.As(err, &cooldown):
+		secs := int(math.Ceil(cooldown.RetryAfter.Seconds()))
+		c.Header("Retry-After", strconv.Itoa(secs))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error(), "code": "otp_cooldown", "retry_after": secs})
+	case errors.Is(err, otp.ErrInvalidPhone):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": "invalid_phone"})
+	case errors.Is(err, otp.ErrInvalidCode):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error(), "code": "invalid_code"})
+	case errors.Is(err, otp.ErrCodeExpired):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error(), "code": "code_expired"})
+	case errors.Is(err, otp.ErrTooManyAttempts):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error(), "code": "too_many_attempts"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "otp failed", "detail": err.Error()})
 	}
 }
 
 type refreshPayload struct {
 	RefreshToken string `json:"refresh_token"`
+	DeviceName   string `json:"device_name"`
 }
 
 func (h *AuthHandler) Refresh() gin.HandlerFunc {
@@ -70,8 +209,12 @@ func (h *AuthHandler) Refresh() gin.HandlerFunc {
 		}
 		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 		defer cancel()
-		principal, err := h.service.Refresh(ctx, p.RefreshToken)
+		principal, err := h.service.Refresh(ctx, p.RefreshToken, deviceFrom(c, p.DeviceName))
 		if err != nil {
+			if errors.Is(err, authpkg.ErrRefreshTokenReused) {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "refresh failed", "code": "refresh_token_reused", "detail": err.Error()})
+				return
+			}
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "refresh failed", "detail": err.Error()})
 			return
 		}
@@ -82,7 +225,9 @@ func (h *AuthHandler) Refresh() gin.HandlerFunc {
 // ExchangeFirebase verifies a Firebase ID token and issues backend JWTs.
 // Request body: { "id_token": "<firebase-id-token>" }
 type exchangePayload struct {
-	IDToken string `json:"id_token"`
+	IDToken    string `json:"id_token"`
+	Role       string `json:"role"`
+	DeviceName string `json:"device_name"`
 }
 
 func (h *AuthHandler) ExchangeFirebase() gin.HandlerFunc {
@@ -100,27 +245,187 @@ func (h *AuthHandler) ExchangeFirebase() gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "id_token is required"})
 			return
 		}
+		h.loginWithFirebase(c, p.IDToken, p.Role, p.DeviceName)
+	}
+}
+
+// loginWithFirebase verifies a Firebase ID token and issues tokens for the user with its UID.
+func (h *AuthHandler) loginWithFirebase(c *gin.Context, idToken, role, deviceName string) {
+	if h.firebaseAuth == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "firebase auth not configured"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	token, err := h.firebaseAuth.VerifyIDToken(ctx, idToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid firebase token", "detail": err.Error()})
+		return
+	}
+	// Use the Firebase UID to perform application login and issue backend JWTs.
+	req := authpkg.LoginRequest{FirebaseUID: token.UID, Role: role, Device: deviceFrom(c, deviceName)}
+	principal, err := h.service.Login(ctx, req)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":        "user not registered",
+				"firebase_uid": token.UID,
+			})
+			return
+		}
+		writeLoginError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"principal": principal})
+}
+
+// sessionIdentity returns the user and session ids carried by the access token.
+func sessionIdentity(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, false
+	}
+	sessionID, _ := uuid.Parse(c.GetString("session_id"))
+	return userID, sessionID, true
+}
+
+// Logout revokes the session the caller's access token belongs to.
+// POST /api/v1/logout
+func (h *AuthHandler) Logout() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, sessionID, ok := sessionIdentity(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token subject"})
+			return
+		}
+		if sessionID == uuid.Nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "token is not bound to a session"})
+			return
+		}
 		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 		defer cancel()
-		token, err := h.firebaseAuth.VerifyIDToken(ctx, p.IDToken)
+		if err := h.service.Logout(ctx, userID, sessionID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "logout failed", "detail": err.Error()})
+			return
+		}
+		// Kill the presented access token too instead of letting it live out its TTL.
+		if exp, ok := c.Get("token_expires_at"); ok {
+			if err := h.service.RevokeAccessToken(ctx, c.GetString("jti"), exp.(time.Time)); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "logout failed", "detail": err.Error()})
+				return
+			}
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+type switchRolePayload struct {
+	Role       string `json:"role" binding:"required"`
+	DeviceName string `json:"device_name"`
+}
+
+// SwitchRole re-issues tokens scoped to another role the caller holds; the current
+// session is signed out.
+// POST /api/v1/auth/switch-role
+func (h *AuthHandler) SwitchRole() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, sessionID, ok := sessionIdentity(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token subject"})
+			return
+		}
+		var p switchRolePayload
+		if err := c.ShouldBindJSON(&p); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload", "detail": err.Error()})
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		principal, err := h.service.SwitchRole(ctx, userID, sessionID, p.Role, deviceFrom(c, p.DeviceName))
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid firebase token", "detail": err.Error()})
+			writeLoginError(c, err)
 			return
 		}
-		// Use the Firebase UID to perform application login and issue backend JWTs.
-		req := authpkg.LoginRequest{FirebaseUID: token.UID}
-		principal, err := h.service.Login(ctx, req)
+		// The old session is revoked; drop the presented access token right away too.
+		if exp, ok := c.Get("token_expires_at"); ok {
+			_ = h.service.RevokeAccessToken(ctx, c.GetString("jti"), exp.(time.Time))
+		}
+		c.JSON(http.StatusOK, gin.H{"principal": principal})
+	}
+}
+
+// Sessions lists the caller's signed-in sessions (one per device).
+// GET /api/v1/sessions
+func (h *AuthHandler) Sessions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, sessionID, ok := sessionIdentity(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token subject"})
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		list, err := h.service.ListSessions(ctx, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list sessions", "detail": err.Error()})
+			return
+		}
+		out := make([]gin.H, 0, len(list))
+		for _, s := range list {
+			out = append(out, gin.H{
+				"id":           s.FamilyID,
+				"role":         s.Role,
+				"device_name":  s.DeviceName,
+				"user_agent":   s.UserAgent,
+				"ip":           s.IP,
+				"signed_in_at": s.SignedInAt,
+				"last_used_at": s.LastUsedAt,
+				"expires_at":   s.ExpiresAt,
+				"current":      s.FamilyID == sessionID,
+			})
+		}
+		c.JSON(http.StatusOK, gin.H{"sessions": out})
+	}
+}
+
+// RevokeSession signs out one of the caller's sessions.
+// DELETE /api/v1/sessions/:id
+func (h *AuthHandler) RevokeSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _, ok := sessionIdentity(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token subject"})
+			return
+		}
+		id, err := uuid.Parse(c.Param("id"))
 		if err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				c.JSON(http.StatusNotFound, gin.H{
-					"error":        "user not registered",
-					"firebase_uid": token.UID,
-				})
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session id"})
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		if err := h.service.RevokeSession(ctx, userID, id); err != nil {
+			if errors.Is(err, authpkg.ErrSessionNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 				return
 			}
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "login failed", "detail": err.Error()})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke session", "detail": err.Error()})
 			return
 		}
-		c.JSON(http.StatusOK, gin.H{"principal": principal})
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// JWKS publishes the public half of our signing keys so other services can verify tokens.
+// GET /.well-known/jwks.json
+func (h *AuthHandler) JWKS() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ks := authpkg.DefaultKeyset()
+		if ks == nil {
+			c.JSON(http.StatusOK, authpkg.JWKS{Keys: []authpkg.JWK{}})
+			return
+		}
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, ks.JWKS())
 	}
 }
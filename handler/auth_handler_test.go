@@ -0,0 +1,71 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	authpkg "github.com/mikios34/delivery-backend/auth"
+	"github.com/mikios34/delivery-backend/otp"
+	"gorm.io/gorm"
+)
+
+// phoneOTP accepts code "123456" for the one phone it knows, compared exactly.
+type phoneOTP struct {
+	otp.Service
+	phone string
+}
+
+func (s *phoneOTP) Verify(_ context.Context, phone, code string) error {
+	if phone != s.phone || code != "123456" {
+		return otp.ErrInvalidCode
+	}
+	return nil
+}
+
+// phoneLogins signs in the one user registered with phone, compared exactly.
+type phoneLogins struct {
+	authpkg.Service
+	phone string
+}
+
+func (s *phoneLogins) Login(_ context.Context, req authpkg.LoginRequest) (*authpkg.Principal, error) {
+	if req.Phone != s.phone || !req.PhoneVerified {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &authpkg.Principal{Phone: req.Phone}, nil
+}
+
+func TestOTPLoginNormalizesPhone(t *testing.T) {
+	const stored = "+251911234567"
+	h := NewAuthHandler(&phoneLogins{phone: stored}).WithOTP(&phoneOTP{phone: stored})
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/login", h.Login())
+	r.POST("/verify", h.VerifyOTP())
+
+	tests := []struct {
+		name, path, body string
+		want             int
+	}{
+		{"login", "/login", `{"phone":"+251 911-234567","otp_code":"123456"}`, http.StatusOK},
+		{"verify", "/verify", `{"phone":"+251 (911) 234 567","code":"123456"}`, http.StatusOK},
+		{"wrong code", "/login", `{"phone":"+251 911-234567","otp_code":"000000"}`, http.StatusUnauthorized},
+		{"unknown number", "/login", `{"phone":"+251 911-000000","otp_code":"123456"}`, http.StatusUnauthorized},
+		{"not a number", "/login", `{"phone":"call me","otp_code":"123456"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("code = %d, want %d (%s)", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/chat"
+	"github.com/mikios34/delivery-backend/entity"
+	"github.com/mikios34/delivery-backend/realtime"
+	"gorm.io/gorm"
+)
+
+// ChatHandler serves the order-scoped chat between a customer and the assigned courier.
+type ChatHandler struct {
+	svc chat.Service
+}
+
+// NewChatHandler constructs a ChatHandler.
+func NewChatHandler(svc chat.Service) *ChatHandler {
+	return &ChatHandler{svc: svc}
+}
+
+// participantFrom derives the chat participant from the auth context.
+func participantFrom(c *gin.Context) (chat.Participant, bool) {
+	role := c.GetString("role")
+	var idStr string
+	switch role {
+	case chat.RoleCustomer:
+		idStr = c.GetString("customer_id")
+	case chat.RoleCourier:
+		idStr = c.GetString("courier_id")
+	}
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return chat.Participant{}, false
+	}
+	return chat.Participant{Role: role, ID: id}, true
+}
+
+// List returns the thread for an order.
+// GET /orders/:id/messages?limit=&before=<RFC3339>
+func (h *ChatHandler) List() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		who, ok := participantFrom(c)
+		if !ok {
+			c.JSON(http.StatusForbidden, gin.H{"error": "profile id missing in context"})
+			return
+		}
+		oid, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order id"})
+			return
+		}
+		const (
+			defaultLimit = 50
+			maxLimit     = 200
+		)
+		limit := defaultLimit
+		if lStr := c.Query("limit"); lStr != "" {
+			if l, err := strconv.Atoi(lStr); err == nil && l > 0 {
+				limit = l
+			}
+		}
+		if limit > maxLimit {
+			limit = maxLimit
+		}
+		var before *time.Time
+		if bStr := c.Query("before"); bStr != "" {
+			t, err := time.Parse(time.RFC3339Nano, bStr)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid before timestamp"})
+				return
+			}
+			before = &t
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		thread, err := h.svc.List(ctx, oid, who, before, limit)
+		if err != nil {
+			writeChatError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, thread)
+	}
+}
+
+// Send posts a message to the thread and pushes it to both parties.
+// POST /orders/:id/messages {"body": "..."}
+func (h *ChatHandler) Send() gin.HandlerFunc {
+	type payload struct {
+		Body string `json:"body" binding:"required"`
+	}
+	return func(c *gin.Context) {
+		who, ok := participantFrom(c)
+		if !ok {
+			c.JSON(http.StatusForbidden, gin.H{"error": "profile id missing in context"})
+			return
+		}
+		oid, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order id"})
+			return
+		}
+		var p payload
+		if err := c.ShouldBindJSON(&p); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload", "detail": err.Error()})
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		msg, ord, err := h.svc.Send(ctx, oid, who, p.Body)
+		if err != nil {
+			writeChatError(c, err)
+			return
+		}
+		if hub := hubFrom(c); hub != nil {
+			payload := realtime.ChatMessagePayload{
+				ID:         msg.ID.String(),
+				OrderID:    msg.OrderID.String(),
+				SenderRole: msg.SenderRole,
+				Body:       msg.Body,
+				CreatedAt:  msg.CreatedAt,
+			}
+			// Both sides receive the message so every device of the sender stays in sync too.
+			notifyChatParties(hub, ord, "chat.message", payload)
+		}
+		c.JSON(http.StatusCreated, msg)
+	}
+}
+
+// MarkRead marks the other party's messages as read and sends a read receipt.
+// POST /orders/:id/messages/read
+func (h *ChatHandler) MarkRead() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		who, ok := participantFrom(c)
+		if !ok {
+			c.JSON(http.StatusForbidden, gin.H{"error": "profile id missing in context"})
+			return
+		}
+		oid, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order id"})
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		ord, n, readAt, err := h.svc.MarkRead(ctx, oid, who)
+		if err != nil {
+			writeChatError(c, err)
+			return
+		}
+		if hub := hubFrom(c); hub != nil && n > 0 {
+			notifyChatParties(hub, ord, "chat.read", realtime.ChatReadPayload{OrderID: oid.String(), ReaderRole: who.Role, ReadAt: readAt})
+		}
+		c.JSON(http.StatusOK, gin.H{"marked_read": n, "read_at": readAt})
+	}
+}
+
+// notifyChatParties sends a chat event to the order's customer and assigned courier.
+func notifyChatParties(hub *realtime.Hub, ord *entity.Order, event string, payload any) {
+	_ = hub.NotifyCustomer(ord.CustomerID.String(), event, payload)
+	if ord.AssignedCourier != nil {
+		_ = hub.Notify(ord.AssignedCourier.String(), event, payload)
+	}
+}
+
+func writeChatError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, chat.ErrNotParticipant):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+	case errors.Is(err, chat.ErrThreadClosed), errors.Is(err, chat.ErrThreadNotOpen):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, chat.ErrEmptyMessage):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
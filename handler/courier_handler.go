@@ -2,28 +2,34 @@ package api
 
 import (
 	"context"
+	"errors"
 	"net/http"
-	"os"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
+	"github.com/mikios34/delivery-backend/account"
+	"github.com/mikios34/delivery-backend/audit"
 	authpkg "github.com/mikios34/delivery-backend/auth"
 	courierSvc "github.com/mikios34/delivery-backend/courier"
 	"github.com/mikios34/delivery-backend/entity"
 	orderpkg "github.com/mikios34/delivery-backend/order"
+	"github.com/mikios34/delivery-backend/tracking"
 )
 
 // CourierHandler bundles dependencies for courier-related HTTP handlers.
 //
-// Note: Firebase verification is intentionally omitted here because you said
-// Firebase authentication will be handled in the frontend. The frontend should
-// include a trusted identifier (e.g. firebase_uid) in the registration payload.
+// Registration must prove the phone number with a Firebase id_token issued for it or
+// an otp_code sent to it (see PhoneVerifier); a bare firebase_uid is rejected.
 type CourierHandler struct {
-	service courierSvc.CourierService
-	orders  orderpkg.Repository
+	service  courierSvc.CourierService
+	orders   orderpkg.Repository
+	tracking tracking.Service
+	auth     authpkg.Service
+	audit    audit.Service
+	phones   *PhoneVerifier
 }
 
 // NewCourierHandler constructs a CourierHandler.
@@ -31,12 +37,36 @@ func NewCourierHandler(svc courierSvc.CourierService) *CourierHandler {
 	return &CourierHandler{service: svc}
 }
 
+// WithAuth injects the auth service used to issue tokens right after registration.
+func (h *CourierHandler) WithAuth(auth authpkg.Service) *CourierHandler {
+	h.auth = auth
+	return h
+}
+
+// WithPhoneVerifier enables registration, which needs proof of the phone number.
+func (h *CourierHandler) WithPhoneVerifier(v *PhoneVerifier) *CourierHandler {
+	h.phones = v
+	return h
+}
+
+// WithAudit records courier profile changes in the audit log.
+func (h *CourierHandler) WithAudit(svc audit.Service) *CourierHandler {
+	h.audit = svc
+	return h
+}
+
 // WithOrders injects the order repository for active order lookup.
 func (h *CourierHandler) WithOrders(orders orderpkg.Repository) *CourierHandler {
 	h.orders = orders
 	return h
 }
 
+// WithTracking injects the tracking service so REST location updates reach the customer too.
+func (h *CourierHandler) WithTracking(t tracking.Service) *CourierHandler {
+	h.tracking = t
+	return h
+}
+
 // payload for POST /api/v1/couriers/register
 type registerCourierPayload struct {
 	FirstName        string `json:"first_name" binding:"required"`
@@ -46,13 +76,13 @@ type registerCourierPayload struct {
 	PrimaryVehicle   string `json:"primary_vehicle"`
 	VehicleDetails   string `json:"vehicle_details"`
 	GuarantyOptionID string `json:"guaranty_option_id" binding:"required"` // UUID string
-	FirebaseUID      string `json:"firebase_uid" binding:"required"`       // provided by frontend after Firebase auth
 	ProfilePicture   string `json:"profile_picture"`                       // optional profile picture URL
+	// id_token or otp_code proves the phone (see PhoneVerifier).
+	phoneProof
 }
 
 // RegisterCourier registers a courier (creates user, courier, guaranty payment placeholder).
-// The frontend is responsible for Firebase authentication and must provide firebase_uid
-// in the payload. The backend trusts that value per your instruction.
+// The caller proves the phone with a Firebase id_token for that number or an otp_code.
 func (h *CourierHandler) RegisterCourier() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var p registerCourierPayload
@@ -60,6 +90,10 @@ func (h *CourierHandler) RegisterCourier() gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload", "detail": err.Error()})
 			return
 		}
+		firebaseUID, ok := h.phones.verify(c, p.Phone, p.phoneProof)
+		if !ok {
+			return
+		}
 
 		// parse guaranty option id
 		guarID, err := uuid.Parse(p.GuarantyOptionID)
@@ -68,12 +102,12 @@ func (h *CourierHandler) RegisterCourier() gin.HandlerFunc {
 			return
 		}
 
-		// prepare service request (firebase uid provided by frontend)
 		req := courierSvc.RegisterCourierRequest{
 			FirstName:        p.FirstName,
 			LastName:         p.LastName,
 			Phone:            p.Phone,
-			FirebaseUID:      p.FirebaseUID,
+			FirebaseUID:      firebaseUID,
+			PhoneVerified:    true,
 			HasVehicle:       p.HasVehicle,
 			PrimaryVehicle:   entity.VehicleType(p.PrimaryVehicle),
 			VehicleDetails:   p.VehicleDetails,
@@ -88,7 +122,7 @@ func (h *CourierHandler) RegisterCourier() gin.HandlerFunc {
 		if err != nil {
 			// best-effort error mapping
 			switch err.Error() {
-			case "courier with this phone already exists":
+			case "courier with this phone already exists", account.ErrPhoneLinkedToOtherAccount.Error(), account.ErrPhoneNotVerified.Error():
 				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
 			case "selected guaranty option not found or inactive", "guaranty option not found or not active":
 				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -98,11 +132,19 @@ func (h *CourierHandler) RegisterCourier() gin.HandlerFunc {
 			return
 		}
 
+		// The new courier is the actor of their own registration.
+		actx := audit.WithActor(ctx,
+			audit.Actor{UserID: createdCourier.UserID.String(), Role: "courier", ID: createdCourier.ID.String()},
+			requestMeta(c))
+		recordAudit(actx, h.audit, audit.Entry{
+			Action: audit.ActionCourierRegistered, TargetType: audit.TargetCourier, TargetID: createdCourier.ID.String(),
+			After: createdCourier,
+		})
+
 		// Build principal-like response and sign JWT for immediate use
 		principal := authpkg.Principal{
 			UserID:    createdCourier.UserID.String(),
 			CourierID: createdCourier.ID.String(),
-			// Role omitted from JSON, but included in JWT claims
 			Role:      "courier",
 			FirstName: p.FirstName,
 			LastName:  p.LastName,
@@ -112,15 +154,9 @@ func (h *CourierHandler) RegisterCourier() gin.HandlerFunc {
 			pp := p.ProfilePicture
 			principal.ProfilePicture = &pp
 		}
-		secret := os.Getenv("JWT_SECRET")
-		if secret == "" {
-			secret = "dev-insecure-secret-change-me"
-		}
-		if token, err := authpkg.SignJWT(secret, &principal, 15*time.Minute, "access"); err == nil {
-			principal.Token = token
-		}
-		if refresh, err := authpkg.SignJWT(secret, &principal, 30*24*time.Hour, "refresh"); err == nil {
-			principal.RefreshToken = refresh
+		// Best-effort: the account exists either way; the client can fall back to /login.
+		if h.auth != nil {
+			_ = h.auth.Issue(ctx, &principal, deviceFrom(c, ""))
 		}
 
 		c.JSON(http.StatusCreated, gin.H{"principal": principal})
@@ -152,20 +188,44 @@ func (h *CourierHandler) SetAvailability() gin.HandlerFunc {
 		}
 		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 		defer cancel()
+		var before *bool
+		if h.audit != nil {
+			if cour, err := h.service.GetCourier(ctx, id); err == nil {
+				before = &cour.Available
+			}
+		}
 		if err := h.service.SetAvailability(ctx, id, p.Available); err != nil {
+			if errors.Is(err, courierSvc.ErrNotApproved) {
+				c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "code": "courier_not_approved"})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update availability", "detail": err.Error()})
 			return
 		}
+		if before == nil || *before != p.Available {
+			entry := audit.Entry{
+				Action: audit.ActionCourierAvailabilityChanged, TargetType: audit.TargetCourier, TargetID: id.String(),
+				After: gin.H{"available": p.Available},
+			}
+			if before != nil {
+				entry.Before = gin.H{"available": *before}
+			}
+			recordAudit(auditContext(c, ctx), h.audit, entry)
+		}
+		if hub := hubFrom(c); hub != nil {
+			hub.CourierChanged(id.String())
+		}
 		c.Status(http.StatusNoContent)
 	}
 }
 
-// UpdateLocation updates courier location (lat/lng) (requires auth + courier role on route).
+// UpdateLocation updates the authenticated courier's location (lat/lng) (requires auth + courier role on route).
 func (h *CourierHandler) UpdateLocation() gin.HandlerFunc {
 	type payload struct {
-		CourierID string   `json:"courier_id" binding:"required"`
 		Latitude  *float64 `json:"latitude"`
 		Longitude *float64 `json:"longitude"`
+		Heading   *float64 `json:"heading"`
+		Speed     *float64 `json:"speed"`
 	}
 	return func(c *gin.Context) {
 		var p payload
@@ -173,9 +233,8 @@ func (h *CourierHandler) UpdateLocation() gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload", "detail": err.Error()})
 			return
 		}
-		id, err := uuid.Parse(p.CourierID)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid courier_id"})
+		id, ok := principalID(c, "courier_id")
+		if !ok {
 			return
 		}
 		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
@@ -184,10 +243,73 @@ func (h *CourierHandler) UpdateLocation() gin.HandlerFunc {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update location", "detail": err.Error()})
 			return
 		}
+		if h.tracking != nil {
+			_ = h.tracking.CourierMoved(ctx, id, tracking.LocationUpdate{Latitude: p.Latitude, Longitude: p.Longitude, Heading: p.Heading, Speed: p.Speed})
+		}
+		if hub := hubFrom(c); hub != nil {
+			hub.CourierChanged(id.String())
+		}
 		c.Status(http.StatusNoContent)
 	}
 }
 
+// UploadDocument stores a verification document for the authenticated courier.
+// POST /api/v1/courier/documents (multipart: kind, file)
+func (h *CourierHandler) UploadDocument() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := principalID(c, "courier_id")
+		if !ok {
+			return
+		}
+		kind := entity.CourierDocumentKind(c.PostForm("kind"))
+		fh, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "file is required", "detail": err.Error()})
+			return
+		}
+		f, err := fh.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unreadable file", "detail": err.Error()})
+			return
+		}
+		defer f.Close()
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+		defer cancel()
+		doc, err := h.service.UploadDocument(ctx, courierSvc.UploadDocumentRequest{
+			CourierID: id, Kind: kind, FileName: fh.Filename, Size: fh.Size, Content: f,
+		})
+		if err != nil {
+			writeOnboardingError(c, "failed to upload document", err)
+			return
+		}
+		recordAudit(auditContext(c, ctx), h.audit, audit.Entry{
+			Action: audit.ActionCourierDocumentUploaded, TargetType: audit.TargetCourier, TargetID: id.String(),
+			After: doc,
+		})
+		c.JSON(http.StatusCreated, doc)
+	}
+}
+
+// Onboarding returns the authenticated courier's review status, documents and what is still missing.
+// GET /api/v1/courier/onboarding
+func (h *CourierHandler) Onboarding() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := principalID(c, "courier_id")
+		if !ok {
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		ob, err := h.service.GetOnboarding(ctx, id)
+		if err != nil {
+			writeOnboardingError(c, "failed to load onboarding", err)
+			return
+		}
+		c.JSON(http.StatusOK, ob)
+	}
+}
+
 // GET /api/v1/guaranty-options
 func (h *CourierHandler) ListGuarantyOptions() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -3,12 +3,12 @@ package api
 import (
 	"context"
 	"net/http"
-	"os"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/account"
 	authpkg "github.com/mikios34/delivery-backend/auth"
 	"github.com/mikios34/delivery-backend/courier"
 	customerpkg "github.com/mikios34/delivery-backend/customer"
@@ -20,6 +20,8 @@ type CustomerHandler struct {
 	service  customerpkg.CustomerService
 	orders   orderpkg.Repository
 	couriers courier.CourierRepository
+	auth     authpkg.Service
+	phones   *PhoneVerifier
 }
 
 // NewCustomerHandler constructs a CustomerHandler.
@@ -28,6 +30,18 @@ func NewCustomerHandler(svc customerpkg.CustomerService) *CustomerHandler {
 	return &CustomerHandler{service: svc}
 }
 
+// WithAuth injects the auth service used to issue tokens right after registration.
+func (h *CustomerHandler) WithAuth(auth authpkg.Service) *CustomerHandler {
+	h.auth = auth
+	return h
+}
+
+// WithPhoneVerifier enables registration, which needs proof of the phone number.
+func (h *CustomerHandler) WithPhoneVerifier(v *PhoneVerifier) *CustomerHandler {
+	h.phones = v
+	return h
+}
+
 // WithRepos allows wiring additional dependencies without breaking existing call sites.
 func (h *CustomerHandler) WithRepos(orders orderpkg.Repository, couriers courier.CourierRepository) *CustomerHandler {
 	h.orders = orders
@@ -39,11 +53,13 @@ type registerCustomerPayload struct {
 	FirstName      string  `json:"first_name" binding:"required"`
 	LastName       string  `json:"last_name" binding:"required"`
 	Phone          string  `json:"phone" binding:"required"`
-	FirebaseUID    string  `json:"firebase_uid" binding:"required"`
 	ProfilePicture *string `json:"profile_picture,omitempty"`
+	// id_token or otp_code proves the phone (see PhoneVerifier).
+	phoneProof
 }
 
-// RegisterCustomer registers a customer (creates user and customer profile).
+// RegisterCustomer registers a customer (creates user and customer profile). The caller
+// proves the phone with a Firebase id_token for that number or an otp_code.
 func (h *CustomerHandler) RegisterCustomer() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var p registerCustomerPayload
@@ -51,12 +67,17 @@ func (h *CustomerHandler) RegisterCustomer() gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload", "detail": err.Error()})
 			return
 		}
+		firebaseUID, ok := h.phones.verify(c, p.Phone, p.phoneProof)
+		if !ok {
+			return
+		}
 
 		req := customerpkg.RegisterCustomerRequest{
 			FirstName:      p.FirstName,
 			LastName:       p.LastName,
 			Phone:          p.Phone,
-			FirebaseUID:    p.FirebaseUID,
+			FirebaseUID:    firebaseUID,
+			PhoneVerified:  true,
 			ProfilePicture: p.ProfilePicture,
 		}
 
@@ -65,7 +86,7 @@ func (h *CustomerHandler) RegisterCustomer() gin.HandlerFunc {
 		createdCustomer, err := h.service.RegisterCustomer(ctx, req)
 		if err != nil {
 			switch err.Error() {
-			case "customer with this phone already exists":
+			case "customer with this phone already exists", account.ErrPhoneLinkedToOtherAccount.Error(), account.ErrPhoneNotVerified.Error():
 				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
 			default:
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to register customer", "detail": err.Error()})
@@ -91,15 +112,9 @@ func (h *CustomerHandler) RegisterCustomer() gin.HandlerFunc {
 		if principal.ProfilePicture == nil && p.ProfilePicture != nil {
 			principal.ProfilePicture = p.ProfilePicture
 		}
-		secret := os.Getenv("JWT_SECRET")
-		if secret == "" {
-			secret = "dev-insecure-secret-change-me"
-		}
-		if token, err := authpkg.SignJWT(secret, &principal, 15*time.Minute, "access"); err == nil {
-			principal.Token = token
-		}
-		if refresh, err := authpkg.SignJWT(secret, &principal, 30*24*time.Hour, "refresh"); err == nil {
-			principal.RefreshToken = refresh
+		// Best-effort: the account exists either way; the client can fall back to /login.
+		if h.auth != nil {
+			_ = h.auth.Issue(ctx, &principal, deviceFrom(c, ""))
 		}
 		c.JSON(http.StatusCreated, gin.H{"principal": principal})
 	}
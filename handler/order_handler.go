@@ -2,19 +2,17 @@ package api
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
+	"errors"
 	"math"
 	"net/http"
-	"os"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	dispatchsvc "github.com/mikios34/delivery-backend/dispatch"
 	orderpkg "github.com/mikios34/delivery-backend/order"
+	"github.com/mikios34/delivery-backend/pricing"
 	"github.com/mikios34/delivery-backend/realtime"
 )
 
@@ -41,6 +39,8 @@ type createOrderPayload struct {
 	DropoffLat          *float64 `json:"dropoff_lat"`
 	DropoffLng          *float64 `json:"dropoff_lng"`
 	EstimatedPriceCents int64    `json:"estimated_price_cents" binding:"required"`
+	// PricingVersionID comes from the tariff quote; optional for older clients.
+	PricingVersionID string `json:"pricing_version_id"`
 }
 
 func (h *OrderHandler) CreateOrder() gin.HandlerFunc {
@@ -84,9 +84,46 @@ func (h *OrderHandler) CreateOrder() gin.HandlerFunc {
 			DropoffLng:          p.DropoffLng,
 			EstimatedPriceCents: p.EstimatedPriceCents,
 		}
+		if p.PricingVersionID != "" {
+			pvid, err := uuid.Parse(p.PricingVersionID)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pricing_version_id"})
+				return
+			}
+			req.PricingVersionID = &pvid
+		}
 		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 		defer cancel()
 		created, err := h.service.CreateOrder(ctx, req)
+		if errors.Is(err, orderpkg.ErrPricingMismatch) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, orderpkg.ErrPricingExpired) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": "pricing_expired"})
+			return
+		}
+		var quoteErr *orderpkg.QuoteMismatchError
+		if errors.As(err, &quoteErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": "quote_mismatch", "quoted_price_cents": quoteErr.PriceCents})
+			return
+		}
+		if errors.Is(err, orderpkg.ErrCoordinatesRequired) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, orderpkg.ErrVehicleTypeUnavailable) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": "vehicle_type_unavailable"})
+			return
+		}
+		if errors.Is(err, orderpkg.ErrOrderTypeUnavailable) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": "order_type_unavailable"})
+			return
+		}
+		if errors.Is(err, orderpkg.ErrCustomerSuspended) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "code": "customer_suspended"})
+			return
+		}
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create order", "detail": err.Error()})
 			return
@@ -127,7 +164,7 @@ func (h *OrderHandler) ListOrderTypes() gin.HandlerFunc {
 
 // EstimateTariffs estimates delivery tariffs for all active vehicle types based on pickup/dropoff coordinates.
 // GET /api/v1/orders/tariffs?pickup_lat=&pickup_lng=&dropoff_lat=&dropoff_lng=
-func (h *OrderHandler) EstimateTariffs(repo orderpkg.Repository) gin.HandlerFunc {
+func (h *OrderHandler) EstimateTariffs(repo orderpkg.Repository, quotes pricing.Service) gin.HandlerFunc {
 	type tariffResp struct {
 		VehicleTypeID string  `json:"vehicle_type_id"`
 		Code          string  `json:"code"`
@@ -136,64 +173,8 @@ func (h *OrderHandler) EstimateTariffs(repo orderpkg.Repository) gin.HandlerFunc
 		DurationMin   float64 `json:"duration_min"`
 		Price         float64 `json:"price"`
 		PriceCents    int64   `json:"price_cents"`
-	}
-
-	haversineKm := func(lat1, lon1, lat2, lon2 float64) float64 {
-		// Haversine formula in km
-		const R = 6371.0 // Earth radius in km
-		toRad := func(d float64) float64 { return d * (math.Pi / 180.0) }
-		dLat := toRad(lat2 - lat1)
-		dLon := toRad(lon2 - lon1)
-		a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
-		c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1.0-a))
-		return R * c
-	}
-
-	// map vehicle code to OSRM profile
-	profileFor := func(code string) string {
-		code = strings.ToLower(strings.TrimSpace(code))
-		switch code {
-		case "bike", "bicycle", "cycle":
-			return "cycling"
-		case "walker", "walk", "foot":
-			return "walking"
-		default:
-			// motorbike, motor, scooter, car, taxi, other -> driving
-			return "driving"
-		}
-	}
-
-	type osrmResponse struct {
-		Routes []struct {
-			Distance float64 `json:"distance"` // meters
-			Duration float64 `json:"duration"` // seconds
-		} `json:"routes"`
-		Code string `json:"code"`
-		Msg  string `json:"message"`
-	}
-
-	getRoute := func(ctx context.Context, baseURL, profile string, oLat, oLng, dLat, dLng float64) (float64, float64, error) {
-		url := fmt.Sprintf("%s/route/v1/%s/%.6f,%.6f;%.6f,%.6f?overview=false&alternatives=false&steps=false", strings.TrimRight(baseURL, "/"), profile, oLng, oLat, dLng, dLat)
-		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-		resp, err := http.DefaultClient.Do(req)
-		if err != nil {
-			return 0, 0, err
-		}
-		defer resp.Body.Close()
-		if resp.StatusCode != http.StatusOK {
-			return 0, 0, fmt.Errorf("routing status %d", resp.StatusCode)
-		}
-		var rr osrmResponse
-		dec := json.NewDecoder(resp.Body)
-		if err := dec.Decode(&rr); err != nil {
-			return 0, 0, err
-		}
-		if rr.Code != "Ok" || len(rr.Routes) == 0 {
-			return 0, 0, fmt.Errorf("routing error: %s", rr.Msg)
-		}
-		distKm := rr.Routes[0].Distance / 1000.0
-		durMin := rr.Routes[0].Duration / 60.0
-		return distKm, durMin, nil
+		// PricingVersionID identifies the fares used; send it back when creating the order.
+		PricingVersionID *uuid.UUID `json:"pricing_version_id,omitempty"`
 	}
 
 	return func(c *gin.Context) {
@@ -215,14 +196,13 @@ func (h *OrderHandler) EstimateTariffs(repo orderpkg.Repository) gin.HandlerFunc
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid lat/lng values"})
 			return
 		}
+		pickup, dropoff := pricing.Point{Lat: pLat, Lng: pLng}, pricing.Point{Lat: dLat, Lng: dLng}
 		// Basic bounds check
-		if pLat < -90 || pLat > 90 || dLat < -90 || dLat > 90 || pLng < -180 || pLng > 180 || dLng < -180 || dLng > 180 {
+		if !pickup.Valid() || !dropoff.Valid() {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "lat must be [-90,90], lng must be [-180,180]"})
 			return
 		}
 
-		distKmFallback := haversineKm(pLat, pLng, dLat, dLng)
-
 		ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 		defer cancel()
 		// Pull active vehicle types with pricing
@@ -231,61 +211,18 @@ func (h *OrderHandler) EstimateTariffs(repo orderpkg.Repository) gin.HandlerFunc
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch vehicle types", "detail": err.Error()})
 			return
 		}
-		// Prepare routing per unique profile to avoid duplicate calls
-		baseURL := os.Getenv("OSRM_BASE_URL")
-		if baseURL == "" {
-			baseURL = "https://router.project-osrm.org"
-		}
-		profileSet := map[string]struct{}{}
-		for _, vt := range types {
-			profileSet[profileFor(vt.Code)] = struct{}{}
-		}
-		routeByProfile := map[string]struct{ dist, dur float64 }{}
-		for profile := range profileSet {
-			if profile == "" {
-				continue
-			}
-			dist, dur, err := getRoute(ctx, baseURL, profile, pLat, pLng, dLat, dLng)
-			if err != nil {
-				// record zero to signal fallback
-				routeByProfile[profile] = struct{ dist, dur float64 }{0, 0}
-				continue
-			}
-			routeByProfile[profile] = struct{ dist, dur float64 }{dist, dur}
-		}
-
 		out := make([]tariffResp, 0, len(types))
-		for _, vt := range types {
-			prof := profileFor(vt.Code)
-			r, ok := routeByProfile[prof]
-			distKm := distKmFallback
-			durMin := 0.0
-			if ok && r.dist > 0 && r.dur > 0 {
-				distKm = r.dist
-				durMin = r.dur
-			} else {
-				// fallback duration from average speed
-				speed := vt.AvgSpeedKmh
-				if speed <= 0 {
-					speed = 30
-				}
-				durMin = (distKm / speed) * 60
-			}
-			// price = max(minimum, base + per_km*distance + per_minute*duration + booking_fee)
-			calc := vt.BaseFare + vt.PerKm*distKm + vt.PerMinute*durMin + vt.BookingFee
-			if calc < vt.MinimumFare {
-				calc = vt.MinimumFare
-			}
-			priceRounded := math.Round(calc*100) / 100
-			priceCents := int64(math.Round(calc * 100))
+		for _, qt := range quotes.Quote(ctx, pickup, dropoff, types) {
+			vt := qt.VehicleType
 			out = append(out, tariffResp{
-				VehicleTypeID: vt.ID.String(),
-				Code:          vt.Code,
-				Name:          vt.Name,
-				DistanceKm:    math.Round(distKm*100) / 100,
-				DurationMin:   math.Round(durMin*10) / 10,
-				Price:         priceRounded,
-				PriceCents:    priceCents,
+				VehicleTypeID:    vt.ID.String(),
+				Code:             vt.Code,
+				Name:             vt.Name,
+				DistanceKm:       math.Round(qt.DistanceKm*100) / 100,
+				DurationMin:      math.Round(qt.DurationMin*10) / 10,
+				Price:            float64(qt.PriceCents) / 100,
+				PriceCents:       qt.PriceCents,
+				PricingVersionID: vt.PricingVersionID,
 			})
 		}
 		c.JSON(http.StatusOK, gin.H{"tariffs": out})
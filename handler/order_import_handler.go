@@ -0,0 +1,173 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/entity"
+	"github.com/mikios34/delivery-backend/orderimport"
+	"gorm.io/gorm"
+)
+
+// OrderImportHandler lets customers create many orders from one CSV or JSON upload.
+type OrderImportHandler struct {
+	svc orderimport.Service
+}
+
+// NewOrderImportHandler constructs an OrderImportHandler.
+func NewOrderImportHandler(svc orderimport.Service) *OrderImportHandler {
+	return &OrderImportHandler{svc: svc}
+}
+
+// Submit starts an import and returns it right away; rows are processed in the
+// background. The body is a CSV file (text/csv) or a JSON array (application/json),
+// either raw or as the multipart field "file" (format taken from the file extension).
+// POST /api/v1/customer/order-imports?atomic=true|false
+func (h *OrderImportHandler) Submit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		customerID, ok := principalID(c, "customer_id")
+		if !ok {
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, orderimport.MaxUploadBytes)
+		format, body, ok := importBody(c)
+		if !ok {
+			return
+		}
+		defer body.Close()
+
+		var rows []orderimport.RowInput
+		var err error
+		if format == "csv" {
+			rows, err = orderimport.ParseCSV(body)
+		} else {
+			rows, err = orderimport.ParseJSON(body)
+		}
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "upload exceeds 2 MB"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + format + " upload", "detail": err.Error()})
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		imp, err := h.svc.Submit(ctx, orderimport.SubmitRequest{
+			CustomerID: customerID, Format: format, Atomic: c.Query("atomic") == "true", Rows: rows,
+		})
+		if err != nil {
+			writeOrderImportError(c, "failed to start import", err)
+			return
+		}
+		c.JSON(http.StatusAccepted, imp)
+	}
+}
+
+// importBody returns the upload and its format ("csv" or "json").
+func importBody(c *gin.Context) (string, io.ReadCloser, bool) {
+	mediaType, _, _ := mime.ParseMediaType(c.ContentType())
+	if mediaType == "multipart/form-data" {
+		fh, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "file is required", "detail": err.Error()})
+			return "", nil, false
+		}
+		var format string
+		switch strings.ToLower(filepath.Ext(fh.Filename)) {
+		case ".csv":
+			format = "csv"
+		case ".json":
+			format = "json"
+		default:
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "file must be .csv or .json"})
+			return "", nil, false
+		}
+		f, err := fh.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unreadable file", "detail": err.Error()})
+			return "", nil, false
+		}
+		return format, f, true
+	}
+	switch mediaType {
+	case "text/csv":
+		return "csv", c.Request.Body, true
+	case "application/json":
+		return "json", c.Request.Body, true
+	}
+	c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "content type must be text/csv, application/json or multipart/form-data"})
+	return "", nil, false
+}
+
+// List pages the caller's imports, newest first, without their rows.
+// GET /api/v1/customer/order-imports?limit=&page=
+func (h *OrderImportHandler) List() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		customerID, ok := principalID(c, "customer_id")
+		if !ok {
+			return
+		}
+		limit, offset, page := parsePagination(c)
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		list, total, err := h.svc.List(ctx, customerID, limit, offset)
+		if err != nil {
+			writeOrderImportError(c, "failed to list imports", err)
+			return
+		}
+		if list == nil {
+			list = []entity.OrderImport{}
+		}
+		resp := pageMeta(total, limit, offset, page, len(list))
+		resp["imports"] = list
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// Get returns an import's progress with every row's result. Poll it, or follow the
+// order_import.progress events, until status is completed or failed.
+// GET /api/v1/customer/order-imports/:id
+func (h *OrderImportHandler) Get() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		customerID, ok := principalID(c, "customer_id")
+		if !ok {
+			return
+		}
+		id, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid import id"})
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		d, err := h.svc.Get(ctx, id, customerID)
+		if err != nil {
+			writeOrderImportError(c, "failed to load import", err)
+			return
+		}
+		c.JSON(http.StatusOK, d)
+	}
+}
+
+func writeOrderImportError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "import not found"})
+	case errors.Is(err, orderimport.ErrCustomerSuspended):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "code": "customer_suspended"})
+	case errors.Is(err, orderimport.ErrNoRows), errors.Is(err, orderimport.ErrTooManyRows):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg, "detail": err.Error()})
+	}
+}
@@ -2,38 +2,183 @@ package api
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/audit"
 	"github.com/mikios34/delivery-backend/courier"
 	"github.com/mikios34/delivery-backend/dispatch"
 	"github.com/mikios34/delivery-backend/entity"
 	orderpkg "github.com/mikios34/delivery-backend/order"
 	"github.com/mikios34/delivery-backend/realtime"
+	"gorm.io/gorm"
 )
 
 type OrderStatusHandler struct {
 	svc      orderpkg.Service
 	couriers courier.CourierRepository
 	dispatch dispatch.Service
+	audit    audit.Service
 }
 
 func NewOrderStatusHandler(svc orderpkg.Service, couriers courier.CourierRepository) *OrderStatusHandler {
 	return &OrderStatusHandler{svc: svc, couriers: couriers}
 }
 
+// WithAudit records status changes and cancellations in the audit log.
+func (h *OrderStatusHandler) WithAudit(svc audit.Service) *OrderStatusHandler {
+	h.audit = svc
+	return h
+}
+
+// orderSnapshot is the part of an order audit records compare.
+type orderSnapshot struct {
+	Status          entity.OrderStatus `json:"status"`
+	AssignedCourier *uuid.UUID         `json:"assigned_courier,omitempty"`
+	CancelReason    string             `json:"cancel_reason,omitempty"`
+}
+
+func snapshotOrder(o *entity.Order) *orderSnapshot {
+	if o == nil {
+		return nil
+	}
+	return &orderSnapshot{Status: o.Status, AssignedCourier: o.AssignedCourier, CancelReason: o.CancelReason}
+}
+
+// auditOrder records an order change. before may be nil if it could not be loaded.
+func (h *OrderStatusHandler) auditOrder(ctx context.Context, action string, before, after *entity.Order) {
+	if h.audit == nil || after == nil {
+		return
+	}
+	h.audit.Record(ctx, audit.Entry{
+		Action: action, TargetType: audit.TargetOrder, TargetID: after.ID.String(),
+		Before: snapshotOrder(before), After: snapshotOrder(after),
+	})
+}
+
+// loadForAudit fetches the pre-change order when auditing is enabled.
+func (h *OrderStatusHandler) loadForAudit(ctx context.Context, orderID uuid.UUID) *entity.Order {
+	if h.audit == nil {
+		return nil
+	}
+	ord, err := h.svc.GetOrder(ctx, orderID)
+	if err != nil {
+		return nil
+	}
+	return ord
+}
+
 // WithDispatch wires the dispatch service for reassignment logic (e.g., on decline).
 func (h *OrderStatusHandler) WithDispatch(d dispatch.Service) *OrderStatusHandler {
 	h.dispatch = d
 	return h
 }
 
+// statusPayload is the body of the courier status endpoints. The acting courier always
+// comes from the token; a courier_id in the body is only accepted if it matches.
 type statusPayload struct {
 	OrderID   string `json:"order_id" binding:"required"`
-	CourierID string `json:"courier_id" binding:"required"`
+	CourierID string `json:"courier_id"`
+}
+
+// principalID reads a profile ID (courier_id/customer_id) placed in the context by
+// RequireAuth, writing the error response when it is missing or malformed.
+func principalID(c *gin.Context, key string) (uuid.UUID, bool) {
+	raw := c.GetString(key)
+	if raw == "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": key + " missing in context"})
+		return uuid.Nil, false
+	}
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + key + " in token"})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// statusCodeFor maps order service errors to HTTP status codes.
+func statusCodeFor(err error) int {
+	switch {
+	case errors.Is(err, orderpkg.ErrNotAssignedCourier), errors.Is(err, orderpkg.ErrNotOrderOwner):
+		return http.StatusForbidden
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// hubFrom returns the realtime hub attached to the request context, if any.
+func hubFrom(c *gin.Context) *realtime.Hub {
+	if v, exists := c.Get("hub"); exists {
+		if hub, ok := v.(*realtime.Hub); ok {
+			return hub
+		}
+	}
+	return nil
+}
+
+// Transition applies a courier-driven status change and sends the resulting notifications.
+// It backs both the REST status endpoints and the courier socket commands.
+func (h *OrderStatusHandler) Transition(ctx context.Context, hub *realtime.Hub, orderID, courierID uuid.UUID, target entity.OrderStatus) (*entity.Order, error) {
+	before := h.loadForAudit(ctx, orderID)
+	updated, err := h.svc.UpdateStatus(ctx, orderID, target, &courierID)
+	if err != nil {
+		return nil, err
+	}
+	h.auditOrder(ctx, audit.ActionOrderStatusChanged, before, updated)
+	// For decline: attempt immediate reassignment and avoid sending a 'declined' notification.
+	if target == entity.OrderDeclined && h.dispatch != nil {
+		if reassigned, _, err := h.dispatch.ReassignAfterDecline(ctx, orderID, courierID); err == nil && reassigned != nil {
+			// Use the updated state after reassignment (assigned or no_nearby_driver)
+			updated = reassigned
+		}
+		return updated, nil
+	}
+
+	// Notify customer about status change (single generic event) for non-decline states
+	if hub != nil {
+		payload := realtime.OrderStatusPayload{OrderID: updated.ID.String(), Status: string(updated.Status)}
+		// For accepted, picked_up, delivered include courier name + profile picture
+		if target == entity.OrderAccepted || target == entity.OrderPickedUp || target == entity.OrderDelivered {
+			if cour, err := h.couriers.GetCourierByID(ctx, courierID); err == nil {
+				if user, err := h.couriers.GetUserByID(ctx, cour.UserID); err == nil {
+					name := strings.TrimSpace(user.FirstName + " " + user.LastName)
+					payload.CourierName = &name
+					if user.ProfilePicture != nil {
+						payload.CourierProfilePicture = user.ProfilePicture
+					}
+				}
+			}
+		}
+		_ = hub.NotifyCustomer(updated.CustomerID.String(), "order.status", payload)
+	}
+	return updated, nil
+}
+
+// CancelAsCustomer cancels an order on behalf of its customer and notifies both parties.
+// Orders owned by another customer are rejected with order.ErrNotOrderOwner.
+func (h *OrderStatusHandler) CancelAsCustomer(ctx context.Context, hub *realtime.Hub, orderID, customerID uuid.UUID) (*entity.Order, error) {
+	before := h.loadForAudit(ctx, orderID)
+	updated, err := h.svc.CancelByCustomer(ctx, orderID, customerID)
+	if err != nil {
+		return nil, err
+	}
+	h.auditOrder(ctx, audit.ActionOrderCanceled, before, updated)
+	if hub != nil {
+		payload := realtime.OrderStatusPayload{OrderID: updated.ID.String(), Status: string(updated.Status)}
+		_ = hub.NotifyCustomer(updated.CustomerID.String(), "order.status", payload)
+		// Also notify assigned courier if still present (before clear assignment happened in service)
+		if updated.AssignedCourier != nil {
+			_ = hub.Notify(updated.AssignedCourier.String(), "order.status", payload)
+		}
+	}
+	return updated, nil
 }
 
 func (h *OrderStatusHandler) update(target entity.OrderStatus) gin.HandlerFunc {
@@ -48,49 +193,21 @@ func (h *OrderStatusHandler) update(target entity.OrderStatus) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order_id"})
 			return
 		}
-		cid, err := uuid.Parse(p.CourierID)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid courier_id"})
+		cid, ok := principalID(c, "courier_id")
+		if !ok {
+			return
+		}
+		if p.CourierID != "" && p.CourierID != cid.String() {
+			c.JSON(http.StatusForbidden, gin.H{"error": "courier_id does not match token"})
 			return
 		}
 		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 		defer cancel()
-		updated, err := h.svc.UpdateStatus(ctx, oid, target, &cid)
+		updated, err := h.Transition(auditContext(c, ctx), hubFrom(c), oid, cid, target)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
-		}
-		// For decline: attempt immediate reassignment and avoid sending a 'declined' notification.
-		if target == entity.OrderDeclined && h.dispatch != nil {
-			if reassigned, _, err := h.dispatch.ReassignAfterDecline(ctx, oid, cid); err == nil && reassigned != nil {
-				// Use the updated state after reassignment (assigned or no_nearby_driver)
-				updated = reassigned
-			}
-			c.JSON(http.StatusOK, updated)
+			c.JSON(statusCodeFor(err), gin.H{"error": err.Error()})
 			return
 		}
-
-		// Notify customer about status change (single generic event) for non-decline states
-		if v, exists := c.Get("hub"); exists {
-			if hub, ok := v.(*realtime.Hub); ok && hub != nil {
-				payload := realtime.OrderStatusPayload{OrderID: updated.ID.String(), Status: string(updated.Status)}
-				// For accepted, picked_up, delivered include courier name + phone + profile picture
-				if target == entity.OrderAccepted || target == entity.OrderPickedUp || target == entity.OrderDelivered {
-					if cour, err := h.couriers.GetCourierByID(ctx, cid); err == nil {
-						if user, err := h.couriers.GetUserByID(ctx, cour.UserID); err == nil {
-							name := strings.TrimSpace(user.FirstName + " " + user.LastName)
-							phone := user.Phone
-							payload.CourierName = &name
-							payload.CourierPhone = &phone
-							if user.ProfilePicture != nil {
-								payload.CourierProfilePicture = user.ProfilePicture
-							}
-						}
-					}
-				}
-				_ = hub.NotifyCustomer(updated.CustomerID.String(), "order.status", payload)
-			}
-		}
 		c.JSON(http.StatusOK, updated)
 	}
 }
@@ -101,7 +218,7 @@ func (h *OrderStatusHandler) Arrived() gin.HandlerFunc   { return h.update(entit
 func (h *OrderStatusHandler) Picked() gin.HandlerFunc    { return h.update(entity.OrderPickedUp) }
 func (h *OrderStatusHandler) Delivered() gin.HandlerFunc { return h.update(entity.OrderDelivered) }
 
-// CancelCustomer allows a customer to cancel an order.
+// CancelCustomer allows a customer to cancel one of their own orders.
 // Payload: {"order_id": "uuid"}
 func (h *OrderStatusHandler) CancelCustomer() gin.HandlerFunc {
 	type payload struct {
@@ -118,36 +235,26 @@ func (h *OrderStatusHandler) CancelCustomer() gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order_id"})
 			return
 		}
+		custID, ok := principalID(c, "customer_id")
+		if !ok {
+			return
+		}
 		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 		defer cancel()
-		updated, err := h.svc.CancelByCustomer(ctx, oid)
+		updated, err := h.CancelAsCustomer(auditContext(c, ctx), hubFrom(c), oid, custID)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			c.JSON(statusCodeFor(err), gin.H{"error": err.Error()})
 			return
 		}
-		if v, exists := c.Get("hub"); exists {
-			if hub, ok := v.(*realtime.Hub); ok && hub != nil {
-				payload := realtime.OrderStatusPayload{OrderID: updated.ID.String(), Status: string(updated.Status)}
-				_ = hub.NotifyCustomer(updated.CustomerID.String(), "order.status", payload)
-				// Also notify assigned courier if still present (before clear assignment happened in service)
-				if updated.AssignedCourier != nil {
-					_ = hub.Notify(updated.AssignedCourier.String(), "order.status", payload)
-				}
-			}
-		}
 		c.JSON(http.StatusOK, updated)
 	}
 }
 
-// CancelCourier allows the assigned courier to cancel an order.
-// Payload: {"order_id": "uuid", "courier_id": "uuid"}
+// CancelCourier allows the assigned courier (from the token) to cancel an order.
+// Payload: {"order_id": "uuid"}
 func (h *OrderStatusHandler) CancelCourier() gin.HandlerFunc {
-	type payload struct {
-		OrderID   string `json:"order_id" binding:"required"`
-		CourierID string `json:"courier_id" binding:"required"`
-	}
 	return func(c *gin.Context) {
-		var p payload
+		var p statusPayload
 		if err := c.ShouldBindJSON(&p); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload", "detail": err.Error()})
 			return
@@ -157,9 +264,12 @@ func (h *OrderStatusHandler) CancelCourier() gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order_id"})
 			return
 		}
-		cid, err := uuid.Parse(p.CourierID)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid courier_id"})
+		cid, ok := principalID(c, "courier_id")
+		if !ok {
+			return
+		}
+		if p.CourierID != "" && p.CourierID != cid.String() {
+			c.JSON(http.StatusForbidden, gin.H{"error": "courier_id does not match token"})
 			return
 		}
 		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
@@ -168,29 +278,31 @@ func (h *OrderStatusHandler) CancelCourier() gin.HandlerFunc {
 		// 1. Validate permission and state
 		ord, err := h.svc.GetOrder(ctx, oid)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			c.JSON(statusCodeFor(err), gin.H{"error": err.Error()})
 			return
 		}
 		if ord.AssignedCourier == nil || *ord.AssignedCourier != cid {
-			c.JSON(http.StatusForbidden, gin.H{"error": "forbidden: not assigned courier"})
+			c.JSON(http.StatusForbidden, gin.H{"error": orderpkg.ErrNotAssignedCourier.Error()})
 			return
 		}
 		if ord.Status == entity.OrderPickedUp || ord.Status == entity.OrderDelivered {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "cannot cancel order after pickup or delivery"})
+			c.JSON(http.StatusBadRequest, gin.H{"error": orderpkg.ErrCannotCancel.Error()})
 			return
 		}
-		if ord.Status == entity.OrderCanceledByCustomer || ord.Status == entity.OrderCanceledByCourier {
+		if ord.Status == entity.OrderCanceledByCustomer || ord.Status == entity.OrderCanceledByCourier || ord.Status == entity.OrderCanceledByAdmin {
 			c.JSON(http.StatusOK, ord)
 			return
 		}
 
 		// 2. Reassign (treat as decline/unassign)
+		actx := auditContext(c, ctx)
 		if h.dispatch != nil {
 			updated, _, err := h.dispatch.ReassignAfterDecline(ctx, oid, cid)
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reassign: " + err.Error()})
 				return
 			}
+			h.auditOrder(actx, audit.ActionOrderCanceled, ord, updated)
 			c.JSON(http.StatusOK, updated)
 			return
 		}
@@ -198,17 +310,16 @@ func (h *OrderStatusHandler) CancelCourier() gin.HandlerFunc {
 		// Fallback if dispatch is not wired
 		updated, err := h.svc.CancelByCourier(ctx, oid, cid)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			c.JSON(statusCodeFor(err), gin.H{"error": err.Error()})
 			return
 		}
-		if v, exists := c.Get("hub"); exists {
-			if hub, ok := v.(*realtime.Hub); ok && hub != nil {
-				payload := realtime.OrderStatusPayload{OrderID: updated.ID.String(), Status: string(updated.Status)}
-				_ = hub.NotifyCustomer(updated.CustomerID.String(), "order.status", payload)
-				// Also notify assigned courier (the canceling courier) so all connected devices stay in sync.
-				if updated.AssignedCourier != nil {
-					_ = hub.Notify(updated.AssignedCourier.String(), "order.status", payload)
-				}
+		h.auditOrder(actx, audit.ActionOrderCanceled, ord, updated)
+		if hub := hubFrom(c); hub != nil {
+			payload := realtime.OrderStatusPayload{OrderID: updated.ID.String(), Status: string(updated.Status)}
+			_ = hub.NotifyCustomer(updated.CustomerID.String(), "order.status", payload)
+			// Also notify assigned courier (the canceling courier) so all connected devices stay in sync.
+			if updated.AssignedCourier != nil {
+				_ = hub.Notify(updated.AssignedCourier.String(), "order.status", payload)
 			}
 		}
 		c.JSON(http.StatusOK, updated)
@@ -0,0 +1,214 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/entity"
+	orderpkg "github.com/mikios34/delivery-backend/order"
+	ordersvc "github.com/mikios34/delivery-backend/order/service"
+	"github.com/mikios34/delivery-backend/realtime/protocol"
+	"gorm.io/gorm"
+)
+
+// memOrders is an in-memory order.Repository covering what status changes use.
+type memOrders struct {
+	orderpkg.Repository
+	orders map[uuid.UUID]*entity.Order
+}
+
+func (r *memOrders) GetOrderByID(_ context.Context, id uuid.UUID) (*entity.Order, error) {
+	o, ok := r.orders[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	cp := *o
+	return &cp, nil
+}
+
+func (r *memOrders) UpdateOrderStatus(_ context.Context, id uuid.UUID, status entity.OrderStatus) error {
+	r.orders[id].Status = status
+	return nil
+}
+
+// statusFixture holds one order placed by owner and assigned to courier.
+type statusFixture struct {
+	repo     *memOrders
+	handler  *OrderStatusHandler
+	order    *entity.Order
+	owner    uuid.UUID
+	courier  uuid.UUID
+	stranger uuid.UUID
+}
+
+func newStatusFixture() *statusFixture {
+	f := &statusFixture{owner: uuid.New(), courier: uuid.New(), stranger: uuid.New()}
+	f.order = &entity.Order{ID: uuid.New(), CustomerID: f.owner, Status: entity.OrderAssigned, AssignedCourier: &f.courier}
+	f.repo = &memOrders{orders: map[uuid.UUID]*entity.Order{f.order.ID: f.order}}
+	f.handler = NewOrderStatusHandler(ordersvc.NewOrderService(f.repo, nil), nil)
+	return f
+}
+
+// serve runs one request through h with the token's profile id already in the context,
+// as RequireAuth would leave it.
+func serve(h gin.HandlerFunc, key string, id uuid.UUID, body string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/", func(c *gin.Context) { c.Set(key, id.String()) }, h)
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCourierStatusRequiresAssignedCourier(t *testing.T) {
+	tests := []struct {
+		name       string
+		as         func(f *statusFixture) uuid.UUID
+		body       func(f *statusFixture) string
+		wantCode   int
+		wantStatus entity.OrderStatus
+	}{
+		{
+			name:       "assigned courier",
+			as:         func(f *statusFixture) uuid.UUID { return f.courier },
+			body:       func(f *statusFixture) string { return `{"order_id":"` + f.order.ID.String() + `"}` },
+			wantCode:   http.StatusOK,
+			wantStatus: entity.OrderAccepted,
+		},
+		{
+			name:       "unassigned courier",
+			as:         func(f *statusFixture) uuid.UUID { return f.stranger },
+			body:       func(f *statusFixture) string { return `{"order_id":"` + f.order.ID.String() + `"}` },
+			wantCode:   http.StatusForbidden,
+			wantStatus: entity.OrderAssigned,
+		},
+		{
+			name: "body courier_id naming the assigned courier",
+			as:   func(f *statusFixture) uuid.UUID { return f.stranger },
+			body: func(f *statusFixture) string {
+				return `{"order_id":"` + f.order.ID.String() + `","courier_id":"` + f.courier.String() + `"}`
+			},
+			wantCode:   http.StatusForbidden,
+			wantStatus: entity.OrderAssigned,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newStatusFixture()
+			w := serve(f.handler.Accept(), "courier_id", tt.as(f), tt.body(f))
+			if w.Code != tt.wantCode {
+				t.Fatalf("code = %d, want %d (%s)", w.Code, tt.wantCode, w.Body)
+			}
+			if got := f.repo.orders[f.order.ID].Status; got != tt.wantStatus {
+				t.Errorf("status = %s, want %s", got, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestCustomerCancelRequiresOwner(t *testing.T) {
+	tests := []struct {
+		name       string
+		as         func(f *statusFixture) uuid.UUID
+		wantCode   int
+		wantStatus entity.OrderStatus
+	}{
+		{"owner", func(f *statusFixture) uuid.UUID { return f.owner }, http.StatusOK, entity.OrderCanceledByCustomer},
+		{"other customer", func(f *statusFixture) uuid.UUID { return f.stranger }, http.StatusForbidden, entity.OrderAssigned},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newStatusFixture()
+			w := serve(f.handler.CancelCustomer(), "customer_id", tt.as(f), `{"order_id":"`+f.order.ID.String()+`"}`)
+			if w.Code != tt.wantCode {
+				t.Fatalf("code = %d, want %d (%s)", w.Code, tt.wantCode, w.Body)
+			}
+			if got := f.repo.orders[f.order.ID].Status; got != tt.wantStatus {
+				t.Errorf("status = %s, want %s", got, tt.wantStatus)
+			}
+		})
+	}
+}
+
+// frameResult decodes a socket reply into its event and, for errors, the error code.
+func frameResult(t *testing.T, env *protocol.Envelope) (string, string) {
+	t.Helper()
+	if env == nil {
+		t.Fatal("no reply")
+	}
+	b, err := json.Marshal(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out struct {
+		Event string `json:"event"`
+		Data  struct {
+			Code string `json:"code"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatal(err)
+	}
+	return out.Event, out.Data.Code
+}
+
+func TestSocketCommandsCheckOwnership(t *testing.T) {
+	frame := func(event string, orderID uuid.UUID) []byte {
+		return []byte(`{"v":1,"event":"` + event + `","id":"1","data":{"order_id":"` + orderID.String() + `"}}`)
+	}
+	tests := []struct {
+		name      string
+		send      func(h *WSHandler, f *statusFixture) *protocol.Envelope
+		wantEvent string
+		wantCode  string
+	}{
+		{
+			name: "assigned courier accepts",
+			send: func(h *WSHandler, f *statusFixture) *protocol.Envelope {
+				return h.handleCourierFrame(context.Background(), f.courier.String(), frame(protocol.EventOrderAccept, f.order.ID))
+			},
+			wantEvent: protocol.EventAck,
+		},
+		{
+			name: "unassigned courier accepts",
+			send: func(h *WSHandler, f *statusFixture) *protocol.Envelope {
+				return h.handleCourierFrame(context.Background(), f.stranger.String(), frame(protocol.EventOrderAccept, f.order.ID))
+			},
+			wantEvent: protocol.EventError,
+			wantCode:  protocol.CodeForbidden,
+		},
+		{
+			name: "owner cancels",
+			send: func(h *WSHandler, f *statusFixture) *protocol.Envelope {
+				return h.handleCustomerFrame(context.Background(), f.owner.String(), frame(protocol.EventOrderCancel, f.order.ID))
+			},
+			wantEvent: protocol.EventAck,
+		},
+		{
+			name: "other customer cancels",
+			send: func(h *WSHandler, f *statusFixture) *protocol.Envelope {
+				return h.handleCustomerFrame(context.Background(), f.stranger.String(), frame(protocol.EventOrderCancel, f.order.ID))
+			},
+			wantEvent: protocol.EventError,
+			wantCode:  protocol.CodeForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newStatusFixture()
+			h := NewWSHandler(nil).WithOrderCommands(f.handler)
+			event, code := frameResult(t, tt.send(h, f))
+			if event != tt.wantEvent || code != tt.wantCode {
+				t.Errorf("reply = %s/%s, want %s/%s", event, code, tt.wantEvent, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parsePagination reads limit + either page (1-based) or offset (row offset), with the
+// same defaults as the history endpoints: limit=25, page=1, maxLimit=100.
+func parsePagination(c *gin.Context) (limit, offset, page int) {
+	const (
+		defaultLimit = 25
+		maxLimit     = 100
+	)
+	limit = defaultLimit
+	page = 1
+	if lStr := c.Query("limit"); lStr != "" {
+		if l, err := strconv.Atoi(lStr); err == nil && l > 0 {
+			limit = l
+		}
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+	if pStr := c.Query("page"); pStr != "" {
+		if p, err := strconv.Atoi(pStr); err == nil && p >= 1 {
+			page = p
+		}
+		offset = (page - 1) * limit
+	} else if oStr := c.Query("offset"); oStr != "" {
+		if o, err := strconv.Atoi(oStr); err == nil && o >= 0 {
+			offset = o
+			page = (offset / limit) + 1
+		}
+	}
+	return limit, offset, page
+}
+
+// pageMeta builds the pagination metadata returned next to list results.
+func pageMeta(total int64, limit, offset, page, returned int) gin.H {
+	totalPages := 0
+	if limit > 0 {
+		totalPages = int((total + int64(limit) - 1) / int64(limit))
+	}
+	return gin.H{
+		"count":       total,
+		"limit":       limit,
+		"offset":      offset,
+		"page":        page,
+		"total_pages": totalPages,
+		"has_more":    int64(offset+returned) < total,
+	}
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	fbAuth "firebase.google.com/go/auth"
+	"github.com/gin-gonic/gin"
+	"github.com/mikios34/delivery-backend/otp"
+)
+
+// PhoneVerifier checks that a registration's caller controls the phone number: with a
+// Firebase ID token whose phone_number claim is that number, or with an SMS one-time code.
+// Either method is unavailable (503) when its client is nil.
+type PhoneVerifier struct {
+	firebaseAuth *fbAuth.Client
+	otp          otp.Service
+}
+
+// NewPhoneVerifier constructs a PhoneVerifier; either argument may be nil.
+func NewPhoneVerifier(firebaseAuth *fbAuth.Client, otpSvc otp.Service) *PhoneVerifier {
+	return &PhoneVerifier{firebaseAuth: firebaseAuth, otp: otpSvc}
+}
+
+// phoneProof is the proof fields shared by the registration payloads.
+type phoneProof struct {
+	// IDToken is a Firebase ID token issued to the phone number being registered.
+	IDToken string `json:"id_token"`
+	// OTPCode is a code sent to the phone with POST /auth/otp/request.
+	OTPCode string `json:"otp_code"`
+	// FirebaseUID is no longer accepted on its own: anyone can send a UID.
+	FirebaseUID string `json:"firebase_uid"`
+}
+
+// samePhone compares numbers ignoring spaces, dashes and parentheses.
+func samePhone(a, b string) bool {
+	strip := strings.NewReplacer(" ", "", "-", "", "(", "", ")", "")
+	return a != "" && strip.Replace(strings.TrimSpace(a)) == strip.Replace(strings.TrimSpace(b))
+}
+
+// verify checks the proof for phone and returns the verified Firebase UID (empty for OTP
+// proofs). It writes the error response and returns false when the phone is not proven.
+func (v *PhoneVerifier) verify(c *gin.Context, phone string, p phoneProof) (string, bool) {
+	switch {
+	case p.IDToken != "":
+		if v == nil || v.firebaseAuth == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "firebase auth not configured"})
+			return "", false
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		token, err := v.firebaseAuth.VerifyIDToken(ctx, p.IDToken)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid firebase token", "detail": err.Error()})
+			return "", false
+		}
+		if claimed, _ := token.Claims["phone_number"].(string); !samePhone(claimed, phone) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "firebase token is not for this phone number", "code": "phone_not_verified"})
+			return "", false
+		}
+		return token.UID, true
+	case p.OTPCode != "":
+		if v == nil || v.otp == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "otp login not configured"})
+			return "", false
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		if err := v.otp.Verify(ctx, phone, p.OTPCode); err != nil {
+			writeOTPError(c, err)
+			return "", false
+		}
+		return "", true
+	case p.FirebaseUID != "":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "firebase_uid is not accepted; send the Firebase id_token instead", "code": "id_token_required"})
+		return "", false
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id_token or otp_code is required to prove the phone number", "code": "phone_not_verified"})
+		return "", false
+	}
+}
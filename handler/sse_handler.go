@@ -0,0 +1,103 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/realtime/protocol"
+)
+
+// sseHeartbeat keeps idle connections open through proxies that drop silent streams.
+const sseHeartbeat = 15 * time.Second
+
+// CustomerStream serves the customer's order events over Server-Sent Events, as a fallback for
+// clients whose network breaks WebSockets. It carries the same events as CustomerSocket and
+// supports resuming via the Last-Event-ID header (or last_event_id query param).
+func (h *WSHandler) CustomerStream() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		customerID := c.GetString("customer_id")
+		if customerID == "" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "customer_id missing in context"})
+			return
+		}
+		lastEventID := c.GetHeader("Last-Event-ID")
+		if lastEventID == "" {
+			lastEventID = c.Query("last_event_id")
+		}
+
+		stream, missed, cursor, resumed := h.hub.SubscribeCustomer(customerID, lastEventID)
+		defer h.hub.UnsubscribeCustomer(stream)
+
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		// Disable response buffering in nginx-style proxies.
+		c.Header("X-Accel-Buffering", "no")
+		c.Status(http.StatusOK)
+		w := c.Writer
+		fmt.Fprint(w, "retry: 3000\n\n")
+
+		if resumed {
+			for _, ev := range missed {
+				writeSSE(w, ev.ID, ev.Envelope)
+			}
+		} else if h.orders != nil {
+			// Fresh connection (or resume point lost): send the active orders snapshot first.
+			if id, err := uuid.Parse(customerID); err == nil {
+				ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+				list, err := h.orders.ListActiveOrdersForCustomer(ctx, id)
+				cancel()
+				if err == nil {
+					writeSSE(w, cursor, protocol.New("order.sync", orderSyncPayload{Orders: list}))
+				}
+			}
+		}
+		w.Flush()
+
+		heartbeat := time.NewTicker(sseHeartbeat)
+		defer heartbeat.Stop()
+		check := principalCheck(c)
+		revalidate := time.NewTicker(socketRevalidateEvery)
+		defer revalidate.Stop()
+		for {
+			select {
+			case <-c.Request.Context().Done():
+				return
+			case ev, ok := <-stream.C:
+				if !ok {
+					// Dropped by the hub for falling behind; the client reconnects and resumes.
+					return
+				}
+				writeSSE(w, ev.ID, ev.Envelope)
+				w.Flush()
+			case <-heartbeat.C:
+				fmt.Fprint(w, ": ping\n\n")
+				w.Flush()
+			case <-revalidate.C:
+				if perr := rejected(check); perr != nil {
+					writeSSE(w, "", protocol.Error("", "", perr.Code, perr.Message))
+					w.Flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// writeSSE writes one event frame; data is the same protocol envelope sent over WebSockets.
+func writeSSE(w io.Writer, id string, env protocol.Envelope) {
+	b, err := json.Marshal(env)
+	if err != nil {
+		return
+	}
+	if id != "" {
+		fmt.Fprintf(w, "id: %s\n", id)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", env.Event, b)
+}
@@ -0,0 +1,270 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/entity"
+	"github.com/mikios34/delivery-backend/support"
+	"gorm.io/gorm"
+)
+
+// SupportHandler lets customers and couriers open tickets about their orders and talk to support.
+type SupportHandler struct {
+	svc support.Service
+}
+
+// NewSupportHandler constructs a SupportHandler.
+func NewSupportHandler(svc support.Service) *SupportHandler {
+	return &SupportHandler{svc: svc}
+}
+
+// partyFrom derives the customer or courier acting on a ticket from the auth context.
+func partyFrom(c *gin.Context) (support.Party, bool) {
+	role := c.GetString("role")
+	var idStr string
+	switch role {
+	case support.RoleCustomer:
+		idStr = c.GetString("customer_id")
+	case support.RoleCourier:
+		idStr = c.GetString("courier_id")
+	}
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "profile id missing in context"})
+		return support.Party{}, false
+	}
+	return support.Party{Role: role, ID: id}, true
+}
+
+type openTicketPayload struct {
+	OrderID  uuid.UUID `json:"order_id" binding:"required"`
+	Category string    `json:"category" binding:"required"`
+	Subject  string    `json:"subject"`
+	Body     string    `json:"body"`
+}
+
+// Open starts a ticket about one of the caller's orders. An opener has at most one
+// unresolved ticket per order; a second attempt returns 409 with its id.
+// POST /api/v1/{customer|courier}/tickets { order_id, category, subject?, body }
+func (h *SupportHandler) Open() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		who, ok := partyFrom(c)
+		if !ok {
+			return
+		}
+		var p openTicketPayload
+		if err := c.ShouldBindJSON(&p); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload", "detail": err.Error()})
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		t, err := h.svc.Open(ctx, support.OpenRequest{
+			Party: who, OrderID: p.OrderID, Category: entity.TicketCategory(p.Category), Subject: p.Subject, Body: p.Body,
+		})
+		if errors.Is(err, support.ErrTicketExists) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "ticket_id": t.ID})
+			return
+		}
+		if err != nil {
+			writeSupportError(c, "failed to open ticket", err)
+			return
+		}
+		c.JSON(http.StatusCreated, t)
+	}
+}
+
+// List pages the caller's tickets, most recently active first.
+// GET /api/v1/{customer|courier}/tickets?status=&limit=&page=
+func (h *SupportHandler) List() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		who, ok := partyFrom(c)
+		if !ok {
+			return
+		}
+		limit, offset, page := parsePagination(c)
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		list, total, err := h.svc.List(ctx, support.TicketFilter{
+			Status: entity.TicketStatus(c.Query("status")), OpenerRole: who.Role, OpenerID: &who.ID, Limit: limit, Offset: offset,
+		})
+		if err != nil {
+			writeSupportError(c, "failed to list tickets", err)
+			return
+		}
+		resp := pageMeta(total, limit, offset, page, len(list))
+		resp["tickets"] = list
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// Get returns one of the caller's tickets with its messages and adjustments.
+// GET /api/v1/{customer|courier}/tickets/:id
+func (h *SupportHandler) Get() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		who, ok := partyFrom(c)
+		if !ok {
+			return
+		}
+		getTicket(c, h.svc, who)
+	}
+}
+
+// Reply adds a message to one of the caller's tickets.
+// POST /api/v1/{customer|courier}/tickets/:id/messages { body }
+func (h *SupportHandler) Reply() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		who, ok := partyFrom(c)
+		if !ok {
+			return
+		}
+		replyTicket(c, h.svc, who)
+	}
+}
+
+// AttachImage adds a message carrying a JPEG or PNG image (up to 10 MB).
+// POST /api/v1/{customer|courier}/tickets/:id/images (multipart: file, caption?)
+func (h *SupportHandler) AttachImage() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		who, ok := partyFrom(c)
+		if !ok {
+			return
+		}
+		id, ok := ticketIDParam(c)
+		if !ok {
+			return
+		}
+		fh, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "file is required", "detail": err.Error()})
+			return
+		}
+		f, err := fh.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unreadable file", "detail": err.Error()})
+			return
+		}
+		defer f.Close()
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+		defer cancel()
+		m, err := h.svc.AttachImage(ctx, id, who, support.ImageUpload{
+			FileName: fh.Filename, Size: fh.Size, Content: f, Caption: c.PostForm("caption"),
+		})
+		if err != nil {
+			writeSupportError(c, "failed to attach image", err)
+			return
+		}
+		c.JSON(http.StatusCreated, m)
+	}
+}
+
+// Attachment streams an image from one of the caller's tickets.
+// GET /api/v1/{customer|courier}/tickets/:id/attachments/:attachmentId
+func (h *SupportHandler) Attachment() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		who, ok := partyFrom(c)
+		if !ok {
+			return
+		}
+		streamAttachment(c, h.svc, who)
+	}
+}
+
+func getTicket(c *gin.Context, svc support.Service, who support.Party) {
+	id, ok := ticketIDParam(c)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	d, err := svc.Get(ctx, id, who)
+	if err != nil {
+		writeSupportError(c, "failed to load ticket", err)
+		return
+	}
+	c.JSON(http.StatusOK, d)
+}
+
+func replyTicket(c *gin.Context, svc support.Service, who support.Party) {
+	id, ok := ticketIDParam(c)
+	if !ok {
+		return
+	}
+	var p struct {
+		Body string `json:"body"`
+	}
+	if err := c.ShouldBindJSON(&p); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload", "detail": err.Error()})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	m, err := svc.Reply(ctx, id, who, p.Body)
+	if err != nil {
+		writeSupportError(c, "failed to send message", err)
+		return
+	}
+	c.JSON(http.StatusCreated, m)
+}
+
+func streamAttachment(c *gin.Context, svc support.Service, who support.Party) {
+	id, ok := ticketIDParam(c)
+	if !ok {
+		return
+	}
+	attID, err := uuid.Parse(c.Param("attachmentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid attachment id"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+	att, rc, err := svc.OpenAttachment(ctx, id, attID, who)
+	if err != nil {
+		writeSupportError(c, "failed to open attachment", err)
+		return
+	}
+	defer rc.Close()
+	if att.FileName != "" {
+		c.Header("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": att.FileName}))
+	}
+	c.Header("Cache-Control", "private, no-store")
+	c.DataFromReader(http.StatusOK, att.SizeBytes, att.ContentType, rc, nil)
+}
+
+func ticketIDParam(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ticket id"})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func writeSupportError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+	case errors.Is(err, support.ErrNotOrderParty):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, support.ErrTicketExists), errors.Is(err, support.ErrTicketResolved),
+		errors.Is(err, support.ErrRefundExceedsPrice), errors.Is(err, support.ErrOrderNotServerPriced):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, support.ErrInvalidCategory), errors.Is(err, support.ErrInvalidBody),
+		errors.Is(err, support.ErrResolutionRequired), errors.Is(err, support.ErrInvalidAssignee),
+		errors.Is(err, support.ErrUnsupportedImage), errors.Is(err, support.ErrInvalidAdjustment),
+		errors.Is(err, support.ErrReasonRequired):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, support.ErrStoreUnavailable):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg, "detail": err.Error()})
+	}
+}
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/entity"
+	orderpkg "github.com/mikios34/delivery-backend/order"
+	"github.com/mikios34/delivery-backend/realtime"
+	"github.com/mikios34/delivery-backend/realtime/protocol"
+	"github.com/mikios34/delivery-backend/tracking"
+)
+
+// TrackingHandler serves shareable tracking links for order receivers.
+type TrackingHandler struct {
+	links  tracking.LinkService
+	orders orderpkg.Repository
+	hub    *realtime.Hub
+}
+
+// NewTrackingHandler constructs a TrackingHandler.
+func NewTrackingHandler(links tracking.LinkService, orders orderpkg.Repository, hub *realtime.Hub) *TrackingHandler {
+	return &TrackingHandler{links: links, orders: orders, hub: hub}
+}
+
+// CreateLink issues a public tracking link for one of the customer's orders.
+// POST /api/v1/customer/orders/:id/tracking-link
+func (h *TrackingHandler) CreateLink() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		customerID := c.GetString("customer_id")
+		if customerID == "" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "customer_id missing in context"})
+			return
+		}
+		oid, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order id"})
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		ord, err := h.orders.GetOrderByID(ctx, oid)
+		if err != nil || ord.CustomerID.String() != customerID {
+			c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+			return
+		}
+		token, link, err := h.links.CreateLink(ctx, oid)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create tracking link", "detail": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{
+			"token":        token,
+			"tracking_url": "/track/" + token,
+			"expires_at":   link.ExpiresAt,
+		})
+	}
+}
+
+// View returns the public tracking view for a token (no auth).
+// GET /track/:token
+func (h *TrackingHandler) View() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		link, ok := h.resolve(ctx, c)
+		if !ok {
+			return
+		}
+		view, err := h.links.View(ctx, link)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load tracking view"})
+			return
+		}
+		c.Header("Cache-Control", "no-store")
+		c.JSON(http.StatusOK, view)
+	}
+}
+
+// Stream pushes "tracking.update" events over SSE whenever the order or courier position changes.
+// The stream ends once the order is completed or the link expires.
+// GET /track/:token/stream
+func (h *TrackingHandler) Stream() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		link, ok := h.resolve(c.Request.Context(), c)
+		if !ok {
+			return
+		}
+		changed, stop := h.hub.WatchOrder(link.OrderID.String())
+		defer stop()
+
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Header("X-Accel-Buffering", "no")
+		c.Status(http.StatusOK)
+		w := c.Writer
+		fmt.Fprint(w, "retry: 5000\n\n")
+
+		var last []byte
+		// push sends the current view if it differs from the last one and reports whether
+		// the stream should continue.
+		push := func() bool {
+			ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+			defer cancel()
+			view, err := h.links.View(ctx, link)
+			if err != nil {
+				return true
+			}
+			b, _ := json.Marshal(view)
+			if !bytes.Equal(b, last) {
+				last = b
+				writeSSE(w, "", protocol.New("tracking.update", view))
+				w.Flush()
+			}
+			return !isFinalStatus(view.Status)
+		}
+		if !push() {
+			return
+		}
+
+		expiry := time.NewTimer(time.Until(link.ExpiresAt))
+		defer expiry.Stop()
+		heartbeat := time.NewTicker(sseHeartbeat)
+		defer heartbeat.Stop()
+		for {
+			select {
+			case <-c.Request.Context().Done():
+				return
+			case <-expiry.C:
+				return
+			case <-changed:
+				if !push() {
+					return
+				}
+			case <-heartbeat.C:
+				fmt.Fprint(w, ": ping\n\n")
+				w.Flush()
+			}
+		}
+	}
+}
+
+// resolve maps the :token param to a link, writing the error response when it is not usable.
+func (h *TrackingHandler) resolve(ctx context.Context, c *gin.Context) (*entity.OrderTrackingLink, bool) {
+	link, err := h.links.Resolve(ctx, c.Param("token"))
+	switch {
+	case err == nil:
+		return link, true
+	case errors.Is(err, tracking.ErrLinkNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, tracking.ErrLinkExpired):
+		c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to resolve tracking link"})
+	}
+	return nil, false
+}
+
+// isFinalStatus reports whether no further tracking updates can happen for the order.
+func isFinalStatus(status entity.OrderStatus) bool {
+	switch status {
+	case entity.OrderDelivered, entity.OrderCanceledByCustomer, entity.OrderCanceledByCourier, entity.OrderCanceledByAdmin, entity.OrderNoNearbyDriver:
+		return true
+	}
+	return false
+}
@@ -3,41 +3,81 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
+	authpkg "github.com/mikios34/delivery-backend/auth"
 	"github.com/mikios34/delivery-backend/entity"
+	orderpkg "github.com/mikios34/delivery-backend/order"
 	"github.com/mikios34/delivery-backend/realtime"
+	"github.com/mikios34/delivery-backend/realtime/protocol"
+	"github.com/mikios34/delivery-backend/tracking"
+	"gorm.io/gorm"
 )
 
 var upgrader = websocket.Upgrader{CheckOrigin: func(r *http.Request) bool { return true }}
 
+// socketRevalidateEvery is how often an open socket's principal is re-checked, so revoked
+// sessions and deactivated or downgraded profiles lose the socket too.
+const socketRevalidateEvery = 30 * time.Second
+
+// orderCommands is implemented by OrderStatusHandler so socket commands run the same
+// logic (and send the same notifications) as the REST status endpoints.
+type orderCommands interface {
+	Transition(ctx context.Context, hub *realtime.Hub, orderID, courierID uuid.UUID, target entity.OrderStatus) (*entity.Order, error)
+	CancelAsCustomer(ctx context.Context, hub *realtime.Hub, orderID, customerID uuid.UUID) (*entity.Order, error)
+}
+
+// courierCommandTargets maps courier socket commands to the order status they request.
+var courierCommandTargets = map[string]entity.OrderStatus{
+	protocol.EventOrderAccept:  entity.OrderAccepted,
+	protocol.EventOrderDecline: entity.OrderDeclined,
+	protocol.EventOrderArrive:  entity.OrderArrived,
+	protocol.EventOrderPickup:  entity.OrderPickedUp,
+	protocol.EventOrderDeliver: entity.OrderDelivered,
+}
+
+// orderSyncPayload is the active orders snapshot sent when a customer connects.
+type orderSyncPayload struct {
+	Orders []entity.Order `json:"orders"`
+}
+
 type WSHandler struct {
 	hub               *realtime.Hub
-	onCourierLocation func(courierID string, lat, lng *float64)
+	onCourierLocation func(courierID string, loc tracking.LocationUpdate)
 	orders            interface { // minimal interface to avoid import cycle
+		GetOrderByID(ctx context.Context, id uuid.UUID) (*entity.Order, error)
 		ListActiveOrdersForCustomer(ctx context.Context, customerID uuid.UUID) ([]entity.Order, error)
 	}
+	commands orderCommands
 }
 
 func NewWSHandler(hub *realtime.Hub) *WSHandler { return &WSHandler{hub: hub} }
 
-func (h *WSHandler) WithCourierLocationHandler(fn func(courierID string, lat, lng *float64)) *WSHandler {
+func (h *WSHandler) WithCourierLocationHandler(fn func(courierID string, loc tracking.LocationUpdate)) *WSHandler {
 	h.onCourierLocation = fn
 	return h
 }
 
 // WithOrders wires an orders repository for initial sync on customer connect.
 func (h *WSHandler) WithOrders(orders interface {
+	GetOrderByID(ctx context.Context, id uuid.UUID) (*entity.Order, error)
 	ListActiveOrdersForCustomer(ctx context.Context, customerID uuid.UUID) ([]entity.Order, error)
 }) *WSHandler {
 	h.orders = orders
 	return h
 }
 
+// WithOrderCommands enables inbound order commands over the sockets.
+func (h *WSHandler) WithOrderCommands(cmds orderCommands) *WSHandler {
+	h.commands = cmds
+	return h
+}
+
 // CourierSocket upgrades to WS and registers the courier connection.
 func (h *WSHandler) CourierSocket() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -52,6 +92,16 @@ func (h *WSHandler) CourierSocket() gin.HandlerFunc {
 			return
 		}
 		h.hub.RegisterCourier(courierID, conn)
+		// Commands outlive the upgrade request; keep only the caller identity for auditing.
+		base := auditContext(c, context.Background())
+		check := principalCheck(c)
+		revoke := func(perr *authpkg.PrincipalError) {
+			_ = h.hub.SendCourier(courierID, protocol.Error("", "", perr.Code, perr.Message))
+			conn.Close()
+		}
+		done := make(chan struct{})
+		defer close(done)
+		go watchPrincipal(check, done, revoke)
 		// read loop: handle incoming events
 		for {
 			_, data, err := conn.ReadMessage()
@@ -59,29 +109,65 @@ func (h *WSHandler) CourierSocket() gin.HandlerFunc {
 				h.hub.UnregisterCourier(courierID)
 				break
 			}
-			var msg struct {
-				Event string          `json:"event"`
-				Data  json.RawMessage `json:"data"`
-			}
-			if err := json.Unmarshal(data, &msg); err != nil {
+			if perr := rejected(check); perr != nil {
+				revoke(perr)
 				continue
 			}
-			switch msg.Event {
-			case "location.update":
-				var p struct {
-					Latitude  *float64 `json:"latitude"`
-					Longitude *float64 `json:"longitude"`
-				}
-				if err := json.Unmarshal(msg.Data, &p); err == nil && h.onCourierLocation != nil {
-					h.onCourierLocation(courierID, p.Latitude, p.Longitude)
-				}
-			default:
-				// ignore
+			if reply := h.handleCourierFrame(base, courierID, data); reply != nil {
+				_ = h.hub.SendCourier(courierID, *reply)
 			}
 		}
 	}
 }
 
+// handleCourierFrame executes one inbound courier frame and returns the reply to send, if any.
+func (h *WSHandler) handleCourierFrame(base context.Context, courierID string, data []byte) *protocol.Envelope {
+	in, err := protocol.Decode(data)
+	if err != nil {
+		return decodeError(in, err)
+	}
+	switch in.Event {
+	case protocol.EventLocationUpdate:
+		var p tracking.LocationUpdate
+		if err := json.Unmarshal(in.Data, &p); err != nil {
+			return replyError(in, protocol.CodeBadRequest, "invalid location payload")
+		}
+		if h.onCourierLocation != nil {
+			h.onCourierLocation(courierID, p)
+		}
+		// Location updates are high-frequency; only acknowledge when the client asks for it.
+		if in.ID == "" {
+			return nil
+		}
+		ack := protocol.Ack(in.ID, in.Event, nil)
+		return &ack
+	}
+
+	target, ok := courierCommandTargets[in.Event]
+	if !ok {
+		return replyError(in, protocol.CodeUnknownEvent, "unknown event "+in.Event)
+	}
+	if h.commands == nil {
+		return replyError(in, protocol.CodeInternal, "order commands not configured")
+	}
+	cid, err := uuid.Parse(courierID)
+	if err != nil {
+		return replyError(in, protocol.CodeForbidden, "invalid courier_id in token")
+	}
+	oid, errEnv := parseOrderCommand(in)
+	if errEnv != nil {
+		return errEnv
+	}
+	ctx, cancel := context.WithTimeout(base, 10*time.Second)
+	defer cancel()
+	updated, err := h.commands.Transition(ctx, h.hub, oid, cid, target)
+	if err != nil {
+		return commandError(in, err)
+	}
+	ack := protocol.Ack(in.ID, in.Event, updated)
+	return &ack
+}
+
 // CustomerSocket upgrades to WS and registers the customer connection.
 func (h *WSHandler) CustomerSocket() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -95,27 +181,153 @@ func (h *WSHandler) CustomerSocket() gin.HandlerFunc {
 			return
 		}
 		h.hub.RegisterCustomer(customerID, conn)
+		base := auditContext(c, context.Background())
+		check := principalCheck(c)
+		revoke := func(perr *authpkg.PrincipalError) {
+			_ = h.hub.SendCustomer(customerID, protocol.Error("", "", perr.Code, perr.Message))
+			conn.Close()
+		}
+		done := make(chan struct{})
+		defer close(done)
+		go watchPrincipal(check, done, revoke)
 		// On connect, push current active orders snapshot if repository is available
 		if h.orders != nil {
-			// lazy imports to avoid tight coupling
-			type snapshot struct {
-				Orders []entity.Order `json:"orders"`
-			}
 			if id, err := uuid.Parse(customerID); err == nil {
 				// give a short-lived context
 				ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 				defer cancel()
 				if list, err := h.orders.ListActiveOrdersForCustomer(ctx, id); err == nil {
-					_ = h.hub.NotifyCustomer(customerID, "order.sync", snapshot{Orders: list})
+					_ = h.hub.NotifyCustomer(customerID, "order.sync", orderSyncPayload{Orders: list})
 				}
 			}
 		}
-		// Currently, no inbound customer events are expected; maintain connection until closed.
+		// Maintain connection until closed, answering inbound commands.
 		for {
-			if _, _, err := conn.ReadMessage(); err != nil {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
 				h.hub.UnregisterCustomer(customerID)
 				break
 			}
+			if perr := rejected(check); perr != nil {
+				revoke(perr)
+				continue
+			}
+			if reply := h.handleCustomerFrame(base, customerID, data); reply != nil {
+				_ = h.hub.SendCustomer(customerID, *reply)
+			}
 		}
 	}
 }
+
+// handleCustomerFrame executes one inbound customer frame and returns the reply to send.
+func (h *WSHandler) handleCustomerFrame(base context.Context, customerID string, data []byte) *protocol.Envelope {
+	in, err := protocol.Decode(data)
+	if err != nil {
+		return decodeError(in, err)
+	}
+	if in.Event != protocol.EventOrderCancel {
+		return replyError(in, protocol.CodeUnknownEvent, "unknown event "+in.Event)
+	}
+	if h.commands == nil {
+		return replyError(in, protocol.CodeInternal, "order commands not configured")
+	}
+	oid, errEnv := parseOrderCommand(in)
+	if errEnv != nil {
+		return errEnv
+	}
+	cid, err := uuid.Parse(customerID)
+	if err != nil {
+		return replyError(in, protocol.CodeForbidden, "invalid customer_id in token")
+	}
+	ctx, cancel := context.WithTimeout(base, 10*time.Second)
+	defer cancel()
+	// The socket is bound to a single customer; the service rejects orders they don't own.
+	updated, err := h.commands.CancelAsCustomer(ctx, h.hub, oid, cid)
+	if err != nil {
+		return commandError(in, err)
+	}
+	ack := protocol.Ack(in.ID, in.Event, updated)
+	return &ack
+}
+
+// principalCheck returns the principal re-validation RequireAuth left on the request, or
+// nil when it runs without a validator.
+func principalCheck(c *gin.Context) func(context.Context) error {
+	v, _ := c.Get("principal_check")
+	check, _ := v.(func(context.Context) error)
+	return check
+}
+
+// watchPrincipal re-runs check every socketRevalidateEvery until done is closed, and
+// calls revoke once when the principal is rejected.
+func watchPrincipal(check func(context.Context) error, done <-chan struct{}, revoke func(*authpkg.PrincipalError)) {
+	if check == nil {
+		return
+	}
+	t := time.NewTicker(socketRevalidateEvery)
+	defer t.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-t.C:
+			if perr := rejected(check); perr != nil {
+				revoke(perr)
+				return
+			}
+		}
+	}
+}
+
+// rejected runs check and returns the rejection, if any. Failures of the check itself
+// (e.g. the database being unreachable) keep the socket open.
+func rejected(check func(context.Context) error) *authpkg.PrincipalError {
+	if check == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var perr *authpkg.PrincipalError
+	if errors.As(check(ctx), &perr) {
+		return perr
+	}
+	return nil
+}
+
+func parseOrderCommand(in *protocol.Inbound) (uuid.UUID, *protocol.Envelope) {
+	var p protocol.OrderCommand
+	if err := json.Unmarshal(in.Data, &p); err != nil {
+		return uuid.Nil, replyError(in, protocol.CodeBadRequest, "invalid request payload")
+	}
+	oid, err := uuid.Parse(p.OrderID)
+	if err != nil {
+		return uuid.Nil, replyError(in, protocol.CodeBadRequest, "invalid order_id")
+	}
+	return oid, nil
+}
+
+func replyError(in *protocol.Inbound, code, message string) *protocol.Envelope {
+	env := protocol.Error(in.ID, in.Event, code, message)
+	return &env
+}
+
+func decodeError(in *protocol.Inbound, err error) *protocol.Envelope {
+	if errors.Is(err, protocol.ErrUnsupportedVersion) {
+		return replyError(in, protocol.CodeUnsupportedVersion, err.Error())
+	}
+	return replyError(&protocol.Inbound{}, protocol.CodeBadRequest, "malformed frame")
+}
+
+// commandError maps service errors onto protocol error codes.
+func commandError(in *protocol.Inbound, err error) *protocol.Envelope {
+	switch {
+	case errors.Is(err, orderpkg.ErrNotAssignedCourier), errors.Is(err, orderpkg.ErrNotOrderOwner):
+		return replyError(in, protocol.CodeForbidden, err.Error())
+	case errors.Is(err, orderpkg.ErrCannotCancel):
+		return replyError(in, protocol.CodeInvalidState, err.Error())
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return replyError(in, protocol.CodeBadRequest, "order not found")
+	default:
+		return replyError(in, protocol.CodeInternal, err.Error())
+	}
+}
@@ -2,37 +2,104 @@ package main
 
 import (
 	"context"
+	"log"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	accountrepo "github.com/mikios34/delivery-backend/account/repository"
+	accountsvc "github.com/mikios34/delivery-backend/account/service"
+	adminpkg "github.com/mikios34/delivery-backend/admin"
 	adminrepo "github.com/mikios34/delivery-backend/admin/repository"
 	adminsvc "github.com/mikios34/delivery-backend/admin/service"
+	analyticsrepo "github.com/mikios34/delivery-backend/analytics/repository"
+	analyticssvc "github.com/mikios34/delivery-backend/analytics/service"
+	announcementrepo "github.com/mikios34/delivery-backend/announcement/repository"
+	announcementsvc "github.com/mikios34/delivery-backend/announcement/service"
+	auditrepo "github.com/mikios34/delivery-backend/audit/repository"
+	auditsvc "github.com/mikios34/delivery-backend/audit/service"
 	authpkg "github.com/mikios34/delivery-backend/auth"
 	authrepo "github.com/mikios34/delivery-backend/auth/repository"
 	authsvc "github.com/mikios34/delivery-backend/auth/service"
+	"github.com/mikios34/delivery-backend/blob"
+	catalogrepo "github.com/mikios34/delivery-backend/catalog/repository"
+	catalogsvc "github.com/mikios34/delivery-backend/catalog/service"
+	chatrepo "github.com/mikios34/delivery-backend/chat/repository"
+	chatsvc "github.com/mikios34/delivery-backend/chat/service"
 	courierrepo "github.com/mikios34/delivery-backend/courier/repository"
 	couriersvc "github.com/mikios34/delivery-backend/courier/service"
 	customerrepo "github.com/mikios34/delivery-backend/customer/repository"
 	customersvc "github.com/mikios34/delivery-backend/customer/service"
+	"github.com/mikios34/delivery-backend/dashboard"
+	dashboardrepo "github.com/mikios34/delivery-backend/dashboard/repository"
+	dashboardsvc "github.com/mikios34/delivery-backend/dashboard/service"
 	dispatchsvc "github.com/mikios34/delivery-backend/dispatch"
 	api "github.com/mikios34/delivery-backend/handler"
 	mw "github.com/mikios34/delivery-backend/middleware"
 	orderrepo "github.com/mikios34/delivery-backend/order/repository"
 	ordersvc "github.com/mikios34/delivery-backend/order/service"
+	orderimportrepo "github.com/mikios34/delivery-backend/orderimport/repository"
+	orderimportsvc "github.com/mikios34/delivery-backend/orderimport/service"
+	"github.com/mikios34/delivery-backend/otp"
+	otprepo "github.com/mikios34/delivery-backend/otp/repository"
+	otpsvc "github.com/mikios34/delivery-backend/otp/service"
+	"github.com/mikios34/delivery-backend/pricing"
+	"github.com/mikios34/delivery-backend/ratelimit"
+	ratelimitrepo "github.com/mikios34/delivery-backend/ratelimit/repository"
 	realtime "github.com/mikios34/delivery-backend/realtime"
+	"github.com/mikios34/delivery-backend/sms"
+	supportrepo "github.com/mikios34/delivery-backend/support/repository"
+	supportsvc "github.com/mikios34/delivery-backend/support/service"
+	"github.com/mikios34/delivery-backend/tracking"
+	trackingrepo "github.com/mikios34/delivery-backend/tracking/repository"
 )
 
 func main() {
+	// one-off maintenance commands
+	if len(os.Args) > 1 && os.Args[1] == "bootstrap-admin" {
+		runBootstrapAdmin(os.Args[2:])
+		return
+	}
+
+	// JWT keys: refuse to start without real keys outside dev mode
+	keyset, err := authpkg.KeysetFromEnv()
+	if err != nil {
+		log.Fatal("jwt keyset: ", err)
+	}
+	authpkg.SetDefaultKeyset(keyset)
+	keyset.WatchFile(5 * time.Minute)
 
 	db := setupDatabase()
 
 	r := gin.Default()
+	// client IPs (rate limits, audit, sessions) only honor X-Forwarded-For from the proxies
+	// listed in TRUSTED_PROXIES (comma-separated IPs/CIDRs); none by default
+	var trustedProxies []string
+	for _, p := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			trustedProxies = append(trustedProxies, p)
+		}
+	}
+	if err := r.SetTrustedProxies(trustedProxies); err != nil {
+		log.Fatal("trusted proxies: ", err)
+	}
 	// setup driver repository + service (impl-style constructors)
 
+	// uploaded files (courier verification documents) on local disk under BLOB_DIR
+	blobDir := os.Getenv("BLOB_DIR")
+	if blobDir == "" {
+		blobDir = "./data/blobs"
+	}
+	blobs, err := blob.NewFileStore(blobDir)
+	if err != nil {
+		log.Fatal("blob store: ", err)
+	}
+
 	// setup courier repository + service
 	courierRepo := courierrepo.NewGormCourierRepo(db)
-	courierService := couriersvc.NewCourierService(courierRepo)
+	courierService := couriersvc.NewCourierService(courierRepo, blobs)
 	courierHandler := api.NewCourierHandler(courierService)
 
 	// setup customer repository + service
@@ -45,36 +112,114 @@ func main() {
 	adminService := adminsvc.NewAdminService(adminRepo)
 	adminHandler := api.NewAdminHandler(adminService)
 
+	// audit trail of privileged and state-changing actions
+	auditService := auditsvc.NewAuditService(auditrepo.NewGormAuditRepo(db))
+	auditHandler := api.NewAuditHandler(auditService)
+	adminHandler = adminHandler.WithAudit(auditService)
+
+	// personal data export and account deletion (anonymized after a cooling-off period)
+	accountService := accountsvc.NewAccountService(accountrepo.NewGormAccountRepo(db), blobs)
+	accountHandler := api.NewAccountHandler(accountService).WithAudit(auditService)
+
 	// setup auth repository + service
 	authRepo := authrepo.NewGormAuthRepo(db)
 	authService := authsvc.NewAuthService(authRepo)
 	authHandler := api.NewAuthHandler(authService)
 	// Initialize Firebase Admin (optional). If not configured, exchange endpoint will be unavailable.
-	if fbClient, err := authpkg.InitFirebaseAuth(context.Background()); err == nil {
-		authHandler = authHandler.WithFirebaseAuth(fbClient)
+	fbClient, err := authpkg.InitFirebaseAuth(context.Background())
+	if err != nil {
+		log.Println("firebase auth:", err)
+	}
+	authHandler = authHandler.WithFirebaseAuth(fbClient)
+	// SMS one-time codes for phone login. Only the logging sender exists so far, and it
+	// writes codes to the log, so OTP login is off (503) outside dev mode.
+	var otpService otp.Service
+	if authpkg.IsDevMode() {
+		otpService = otpsvc.NewOTPService(otprepo.NewGormOTPRepo(db), sms.NewLogSender())
+		authHandler = authHandler.WithOTP(otpService)
+	} else {
+		log.Println("otp: no SMS provider configured; phone (OTP) login is disabled")
 	}
 
+	// registration endpoints prove the phone (Firebase ID token or OTP) and issue tokens
+	// through the auth service (session tracking)
+	phoneVerifier := api.NewPhoneVerifier(fbClient, otpService)
+	courierHandler = courierHandler.WithAuth(authService).WithPhoneVerifier(phoneVerifier)
+	customerHandler = customerHandler.WithAuth(authService).WithPhoneVerifier(phoneVerifier)
+
+	// every authenticated request re-checks the principal (cached briefly) so deactivated
+	// profiles and revoked tokens lose access before their JWT expires; requests made with
+	// impersonation tokens are audited
+	requireAuth := mw.RequireAuth(
+		mw.WithPrincipalValidator(authpkg.NewPrincipalValidator(authRepo, 15*time.Second)),
+		mw.WithImpersonationAudit(auditService),
+	)
+	// support impersonation of customers and couriers (read-only unless granted write)
+	impersonationHandler := api.NewAdminImpersonationHandler(authService).WithAudit(auditService)
+	denyImpersonation := mw.DenyImpersonation()
+
 	// setup realtime hub
 	hub := realtime.NewHub()
-	wsHandler := api.NewWSHandler(hub).WithCourierLocationHandler(func(courierID string, lat, lng *float64) {
-		if id, err := uuid.Parse(courierID); err == nil {
-			_ = courierService.UpdateLocation(context.Background(), id, lat, lng)
-		}
-	})
 
+	// fares from vehicle type pricing and road routes (OSRM_BASE_URL, public OSRM by default)
+	pricingService := pricing.New(os.Getenv("OSRM_BASE_URL"))
 	// setup order repository + service
 	orderRepo := orderrepo.NewGormOrderRepo(db)
-	orderService := ordersvc.NewOrderService(orderRepo)
+	orderService := ordersvc.NewOrderService(orderRepo, pricingService)
+	// live courier location forwarding to customers
+	trackingService := tracking.New(orderRepo, hub)
+	wsHandler := api.NewWSHandler(hub).WithCourierLocationHandler(func(courierID string, loc tracking.LocationUpdate) {
+		if id, err := uuid.Parse(courierID); err == nil {
+			ctx := context.Background()
+			if err := courierService.UpdateLocation(ctx, id, loc.Latitude, loc.Longitude); err == nil {
+				_ = trackingService.CourierMoved(ctx, id, loc)
+				hub.CourierChanged(courierID)
+			}
+		}
+	})
 	// setup dispatch service (with hub for notifications)
 	dispatchService := dispatchsvc.New(orderRepo, courierRepo, hub)
 	// Inject repos into customer handler now that orderRepo is available
 	customerHandler = customerHandler.WithRepos(orderRepo, courierRepo)
 	// Inject orders repo into courier handler for active order lookup
-	courierHandler = courierHandler.WithOrders(orderRepo)
+	courierHandler = courierHandler.WithOrders(orderRepo).WithTracking(trackingService).WithAudit(auditService)
+	// public tracking links for receivers
+	trackingLinks := tracking.NewLinkService(trackingrepo.NewGormTrackingRepo(db), orderRepo, courierRepo)
+	trackingHandler := api.NewTrackingHandler(trackingLinks, orderRepo, hub)
+	// order-scoped chat between customer and assigned courier
+	chatService := chatsvc.NewChatService(chatrepo.NewGormChatRepo(db), orderRepo)
+	chatHandler := api.NewChatHandler(chatService)
 	// Provide orders repo to websocket handler for initial sync on customer connect
 	wsHandler = wsHandler.WithOrders(orderRepo)
 	orderHandler := api.NewOrderHandler(orderService, dispatchService)
-	statusHandler := api.NewOrderStatusHandler(orderService, courierRepo).WithDispatch(dispatchService)
+	statusHandler := api.NewOrderStatusHandler(orderService, courierRepo).WithDispatch(dispatchService).WithAudit(auditService)
+	// operator tools: order search/inspection and forced transitions
+	adminOrderHandler := api.NewAdminOrderHandler(orderService, dispatchService, courierRepo, customerRepo).WithAudit(auditService)
+	adminCourierHandler := api.NewAdminCourierHandler(courierService).WithAudit(auditService)
+	adminCustomerHandler := api.NewAdminCustomerHandler(customerService).WithAudit(auditService)
+	// order types, vehicle pricing and guaranty options
+	catalogService := catalogsvc.NewCatalogService(catalogrepo.NewGormCatalogRepo(db))
+	adminCatalogHandler := api.NewAdminCatalogHandler(catalogService).WithAudit(auditService)
+	// live operations dashboard: REST snapshots plus deltas on admin sockets
+	dashboardService := dashboardsvc.NewDashboardService(dashboardrepo.NewGormDashboardRepo(db), hub)
+	go dashboard.NewFeed(dashboardService, hub, time.Second).Run(context.Background())
+	adminDashboardHandler := api.NewAdminDashboardHandler(dashboardService, hub)
+	// reports over orders and assignment attempts, filterable by zone
+	analyticsService := analyticssvc.NewAnalyticsService(analyticsrepo.NewGormAnalyticsRepo(db))
+	adminAnalyticsHandler := api.NewAdminAnalyticsHandler(analyticsService).WithAudit(auditService)
+	// operations announcements, pushed live to connected couriers and customers
+	announcementService := announcementsvc.NewAnnouncementService(announcementrepo.NewGormAnnouncementRepo(db), hub)
+	announcementHandler := api.NewAnnouncementHandler(announcementService).WithAudit(auditService)
+	// support tickets about orders; images go to the blob store
+	supportService := supportsvc.NewSupportService(supportrepo.NewGormSupportRepo(db), blobs, hub)
+	supportHandler := api.NewSupportHandler(supportService)
+	adminSupportHandler := api.NewAdminSupportHandler(supportService).WithAudit(auditService)
+	// bulk order imports (CSV/JSON), priced server-side and dispatched in the background
+	orderImportService := orderimportsvc.NewOrderImportService(orderimportrepo.NewGormOrderImportRepo(db), orderRepo, pricingService, dispatchService, hub)
+	orderImportHandler := api.NewOrderImportHandler(orderImportService)
+
+	// Allow couriers/customers to drive order status over their sockets
+	wsHandler = wsHandler.WithOrderCommands(statusHandler)
 
 	// background reassign ticker (every 15s, cutoff 15s)
 	go func() {
@@ -97,6 +242,44 @@ func main() {
 		}
 	}()
 
+	// forget SSE replay buffers of customers who have not reconnected in time (every minute)
+	go func() {
+		t := time.NewTicker(time.Minute)
+		defer t.Stop()
+		for range t.C {
+			hub.SweepStreamHistory(time.Now())
+		}
+	}()
+
+	// fail imports whose instance stopped heartbeating; imports run in-process, so other
+	// instances' live imports keep their heartbeat fresh and are left alone (every minute)
+	go func() {
+		t := time.NewTicker(time.Minute)
+		defer t.Stop()
+		for {
+			if _, err := orderImportService.FailInterrupted(context.Background(), time.Now()); err != nil {
+				log.Println("order imports:", err)
+			}
+			<-t.C
+		}
+	}()
+
+	// background anonymizer for account deletions past their cooling-off period (hourly)
+	go func() {
+		t := time.NewTicker(time.Hour)
+		defer t.Stop()
+		for range t.C {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+			n, err := accountService.ProcessDueDeletions(ctx, time.Now())
+			cancel()
+			if err != nil {
+				log.Println("account deletions:", err)
+			} else if n > 0 {
+				log.Printf("account deletions: anonymized %d accounts", n)
+			}
+		}
+	}()
+
 	r.Use(gin.Recovery(), gin.Logger())
 	// attach hub to context for downstream notifications
 	r.Use(func(c *gin.Context) {
@@ -108,38 +291,103 @@ func main() {
 		c.JSON(200, gin.H{"message": "pong"})
 	})
 
+	// public (unauthenticated) order tracking for receivers
+	r.GET("/.well-known/jwks.json", authHandler.JWKS())
+	r.GET("/track/:token", trackingHandler.View())
+	r.GET("/track/:token/stream", trackingHandler.Stream())
+
+	// rate limiting: per-route token buckets, in memory unless RATE_LIMIT_STORE=postgres
+	// (needed once more than one replica serves traffic)
+	var limitStore ratelimit.Store = ratelimit.NewMemoryStore()
+	if os.Getenv("RATE_LIMIT_STORE") == "postgres" {
+		pgStore := ratelimitrepo.NewGormRateLimitStore(db)
+		limitStore = pgStore
+		go func() {
+			t := time.NewTicker(10 * time.Minute)
+			defer t.Stop()
+			for range t.C {
+				ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+				_, _ = pgStore.PurgeIdle(ctx, time.Now().Add(-time.Hour))
+				cancel()
+			}
+		}()
+	}
+	limit := func(limits ...mw.Limit) gin.HandlerFunc { return mw.RateLimit(limitStore, limits...) }
+	var (
+		loginLimit = limit(
+			mw.Limit{Name: "login", Policy: ratelimit.Every(10, time.Minute), Key: mw.ByIP},
+			mw.Limit{Name: "login", Policy: ratelimit.Every(5, 10*time.Minute), Key: mw.ByPhone},
+		)
+		otpRequestLimit = limit(
+			mw.Limit{Name: "otp_request", Policy: ratelimit.Every(5, time.Minute), Key: mw.ByIP},
+			mw.Limit{Name: "otp_request", Policy: ratelimit.Every(3, 10*time.Minute), Key: mw.ByPhone},
+		)
+		otpVerifyLimit = limit(
+			mw.Limit{Name: "otp_verify", Policy: ratelimit.Every(10, time.Minute), Key: mw.ByIP},
+			mw.Limit{Name: "otp_verify", Policy: ratelimit.Every(10, 10*time.Minute), Key: mw.ByPhone},
+		)
+		refreshLimit    = limit(mw.Limit{Name: "refresh", Policy: ratelimit.Every(30, time.Minute), Key: mw.ByIP})
+		exchangeLimit   = limit(mw.Limit{Name: "exchange", Policy: ratelimit.Every(10, time.Minute), Key: mw.ByIP})
+		registerLimit   = limit(mw.Limit{Name: "register", Policy: ratelimit.Every(10, time.Hour), Key: mw.ByIP})
+		switchRoleLimit = limit(mw.Limit{Name: "switch_role", Policy: ratelimit.Every(10, time.Minute), Key: mw.ByUser})
+		orderLimit      = limit(mw.Limit{Name: "create_order", Policy: ratelimit.Every(10, time.Minute), Key: mw.ByUser})
+		tariffLimit     = limit(
+			mw.Limit{Name: "tariffs", Policy: ratelimit.Every(30, time.Minute), Key: mw.ByUser},
+			mw.Limit{Name: "tariffs", Policy: ratelimit.Every(60, time.Minute), Key: mw.ByIP},
+		)
+	)
+
 	// API v1 routes
 	v1 := r.Group("/api/v1")
 	{
 		v1.GET("/guaranty-options", courierHandler.ListGuarantyOptions())
-		v1.POST("/couriers/register", courierHandler.RegisterCourier())
-		v1.POST("/customers/register", customerHandler.RegisterCustomer())
-		v1.POST("/admins/register", adminHandler.RegisterAdmin())
-		v1.POST("/login", authHandler.Login())
-		v1.POST("/refresh", authHandler.Refresh())
+		v1.POST("/couriers/register", registerLimit, courierHandler.RegisterCourier())
+		v1.POST("/customers/register", registerLimit, customerHandler.RegisterCustomer())
+		v1.POST("/login", loginLimit, authHandler.Login())
+		v1.POST("/auth/otp/request", otpRequestLimit, authHandler.RequestOTP())
+		v1.POST("/auth/otp/verify", otpVerifyLimit, authHandler.VerifyOTP())
+		v1.POST("/refresh", refreshLimit, authHandler.Refresh())
+		// session management (all roles)
+		v1.POST("/logout", requireAuth, denyImpersonation, authHandler.Logout())
+		v1.GET("/sessions", requireAuth, denyImpersonation, authHandler.Sessions())
+		v1.DELETE("/sessions/:id", requireAuth, denyImpersonation, authHandler.RevokeSession())
+		// personal data export and account deletion (customers and couriers)
+		v1.GET("/account/export", requireAuth, denyImpersonation, mw.RequireRoles("customer", "courier"), accountHandler.Export())
+		v1.POST("/account/deletion", requireAuth, denyImpersonation, mw.RequireRoles("customer", "courier"), accountHandler.RequestDeletion())
+		v1.GET("/account/deletion", requireAuth, mw.RequireRoles("customer", "courier"), accountHandler.GetDeletion())
+		v1.DELETE("/account/deletion", requireAuth, denyImpersonation, mw.RequireRoles("customer", "courier"), accountHandler.CancelDeletion())
+		// multi-role accounts: re-issue tokens for another held role
+		v1.POST("/auth/switch-role", requireAuth, denyImpersonation, switchRoleLimit, authHandler.SwitchRole())
 		// Firebase token exchange: verify Firebase ID token and issue backend JWTs
-		v1.POST("/auth/firebase/exchange", authHandler.ExchangeFirebase())
+		v1.POST("/auth/firebase/exchange", exchangeLimit, authHandler.ExchangeFirebase())
 
 		// order endpoints
-		v1.GET("/order-types", mw.RequireAuth(), orderHandler.ListOrderTypes())
-		v1.POST("/orders", mw.RequireAuth(), mw.RequireRoles("customer"), orderHandler.CreateOrder())
+		v1.GET("/order-types", requireAuth, orderHandler.ListOrderTypes())
+		v1.POST("/orders", requireAuth, mw.RequireRoles("customer"), orderLimit, orderHandler.CreateOrder())
 		// fare estimation (customer): GET /orders/tariffs?pickup_lat=&pickup_lng=&dropoff_lat=&dropoff_lng=
-		v1.GET("/orders/tariffs", mw.RequireAuth(), mw.RequireRoles("customer"), orderHandler.EstimateTariffs(orderRepo))
+		v1.GET("/orders/tariffs", requireAuth, mw.RequireRoles("customer"), tariffLimit, orderHandler.EstimateTariffs(orderRepo, pricingService))
 
 		// websocket endpoints
 		courierWS := v1.Group("/ws/courier")
-		courierWS.Use(mw.RequireAuth(), mw.RequireRoles("courier"))
+		courierWS.Use(requireAuth, denyImpersonation, mw.RequireRoles("courier"))
 		courierWS.GET("", wsHandler.CourierSocket())
 
 		customerWS := v1.Group("/ws/customer")
-		customerWS.Use(mw.RequireAuth(), mw.RequireRoles("customer"))
+		customerWS.Use(requireAuth, denyImpersonation, mw.RequireRoles("customer"))
 		customerWS.GET("", wsHandler.CustomerSocket())
+
+		adminWS := v1.Group("/ws/admin")
+		adminWS.Use(requireAuth, mw.RequireRoles("admin"), mw.RequirePermission(adminpkg.PermViewOrders), mw.RequirePermission(adminpkg.PermViewCouriers))
+		adminWS.GET("", adminDashboardHandler.Socket())
 	}
 	// Example protected groups (not yet used by any specific endpoints):
 	courierGroup := v1.Group("/courier")
-	courierGroup.Use(mw.RequireAuth(), mw.RequireRoles("courier"))
+	courierGroup.Use(requireAuth, mw.RequireRoles("courier"))
 	courierGroup.POST("/availability", courierHandler.SetAvailability())
 	courierGroup.POST("/location", courierHandler.UpdateLocation())
+	// onboarding: verification documents and review status
+	courierGroup.POST("/documents", courierHandler.UploadDocument())
+	courierGroup.GET("/onboarding", courierHandler.Onboarding())
 	courierGroup.POST("/orders/accept", statusHandler.Accept())
 	courierGroup.POST("/orders/decline", statusHandler.Decline())
 	courierGroup.POST("/orders/arrived", statusHandler.Arrived())
@@ -152,9 +400,23 @@ func main() {
 	courierGroup.GET("/activeOrder", courierHandler.ActiveOrder())
 	// courier delivered orders (history)
 	courierGroup.GET("/orders/history", courierHandler.DeliveredOrders())
+	// order chat with the customer
+	courierGroup.GET("/orders/:id/messages", chatHandler.List())
+	courierGroup.POST("/orders/:id/messages", chatHandler.Send())
+	courierGroup.POST("/orders/:id/messages/read", chatHandler.MarkRead())
+	// announcements from operations
+	courierGroup.GET("/announcements", announcementHandler.Unread())
+	courierGroup.POST("/announcements/:id/ack", announcementHandler.Ack())
+	// support tickets about the caller's orders
+	courierGroup.POST("/tickets", supportHandler.Open())
+	courierGroup.GET("/tickets", supportHandler.List())
+	courierGroup.GET("/tickets/:id", supportHandler.Get())
+	courierGroup.POST("/tickets/:id/messages", supportHandler.Reply())
+	courierGroup.POST("/tickets/:id/images", supportHandler.AttachImage())
+	courierGroup.GET("/tickets/:id/attachments/:attachmentId", supportHandler.Attachment())
 
 	customerGroup := v1.Group("/customer")
-	customerGroup.Use(mw.RequireAuth(), mw.RequireRoles("customer"))
+	customerGroup.Use(requireAuth, mw.RequireRoles("customer"))
 	customerGroup.GET("/active-order", customerHandler.ActiveOrder())
 	customerGroup.GET("/activeOrder", customerHandler.ActiveOrder())
 	// multi-order support: list all active orders for the customer
@@ -165,9 +427,98 @@ func main() {
 	customerGroup.POST("/orders/cancel", statusHandler.CancelCustomer())
 	// completed orders (delivered only)
 	customerGroup.GET("/orders/completed", customerHandler.CompletedOrders())
+	// bulk order import with per-row results; progress via polling or order_import.progress events
+	customerGroup.POST("/order-imports", orderLimit, orderImportHandler.Submit())
+	customerGroup.GET("/order-imports", orderImportHandler.List())
+	customerGroup.GET("/order-imports/:id", orderImportHandler.Get())
+	// SSE fallback for clients that cannot hold a WebSocket
+	customerGroup.GET("/orders/stream", wsHandler.CustomerStream())
+	// shareable tracking link for the receiver
+	customerGroup.POST("/orders/:id/tracking-link", trackingHandler.CreateLink())
+	// order chat with the assigned courier
+	customerGroup.GET("/orders/:id/messages", chatHandler.List())
+	customerGroup.POST("/orders/:id/messages", chatHandler.Send())
+	customerGroup.POST("/orders/:id/messages/read", chatHandler.MarkRead())
+	// announcements from operations
+	customerGroup.GET("/announcements", announcementHandler.Unread())
+	customerGroup.POST("/announcements/:id/ack", announcementHandler.Ack())
+	// support tickets about the caller's orders
+	customerGroup.POST("/tickets", supportHandler.Open())
+	customerGroup.GET("/tickets", supportHandler.List())
+	customerGroup.GET("/tickets/:id", supportHandler.Get())
+	customerGroup.POST("/tickets/:id/messages", supportHandler.Reply())
+	customerGroup.POST("/tickets/:id/images", supportHandler.AttachImage())
+	customerGroup.GET("/tickets/:id/attachments/:attachmentId", supportHandler.Attachment())
 
 	adminGroup := v1.Group("/admin")
-	adminGroup.Use(mw.RequireAuth(), mw.RequireRoles("admin"))
+	adminGroup.Use(requireAuth, mw.RequireRoles("admin"))
+	// admin accounts and roles (super admin only)
+	adminGroup.GET("/admins", mw.RequirePermission(adminpkg.PermManageAdmins), adminHandler.ListAdmins())
+	adminGroup.POST("/admins", mw.RequirePermission(adminpkg.PermManageAdmins), adminHandler.RegisterAdmin())
+	adminGroup.PATCH("/admins/:id", mw.RequirePermission(adminpkg.PermManageAdmins), adminHandler.UpdateAdmin())
+	// audit trail
+	adminGroup.GET("/audit-logs", mw.RequirePermission(adminpkg.PermViewAudit), auditHandler.List())
+	// order management
+	adminGroup.GET("/orders", mw.RequirePermission(adminpkg.PermViewOrders), adminOrderHandler.Search())
+	adminGroup.GET("/orders/:id", mw.RequirePermission(adminpkg.PermViewOrders), adminOrderHandler.Get())
+	adminGroup.POST("/orders/:id/assign", mw.RequirePermission(adminpkg.PermManageOrders), adminOrderHandler.Assign())
+	adminGroup.POST("/orders/:id/redispatch", mw.RequirePermission(adminpkg.PermManageOrders), adminOrderHandler.Redispatch())
+	adminGroup.POST("/orders/:id/cancel", mw.RequirePermission(adminpkg.PermManageOrders), adminOrderHandler.Cancel())
+	adminGroup.POST("/orders/:id/deliver", mw.RequirePermission(adminpkg.PermManageOrders), adminOrderHandler.Deliver())
+	// courier onboarding review
+	adminGroup.GET("/couriers", mw.RequirePermission(adminpkg.PermViewCouriers), adminCourierHandler.List())
+	adminGroup.GET("/couriers/:id", mw.RequirePermission(adminpkg.PermViewCouriers), adminCourierHandler.Get())
+	adminGroup.GET("/couriers/:id/documents/:docId", mw.RequirePermission(adminpkg.PermViewCouriers), adminCourierHandler.Document())
+	adminGroup.POST("/couriers/:id/review", mw.RequirePermission(adminpkg.PermManageCouriers), adminCourierHandler.Review())
+	// live operations dashboard (deltas stream on /ws/admin)
+	adminGroup.GET("/dashboard", mw.RequirePermission(adminpkg.PermViewOrders), adminDashboardHandler.Summary())
+	adminGroup.GET("/dashboard/couriers", mw.RequirePermission(adminpkg.PermViewCouriers), adminDashboardHandler.Couriers())
+	// reports (JSON or CSV) and the zones they filter by
+	adminGroup.GET("/analytics/kpis", mw.RequirePermission(adminpkg.PermViewAnalytics), adminAnalyticsHandler.KPIs())
+	adminGroup.GET("/analytics/revenue", mw.RequirePermission(adminpkg.PermViewAnalytics), adminAnalyticsHandler.Revenue())
+	adminGroup.GET("/zones", mw.RequirePermission(adminpkg.PermViewAnalytics), adminAnalyticsHandler.ListZones())
+	adminGroup.POST("/zones", mw.RequirePermission(adminpkg.PermManageCatalog), adminAnalyticsHandler.CreateZone())
+	adminGroup.PATCH("/zones/:id", mw.RequirePermission(adminpkg.PermManageCatalog), adminAnalyticsHandler.UpdateZone())
+	// announcements to couriers and customers, pushed live and kept until expiry
+	adminGroup.GET("/announcements", mw.RequirePermission(adminpkg.PermAnnounce), announcementHandler.List())
+	adminGroup.POST("/announcements", mw.RequirePermission(adminpkg.PermAnnounce), announcementHandler.Create())
+	adminGroup.POST("/announcements/:id/expire", mw.RequirePermission(adminpkg.PermAnnounce), announcementHandler.Expire())
+	// support queue: reading needs orders.view, refunds and adjustments need refunds.issue
+	adminGroup.GET("/tickets", mw.RequirePermission(adminpkg.PermViewOrders), adminSupportHandler.List())
+	adminGroup.GET("/tickets/:id", mw.RequirePermission(adminpkg.PermViewOrders), adminSupportHandler.Get())
+	adminGroup.GET("/tickets/:id/attachments/:attachmentId", mw.RequirePermission(adminpkg.PermViewOrders), adminSupportHandler.Attachment())
+	adminGroup.POST("/tickets/:id/messages", mw.RequirePermission(adminpkg.PermManageSupport), adminSupportHandler.Reply())
+	adminGroup.POST("/tickets/:id/assign", mw.RequirePermission(adminpkg.PermManageSupport), adminSupportHandler.Assign())
+	adminGroup.POST("/tickets/:id/resolve", mw.RequirePermission(adminpkg.PermManageSupport), adminSupportHandler.Resolve())
+	adminGroup.POST("/tickets/:id/adjustments", mw.RequirePermission(adminpkg.PermIssueRefunds), adminSupportHandler.Adjust())
+	// impersonation tokens for support debugging (write scope checked in the handler)
+	adminGroup.POST("/impersonations", mw.RequirePermission(adminpkg.PermImpersonate), impersonationHandler.Start())
+	adminGroup.POST("/impersonations/:tokenId/revoke", mw.RequirePermission(adminpkg.PermImpersonate), impersonationHandler.Revoke())
+	// customer lookup, suspension and support notes
+	adminGroup.GET("/customers", mw.RequirePermission(adminpkg.PermViewCustomers), adminCustomerHandler.List())
+	adminGroup.GET("/customers/:id", mw.RequirePermission(adminpkg.PermViewCustomers), adminCustomerHandler.Get())
+	adminGroup.GET("/customers/:id/notes", mw.RequirePermission(adminpkg.PermViewCustomers), adminCustomerHandler.Notes())
+	adminGroup.POST("/customers/:id/notes", mw.RequirePermission(adminpkg.PermManageCustomers), adminCustomerHandler.AddNote())
+	adminGroup.POST("/customers/:id/suspend", mw.RequirePermission(adminpkg.PermManageCustomers), adminCustomerHandler.Suspend())
+	adminGroup.POST("/customers/:id/reactivate", mw.RequirePermission(adminpkg.PermManageCustomers), adminCustomerHandler.Reactivate())
+	// catalogs: entries are deactivated (DELETE), never removed
+	catalogGroup := adminGroup.Group("", mw.RequirePermission(adminpkg.PermManageCatalog))
+	catalogGroup.GET("/order-types", adminCatalogHandler.ListOrderTypes())
+	catalogGroup.POST("/order-types", adminCatalogHandler.CreateOrderType())
+	catalogGroup.PUT("/order-types/order", adminCatalogHandler.ReorderOrderTypes())
+	catalogGroup.PATCH("/order-types/:id", adminCatalogHandler.UpdateOrderType())
+	catalogGroup.DELETE("/order-types/:id", adminCatalogHandler.DeactivateOrderType())
+	catalogGroup.GET("/vehicle-types", adminCatalogHandler.ListVehicleTypes())
+	catalogGroup.POST("/vehicle-types", adminCatalogHandler.CreateVehicleType())
+	catalogGroup.PUT("/vehicle-types/order", adminCatalogHandler.ReorderVehicleTypes())
+	catalogGroup.PATCH("/vehicle-types/:id", adminCatalogHandler.UpdateVehicleType())
+	catalogGroup.DELETE("/vehicle-types/:id", adminCatalogHandler.DeactivateVehicleType())
+	catalogGroup.GET("/vehicle-types/:id/pricing-versions", adminCatalogHandler.PricingVersions())
+	catalogGroup.GET("/guaranty-options", adminCatalogHandler.ListGuarantyOptions())
+	catalogGroup.POST("/guaranty-options", adminCatalogHandler.CreateGuarantyOption())
+	catalogGroup.PUT("/guaranty-options/order", adminCatalogHandler.ReorderGuarantyOptions())
+	catalogGroup.PATCH("/guaranty-options/:id", adminCatalogHandler.UpdateGuarantyOption())
+	catalogGroup.DELETE("/guaranty-options/:id", adminCatalogHandler.DeactivateGuarantyOption())
 
 	r.Run() // listen and serve on 0.0.0.0:8080
 }
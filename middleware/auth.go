@@ -1,16 +1,48 @@
 package middleware
 
 import (
+	"context"
+	"errors"
+	"log"
 	"net/http"
-	"os"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
+	adminpkg "github.com/mikios34/delivery-backend/admin"
+	"github.com/mikios34/delivery-backend/audit"
 	authpkg "github.com/mikios34/delivery-backend/auth"
+	"github.com/mikios34/delivery-backend/entity"
 )
 
+// PrincipalValidator re-checks a verified token against current state (user exists,
+// profile active, token not revoked). Returning *authpkg.PrincipalError rejects with its code.
+type PrincipalValidator interface {
+	ValidatePrincipal(ctx context.Context, claims *authpkg.Claims) error
+}
+
+type authConfig struct {
+	validator PrincipalValidator
+	audit     audit.Service
+}
+
+// AuthOption configures RequireAuth.
+type AuthOption func(*authConfig)
+
+// WithPrincipalValidator makes RequireAuth consult v after the signature checks pass.
+func WithPrincipalValidator(v PrincipalValidator) AuthOption {
+	return func(cfg *authConfig) { cfg.validator = v }
+}
+
+// WithImpersonationAudit records every request made with an impersonation token.
+func WithImpersonationAudit(svc audit.Service) AuthOption {
+	return func(cfg *authConfig) { cfg.audit = svc }
+}
+
 // RequireAuth validates Bearer JWT, places claims into context and continues.
-func RequireAuth() gin.HandlerFunc {
+func RequireAuth(opts ...AuthOption) gin.HandlerFunc {
+	cfg := &authConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if len(authHeader) < 8 || authHeader[:7] != "Bearer " {
@@ -19,20 +51,27 @@ func RequireAuth() gin.HandlerFunc {
 		}
 		tokenString := authHeader[7:]
 
-		secret := os.Getenv("JWT_SECRET")
-		if secret == "" {
-			secret = "dev-insecure-secret-change-me"
-		}
-
-		claims := &authpkg.Claims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			return []byte(secret), nil
-		})
-		if err != nil || !token.Valid {
+		claims, err := authpkg.ParseAndValidate(tokenString)
+		if err != nil || claims.TokenType == "refresh" {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
 			return
 		}
 
+		if cfg.validator != nil {
+			if err := cfg.validator.ValidatePrincipal(c.Request.Context(), claims); err != nil {
+				var perr *authpkg.PrincipalError
+				if errors.As(err, &perr) {
+					c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": perr.Message, "code": perr.Code})
+					return
+				}
+				log.Printf("auth: principal validation failed: %v", err)
+				c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "unable to validate token"})
+				return
+			}
+			// Long-lived connections (sockets) re-run the check while they stay open.
+			c.Set("principal_check", func(ctx context.Context) error { return cfg.validator.ValidatePrincipal(ctx, claims) })
+		}
+
 		c.Set("user_id", claims.UserID)
 		c.Set("role", claims.Role)
 		if claims.CourierID != "" {
@@ -43,6 +82,59 @@ func RequireAuth() gin.HandlerFunc {
 		}
 		if claims.AdminID != "" {
 			c.Set("admin_id", claims.AdminID)
+			c.Set("admin_role", claims.AdminRole)
+		}
+		if claims.SessionID != "" {
+			c.Set("session_id", claims.SessionID)
+		}
+		c.Set("jti", claims.ID)
+		if claims.ExpiresAt != nil {
+			c.Set("token_expires_at", claims.ExpiresAt.Time)
+		}
+		if claims.ImpersonatorAdminID != "" {
+			c.Set("impersonator_admin_id", claims.ImpersonatorAdminID)
+			c.Set("impersonation_scope", claims.ImpersonationScope)
+			if cfg.audit != nil {
+				defer recordImpersonatedRequest(c, cfg.audit, claims)
+			}
+			if claims.ImpersonationScope != authpkg.ScopeWrite && !readOnlyMethod(c.Request.Method) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "impersonation token is read-only", "code": "impersonation_read_only"})
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+func readOnlyMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// recordImpersonatedRequest logs the request against the impersonated profile, with the
+// impersonating admin and the response status.
+func recordImpersonatedRequest(c *gin.Context, svc audit.Service, claims *authpkg.Claims) {
+	actor := audit.Actor{UserID: claims.UserID, Role: claims.Role, ID: claims.CourierID + claims.CustomerID, ImpersonatorID: claims.ImpersonatorAdminID}
+	meta := audit.RequestMeta{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		Method:    c.Request.Method,
+		Path:      c.FullPath(),
+		RequestID: c.GetHeader("X-Request-ID"),
+	}
+	svc.Record(audit.WithActor(c.Request.Context(), actor, meta), audit.Entry{
+		Action: audit.ActionImpersonationRequest, TargetType: claims.Role, TargetID: actor.ID,
+		After: gin.H{"status": c.Writer.Status(), "scope": claims.ImpersonationScope, "token_id": claims.ID},
+	})
+}
+
+// DenyImpersonation rejects impersonation tokens outright, whatever their scope. It guards
+// endpoints that would act for the real user beyond debugging: sockets, sessions, account
+// export and deletion.
+func DenyImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("impersonator_admin_id") != "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "not available to impersonation tokens", "code": "impersonation_denied"})
+			return
 		}
 		c.Next()
 	}
@@ -59,7 +151,19 @@ func RequireRoles(allowedRoles ...string) gin.HandlerFunc {
 		if _, ok := roleSet[role]; !ok {
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden: insufficient role"})
 			return
-		}	
+		}
+		c.Next()
+	}
+}
+
+// RequirePermission ensures the authenticated admin's role grants perm (run after RequireRoles("admin")).
+func RequirePermission(perm adminpkg.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := entity.AdminRole(c.GetString("admin_role"))
+		if !adminpkg.HasPermission(role, perm) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden: missing permission", "permission": perm})
+			return
+		}
 		c.Next()
 	}
 }
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mikios34/delivery-backend/ratelimit"
+)
+
+func TestRateLimitRetryAfter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/login", RateLimit(ratelimit.NewMemoryStore(),
+		Limit{Name: "login_phone", Policy: ratelimit.Every(2, time.Hour), Key: ByPhone},
+	), func(c *gin.Context) {
+		// The handler still sees the body ByPhone read.
+		var body struct {
+			Phone string `json:"phone"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil || body.Phone == "" {
+			c.Status(http.StatusBadRequest)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+	post := func(phone string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(`{"phone":"`+phone+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// Formatting does not give a phone extra attempts.
+	for _, phone := range []string{"+251911234567", "+251 911-234567"} {
+		if w := post(phone); w.Code != http.StatusOK {
+			t.Fatalf("%s: code = %d, want 200", phone, w.Code)
+		}
+	}
+	w := post("+251 (911) 234567")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("code = %d, want 429", w.Code)
+	}
+	// One token per 30 minutes.
+	if got := w.Header().Get("Retry-After"); got != "1800" {
+		t.Errorf("Retry-After = %q, want 1800", got)
+	}
+	if !strings.Contains(w.Body.String(), `"code":"rate_limited"`) {
+		t.Errorf("body = %s, want code rate_limited", w.Body)
+	}
+
+	if w := post("+251922000000"); w.Code != http.StatusOK {
+		t.Errorf("another phone: code = %d, want 200", w.Code)
+	}
+}
@@ -23,8 +23,18 @@ type Repository interface {
 	// Assignment attempts tracking to avoid reassigning the same courier
 	RecordAssignmentAttempt(ctx context.Context, orderID, courierID uuid.UUID) error
 	ListTriedCouriers(ctx context.Context, orderID uuid.UUID) (map[uuid.UUID]struct{}, error)
+	ListAssignmentAttempts(ctx context.Context, orderID uuid.UUID) ([]entity.OrderAssignmentAttempt, error)
+
+	// SearchOrders filters orders for the admin API; see SearchFilter.
+	SearchOrders(ctx context.Context, f SearchFilter) ([]entity.Order, int64, error)
+	// CancelWithReason sets status canceled_by_admin and the cancel reason.
+	CancelWithReason(ctx context.Context, id uuid.UUID, reason string) error
+
+	// GetCustomer returns the customer placing an order, to check they may order.
+	GetCustomer(ctx context.Context, id uuid.UUID) (*entity.Customer, error)
 
 	ListOrderTypes(ctx context.Context) ([]entity.OrderType, error)
+	GetOrderType(ctx context.Context, id uuid.UUID) (*entity.OrderType, error)
 	CreateOrderType(ctx context.Context, t *entity.OrderType) (*entity.OrderType, error)
 
 	// GetActiveOrderForCustomer returns the most recently updated active order for a customer
@@ -63,4 +73,9 @@ type Repository interface {
 
 	// Pricing configs (vehicle types with pricing)
 	ListActiveVehicleTypes(ctx context.Context) ([]entity.VehicleTypeConfig, error)
+	GetVehicleType(ctx context.Context, id uuid.UUID) (*entity.VehicleTypeConfig, error)
+	GetPricingVersion(ctx context.Context, id uuid.UUID) (*entity.VehiclePricingVersion, error)
+	// GetNextPricingVersion returns the version that replaced v, i.e. the vehicle type's
+	// next higher version number.
+	GetNextPricingVersion(ctx context.Context, v *entity.VehiclePricingVersion) (*entity.VehiclePricingVersion, error)
 }
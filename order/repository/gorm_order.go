@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -29,8 +30,22 @@ func (r *GormOrderRepo) GetOrderByID(ctx context.Context, id uuid.UUID) (*entity
 	return &o, nil
 }
 
+// stageColumns are the timestamps recorded when an order enters a status.
+var stageColumns = map[entity.OrderStatus]string{
+	entity.OrderAccepted:           "accepted_at",
+	entity.OrderPickedUp:           "picked_up_at",
+	entity.OrderDelivered:          "delivered_at",
+	entity.OrderCanceledByCustomer: "canceled_at",
+	entity.OrderCanceledByCourier:  "canceled_at",
+	entity.OrderCanceledByAdmin:    "canceled_at",
+}
+
 func (r *GormOrderRepo) UpdateOrderStatus(ctx context.Context, id uuid.UUID, status entity.OrderStatus) error {
-	return r.db.WithContext(ctx).Model(&entity.Order{}).Where("id = ?", id).Update("status", status).Error
+	updates := map[string]interface{}{"status": status}
+	if col, ok := stageColumns[status]; ok {
+		updates[col] = time.Now()
+	}
+	return r.db.WithContext(ctx).Model(&entity.Order{}).Where("id = ?", id).Updates(updates).Error
 }
 
 func (r *GormOrderRepo) AssignCourier(ctx context.Context, id uuid.UUID, courierID uuid.UUID) error {
@@ -41,14 +56,30 @@ func (r *GormOrderRepo) ClearAssignment(ctx context.Context, id uuid.UUID) error
 	return r.db.WithContext(ctx).Model(&entity.Order{}).Where("id = ?", id).Update("assigned_courier", nil).Error
 }
 
+func (r *GormOrderRepo) GetCustomer(ctx context.Context, id uuid.UUID) (*entity.Customer, error) {
+	var c entity.Customer
+	if err := r.db.WithContext(ctx).First(&c, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
 func (r *GormOrderRepo) ListOrderTypes(ctx context.Context) ([]entity.OrderType, error) {
 	var types []entity.OrderType
-	if err := r.db.WithContext(ctx).Where("active = ?", true).Find(&types).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("active = ?", true).Order("sort_order, created_at").Find(&types).Error; err != nil {
 		return nil, err
 	}
 	return types, nil
 }
 
+func (r *GormOrderRepo) GetOrderType(ctx context.Context, id uuid.UUID) (*entity.OrderType, error) {
+	var t entity.OrderType
+	if err := r.db.WithContext(ctx).First(&t, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
 func (r *GormOrderRepo) CreateOrderType(ctx context.Context, t *entity.OrderType) (*entity.OrderType, error) {
 	if err := r.db.WithContext(ctx).Create(t).Error; err != nil {
 		return nil, err
@@ -74,8 +105,9 @@ func (r *GormOrderRepo) CountAssignedOrders(ctx context.Context) (int64, error)
 
 func (r *GormOrderRepo) MarkNoNearbyDriver(ctx context.Context, id uuid.UUID) error {
 	return r.db.WithContext(ctx).Model(&entity.Order{}).Where("id = ?", id).Updates(map[string]interface{}{
-		"assigned_courier": nil,
-		"status":           entity.OrderNoNearbyDriver,
+		"assigned_courier":    nil,
+		"status":              entity.OrderNoNearbyDriver,
+		"no_nearby_driver_at": gorm.Expr("COALESCE(no_nearby_driver_at, ?)", time.Now()),
 	}).Error
 }
 
@@ -96,10 +128,63 @@ func (r *GormOrderRepo) ListTriedCouriers(ctx context.Context, orderID uuid.UUID
 	return m, nil
 }
 
+func (r *GormOrderRepo) ListAssignmentAttempts(ctx context.Context, orderID uuid.UUID) ([]entity.OrderAssignmentAttempt, error) {
+	var list []entity.OrderAssignmentAttempt
+	if err := r.db.WithContext(ctx).Where("order_id = ?", orderID).Order("created_at").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *GormOrderRepo) SearchOrders(ctx context.Context, f orderpkg.SearchFilter) ([]entity.Order, int64, error) {
+	q := r.db.WithContext(ctx).Model(&entity.Order{})
+	if len(f.Statuses) > 0 {
+		q = q.Where("status IN ?", f.Statuses)
+	}
+	if f.CustomerID != nil {
+		q = q.Where("customer_id = ?", *f.CustomerID)
+	}
+	if f.CourierID != nil {
+		q = q.Where("assigned_courier = ?", *f.CourierID)
+	}
+	if f.From != nil {
+		q = q.Where("created_at >= ?", *f.From)
+	}
+	if f.To != nil {
+		q = q.Where("created_at < ?", *f.To)
+	}
+	if f.Address != "" {
+		like := "%" + escapeLike(f.Address) + "%"
+		q = q.Where("pickup_address ILIKE ? OR dropoff_address ILIKE ?", like, like)
+	}
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var list []entity.Order
+	if err := q.Order("created_at DESC").Limit(f.Limit).Offset(f.Offset).Find(&list).Error; err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
+
+// escapeLike makes user text match literally inside a LIKE pattern.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+func (r *GormOrderRepo) CancelWithReason(ctx context.Context, id uuid.UUID, reason string) error {
+	return r.db.WithContext(ctx).Model(&entity.Order{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":        entity.OrderCanceledByAdmin,
+		"cancel_reason": reason,
+		"canceled_at":   time.Now(),
+	}).Error
+}
+
 func (r *GormOrderRepo) GetActiveOrderForCustomer(ctx context.Context, customerID uuid.UUID) (*entity.Order, error) {
 	var o entity.Order
 	err := r.db.WithContext(ctx).
-		Where("customer_id = ? AND status NOT IN (?, ?, ?, ?, ?)", customerID, entity.OrderNoNearbyDriver, entity.OrderDelivered, entity.OrderCanceledByCustomer, entity.OrderCanceledByCourier, entity.OrderDeclined).
+		Where("customer_id = ? AND status NOT IN (?, ?, ?, ?, ?, ?)", customerID, entity.OrderNoNearbyDriver, entity.OrderDelivered, entity.OrderCanceledByCustomer, entity.OrderCanceledByCourier, entity.OrderCanceledByAdmin, entity.OrderDeclined).
 		Order("updated_at DESC").
 		First(&o).Error
 	if err != nil {
@@ -114,7 +199,7 @@ func (r *GormOrderRepo) GetActiveOrderForCustomer(ctx context.Context, customerI
 func (r *GormOrderRepo) ListActiveOrdersForCustomer(ctx context.Context, customerID uuid.UUID) ([]entity.Order, error) {
 	var list []entity.Order
 	if err := r.db.WithContext(ctx).
-		Where("customer_id = ? AND status NOT IN (?, ?, ?, ?, ?)", customerID, entity.OrderNoNearbyDriver, entity.OrderDelivered, entity.OrderCanceledByCustomer, entity.OrderCanceledByCourier, entity.OrderDeclined).
+		Where("customer_id = ? AND status NOT IN (?, ?, ?, ?, ?, ?)", customerID, entity.OrderNoNearbyDriver, entity.OrderDelivered, entity.OrderCanceledByCustomer, entity.OrderCanceledByCourier, entity.OrderCanceledByAdmin, entity.OrderDeclined).
 		Order("updated_at DESC").
 		Find(&list).Error; err != nil {
 		return nil, err
@@ -143,7 +228,7 @@ func (r *GormOrderRepo) ListOrdersForCustomer(ctx context.Context, customerID uu
 func (r *GormOrderRepo) GetActiveOrderForCourier(ctx context.Context, courierID uuid.UUID) (*entity.Order, error) {
 	var o entity.Order
 	err := r.db.WithContext(ctx).
-		Where("assigned_courier = ? AND status NOT IN (?, ?, ?, ?, ?)", courierID, entity.OrderNoNearbyDriver, entity.OrderDelivered, entity.OrderCanceledByCustomer, entity.OrderCanceledByCourier, entity.OrderDeclined).
+		Where("assigned_courier = ? AND status NOT IN (?, ?, ?, ?, ?, ?)", courierID, entity.OrderNoNearbyDriver, entity.OrderDelivered, entity.OrderCanceledByCustomer, entity.OrderCanceledByCourier, entity.OrderCanceledByAdmin, entity.OrderDeclined).
 		Order("updated_at DESC").
 		First(&o).Error
 	if err != nil {
@@ -228,8 +313,34 @@ func (r *GormOrderRepo) CountDeliveredOrdersForCustomer(ctx context.Context, cus
 // ListActiveVehicleTypes returns active vehicle types with pricing info for fare estimations.
 func (r *GormOrderRepo) ListActiveVehicleTypes(ctx context.Context) ([]entity.VehicleTypeConfig, error) {
 	var list []entity.VehicleTypeConfig
-	if err := r.db.WithContext(ctx).Where("active = ?", true).Order("name ASC").Find(&list).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("active = ?", true).Order("sort_order ASC, name ASC").Find(&list).Error; err != nil {
 		return nil, err
 	}
 	return list, nil
 }
+
+func (r *GormOrderRepo) GetVehicleType(ctx context.Context, id uuid.UUID) (*entity.VehicleTypeConfig, error) {
+	var vt entity.VehicleTypeConfig
+	if err := r.db.WithContext(ctx).First(&vt, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &vt, nil
+}
+
+func (r *GormOrderRepo) GetPricingVersion(ctx context.Context, id uuid.UUID) (*entity.VehiclePricingVersion, error) {
+	var v entity.VehiclePricingVersion
+	if err := r.db.WithContext(ctx).First(&v, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func (r *GormOrderRepo) GetNextPricingVersion(ctx context.Context, v *entity.VehiclePricingVersion) (*entity.VehiclePricingVersion, error) {
+	var next entity.VehiclePricingVersion
+	if err := r.db.WithContext(ctx).
+		Where("vehicle_type_id = ? AND version > ?", v.VehicleTypeID, v.Version).
+		Order("version").First(&next).Error; err != nil {
+		return nil, err
+	}
+	return &next, nil
+}
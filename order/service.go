@@ -2,30 +2,109 @@ package order
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/mikios34/delivery-backend/entity"
 )
 
+var (
+	// ErrNotAssignedCourier is returned when a courier acts on an order not assigned to them.
+	ErrNotAssignedCourier = errors.New("forbidden: not assigned courier")
+	// ErrNotOrderOwner is returned when a customer acts on an order placed by someone else.
+	ErrNotOrderOwner = errors.New("forbidden: not order owner")
+	// ErrCannotCancel is returned when canceling an order that was already picked up or delivered.
+	ErrCannotCancel = errors.New("cannot cancel order after pickup or delivery")
+	// ErrOrderClosed is returned when forcing a change on a delivered or canceled order.
+	ErrOrderClosed = errors.New("order is already delivered or canceled")
+	// ErrNoCourierAssigned is returned when an action needs an assigned courier.
+	ErrNoCourierAssigned = errors.New("order has no assigned courier")
+	// ErrPricingMismatch is returned when the quoted pricing version belongs to another vehicle type.
+	ErrPricingMismatch = errors.New("pricing_version_id does not belong to vehicle_type_id")
+	// ErrPricingExpired is returned when the quoted pricing version was replaced more than
+	// PricingGracePeriod ago; the client should fetch a new quote.
+	ErrPricingExpired = errors.New("pricing_version_id is no longer current; request a new quote")
+	// ErrVehicleTypeUnavailable is returned for unknown or inactive vehicle types.
+	ErrVehicleTypeUnavailable = errors.New("vehicle type is not available")
+	// ErrOrderTypeUnavailable is returned for unknown or inactive order types.
+	ErrOrderTypeUnavailable = errors.New("order type is not available")
+	// ErrCoordinatesRequired is returned when pickup or dropoff coordinates are missing or
+	// out of range; they are needed to price the order.
+	ErrCoordinatesRequired = errors.New("valid pickup and dropoff coordinates are required")
+	// ErrCustomerSuspended is returned when a suspended or deactivated customer places an order.
+	ErrCustomerSuspended = errors.New("customer account is suspended")
+)
+
+// PricingGracePeriod is how long a replaced pricing version is still accepted, so a
+// customer who got a quote just before a fare change can still order with it.
+const PricingGracePeriod = 15 * time.Minute
+
+// PriceTolerance is how far, as a fraction of our quote, the client's estimated price may
+// be from it; routes can shift slightly between the tariff quote and the order.
+const PriceTolerance = 0.05
+
+// QuoteMismatchError is returned when the client's estimated price is not within
+// PriceTolerance of the server's quote, which it carries.
+type QuoteMismatchError struct {
+	PriceCents int64
+}
+
+func (e *QuoteMismatchError) Error() string {
+	return fmt.Sprintf("estimated_price_cents does not match the current quote of %d", e.PriceCents)
+}
+
+// SearchFilter narrows admin order searches; zero values are ignored.
+type SearchFilter struct {
+	Statuses   []entity.OrderStatus
+	CustomerID *uuid.UUID
+	CourierID  *uuid.UUID
+	// From/To bound created_at.
+	From *time.Time
+	To   *time.Time
+	// Address matches pickup or dropoff address text (case-insensitive substring).
+	Address string
+	Limit   int
+	Offset  int
+}
+
 type CreateOrderRequest struct {
-	CustomerID          uuid.UUID
-	TypeID              uuid.UUID
-	VehicleTypeID       uuid.UUID
-	ReceiverPhone       string
-	PickupAddress       string
-	PickupLat           *float64
-	PickupLng           *float64
-	DropoffAddress      string
-	DropoffLat          *float64
-	DropoffLng          *float64
+	CustomerID     uuid.UUID
+	TypeID         uuid.UUID
+	VehicleTypeID  uuid.UUID
+	ReceiverPhone  string
+	PickupAddress  string
+	PickupLat      *float64
+	PickupLng      *float64
+	DropoffAddress string
+	DropoffLat     *float64
+	DropoffLng     *float64
+	// EstimatedPriceCents is the price the client was shown. The order is re-quoted and
+	// rejected when this is not within PriceTolerance of the quote.
 	EstimatedPriceCents int64
+	// PricingVersionID is the version returned with the tariff quote. It must be the
+	// vehicle type's current version or one replaced within PricingGracePeriod. When nil
+	// the current pricing is recorded.
+	PricingVersionID *uuid.UUID
 }
 
 type Service interface {
 	CreateOrder(ctx context.Context, req CreateOrderRequest) (*entity.Order, error)
 	ListOrderTypes(ctx context.Context) ([]entity.OrderType, error)
+	// UpdateStatus applies a status change. When byCourierID is set, the order must be
+	// assigned to that courier.
 	UpdateStatus(ctx context.Context, orderID uuid.UUID, newStatus entity.OrderStatus, byCourierID *uuid.UUID) (*entity.Order, error)
 	GetOrder(ctx context.Context, orderID uuid.UUID) (*entity.Order, error)
-	CancelByCustomer(ctx context.Context, orderID uuid.UUID) (*entity.Order, error)
+	// CancelByCustomer cancels an order owned by customerID.
+	CancelByCustomer(ctx context.Context, orderID uuid.UUID, customerID uuid.UUID) (*entity.Order, error)
 	CancelByCourier(ctx context.Context, orderID uuid.UUID, courierID uuid.UUID) (*entity.Order, error)
+
+	// SearchOrders returns a page of orders matching f, newest first, and the total match count.
+	SearchOrders(ctx context.Context, f SearchFilter) ([]entity.Order, int64, error)
+	ListAssignmentAttempts(ctx context.Context, orderID uuid.UUID) ([]entity.OrderAssignmentAttempt, error)
+	// CancelByAdmin cancels any open order, recording the reason.
+	CancelByAdmin(ctx context.Context, orderID uuid.UUID, reason string) (*entity.Order, error)
+	// ForceDeliver marks an open order with an assigned courier as delivered.
+	ForceDeliver(ctx context.Context, orderID uuid.UUID) (*entity.Order, error)
 }
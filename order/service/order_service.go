@@ -2,37 +2,117 @@ package service
 
 import (
 	"context"
-	"fmt"
+	"errors"
+	"math"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/mikios34/delivery-backend/entity"
 	orderpkg "github.com/mikios34/delivery-backend/order"
+	"github.com/mikios34/delivery-backend/pricing"
+	"gorm.io/gorm"
 )
 
 type orderService struct {
-	repo orderpkg.Repository
+	repo    orderpkg.Repository
+	pricing pricing.Service
 }
 
-func NewOrderService(repo orderpkg.Repository) orderpkg.Service { return &orderService{repo: repo} }
+// NewOrderService constructs an order.Service; new orders are priced with p.
+func NewOrderService(repo orderpkg.Repository, p pricing.Service) orderpkg.Service {
+	return &orderService{repo: repo, pricing: p}
+}
 
 func (s *orderService) CreateOrder(ctx context.Context, req orderpkg.CreateOrderRequest) (*entity.Order, error) {
+	// RequireAuth rejects suspended customers too, but its cache lags a suspension by a few seconds.
+	cust, err := s.repo.GetCustomer(ctx, req.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+	if !cust.Active || cust.SuspendedAt != nil {
+		return nil, orderpkg.ErrCustomerSuspended
+	}
 	o := &entity.Order{
-		CustomerID:          req.CustomerID,
-		TypeID:              req.TypeID,
-		VehicleTypeID:       req.VehicleTypeID,
-		ReceiverPhone:       req.ReceiverPhone,
-		PickupAddress:       req.PickupAddress,
-		PickupLat:           req.PickupLat,
-		PickupLng:           req.PickupLng,
-		DropoffAddress:      req.DropoffAddress,
-		DropoffLat:          req.DropoffLat,
-		DropoffLng:          req.DropoffLng,
-		EstimatedPriceCents: req.EstimatedPriceCents,
-		Status:              entity.OrderPending,
+		CustomerID:     req.CustomerID,
+		TypeID:         req.TypeID,
+		VehicleTypeID:  req.VehicleTypeID,
+		ReceiverPhone:  req.ReceiverPhone,
+		PickupAddress:  req.PickupAddress,
+		PickupLat:      req.PickupLat,
+		PickupLng:      req.PickupLng,
+		DropoffAddress: req.DropoffAddress,
+		DropoffLat:     req.DropoffLat,
+		DropoffLng:     req.DropoffLng,
+		Status:         entity.OrderPending,
+	}
+	pickup, pickupOK := point(req.PickupLat, req.PickupLng)
+	dropoff, dropoffOK := point(req.DropoffLat, req.DropoffLng)
+	if !pickupOK || !dropoffOK {
+		return nil, orderpkg.ErrCoordinatesRequired
 	}
+	ot, err := s.repo.GetOrderType(ctx, req.TypeID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || err == nil && !ot.Active {
+		return nil, orderpkg.ErrOrderTypeUnavailable
+	} else if err != nil {
+		return nil, err
+	}
+	vt, err := s.repo.GetVehicleType(ctx, req.VehicleTypeID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || err == nil && !vt.Active {
+		return nil, orderpkg.ErrVehicleTypeUnavailable
+	} else if err != nil {
+		return nil, err
+	}
+	o.PricingVersionID = vt.PricingVersionID
+	// fares are the quoted version's, which may be the one just replaced.
+	fares := *vt
+	if req.PricingVersionID != nil {
+		v, err := s.repo.GetPricingVersion(ctx, *req.PricingVersionID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, orderpkg.ErrPricingMismatch
+			}
+			return nil, err
+		}
+		if v.VehicleTypeID != req.VehicleTypeID {
+			return nil, orderpkg.ErrPricingMismatch
+		}
+		if vt.PricingVersionID == nil || *vt.PricingVersionID != v.ID {
+			// An older quote is honoured only briefly after the fares changed.
+			next, err := s.repo.GetNextPricingVersion(ctx, v)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, orderpkg.ErrPricingExpired
+			} else if err != nil {
+				return nil, err
+			}
+			if time.Since(next.CreatedAt) > orderpkg.PricingGracePeriod {
+				return nil, orderpkg.ErrPricingExpired
+			}
+		}
+		o.PricingVersionID = &v.ID
+		fares.BaseFare, fares.PerKm, fares.PerMinute = v.BaseFare, v.PerKm, v.PerMinute
+		fares.AvgSpeedKmh, fares.MinimumFare, fares.BookingFee = v.AvgSpeedKmh, v.MinimumFare, v.BookingFee
+	}
+
+	// The client's figure is only a check: the stored price is always our own quote.
+	qctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	quote := s.pricing.Quote(qctx, pickup, dropoff, []entity.VehicleTypeConfig{fares})[0]
+	if diff := math.Abs(float64(req.EstimatedPriceCents - quote.PriceCents)); diff > orderpkg.PriceTolerance*float64(quote.PriceCents) {
+		return nil, &orderpkg.QuoteMismatchError{PriceCents: quote.PriceCents}
+	}
+	o.EstimatedPriceCents = quote.PriceCents
+	o.ServerPriced = true
 	return s.repo.CreateOrder(ctx, o)
 }
 
+func point(lat, lng *float64) (pricing.Point, bool) {
+	if lat == nil || lng == nil {
+		return pricing.Point{}, false
+	}
+	p := pricing.Point{Lat: *lat, Lng: *lng}
+	return p, p.Valid()
+}
+
 func (s *orderService) ListOrderTypes(ctx context.Context) ([]entity.OrderType, error) {
 	return s.repo.ListOrderTypes(ctx)
 }
@@ -46,8 +126,8 @@ func (s *orderService) UpdateStatus(ctx context.Context, orderID uuid.UUID, newS
 	if err != nil {
 		return nil, err
 	}
-	if byCourierID != nil && ord.AssignedCourier != nil && *byCourierID != *ord.AssignedCourier {
-		return nil, fmt.Errorf("forbidden: not assigned courier")
+	if byCourierID != nil && (ord.AssignedCourier == nil || *byCourierID != *ord.AssignedCourier) {
+		return nil, orderpkg.ErrNotAssignedCourier
 	}
 	if newStatus == entity.OrderDeclined {
 		if err := s.repo.UpdateOrderStatus(ctx, orderID, newStatus); err != nil {
@@ -64,16 +144,66 @@ func (s *orderService) UpdateStatus(ctx context.Context, orderID uuid.UUID, newS
 	return s.repo.GetOrderByID(ctx, orderID)
 }
 
-// CancelByCustomer sets status to canceled_by_customer if order is not already delivered.
-func (s *orderService) CancelByCustomer(ctx context.Context, orderID uuid.UUID) (*entity.Order, error) {
+func (s *orderService) SearchOrders(ctx context.Context, f orderpkg.SearchFilter) ([]entity.Order, int64, error) {
+	return s.repo.SearchOrders(ctx, f)
+}
+
+func (s *orderService) ListAssignmentAttempts(ctx context.Context, orderID uuid.UUID) ([]entity.OrderAssignmentAttempt, error) {
+	return s.repo.ListAssignmentAttempts(ctx, orderID)
+}
+
+// isClosed reports whether an order reached a final state no admin action may change.
+func isClosed(status entity.OrderStatus) bool {
+	switch status {
+	case entity.OrderDelivered, entity.OrderCanceledByCustomer, entity.OrderCanceledByCourier, entity.OrderCanceledByAdmin:
+		return true
+	}
+	return false
+}
+
+// CancelByAdmin cancels an order in any state short of delivered/canceled, even after pickup.
+func (s *orderService) CancelByAdmin(ctx context.Context, orderID uuid.UUID, reason string) (*entity.Order, error) {
+	ord, err := s.repo.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if isClosed(ord.Status) {
+		return nil, orderpkg.ErrOrderClosed
+	}
+	if err := s.repo.CancelWithReason(ctx, orderID, reason); err != nil {
+		return nil, err
+	}
+	return s.repo.GetOrderByID(ctx, orderID)
+}
+
+// ForceDeliver completes an order on the assigned courier's behalf (e.g. the courier's app failed).
+func (s *orderService) ForceDeliver(ctx context.Context, orderID uuid.UUID) (*entity.Order, error) {
 	ord, err := s.repo.GetOrderByID(ctx, orderID)
 	if err != nil {
 		return nil, err
 	}
+	if isClosed(ord.Status) {
+		return nil, orderpkg.ErrOrderClosed
+	}
+	if ord.AssignedCourier == nil {
+		return nil, orderpkg.ErrNoCourierAssigned
+	}
+	return s.UpdateStatus(ctx, orderID, entity.OrderDelivered, nil)
+}
+
+// CancelByCustomer sets status to canceled_by_customer if the customer owns the order and it is not already delivered.
+func (s *orderService) CancelByCustomer(ctx context.Context, orderID uuid.UUID, customerID uuid.UUID) (*entity.Order, error) {
+	ord, err := s.repo.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if ord.CustomerID != customerID {
+		return nil, orderpkg.ErrNotOrderOwner
+	}
 	if ord.Status == entity.OrderPickedUp || ord.Status == entity.OrderDelivered {
-		return nil, fmt.Errorf("cannot cancel order after pickup or delivery")
+		return nil, orderpkg.ErrCannotCancel
 	}
-	if ord.Status == entity.OrderCanceledByCustomer || ord.Status == entity.OrderCanceledByCourier {
+	if ord.Status == entity.OrderCanceledByCustomer || ord.Status == entity.OrderCanceledByCourier || ord.Status == entity.OrderCanceledByAdmin {
 		return ord, nil
 	}
 	if err := s.repo.UpdateOrderStatus(ctx, orderID, entity.OrderCanceledByCustomer); err != nil {
@@ -89,12 +219,12 @@ func (s *orderService) CancelByCourier(ctx context.Context, orderID uuid.UUID, c
 		return nil, err
 	}
 	if ord.Status == entity.OrderPickedUp || ord.Status == entity.OrderDelivered {
-		return nil, fmt.Errorf("cannot cancel order after pickup or delivery")
+		return nil, orderpkg.ErrCannotCancel
 	}
 	if ord.AssignedCourier == nil || *ord.AssignedCourier != courierID {
-		return nil, fmt.Errorf("forbidden: not assigned courier")
+		return nil, orderpkg.ErrNotAssignedCourier
 	}
-	if ord.Status == entity.OrderCanceledByCustomer || ord.Status == entity.OrderCanceledByCourier {
+	if ord.Status == entity.OrderCanceledByCustomer || ord.Status == entity.OrderCanceledByCourier || ord.Status == entity.OrderCanceledByAdmin {
 		return ord, nil
 	}
 	if err := s.repo.UpdateOrderStatus(ctx, orderID, entity.OrderCanceledByCourier); err != nil {
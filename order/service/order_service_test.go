@@ -0,0 +1,320 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/entity"
+	orderpkg "github.com/mikios34/delivery-backend/order"
+	"github.com/mikios34/delivery-backend/pricing"
+	"gorm.io/gorm"
+)
+
+// memOrders is an in-memory order.Repository covering what status changes use; any
+// other method panics through the nil embedded interface.
+type memOrders struct {
+	orderpkg.Repository
+	orders map[uuid.UUID]*entity.Order
+}
+
+func newMemOrders(orders ...*entity.Order) *memOrders {
+	r := &memOrders{orders: make(map[uuid.UUID]*entity.Order)}
+	for _, o := range orders {
+		r.orders[o.ID] = o
+	}
+	return r
+}
+
+func (r *memOrders) GetOrderByID(_ context.Context, id uuid.UUID) (*entity.Order, error) {
+	o, ok := r.orders[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	cp := *o
+	return &cp, nil
+}
+
+func (r *memOrders) UpdateOrderStatus(_ context.Context, id uuid.UUID, status entity.OrderStatus) error {
+	r.orders[id].Status = status
+	return nil
+}
+
+func (r *memOrders) ClearAssignment(_ context.Context, id uuid.UUID) error {
+	r.orders[id].AssignedCourier = nil
+	return nil
+}
+
+func TestCancelByCustomer(t *testing.T) {
+	owner, other := uuid.New(), uuid.New()
+	tests := []struct {
+		name       string
+		customerID uuid.UUID
+		wantErr    error
+		wantStatus entity.OrderStatus
+	}{
+		{"owner cancels", owner, nil, entity.OrderCanceledByCustomer},
+		{"other customer is rejected", other, orderpkg.ErrNotOrderOwner, entity.OrderPending},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &entity.Order{ID: uuid.New(), CustomerID: owner, Status: entity.OrderPending}
+			repo := newMemOrders(o)
+			svc := NewOrderService(repo, nil)
+
+			_, err := svc.CancelByCustomer(context.Background(), o.ID, tt.customerID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if got := repo.orders[o.ID].Status; got != tt.wantStatus {
+				t.Errorf("status = %s, want %s", got, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestUpdateStatusByCourier(t *testing.T) {
+	assigned, other := uuid.New(), uuid.New()
+	tests := []struct {
+		name       string
+		assignedTo *uuid.UUID
+		by         *uuid.UUID
+		wantErr    error
+		wantStatus entity.OrderStatus
+	}{
+		{"assigned courier", &assigned, &assigned, nil, entity.OrderAccepted},
+		{"another courier is rejected", &assigned, &other, orderpkg.ErrNotAssignedCourier, entity.OrderAssigned},
+		{"unassigned order is rejected", nil, &other, orderpkg.ErrNotAssignedCourier, entity.OrderAssigned},
+		{"no courier (admin) skips the check", &assigned, nil, nil, entity.OrderAccepted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &entity.Order{ID: uuid.New(), CustomerID: uuid.New(), Status: entity.OrderAssigned, AssignedCourier: tt.assignedTo}
+			repo := newMemOrders(o)
+			svc := NewOrderService(repo, nil)
+
+			updated, err := svc.UpdateStatus(context.Background(), o.ID, entity.OrderAccepted, tt.by)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if got := repo.orders[o.ID].Status; got != tt.wantStatus {
+				t.Errorf("stored status = %s, want %s", got, tt.wantStatus)
+			}
+			if err == nil && updated.Status != tt.wantStatus {
+				t.Errorf("returned status = %s, want %s", updated.Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestDeclineClearsAssignment(t *testing.T) {
+	courierID := uuid.New()
+	o := &entity.Order{ID: uuid.New(), CustomerID: uuid.New(), Status: entity.OrderAssigned, AssignedCourier: &courierID}
+	repo := newMemOrders(o)
+	svc := NewOrderService(repo, nil)
+
+	if _, err := svc.UpdateStatus(context.Background(), o.ID, entity.OrderDeclined, &courierID); err != nil {
+		t.Fatal(err)
+	}
+	if repo.orders[o.ID].AssignedCourier != nil {
+		t.Error("declined order is still assigned")
+	}
+}
+
+// catalogRepo is an order.Repository with one customer, order type and vehicle type
+// whose current pricing version is v2, which replaced v1.
+type catalogRepo struct {
+	orderpkg.Repository
+	customer    entity.Customer
+	orderType   entity.OrderType
+	vehicleType entity.VehicleTypeConfig
+	v1, v2      entity.VehiclePricingVersion
+}
+
+func newCatalogRepo(replacedAgo time.Duration) *catalogRepo {
+	r := &catalogRepo{
+		customer:    entity.Customer{ID: uuid.New(), Active: true},
+		orderType:   entity.OrderType{ID: uuid.New(), Active: true},
+		vehicleType: entity.VehicleTypeConfig{ID: uuid.New(), Active: true},
+	}
+	r.v1 = entity.VehiclePricingVersion{ID: uuid.New(), VehicleTypeID: r.vehicleType.ID, Version: 1, BaseFare: 100}
+	r.v2 = entity.VehiclePricingVersion{ID: uuid.New(), VehicleTypeID: r.vehicleType.ID, Version: 2, BaseFare: 120, CreatedAt: time.Now().Add(-replacedAgo)}
+	r.vehicleType.PricingVersionID = &r.v2.ID
+	r.vehicleType.BaseFare = r.v2.BaseFare
+	return r
+}
+
+func (r *catalogRepo) GetCustomer(context.Context, uuid.UUID) (*entity.Customer, error) {
+	return &r.customer, nil
+}
+
+func (r *catalogRepo) GetOrderType(_ context.Context, id uuid.UUID) (*entity.OrderType, error) {
+	if id != r.orderType.ID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &r.orderType, nil
+}
+
+func (r *catalogRepo) GetVehicleType(_ context.Context, id uuid.UUID) (*entity.VehicleTypeConfig, error) {
+	if id != r.vehicleType.ID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &r.vehicleType, nil
+}
+
+func (r *catalogRepo) GetPricingVersion(_ context.Context, id uuid.UUID) (*entity.VehiclePricingVersion, error) {
+	for _, v := range []*entity.VehiclePricingVersion{&r.v1, &r.v2} {
+		if v.ID == id {
+			return v, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *catalogRepo) GetNextPricingVersion(_ context.Context, v *entity.VehiclePricingVersion) (*entity.VehiclePricingVersion, error) {
+	if v.ID == r.v1.ID {
+		return &r.v2, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *catalogRepo) CreateOrder(_ context.Context, o *entity.Order) (*entity.Order, error) {
+	return o, nil
+}
+
+// baseFares quotes every trip at the vehicle type's base fare, so tests can tell which
+// pricing version was applied.
+type baseFares struct{}
+
+func (baseFares) Quote(_ context.Context, _, _ pricing.Point, types []entity.VehicleTypeConfig) []pricing.Quote {
+	quotes := make([]pricing.Quote, len(types))
+	for i, vt := range types {
+		quotes[i] = pricing.Quote{VehicleType: vt, PriceCents: int64(vt.BaseFare * 100)}
+	}
+	return quotes
+}
+
+func TestCreateOrderChecksCatalog(t *testing.T) {
+	tests := []struct {
+		name        string
+		replacedAgo time.Duration
+		setup       func(r *catalogRepo, req *orderpkg.CreateOrderRequest)
+		wantErr     error
+		wantVersion func(r *catalogRepo) uuid.UUID
+	}{
+		{
+			name:        "no quote records the current version",
+			setup:       func(*catalogRepo, *orderpkg.CreateOrderRequest) {},
+			wantVersion: func(r *catalogRepo) uuid.UUID { return r.v2.ID },
+		},
+		{
+			name:        "current version",
+			setup:       func(r *catalogRepo, req *orderpkg.CreateOrderRequest) { req.PricingVersionID = &r.v2.ID },
+			wantVersion: func(r *catalogRepo) uuid.UUID { return r.v2.ID },
+		},
+		{
+			name:        "version replaced within the grace period",
+			replacedAgo: time.Minute,
+			setup:       func(r *catalogRepo, req *orderpkg.CreateOrderRequest) { req.PricingVersionID = &r.v1.ID },
+			wantVersion: func(r *catalogRepo) uuid.UUID { return r.v1.ID },
+		},
+		{
+			name:        "version replaced before the grace period",
+			replacedAgo: orderpkg.PricingGracePeriod + time.Minute,
+			setup:       func(r *catalogRepo, req *orderpkg.CreateOrderRequest) { req.PricingVersionID = &r.v1.ID },
+			wantErr:     orderpkg.ErrPricingExpired,
+		},
+		{
+			name:    "inactive vehicle type",
+			setup:   func(r *catalogRepo, _ *orderpkg.CreateOrderRequest) { r.vehicleType.Active = false },
+			wantErr: orderpkg.ErrVehicleTypeUnavailable,
+		},
+		{
+			name:    "inactive order type",
+			setup:   func(r *catalogRepo, _ *orderpkg.CreateOrderRequest) { r.orderType.Active = false },
+			wantErr: orderpkg.ErrOrderTypeUnavailable,
+		},
+		{
+			name:    "unknown order type",
+			setup:   func(_ *catalogRepo, req *orderpkg.CreateOrderRequest) { req.TypeID = uuid.New() },
+			wantErr: orderpkg.ErrOrderTypeUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newCatalogRepo(tt.replacedAgo)
+			req := newCreateRequest(r, 12000)
+			tt.setup(r, &req)
+			if req.PricingVersionID != nil && *req.PricingVersionID == r.v1.ID {
+				req.EstimatedPriceCents = 10000
+			}
+			o, err := NewOrderService(r, baseFares{}).CreateOrder(context.Background(), req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if o.PricingVersionID == nil || *o.PricingVersionID != tt.wantVersion(r) {
+				t.Errorf("pricing version = %v, want %v", o.PricingVersionID, tt.wantVersion(r))
+			}
+		})
+	}
+}
+
+func newCreateRequest(r *catalogRepo, priceCents int64) orderpkg.CreateOrderRequest {
+	lat, lng := 9.01, 38.76
+	return orderpkg.CreateOrderRequest{
+		CustomerID: r.customer.ID, TypeID: r.orderType.ID, VehicleTypeID: r.vehicleType.ID,
+		PickupLat: &lat, PickupLng: &lng, DropoffLat: &lat, DropoffLng: &lng,
+		EstimatedPriceCents: priceCents,
+	}
+}
+
+func TestCreateOrderRequotes(t *testing.T) {
+	tests := []struct {
+		name        string
+		clientPrice int64
+		quoteOf     func(r *catalogRepo) *uuid.UUID
+		wantErr     bool
+		wantPrice   int64
+	}{
+		{"matching price", 12000, func(*catalogRepo) *uuid.UUID { return nil }, false, 12000},
+		{"small drift keeps the server price", 12300, func(*catalogRepo) *uuid.UUID { return nil }, false, 12000},
+		{"lowered price is rejected", 100, func(*catalogRepo) *uuid.UUID { return nil }, true, 0},
+		{"older version is quoted at its own fares", 10000, func(r *catalogRepo) *uuid.UUID { return &r.v1.ID }, false, 10000},
+		{"older version at current fares is rejected", 12000, func(r *catalogRepo) *uuid.UUID { return &r.v1.ID }, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newCatalogRepo(time.Minute)
+			req := newCreateRequest(r, tt.clientPrice)
+			req.PricingVersionID = tt.quoteOf(r)
+			o, err := NewOrderService(r, baseFares{}).CreateOrder(context.Background(), req)
+			var mismatch *orderpkg.QuoteMismatchError
+			if tt.wantErr {
+				if !errors.As(err, &mismatch) {
+					t.Fatalf("err = %v, want a QuoteMismatchError", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if o.EstimatedPriceCents != tt.wantPrice || !o.ServerPriced {
+				t.Errorf("price = %d (server priced %v), want %d from the server", o.EstimatedPriceCents, o.ServerPriced, tt.wantPrice)
+			}
+		})
+	}
+}
+
+func TestCreateOrderRequiresCoordinates(t *testing.T) {
+	r := newCatalogRepo(0)
+	req := newCreateRequest(r, 12000)
+	req.DropoffLat = nil
+	if _, err := NewOrderService(r, baseFares{}).CreateOrder(context.Background(), req); !errors.Is(err, orderpkg.ErrCoordinatesRequired) {
+		t.Errorf("err = %v, want ErrCoordinatesRequired", err)
+	}
+}
@@ -0,0 +1,116 @@
+package orderimport
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// csvColumns are the required CSV header names; "reference" is optional. Column order
+// does not matter and unknown columns are ignored.
+var csvColumns = []string{
+	"type_id", "vehicle_type_id", "receiver_phone",
+	"pickup_address", "pickup_lat", "pickup_lng",
+	"dropoff_address", "dropoff_lat", "dropoff_lng",
+}
+
+// ParseCSV reads rows from a CSV file with a header line.
+func ParseCSV(r io.Reader) ([]RowInput, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, ErrNoRows
+	}
+	if err != nil {
+		return nil, err
+	}
+	col := make(map[string]int, len(header))
+	for i, name := range header {
+		// Spreadsheet exports often start with a byte order mark.
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		col[name] = i
+	}
+	var missing []string
+	for _, name := range csvColumns {
+		if _, ok := col[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrMissingColumns, strings.Join(missing, ", "))
+	}
+
+	var rows []RowInput
+	for {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) == MaxRows {
+			return nil, ErrTooManyRows
+		}
+		cell := func(name string) string {
+			if i, ok := col[name]; ok && i < len(rec) {
+				return strings.TrimSpace(rec[i])
+			}
+			return ""
+		}
+		row := RowInput{
+			Reference:      cell("reference"),
+			TypeID:         cell("type_id"),
+			VehicleTypeID:  cell("vehicle_type_id"),
+			ReceiverPhone:  cell("receiver_phone"),
+			PickupAddress:  cell("pickup_address"),
+			DropoffAddress: cell("dropoff_address"),
+		}
+		for _, f := range []struct {
+			name string
+			dst  **float64
+		}{
+			{"pickup_lat", &row.PickupLat}, {"pickup_lng", &row.PickupLng},
+			{"dropoff_lat", &row.DropoffLat}, {"dropoff_lng", &row.DropoffLng},
+		} {
+			raw := cell(f.name)
+			if raw == "" {
+				continue
+			}
+			v, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				row.parseErrors = append(row.parseErrors, f.name+" is not a number")
+				continue
+			}
+			*f.dst = &v
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return nil, ErrNoRows
+	}
+	return rows, nil
+}
+
+// ParseErrors returns the CSV cells of the row that could not be read.
+func (r RowInput) ParseErrors() []string { return r.parseErrors }
+
+// ParseJSON reads rows from a JSON array of RowInput objects.
+func ParseJSON(r io.Reader) ([]RowInput, error) {
+	var rows []RowInput
+	if err := json.NewDecoder(r).Decode(&rows); err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, ErrNoRows
+	}
+	if len(rows) > MaxRows {
+		return nil, ErrTooManyRows
+	}
+	return rows, nil
+}
@@ -0,0 +1,33 @@
+package orderimport
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/entity"
+)
+
+// RowOrder is the order built from a row.
+type RowOrder struct {
+	RowNumber int
+	Order     *entity.Order
+}
+
+// Repository specifies import persistence.
+type Repository interface {
+	// CreateImport stores the import and its rows in one transaction.
+	CreateImport(ctx context.Context, imp *entity.OrderImport, rows []entity.OrderImportRow) error
+	GetImport(ctx context.Context, id uuid.UUID) (*entity.OrderImport, error)
+	ListImports(ctx context.Context, customerID uuid.UUID, limit, offset int) ([]entity.OrderImport, int64, error)
+	// ListRows returns the import's rows in row order.
+	ListRows(ctx context.Context, importID uuid.UUID) ([]entity.OrderImportRow, error)
+	UpdateImport(ctx context.Context, id uuid.UUID, fields map[string]interface{}) error
+	// UpdateRow saves the named columns of the row.
+	UpdateRow(ctx context.Context, row *entity.OrderImportRow, columns ...string) error
+	// CreateOrders stores the orders and marks their rows created, all in one transaction.
+	CreateOrders(ctx context.Context, importID uuid.UUID, orders []RowOrder) error
+	// FailStale fails imports that are not completed or failed and whose heartbeat is
+	// older than the cutoff (or missing), except those run by exceptInstance.
+	FailStale(ctx context.Context, exceptInstance string, heartbeatBefore time.Time, reason string) (int64, error)
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/entity"
+	"github.com/mikios34/delivery-backend/orderimport"
+	"gorm.io/gorm"
+)
+
+// GormOrderImportRepo implements orderimport.Repository using GORM.
+type GormOrderImportRepo struct{ db *gorm.DB }
+
+func NewGormOrderImportRepo(db *gorm.DB) orderimport.Repository {
+	return &GormOrderImportRepo{db: db}
+}
+
+func (r *GormOrderImportRepo) CreateImport(ctx context.Context, imp *entity.OrderImport, rows []entity.OrderImportRow) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(imp).Error; err != nil {
+			return err
+		}
+		for i := range rows {
+			rows[i].ImportID = imp.ID
+		}
+		return tx.CreateInBatches(rows, 100).Error
+	})
+}
+
+func (r *GormOrderImportRepo) GetImport(ctx context.Context, id uuid.UUID) (*entity.OrderImport, error) {
+	var imp entity.OrderImport
+	if err := r.db.WithContext(ctx).First(&imp, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &imp, nil
+}
+
+func (r *GormOrderImportRepo) ListImports(ctx context.Context, customerID uuid.UUID, limit, offset int) ([]entity.OrderImport, int64, error) {
+	q := r.db.WithContext(ctx).Model(&entity.OrderImport{}).Where("customer_id = ?", customerID)
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var list []entity.OrderImport
+	err := q.Order("created_at DESC").Limit(limit).Offset(offset).Find(&list).Error
+	return list, total, err
+}
+
+func (r *GormOrderImportRepo) ListRows(ctx context.Context, importID uuid.UUID) ([]entity.OrderImportRow, error) {
+	var rows []entity.OrderImportRow
+	if err := r.db.WithContext(ctx).Where("import_id = ?", importID).Order("row_number ASC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func (r *GormOrderImportRepo) UpdateImport(ctx context.Context, id uuid.UUID, fields map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&entity.OrderImport{}).Where("id = ?", id).Updates(fields).Error
+}
+
+func (r *GormOrderImportRepo) UpdateRow(ctx context.Context, row *entity.OrderImportRow, columns ...string) error {
+	// A struct update, so Errors goes through its JSON serializer.
+	return r.db.WithContext(ctx).Model(row).Select(columns).Updates(row).Error
+}
+
+func (r *GormOrderImportRepo) CreateOrders(ctx context.Context, importID uuid.UUID, orders []orderimport.RowOrder) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, ro := range orders {
+			if err := tx.Create(ro.Order).Error; err != nil {
+				return err
+			}
+			err := tx.Model(&entity.OrderImportRow{}).Where("import_id = ? AND row_number = ?", importID, ro.RowNumber).
+				Updates(map[string]interface{}{"status": entity.ImportRowCreated, "order_id": ro.Order.ID}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *GormOrderImportRepo) FailStale(ctx context.Context, exceptInstance string, heartbeatBefore time.Time, reason string) (int64, error) {
+	now := time.Now()
+	res := r.db.WithContext(ctx).Model(&entity.OrderImport{}).
+		Where("(heartbeat_at IS NULL OR heartbeat_at < ?) AND status NOT IN ?", heartbeatBefore, []entity.OrderImportStatus{entity.ImportCompleted, entity.ImportFailed}).
+		Where("instance IS DISTINCT FROM ?", exceptInstance).
+		Updates(map[string]interface{}{"status": entity.ImportFailed, "error": reason, "completed_at": now})
+	return res.RowsAffected, res.Error
+}
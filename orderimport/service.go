@@ -0,0 +1,92 @@
+// Package orderimport creates many orders from one CSV or JSON upload. Rows are checked,
+// priced server-side and created in one transaction in the background, then each order
+// is dispatched; the import's counters and per-row results report progress.
+package orderimport
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/entity"
+)
+
+// MaxRows caps the rows in one import.
+const MaxRows = 500
+
+// MaxUploadBytes caps the size of an uploaded file.
+const MaxUploadBytes = 2 << 20
+
+// HeartbeatEvery is how often a running import refreshes its heartbeat.
+const HeartbeatEvery = 30 * time.Second
+
+// StaleAfter is how long an unfinished import may go without a heartbeat before it is
+// considered abandoned by a stopped instance.
+const StaleAfter = 2 * time.Minute
+
+// EventProgress carries the OrderImport (without rows) to the customer as it advances.
+const EventProgress = "order_import.progress"
+
+var (
+	ErrNoRows = errors.New("import has no rows")
+	// ErrTooManyRows is returned for imports over MaxRows.
+	ErrTooManyRows = errors.New("import has more than 500 rows")
+	// ErrMissingColumns is returned for CSV files whose header lacks required columns.
+	ErrMissingColumns = errors.New("csv header is missing required columns")
+	// ErrCustomerSuspended is returned when a suspended or deactivated customer imports orders.
+	ErrCustomerSuspended = errors.New("customer account is suspended")
+)
+
+// RowInput is one order to create. Coordinates are required: they are needed for pricing.
+type RowInput struct {
+	// Reference is the merchant's own identifier, echoed in the row's result.
+	Reference      string   `json:"reference,omitempty"`
+	TypeID         string   `json:"type_id"`
+	VehicleTypeID  string   `json:"vehicle_type_id"`
+	ReceiverPhone  string   `json:"receiver_phone"`
+	PickupAddress  string   `json:"pickup_address"`
+	PickupLat      *float64 `json:"pickup_lat"`
+	PickupLng      *float64 `json:"pickup_lng"`
+	DropoffAddress string   `json:"dropoff_address"`
+	DropoffLat     *float64 `json:"dropoff_lat"`
+	DropoffLng     *float64 `json:"dropoff_lng"`
+
+	// parseErrors are CSV cells that could not be read; they make the row invalid.
+	parseErrors []string
+}
+
+// SubmitRequest starts an import. Atomic imports create nothing unless every row is
+// valid; otherwise valid rows are created and invalid ones reported.
+type SubmitRequest struct {
+	CustomerID uuid.UUID
+	Format     string // "csv" or "json"
+	Atomic     bool
+	Rows       []RowInput
+}
+
+// ImportDetail is an import with its per-row results in row order.
+type ImportDetail struct {
+	Import entity.OrderImport      `json:"import"`
+	Rows   []entity.OrderImportRow `json:"rows"`
+}
+
+// Notifier delivers progress events; the realtime hub implements it.
+type Notifier interface {
+	NotifyCustomer(customerID string, event string, payload any) error
+}
+
+// Service runs bulk order imports. Methods taking a customer id return
+// gorm.ErrRecordNotFound for imports of other customers.
+type Service interface {
+	// Submit stores the import and its rows and processes it in the background.
+	Submit(ctx context.Context, req SubmitRequest) (*entity.OrderImport, error)
+	Get(ctx context.Context, id, customerID uuid.UUID) (*ImportDetail, error)
+	// List returns the customer's imports, newest first, without rows.
+	List(ctx context.Context, customerID uuid.UUID, limit, offset int) ([]entity.OrderImport, int64, error)
+	// FailInterrupted marks unfinished imports whose heartbeat is older than StaleAfter
+	// at now as failed: the instance running them stopped. Imports still running on any
+	// instance are left alone, and the calling instance never fails its own. Orders an interrupted import already created are kept;
+	// they may still need dispatching.
+	FailInterrupted(ctx context.Context, now time.Time) (int64, error)
+}
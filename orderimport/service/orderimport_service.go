@@ -0,0 +1,344 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/dispatch"
+	"github.com/mikios34/delivery-backend/entity"
+	"github.com/mikios34/delivery-backend/order"
+	"github.com/mikios34/delivery-backend/orderimport"
+	"github.com/mikios34/delivery-backend/pricing"
+	"gorm.io/gorm"
+)
+
+// runTimeout bounds the background processing of one import.
+const runTimeout = 30 * time.Minute
+
+// progressEvery is how many rows pass between progress events within a stage.
+const progressEvery = 10
+
+// maxAddressLen caps pickup and dropoff addresses.
+const maxAddressLen = 500
+
+// orderImportService implements orderimport.Service.
+type orderImportService struct {
+	repo     orderimport.Repository
+	orders   order.Repository
+	pricing  pricing.Service
+	dispatch dispatch.Service
+	notifier orderimport.Notifier
+	// instance names this process on the imports it runs.
+	instance string
+}
+
+// NewOrderImportService constructs an orderimport.Service. Orders are priced with
+// pricing and dispatched with dispatch; notifier may be nil.
+func NewOrderImportService(repo orderimport.Repository, orders order.Repository, p pricing.Service, d dispatch.Service, notifier orderimport.Notifier) orderimport.Service {
+	return &orderImportService{repo: repo, orders: orders, pricing: p, dispatch: d, notifier: notifier, instance: instanceName()}
+}
+
+// instanceName is the host name and a random suffix, unique per process.
+func instanceName() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return host + "/" + uuid.NewString()[:8]
+}
+
+func (s *orderImportService) Submit(ctx context.Context, req orderimport.SubmitRequest) (*entity.OrderImport, error) {
+	if len(req.Rows) == 0 {
+		return nil, orderimport.ErrNoRows
+	}
+	if len(req.Rows) > orderimport.MaxRows {
+		return nil, orderimport.ErrTooManyRows
+	}
+	cust, err := s.orders.GetCustomer(ctx, req.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+	if !cust.Active || cust.SuspendedAt != nil {
+		return nil, orderimport.ErrCustomerSuspended
+	}
+	imp := &entity.OrderImport{
+		CustomerID: req.CustomerID,
+		Format:     req.Format,
+		Atomic:     req.Atomic,
+		Status:     entity.ImportPending,
+		TotalRows:  len(req.Rows),
+		Instance:   s.instance,
+	}
+	now := time.Now()
+	imp.HeartbeatAt = &now
+	rows := make([]entity.OrderImportRow, len(req.Rows))
+	for i, in := range req.Rows {
+		raw, _ := json.Marshal(in)
+		rows[i] = entity.OrderImportRow{RowNumber: i + 1, Status: entity.ImportRowPending, Reference: in.Reference, Input: raw}
+	}
+	if err := s.repo.CreateImport(ctx, imp, rows); err != nil {
+		return nil, err
+	}
+	go s.run(*imp, req.Rows)
+	return imp, nil
+}
+
+// run validates and prices every row, creates the orders in one transaction and
+// dispatches them, recording progress on imp as it goes.
+func (s *orderImportService) run(imp entity.OrderImport, inputs []orderimport.RowInput) {
+	ctx, cancel := context.WithTimeout(context.Background(), runTimeout)
+	defer cancel()
+	go s.heartbeat(ctx, imp.ID)
+
+	s.advance(ctx, &imp, map[string]interface{}{"status": entity.ImportValidating})
+	orderTypes, err := s.orders.ListOrderTypes(ctx)
+	if err != nil {
+		s.fail(ctx, &imp, "failed to load order types: "+err.Error())
+		return
+	}
+	vehicleTypes, err := s.orders.ListActiveVehicleTypes(ctx)
+	if err != nil {
+		s.fail(ctx, &imp, "failed to load vehicle types: "+err.Error())
+		return
+	}
+	activeTypes := make(map[uuid.UUID]bool, len(orderTypes))
+	for _, t := range orderTypes {
+		activeTypes[t.ID] = true
+	}
+	vehicles := make(map[uuid.UUID]entity.VehicleTypeConfig, len(vehicleTypes))
+	for _, vt := range vehicleTypes {
+		vehicles[vt.ID] = vt
+	}
+
+	var valid []orderimport.RowOrder
+	for i, in := range inputs {
+		rowNumber := i + 1
+		o, errs := s.build(ctx, imp.CustomerID, in, activeTypes, vehicles)
+		row := &entity.OrderImportRow{ImportID: imp.ID, RowNumber: rowNumber, Status: entity.ImportRowValid}
+		if len(errs) > 0 {
+			row.Status, row.Errors = entity.ImportRowInvalid, errs
+			imp.Invalid++
+		} else {
+			row.PriceCents = o.EstimatedPriceCents
+			valid = append(valid, orderimport.RowOrder{RowNumber: rowNumber, Order: o})
+		}
+		if err := s.repo.UpdateRow(ctx, row, "status", "errors", "price_cents"); err != nil {
+			log.Printf("order import %s: row %d: %v", imp.ID, rowNumber, err)
+		}
+		imp.Validated++
+		s.progress(ctx, &imp, rowNumber, map[string]interface{}{"validated": imp.Validated, "invalid": imp.Invalid})
+	}
+
+	if imp.Atomic && imp.Invalid > 0 {
+		for _, ro := range valid {
+			_ = s.repo.UpdateRow(ctx, &entity.OrderImportRow{ImportID: imp.ID, RowNumber: ro.RowNumber, Status: entity.ImportRowSkipped}, "status")
+		}
+		s.fail(ctx, &imp, "some rows are invalid; no orders were created")
+		return
+	}
+	if len(valid) == 0 {
+		s.complete(ctx, &imp)
+		return
+	}
+
+	s.advance(ctx, &imp, map[string]interface{}{"status": entity.ImportCreating})
+	if err := s.repo.CreateOrders(ctx, imp.ID, valid); err != nil {
+		s.fail(ctx, &imp, "failed to create orders: "+err.Error())
+		return
+	}
+	imp.Created = len(valid)
+	s.advance(ctx, &imp, map[string]interface{}{"status": entity.ImportDispatching, "created": imp.Created})
+
+	for i, ro := range valid {
+		o := ro.Order
+		if s.notifier != nil {
+			_ = s.notifier.NotifyCustomer(o.CustomerID.String(), "order.created", map[string]any{"order_id": o.ID.String(), "status": string(o.Status)})
+		}
+		row := &entity.OrderImportRow{ImportID: imp.ID, RowNumber: ro.RowNumber}
+		assigned, courier, err := s.dispatch.FindAndAssign(ctx, o.ID)
+		if err != nil {
+			row.DispatchError = err.Error()
+		} else {
+			row.OrderStatus = assigned.Status
+			if courier != nil {
+				imp.Assigned++
+			}
+		}
+		if err := s.repo.UpdateRow(ctx, row, "order_status", "dispatch_error"); err != nil {
+			log.Printf("order import %s: row %d: %v", imp.ID, ro.RowNumber, err)
+		}
+		imp.Dispatched++
+		s.progress(ctx, &imp, i+1, map[string]interface{}{"dispatched": imp.Dispatched, "assigned": imp.Assigned})
+	}
+	s.complete(ctx, &imp)
+}
+
+// heartbeat refreshes the import's heartbeat until ctx ends, i.e. until run returns.
+func (s *orderImportService) heartbeat(ctx context.Context, id uuid.UUID) {
+	t := time.NewTicker(orderimport.HeartbeatEvery)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-t.C:
+			if err := s.repo.UpdateImport(ctx, id, map[string]interface{}{"heartbeat_at": now}); err != nil && ctx.Err() == nil {
+				log.Printf("order import %s: heartbeat: %v", id, err)
+			}
+		}
+	}
+}
+
+// build checks a row and prices it, returning the order to create or the row's errors.
+func (s *orderImportService) build(ctx context.Context, customerID uuid.UUID, in orderimport.RowInput, activeTypes map[uuid.UUID]bool, vehicles map[uuid.UUID]entity.VehicleTypeConfig) (*entity.Order, []string) {
+	errs := append([]string(nil), in.ParseErrors()...)
+	typeID, err := uuid.Parse(in.TypeID)
+	if err != nil {
+		errs = append(errs, "type_id is not a valid id")
+	} else if !activeTypes[typeID] {
+		errs = append(errs, "type_id is not an active order type")
+	}
+	vt, ok := entity.VehicleTypeConfig{}, false
+	if vtID, err := uuid.Parse(in.VehicleTypeID); err != nil {
+		errs = append(errs, "vehicle_type_id is not a valid id")
+	} else if vt, ok = vehicles[vtID]; !ok {
+		errs = append(errs, "vehicle_type_id is not an active vehicle type")
+	}
+	if !validPhone(in.ReceiverPhone) {
+		errs = append(errs, "receiver_phone must be 7-15 digits, optionally starting with +")
+	}
+	for _, a := range []struct{ name, value string }{{"pickup_address", in.PickupAddress}, {"dropoff_address", in.DropoffAddress}} {
+		if v := strings.TrimSpace(a.value); v == "" || len(v) > maxAddressLen {
+			errs = append(errs, a.name+" must be 1-500 characters")
+		}
+	}
+	pickup, pickupOK := point(in.PickupLat, in.PickupLng)
+	if !pickupOK {
+		errs = append(errs, "pickup_lat and pickup_lng are required and must be valid coordinates")
+	}
+	dropoff, dropoffOK := point(in.DropoffLat, in.DropoffLng)
+	if !dropoffOK {
+		errs = append(errs, "dropoff_lat and dropoff_lng are required and must be valid coordinates")
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	qctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	quote := s.pricing.Quote(qctx, pickup, dropoff, []entity.VehicleTypeConfig{vt})[0]
+	return &entity.Order{
+		CustomerID:          customerID,
+		TypeID:              typeID,
+		VehicleTypeID:       vt.ID,
+		ReceiverPhone:       strings.TrimSpace(in.ReceiverPhone),
+		PickupAddress:       strings.TrimSpace(in.PickupAddress),
+		PickupLat:           in.PickupLat,
+		PickupLng:           in.PickupLng,
+		DropoffAddress:      strings.TrimSpace(in.DropoffAddress),
+		DropoffLat:          in.DropoffLat,
+		DropoffLng:          in.DropoffLng,
+		EstimatedPriceCents: quote.PriceCents,
+		ServerPriced:        true,
+		PricingVersionID:    vt.PricingVersionID,
+		Status:              entity.OrderPending,
+	}, nil
+}
+
+func point(lat, lng *float64) (pricing.Point, bool) {
+	if lat == nil || lng == nil {
+		return pricing.Point{}, false
+	}
+	p := pricing.Point{Lat: *lat, Lng: *lng}
+	return p, p.Valid()
+}
+
+// validPhone accepts 7-15 digits with an optional leading +; spaces, dashes and
+// parentheses are ignored.
+func validPhone(phone string) bool {
+	phone = strings.TrimPrefix(strings.TrimSpace(phone), "+")
+	digits := 0
+	for _, r := range phone {
+		switch {
+		case r >= '0' && r <= '9':
+			digits++
+		case r == ' ' || r == '-' || r == '(' || r == ')':
+		default:
+			return false
+		}
+	}
+	return digits >= 7 && digits <= 15
+}
+
+// progress stores the counters and, every progressEvery rows, tells the customer.
+func (s *orderImportService) progress(ctx context.Context, imp *entity.OrderImport, n int, fields map[string]interface{}) {
+	if err := s.repo.UpdateImport(ctx, imp.ID, fields); err != nil {
+		log.Printf("order import %s: %v", imp.ID, err)
+	}
+	if n%progressEvery == 0 {
+		s.notify(imp)
+	}
+}
+
+// advance moves the import to another stage and tells the customer.
+func (s *orderImportService) advance(ctx context.Context, imp *entity.OrderImport, fields map[string]interface{}) {
+	if status, ok := fields["status"].(entity.OrderImportStatus); ok {
+		imp.Status = status
+	}
+	if err := s.repo.UpdateImport(ctx, imp.ID, fields); err != nil {
+		log.Printf("order import %s: %v", imp.ID, err)
+	}
+	s.notify(imp)
+}
+
+func (s *orderImportService) complete(ctx context.Context, imp *entity.OrderImport) {
+	now := time.Now()
+	imp.CompletedAt = &now
+	s.advance(ctx, imp, map[string]interface{}{"status": entity.ImportCompleted, "completed_at": now})
+}
+
+func (s *orderImportService) fail(ctx context.Context, imp *entity.OrderImport, reason string) {
+	now := time.Now()
+	imp.Error, imp.CompletedAt = reason, &now
+	s.advance(ctx, imp, map[string]interface{}{"status": entity.ImportFailed, "error": reason, "completed_at": now})
+}
+
+func (s *orderImportService) notify(imp *entity.OrderImport) {
+	if s.notifier != nil {
+		_ = s.notifier.NotifyCustomer(imp.CustomerID.String(), orderimport.EventProgress, imp)
+	}
+}
+
+func (s *orderImportService) Get(ctx context.Context, id, customerID uuid.UUID) (*orderimport.ImportDetail, error) {
+	imp, err := s.repo.GetImport(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if imp.CustomerID != customerID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	rows, err := s.repo.ListRows(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &orderimport.ImportDetail{Import: *imp, Rows: rows}, nil
+}
+
+func (s *orderImportService) List(ctx context.Context, customerID uuid.UUID, limit, offset int) ([]entity.OrderImport, int64, error) {
+	return s.repo.ListImports(ctx, customerID, limit, offset)
+}
+
+func (s *orderImportService) FailInterrupted(ctx context.Context, now time.Time) (int64, error) {
+	// Our own imports are still running in this process even if a slow database delayed
+	// their heartbeat; after a restart the instance name differs, so they are caught then.
+	n, err := s.repo.FailStale(ctx, s.instance, now.Add(-orderimport.StaleAfter), "interrupted: the server running it stopped")
+	if err == nil && n > 0 {
+		log.Printf("order imports: instance %s failed %d imports interrupted on other instances", s.instance, n)
+	}
+	return n, err
+}
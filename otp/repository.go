@@ -0,0 +1,22 @@
+package otp
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/entity"
+)
+
+// Repository specifies OTP code persistence.
+type Repository interface {
+	CreateCode(ctx context.Context, c *entity.OTPCode) error
+	// LatestCode returns the most recently issued code for the phone.
+	LatestCode(ctx context.Context, phone string) (*entity.OTPCode, error)
+	// CountSentSince counts codes issued to the phone since the given time.
+	CountSentSince(ctx context.Context, phone string, since time.Time) (int64, error)
+	// IncrementAttempts records a verification attempt unless maxAttempts was already reached.
+	IncrementAttempts(ctx context.Context, id uuid.UUID, maxAttempts int) (bool, error)
+	// Consume marks the code used; false if it had already been consumed.
+	Consume(ctx context.Context, id uuid.UUID, at time.Time) (bool, error)
+}
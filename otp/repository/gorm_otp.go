@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/entity"
+	"github.com/mikios34/delivery-backend/otp"
+	"gorm.io/gorm"
+)
+
+// GormOTPRepo implements otp.Repository using GORM.
+type GormOTPRepo struct {
+	db *gorm.DB
+}
+
+// NewGormOTPRepo constructs a new GormOTPRepo.
+func NewGormOTPRepo(db *gorm.DB) otp.Repository {
+	return &GormOTPRepo{db: db}
+}
+
+func (r *GormOTPRepo) CreateCode(ctx context.Context, c *entity.OTPCode) error {
+	return r.db.WithContext(ctx).Create(c).Error
+}
+
+func (r *GormOTPRepo) LatestCode(ctx context.Context, phone string) (*entity.OTPCode, error) {
+	var c entity.OTPCode
+	if err := r.db.WithContext(ctx).Where("phone = ?", phone).Order("created_at DESC").First(&c).Error; err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *GormOTPRepo) CountSentSince(ctx context.Context, phone string, since time.Time) (int64, error) {
+	var n int64
+	err := r.db.WithContext(ctx).Model(&entity.OTPCode{}).
+		Where("phone = ? AND created_at >= ?", phone, since).
+		Count(&n).Error
+	return n, err
+}
+
+func (r *GormOTPRepo) IncrementAttempts(ctx context.Context, id uuid.UUID, maxAttempts int) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&entity.OTPCode{}).
+		Where("id = ? AND attempts < ?", id, maxAttempts).
+		UpdateColumn("attempts", gorm.Expr("attempts + 1"))
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *GormOTPRepo) Consume(ctx context.Context, id uuid.UUID, at time.Time) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&entity.OTPCode{}).
+		Where("id = ? AND consumed_at IS NULL", id).
+		Update("consumed_at", at)
+	return res.RowsAffected > 0, res.Error
+}
@@ -0,0 +1,56 @@
+package otp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidPhone is returned for phone numbers that cannot receive a code.
+	ErrInvalidPhone = errors.New("invalid phone number")
+	// ErrInvalidCode is returned when the code does not match the latest one sent.
+	ErrInvalidCode = errors.New("invalid verification code")
+	// ErrCodeExpired is returned when the latest code expired or was already used.
+	ErrCodeExpired = errors.New("verification code expired; request a new one")
+	// ErrTooManyAttempts is returned once a code has been guessed wrong too often.
+	ErrTooManyAttempts = errors.New("too many attempts; request a new code")
+)
+
+var phonePattern = regexp.MustCompile(`^\+?[0-9]{7,15}$`)
+
+// NormalizePhone strips formatting so "+251 91-234" and "+25191234" share codes and
+// limits. Callers that look a user up after verifying a code must use the same form.
+func NormalizePhone(phone string) (string, error) {
+	p := strings.NewReplacer(" ", "", "-", "", "(", "", ")", "").Replace(strings.TrimSpace(phone))
+	if !phonePattern.MatchString(p) {
+		return "", ErrInvalidPhone
+	}
+	return p, nil
+}
+
+// CooldownError is returned when a code is requested before the phone may receive another.
+type CooldownError struct {
+	RetryAfter time.Duration
+}
+
+func (e *CooldownError) Error() string {
+	return fmt.Sprintf("code recently sent; retry in %ds", int(e.RetryAfter.Seconds()))
+}
+
+// Challenge describes a code that was just sent.
+type Challenge struct {
+	ExpiresAt time.Time `json:"expires_at"`
+	ResendAt  time.Time `json:"resend_at"`
+}
+
+// Service issues and verifies SMS one-time codes.
+type Service interface {
+	// Request sends a fresh code to the phone, superseding earlier ones.
+	Request(ctx context.Context, phone string) (*Challenge, error)
+	// Verify checks and consumes the latest code sent to the phone.
+	Verify(ctx context.Context, phone, code string) error
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/entity"
+	"github.com/mikios34/delivery-backend/otp"
+	"github.com/mikios34/delivery-backend/sms"
+	"gorm.io/gorm"
+)
+
+const (
+	codeDigits      = 6
+	codeTTL         = 5 * time.Minute
+	resendCooldown  = 60 * time.Second
+	maxAttempts     = 5
+	maxSendsPerHour = 5
+)
+
+// otpService implements otp.Service.
+type otpService struct {
+	repo   otp.Repository
+	sender sms.Sender
+}
+
+// NewOTPService constructs an otp.Service that delivers codes through sender.
+func NewOTPService(repo otp.Repository, sender sms.Sender) otp.Service {
+	return &otpService{repo: repo, sender: sender}
+}
+
+// hashCode binds the code to its row so equal codes never produce equal hashes.
+func hashCode(id uuid.UUID, code string) string {
+	sum := sha256.Sum256([]byte(id.String() + ":" + code))
+	return hex.EncodeToString(sum[:])
+}
+
+func generateCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", codeDigits, n.Int64()), nil
+}
+
+func (s *otpService) Request(ctx context.Context, phone string) (*otp.Challenge, error) {
+	phone, err := otp.NormalizePhone(phone)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	latest, err := s.repo.LatestCode(ctx, phone)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if latest != nil {
+		if wait := latest.CreatedAt.Add(resendCooldown).Sub(now); wait > 0 {
+			return nil, &otp.CooldownError{RetryAfter: wait}
+		}
+		sent, err := s.repo.CountSentSince(ctx, phone, now.Add(-time.Hour))
+		if err != nil {
+			return nil, err
+		}
+		if sent >= maxSendsPerHour {
+			// Upper bound: the window has certainly slid past the limit by then.
+			return nil, &otp.CooldownError{RetryAfter: latest.CreatedAt.Add(time.Hour).Sub(now)}
+		}
+	}
+
+	code, err := generateCode()
+	if err != nil {
+		return nil, err
+	}
+	rec := &entity.OTPCode{
+		ID:        uuid.New(),
+		Phone:     phone,
+		ExpiresAt: now.Add(codeTTL),
+	}
+	rec.CodeHash = hashCode(rec.ID, code)
+	if err := s.repo.CreateCode(ctx, rec); err != nil {
+		return nil, err
+	}
+	msg := fmt.Sprintf("Your verification code is %s. It expires in %d minutes.", code, int(codeTTL.Minutes()))
+	if err := s.sender.Send(ctx, phone, msg); err != nil {
+		return nil, fmt.Errorf("send sms: %w", err)
+	}
+	return &otp.Challenge{ExpiresAt: rec.ExpiresAt, ResendAt: rec.CreatedAt.Add(resendCooldown)}, nil
+}
+
+func (s *otpService) Verify(ctx context.Context, phone, code string) error {
+	phone, err := otp.NormalizePhone(phone)
+	if err != nil {
+		return err
+	}
+	rec, err := s.repo.LatestCode(ctx, phone)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return otp.ErrCodeExpired
+		}
+		return err
+	}
+	now := time.Now()
+	if rec.ConsumedAt != nil || now.After(rec.ExpiresAt) {
+		return otp.ErrCodeExpired
+	}
+	ok, err := s.repo.IncrementAttempts(ctx, rec.ID, maxAttempts)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return otp.ErrTooManyAttempts
+	}
+	if subtle.ConstantTimeCompare([]byte(hashCode(rec.ID, strings.TrimSpace(code))), []byte(rec.CodeHash)) != 1 {
+		return otp.ErrInvalidCode
+	}
+	consumed, err := s.repo.Consume(ctx, rec.ID, now)
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return otp.ErrCodeExpired
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/entity"
+	"github.com/mikios34/delivery-backend/otp"
+	"gorm.io/gorm"
+)
+
+// memCodes is an in-memory otp.Repository.
+type memCodes struct {
+	codes []*entity.OTPCode
+}
+
+func (r *memCodes) CreateCode(_ context.Context, c *entity.OTPCode) error {
+	if c.CreatedAt.IsZero() {
+		c.CreatedAt = time.Now()
+	}
+	r.codes = append(r.codes, c)
+	return nil
+}
+
+func (r *memCodes) LatestCode(_ context.Context, phone string) (*entity.OTPCode, error) {
+	var latest *entity.OTPCode
+	for _, c := range r.codes {
+		if c.Phone == phone && (latest == nil || c.CreatedAt.After(latest.CreatedAt)) {
+			latest = c
+		}
+	}
+	if latest == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return latest, nil
+}
+
+func (r *memCodes) CountSentSince(_ context.Context, phone string, since time.Time) (int64, error) {
+	var n int64
+	for _, c := range r.codes {
+		if c.Phone == phone && !c.CreatedAt.Before(since) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *memCodes) find(id uuid.UUID) *entity.OTPCode {
+	for _, c := range r.codes {
+		if c.ID == id {
+			return c
+		}
+	}
+	return nil
+}
+
+func (r *memCodes) IncrementAttempts(_ context.Context, id uuid.UUID, maxAttempts int) (bool, error) {
+	c := r.find(id)
+	if c.Attempts >= maxAttempts {
+		return false, nil
+	}
+	c.Attempts++
+	return true, nil
+}
+
+func (r *memCodes) Consume(_ context.Context, id uuid.UUID, at time.Time) (bool, error) {
+	c := r.find(id)
+	if c.ConsumedAt != nil {
+		return false, nil
+	}
+	c.ConsumedAt = &at
+	return true, nil
+}
+
+// inbox records sent messages; code returns the code in the latest one.
+type inbox struct {
+	to, last string
+}
+
+func (b *inbox) Send(_ context.Context, phone, message string) error {
+	b.to, b.last = phone, message
+	return nil
+}
+
+var codePattern = regexp.MustCompile(`\d{6}`)
+
+func (b *inbox) code() string { return codePattern.FindString(b.last) }
+
+const testPhone = "+251911234567"
+
+func newOTPFixture(t *testing.T) (*memCodes, *inbox, otp.Service, string) {
+	t.Helper()
+	repo, sms := &memCodes{}, &inbox{}
+	svc := NewOTPService(repo, sms)
+	if _, err := svc.Request(context.Background(), testPhone); err != nil {
+		t.Fatal(err)
+	}
+	return repo, sms, svc, sms.code()
+}
+
+func TestVerifyConsumesCode(t *testing.T) {
+	_, _, svc, code := newOTPFixture(t)
+	ctx := context.Background()
+	if err := svc.Verify(ctx, testPhone, code); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if err := svc.Verify(ctx, testPhone, code); !errors.Is(err, otp.ErrCodeExpired) {
+		t.Errorf("second use err = %v, want ErrCodeExpired", err)
+	}
+}
+
+func TestVerifyIgnoresPhoneFormatting(t *testing.T) {
+	_, sms, svc, code := newOTPFixture(t)
+	if sms.to != testPhone {
+		t.Errorf("sent to %q, want %q", sms.to, testPhone)
+	}
+	if err := svc.Verify(context.Background(), "+251 911-234567", code); err != nil {
+		t.Errorf("verify with a formatted number: %v", err)
+	}
+}
+
+func TestVerifyAttemptLimit(t *testing.T) {
+	_, _, svc, code := newOTPFixture(t)
+	ctx := context.Background()
+	wrong := "000000"
+	if wrong == code {
+		wrong = "111111"
+	}
+	for i := 0; i < maxAttempts; i++ {
+		if err := svc.Verify(ctx, testPhone, wrong); !errors.Is(err, otp.ErrInvalidCode) {
+			t.Fatalf("attempt %d err = %v, want ErrInvalidCode", i+1, err)
+		}
+	}
+	// Even the right code is refused once the attempts are used up.
+	if err := svc.Verify(ctx, testPhone, code); !errors.Is(err, otp.ErrTooManyAttempts) {
+		t.Errorf("err = %v, want ErrTooManyAttempts", err)
+	}
+}
+
+func TestVerifyExpiredCode(t *testing.T) {
+	repo, _, svc, code := newOTPFixture(t)
+	repo.codes[0].ExpiresAt = time.Now().Add(-time.Second)
+	if err := svc.Verify(context.Background(), testPhone, code); !errors.Is(err, otp.ErrCodeExpired) {
+		t.Errorf("err = %v, want ErrCodeExpired", err)
+	}
+}
+
+func TestVerifyOnlyLatestCode(t *testing.T) {
+	repo, sms, svc, first := newOTPFixture(t)
+	repo.codes[0].CreatedAt = time.Now().Add(-2 * resendCooldown)
+	if _, err := svc.Request(context.Background(), testPhone); err != nil {
+		t.Fatal(err)
+	}
+	if second := sms.code(); second == first {
+		t.Skip("both codes happen to be equal")
+	}
+	if err := svc.Verify(context.Background(), testPhone, first); !errors.Is(err, otp.ErrInvalidCode) {
+		t.Errorf("superseded code err = %v, want ErrInvalidCode", err)
+	}
+}
+
+func TestRequestCooldown(t *testing.T) {
+	_, _, svc, _ := newOTPFixture(t)
+	_, err := svc.Request(context.Background(), testPhone)
+	var cooldown *otp.CooldownError
+	if !errors.As(err, &cooldown) {
+		t.Fatalf("err = %v, want a CooldownError", err)
+	}
+	if cooldown.RetryAfter <= 0 || cooldown.RetryAfter > resendCooldown {
+		t.Errorf("retry after %s, want within %s", cooldown.RetryAfter, resendCooldown)
+	}
+}
+
+func TestRequestHourlyCap(t *testing.T) {
+	repo, sms := &memCodes{}, &inbox{}
+	svc := NewOTPService(repo, sms)
+	now := time.Now()
+	// maxSendsPerHour codes, each sent after the previous one's cooldown.
+	for i := 0; i < maxSendsPerHour; i++ {
+		repo.codes = append(repo.codes, &entity.OTPCode{
+			ID: uuid.New(), Phone: testPhone, ExpiresAt: now,
+			CreatedAt: now.Add(-time.Duration(maxSendsPerHour-i) * 2 * resendCooldown),
+		})
+	}
+	_, err := svc.Request(context.Background(), testPhone)
+	var cooldown *otp.CooldownError
+	if !errors.As(err, &cooldown) {
+		t.Fatalf("err = %v, want a CooldownError", err)
+	}
+	if cooldown.RetryAfter <= resendCooldown {
+		t.Errorf("retry after %s, want the hourly window rather than the resend cooldown", cooldown.RetryAfter)
+	}
+	if sms.last != "" {
+		t.Error("a code was sent past the hourly cap")
+	}
+
+	// Once the oldest send leaves the hour window, codes flow again.
+	repo.codes[0].CreatedAt = now.Add(-2 * time.Hour)
+	if _, err := svc.Request(context.Background(), testPhone); err != nil {
+		t.Errorf("request after the window slid: %v", err)
+	}
+}
+
+func TestRequestInvalidPhone(t *testing.T) {
+	svc := NewOTPService(&memCodes{}, &inbox{})
+	if _, err := svc.Request(context.Background(), "call me"); !errors.Is(err, otp.ErrInvalidPhone) {
+		t.Errorf("err = %v, want ErrInvalidPhone", err)
+	}
+}
@@ -0,0 +1,154 @@
+// Package pricing quotes delivery fares from vehicle type pricing and road routes.
+package pricing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/mikios34/delivery-backend/entity"
+)
+
+// DefaultRouterURL is the public OSRM server used when no router is configured.
+const DefaultRouterURL = "https://router.project-osrm.org"
+
+// Point is a WGS84 coordinate.
+type Point struct {
+	Lat float64
+	Lng float64
+}
+
+// Valid reports whether the point is within latitude/longitude bounds.
+func (p Point) Valid() bool {
+	return p.Lat >= -90 && p.Lat <= 90 && p.Lng >= -180 && p.Lng <= 180
+}
+
+// Quote is the fare for one vehicle type.
+type Quote struct {
+	VehicleType entity.VehicleTypeConfig
+	DistanceKm  float64
+	DurationMin float64
+	// PriceCents is max(minimum, base + per_km*distance + per_minute*duration + booking_fee).
+	PriceCents int64
+}
+
+// Service prices trips.
+type Service interface {
+	// Quote prices the trip for each vehicle type, in the order given. Routes are fetched
+	// once per routing profile; when routing fails the straight-line distance and the
+	// vehicle type's average speed are used instead.
+	Quote(ctx context.Context, pickup, dropoff Point, types []entity.VehicleTypeConfig) []Quote
+}
+
+type service struct {
+	routerURL string
+	client    *http.Client
+}
+
+// New constructs a pricing Service routing through the OSRM server at routerURL
+// (DefaultRouterURL when empty).
+func New(routerURL string) Service {
+	if routerURL == "" {
+		routerURL = DefaultRouterURL
+	}
+	return &service{routerURL: strings.TrimRight(routerURL, "/"), client: http.DefaultClient}
+}
+
+type route struct{ distKm, durMin float64 }
+
+func (s *service) Quote(ctx context.Context, pickup, dropoff Point, types []entity.VehicleTypeConfig) []Quote {
+	distKmFallback := haversineKm(pickup, dropoff)
+	routes := map[string]route{}
+	for _, vt := range types {
+		profile := profileFor(vt.Code)
+		if _, done := routes[profile]; done {
+			continue
+		}
+		r, err := s.route(ctx, profile, pickup, dropoff)
+		if err != nil {
+			// record zero to signal fallback
+			r = route{}
+		}
+		routes[profile] = r
+	}
+
+	out := make([]Quote, 0, len(types))
+	for _, vt := range types {
+		r := routes[profileFor(vt.Code)]
+		distKm, durMin := r.distKm, r.durMin
+		if distKm <= 0 || durMin <= 0 {
+			// fallback duration from average speed
+			distKm = distKmFallback
+			speed := vt.AvgSpeedKmh
+			if speed <= 0 {
+				speed = 30
+			}
+			durMin = (distKm / speed) * 60
+		}
+		calc := vt.BaseFare + vt.PerKm*distKm + vt.PerMinute*durMin + vt.BookingFee
+		if calc < vt.MinimumFare {
+			calc = vt.MinimumFare
+		}
+		out = append(out, Quote{VehicleType: vt, DistanceKm: distKm, DurationMin: durMin, PriceCents: int64(math.Round(calc * 100))})
+	}
+	return out
+}
+
+type osrmResponse struct {
+	Routes []struct {
+		Distance float64 `json:"distance"` // meters
+		Duration float64 `json:"duration"` // seconds
+	} `json:"routes"`
+	Code string `json:"code"`
+	Msg  string `json:"message"`
+}
+
+func (s *service) route(ctx context.Context, profile string, from, to Point) (route, error) {
+	url := fmt.Sprintf("%s/route/v1/%s/%.6f,%.6f;%.6f,%.6f?overview=false&alternatives=false&steps=false", s.routerURL, profile, from.Lng, from.Lat, to.Lng, to.Lat)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return route{}, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return route{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return route{}, fmt.Errorf("routing status %d", resp.StatusCode)
+	}
+	var rr osrmResponse
+	if err := json.NewDecoder(resp.Body).Decode(&rr); err != nil {
+		return route{}, err
+	}
+	if rr.Code != "Ok" || len(rr.Routes) == 0 {
+		return route{}, fmt.Errorf("routing error: %s", rr.Msg)
+	}
+	return route{distKm: rr.Routes[0].Distance / 1000.0, durMin: rr.Routes[0].Duration / 60.0}, nil
+}
+
+// profileFor maps a vehicle code to an OSRM profile.
+func profileFor(code string) string {
+	switch strings.ToLower(strings.TrimSpace(code)) {
+	case "bike", "bicycle", "cycle":
+		return "cycling"
+	case "walker", "walk", "foot":
+		return "walking"
+	default:
+		// motorbike, motor, scooter, car, taxi, other -> driving
+		return "driving"
+	}
+}
+
+// haversineKm is the great-circle distance in km.
+func haversineKm(a, b Point) float64 {
+	const R = 6371.0 // Earth radius in km
+	toRad := func(d float64) float64 { return d * (math.Pi / 180.0) }
+	dLat := toRad(b.Lat - a.Lat)
+	dLon := toRad(b.Lng - a.Lng)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(toRad(a.Lat))*math.Cos(toRad(b.Lat))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return R * 2 * math.Atan2(math.Sqrt(h), math.Sqrt(1.0-h))
+}
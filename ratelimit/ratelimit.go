@@ -0,0 +1,92 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Policy is a token bucket: Burst tokens at most, refilled at Rate tokens per second.
+type Policy struct {
+	Rate  float64
+	Burst int
+}
+
+// Every allows n requests per period, all of which may be used in a burst.
+func Every(n int, per time.Duration) Policy {
+	return Policy{Rate: float64(n) / per.Seconds(), Burst: n}
+}
+
+// Decision is the outcome of taking a token.
+type Decision struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+// Store persists buckets. Take refills the bucket for key up to now and consumes one token.
+type Store interface {
+	Take(ctx context.Context, key string, p Policy, now time.Time) (Decision, error)
+}
+
+// Refill applies the token bucket algorithm to a bucket last updated at `last` holding
+// `tokens`, consuming one token if possible. It returns the new token count.
+// A zero `last` means a new, full bucket.
+func Refill(tokens float64, last time.Time, p Policy, now time.Time) (float64, Decision) {
+	burst := float64(p.Burst)
+	if last.IsZero() {
+		tokens = burst
+	} else if elapsed := now.Sub(last).Seconds(); elapsed > 0 {
+		tokens = math.Min(burst, tokens+elapsed*p.Rate)
+	}
+	if tokens >= 1 {
+		tokens--
+		return tokens, Decision{Allowed: true, Remaining: int(tokens)}
+	}
+	wait := time.Duration((1 - tokens) / p.Rate * float64(time.Second))
+	return tokens, Decision{Allowed: false, RetryAfter: wait}
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	// idle is when the bucket will be full again and can be forgotten.
+	idle time.Time
+}
+
+// MemoryStore keeps buckets in process memory. Suitable for a single replica.
+type MemoryStore struct {
+	mu       sync.Mutex
+	buckets  map[string]*bucket
+	lastGC   time.Time
+	gcPeriod time.Duration
+}
+
+// NewMemoryStore constructs an in-memory Store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket), gcPeriod: time.Minute}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, p Policy, now time.Time) (Decision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now.Sub(s.lastGC) > s.gcPeriod {
+		// Full buckets behave exactly like missing ones, so they can be dropped.
+		for k, b := range s.buckets {
+			if now.After(b.idle) {
+				delete(s.buckets, k)
+			}
+		}
+		s.lastGC = now
+	}
+	b := s.buckets[key]
+	if b == nil {
+		b = &bucket{}
+		s.buckets[key] = b
+	}
+	tokens, d := Refill(b.tokens, b.last, p, now)
+	b.tokens, b.last = tokens, now
+	b.idle = now.Add(time.Duration((float64(p.Burst) - tokens) / p.Rate * float64(time.Second)))
+	return d, nil
+}
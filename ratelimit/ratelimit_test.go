@@ -0,0 +1,86 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreBurstAndRefill(t *testing.T) {
+	s := NewMemoryStore()
+	ctx := context.Background()
+	p := Every(3, time.Minute) // one token every 20s
+	now := time.Unix(1_700_000_000, 0)
+
+	for i := 0; i < 3; i++ {
+		d, err := s.Take(ctx, "k", p, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !d.Allowed || d.Remaining != 2-i {
+			t.Fatalf("take %d = %+v, want allowed with %d remaining", i+1, d, 2-i)
+		}
+	}
+
+	d, _ := s.Take(ctx, "k", p, now)
+	if d.Allowed {
+		t.Fatal("fourth take within the burst was allowed")
+	}
+	if d.RetryAfter != 20*time.Second {
+		t.Errorf("retry after %s, want 20s", d.RetryAfter)
+	}
+
+	// Half a token later the wait shrinks accordingly.
+	d, _ = s.Take(ctx, "k", p, now.Add(10*time.Second))
+	if d.Allowed || d.RetryAfter != 10*time.Second {
+		t.Errorf("after 10s = %+v, want denied with 10s to wait", d)
+	}
+
+	// One token has refilled after RetryAfter.
+	if d, _ = s.Take(ctx, "k", p, now.Add(20*time.Second)); !d.Allowed || d.Remaining != 0 {
+		t.Errorf("after 20s = %+v, want allowed with 0 remaining", d)
+	}
+
+	// Refill never exceeds the burst.
+	if d, _ = s.Take(ctx, "k", p, now.Add(time.Hour)); !d.Allowed || d.Remaining != 2 {
+		t.Errorf("after an hour = %+v, want allowed with 2 remaining", d)
+	}
+}
+
+func TestMemoryStoreKeysAreIndependent(t *testing.T) {
+	s := NewMemoryStore()
+	ctx := context.Background()
+	p := Every(1, time.Minute)
+	now := time.Now()
+
+	if d, _ := s.Take(ctx, "a", p, now); !d.Allowed {
+		t.Fatal("first take for a was denied")
+	}
+	if d, _ := s.Take(ctx, "a", p, now); d.Allowed {
+		t.Fatal("second take for a was allowed")
+	}
+	if d, _ := s.Take(ctx, "b", p, now); !d.Allowed {
+		t.Error("an exhausted key limited another key")
+	}
+}
+
+func TestMemoryStoreForgetsFullBuckets(t *testing.T) {
+	s := NewMemoryStore()
+	ctx := context.Background()
+	p := Every(2, time.Minute)
+	now := time.Now()
+
+	s.Take(ctx, "idle", p, now)
+	s.Take(ctx, "busy", p, now.Add(50*time.Second))
+	s.Take(ctx, "busy", p, now.Add(50*time.Second))
+	// The first take after gcPeriod sweeps: "idle" is full again, "busy" is not.
+	if _, err := s.Take(ctx, "other", p, now.Add(time.Minute+10*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.buckets["idle"]; ok {
+		t.Error("a bucket that refilled completely was kept")
+	}
+	if _, ok := s.buckets["busy"]; !ok {
+		t.Error("a bucket still refilling was dropped")
+	}
+}
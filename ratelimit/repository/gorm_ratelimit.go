@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/mikios34/delivery-backend/entity"
+	"github.com/mikios34/delivery-backend/ratelimit"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormRateLimitStore implements ratelimit.Store on Postgres so limits hold across replicas.
+type GormRateLimitStore struct {
+	db *gorm.DB
+}
+
+// NewGormRateLimitStore constructs a new GormRateLimitStore.
+func NewGormRateLimitStore(db *gorm.DB) *GormRateLimitStore {
+	return &GormRateLimitStore{db: db}
+}
+
+// Take locks the bucket row for the duration of the refill so concurrent requests on
+// different replicas serialize on it.
+func (s *GormRateLimitStore) Take(ctx context.Context, key string, p ratelimit.Policy, now time.Time) (ratelimit.Decision, error) {
+	var d ratelimit.Decision
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var b entity.RateLimitBucket
+		res := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("key = ?", key).Limit(1).Find(&b)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			tokens, dec := ratelimit.Refill(0, time.Time{}, p, now)
+			d = dec
+			// Another replica may have created it meanwhile; fall back to updating theirs.
+			ins := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&entity.RateLimitBucket{Key: key, Tokens: tokens, UpdatedAt: now})
+			if ins.Error != nil || ins.RowsAffected == 1 {
+				return ins.Error
+			}
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("key = ?", key).First(&b).Error; err != nil {
+				return err
+			}
+		}
+		tokens, dec := ratelimit.Refill(b.Tokens, b.UpdatedAt, p, now)
+		d = dec
+		return tx.Model(&entity.RateLimitBucket{}).Where("key = ?", key).
+			Updates(map[string]interface{}{"tokens": tokens, "updated_at": now}).Error
+	})
+	return d, err
+}
+
+// PurgeIdle deletes buckets untouched since before; they would be full again anyway.
+func (s *GormRateLimitStore) PurgeIdle(ctx context.Context, before time.Time) (int64, error) {
+	res := s.db.WithContext(ctx).Where("updated_at < ?", before).Delete(&entity.RateLimitBucket{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,108 @@
+package realtime
+
+import (
+	"log"
+
+	"github.com/gorilla/websocket"
+	"github.com/mikios34/delivery-backend/realtime/protocol"
+)
+
+// Change kinds reported to the OnChange observer.
+const (
+	ChangeOrder   = "order"
+	ChangeCourier = "courier"
+)
+
+// Change tells the observer that an order or courier may have changed; it re-reads state.
+type Change struct {
+	Kind string
+	ID   string
+}
+
+// OnChange sets the observer called for every order event sent through the hub, courier
+// socket connects and disconnects, and CourierChanged calls. It must not block.
+func (h *Hub) OnChange(fn func(Change)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.onChange = fn
+}
+
+// CourierChanged reports a courier update (location, availability, onboarding) that does
+// not otherwise pass through the hub.
+func (h *Hub) CourierChanged(courierID string) {
+	h.changed(Change{Kind: ChangeCourier, ID: courierID})
+}
+
+func (h *Hub) changed(ch Change) {
+	h.mu.RLock()
+	fn := h.onChange
+	h.mu.RUnlock()
+	if fn != nil {
+		fn(ch)
+	}
+}
+
+// AdminSocket is a registered operations dashboard socket.
+type AdminSocket struct {
+	hub *Hub
+	wc  *wsConn
+}
+
+// RegisterAdmin adds a dashboard socket. An admin may have several consoles open, so
+// sockets are not keyed by admin. Close the returned socket when the connection ends.
+func (h *Hub) RegisterAdmin(conn *websocket.Conn) *AdminSocket {
+	s := &AdminSocket{hub: h, wc: &wsConn{conn: conn}}
+	h.mu.Lock()
+	h.admins[s.wc] = struct{}{}
+	h.mu.Unlock()
+	return s
+}
+
+// Send writes an event to this socket only, serialized with broadcasts.
+func (s *AdminSocket) Send(event string, payload any) error {
+	return s.wc.write(protocol.New(event, payload))
+}
+
+// Close removes the socket from the hub; it is safe to call more than once.
+func (s *AdminSocket) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	if _, ok := s.hub.admins[s.wc]; ok {
+		s.wc.conn.Close()
+		delete(s.hub.admins, s.wc)
+	}
+}
+
+// AdminsConnected reports how many dashboard sockets are open.
+func (h *Hub) AdminsConnected() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.admins)
+}
+
+// BroadcastAdmins sends an event to every dashboard socket.
+func (h *Hub) BroadcastAdmins(event string, payload any) {
+	env := protocol.New(event, payload)
+	h.mu.RLock()
+	conns := make([]*wsConn, 0, len(h.admins))
+	for wc := range h.admins {
+		conns = append(conns, wc)
+	}
+	h.mu.RUnlock()
+	for _, wc := range conns {
+		if err := wc.write(env); err != nil {
+			log.Printf("ws: write to admin socket failed for event %s: %v", event, err)
+		}
+	}
+}
+
+// OnlineCouriers returns the ids of couriers with an open socket.
+func (h *Hub) OnlineCouriers() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	ids := make([]string, 0, len(h.byCourier))
+	for id := range h.byCourier {
+		ids = append(ids, id)
+	}
+	return ids
+}
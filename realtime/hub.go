@@ -4,18 +4,41 @@ import (
 	"encoding/json"
 	"log"
 	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/mikios34/delivery-backend/realtime/protocol"
 )
 
 type Hub struct {
 	mu         sync.RWMutex
 	byCourier  map[string]*wsConn
 	byCustomer map[string]*wsConn
+
+	// Customer event streams (SSE) with a short replay buffer for Last-Event-ID resume.
+	epoch   int64
+	seq     uint64
+	streams map[string]map[*Stream]struct{}
+	history map[string]*customerHistory
+
+	// Order watchers (public tracking streams) keyed by order id.
+	watchers map[string]map[chan struct{}]struct{}
+
+	// Operations dashboard sockets and the observer feeding them.
+	admins   map[*wsConn]struct{}
+	onChange func(Change)
 }
 
 func NewHub() *Hub {
-	return &Hub{byCourier: make(map[string]*wsConn), byCustomer: make(map[string]*wsConn)}
+	return &Hub{
+		byCourier:  make(map[string]*wsConn),
+		byCustomer: make(map[string]*wsConn),
+		epoch:      time.Now().UnixNano(),
+		streams:    make(map[string]map[*Stream]struct{}),
+		history:    make(map[string]*customerHistory),
+		watchers:   make(map[string]map[chan struct{}]struct{}),
+		admins:     make(map[*wsConn]struct{}),
+	}
 }
 
 // wsConn wraps a websocket connection with a write mutex to serialize writes.
@@ -26,38 +49,50 @@ type wsConn struct {
 
 func (h *Hub) RegisterCourier(courierID string, conn *websocket.Conn) {
 	h.mu.Lock()
-	defer h.mu.Unlock()
 	if old, ok := h.byCourier[courierID]; ok {
 		old.conn.Close()
 	}
 	h.byCourier[courierID] = &wsConn{conn: conn}
+	h.mu.Unlock()
+	h.CourierChanged(courierID)
 }
 
 func (h *Hub) UnregisterCourier(courierID string) {
 	h.mu.Lock()
-	defer h.mu.Unlock()
-	if c, ok := h.byCourier[courierID]; ok {
+	c, ok := h.byCourier[courierID]
+	if ok {
 		c.conn.Close()
 		delete(h.byCourier, courierID)
 	}
+	h.mu.Unlock()
+	if ok {
+		h.CourierChanged(courierID)
+	}
 }
 
-// Notify sends a typed event payload to the courier if connected.
+// Notify sends a typed event payload to the courier if connected. Order events also report
+// the courier as changed, since they may have gained or lost the order.
 func (h *Hub) Notify(courierID string, event string, payload any) error {
+	h.signalOrder(payload)
+	if _, ok := payload.(orderScoped); ok {
+		h.CourierChanged(courierID)
+	}
+	return h.SendCourier(courierID, protocol.New(event, payload))
+}
+
+// SendCourier writes a protocol frame (event, ack or error) to the courier if connected.
+func (h *Hub) SendCourier(courierID string, env protocol.Envelope) error {
 	h.mu.RLock()
 	wc, ok := h.byCourier[courierID]
 	h.mu.RUnlock()
 	if !ok {
-		log.Printf("ws: courier %s not connected; drop event %s", courierID, event)
+		log.Printf("ws: courier %s not connected; drop event %s", courierID, env.Event)
 		return nil
 	}
-	msg := map[string]any{"event": event, "data": payload}
-	wc.mu.Lock()
-	defer wc.mu.Unlock()
 	// Log successful send attempts for visibility during development
-	log.Printf("ws: sending to courier %s event=%s", courierID, event)
-	if err := wc.conn.WriteJSON(msg); err != nil {
-		log.Printf("ws: write to courier %s failed for event %s: %v", courierID, event, err)
+	log.Printf("ws: sending to courier %s event=%s", courierID, env.Event)
+	if err := wc.write(env); err != nil {
+		log.Printf("ws: write to courier %s failed for event %s: %v", courierID, env.Event, err)
 		return err
 	}
 	return nil
@@ -82,27 +117,67 @@ func (h *Hub) UnregisterCustomer(customerID string) {
 	}
 }
 
-// NotifyCustomer sends an event to the customer if connected.
+// DisconnectCustomer closes the customer's socket and event streams, e.g. after a
+// suspension. Events already queued on a stream are still delivered.
+func (h *Hub) DisconnectCustomer(customerID string) {
+	h.UnregisterCustomer(customerID)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for s := range h.streams[customerID] {
+		h.dropStreamLocked(s)
+	}
+}
+
+// OnlineCustomers returns the ids of customers with an open socket or event stream.
+func (h *Hub) OnlineCustomers() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	ids := make([]string, 0, len(h.byCustomer))
+	for id := range h.byCustomer {
+		ids = append(ids, id)
+	}
+	for id, set := range h.streams {
+		if _, ok := h.byCustomer[id]; !ok && len(set) > 0 {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// NotifyCustomer sends an event to the customer's socket if connected and to any
+// event streams (SSE) the customer has open.
 func (h *Hub) NotifyCustomer(customerID string, event string, payload any) error {
+	env := protocol.New(event, payload)
+	h.signalOrder(payload)
+	h.publishCustomer(customerID, env)
+	return h.SendCustomer(customerID, env)
+}
+
+// SendCustomer writes a protocol frame (event, ack or error) to the customer if connected.
+func (h *Hub) SendCustomer(customerID string, env protocol.Envelope) error {
 	h.mu.RLock()
 	wc, ok := h.byCustomer[customerID]
 	h.mu.RUnlock()
 	if !ok {
-		log.Printf("ws: customer %s not connected; drop event %s", customerID, event)
+		log.Printf("ws: customer %s not connected; drop event %s", customerID, env.Event)
 		return nil
 	}
-	msg := map[string]any{"event": event, "data": payload}
-	wc.mu.Lock()
-	defer wc.mu.Unlock()
 	// Log successful send attempts for visibility during development
-	log.Printf("ws: sending to customer %s event=%s", customerID, event)
-	if err := wc.conn.WriteJSON(msg); err != nil {
-		log.Printf("ws: write to customer %s failed for event %s: %v", customerID, event, err)
+	log.Printf("ws: sending to customer %s event=%s", customerID, env.Event)
+	if err := wc.write(env); err != nil {
+		log.Printf("ws: write to customer %s failed for event %s: %v", customerID, env.Event, err)
 		return err
 	}
 	return nil
 }
 
+// write serializes frames on a single connection.
+func (wc *wsConn) write(env protocol.Envelope) error {
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+	return wc.conn.WriteJSON(env)
+}
+
 // Helper payloads
 type AssignmentPayload struct {
 	OrderID    string `json:"order_id"`
@@ -122,12 +197,12 @@ type OrderAssignedPayload struct {
 	ReceiverPhone  string   `json:"receiver_phone"`
 }
 
-// OrderStatusPayload is sent to customers on status changes.
+// OrderStatusPayload is sent to customers on status changes. It carries no phone
+// numbers: customer and courier talk through the order chat.
 type OrderStatusPayload struct {
 	OrderID               string  `json:"order_id"`
 	Status                string  `json:"status"`
 	CourierName           *string `json:"courier_name,omitempty"`
-	CourierPhone          *string `json:"courier_phone,omitempty"`
 	CourierProfilePicture *string `json:"courier_profile_picture,omitempty"`
 	// When Status == "assigned", include order details so the app can render without an extra fetch
 	PickupAddress  *string  `json:"pickup_address,omitempty"`
@@ -136,7 +211,36 @@ type OrderStatusPayload struct {
 	DropoffAddress *string  `json:"dropoff_address,omitempty"`
 	DropoffLat     *float64 `json:"dropoff_lat,omitempty"`
 	DropoffLng     *float64 `json:"dropoff_lng,omitempty"`
-	ReceiverPhone  *string  `json:"receiver_phone,omitempty"`
+}
+
+// CourierLocationPayload is sent to customers while their order's courier is en route.
+type CourierLocationPayload struct {
+	OrderID   string   `json:"order_id"`
+	CourierID string   `json:"courier_id"`
+	Latitude  float64  `json:"latitude"`
+	Longitude float64  `json:"longitude"`
+	Heading   *float64 `json:"heading,omitempty"`
+	Speed     *float64 `json:"speed,omitempty"`
+	// NextStop is "pickup" until the package is picked up, then "dropoff".
+	NextStop   string    `json:"next_stop"`
+	ETASeconds *int64    `json:"eta_seconds,omitempty"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// ChatMessagePayload is sent to both chat parties when a message is posted.
+type ChatMessagePayload struct {
+	ID         string    `json:"id"`
+	OrderID    string    `json:"order_id"`
+	SenderRole string    `json:"sender_role"`
+	Body       string    `json:"body"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ChatReadPayload is the read receipt sent when a party reads the other's messages.
+type ChatReadPayload struct {
+	OrderID    string    `json:"order_id"`
+	ReaderRole string    `json:"reader_role"`
+	ReadAt     time.Time `json:"read_at"`
 }
 
 func Marshal(v any) []byte {
@@ -0,0 +1,113 @@
+// Package protocol defines the versioned message format spoken over the realtime sockets.
+//
+// Every frame, in either direction, is an Envelope: {"v": 1, "event": "...", "id": "...", "data": {...}}.
+// Clients attach an optional request id to inbound commands; the server answers each command
+// with either an "ack" or an "error" frame carrying the same id.
+package protocol
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// Version is the current protocol version. Frames without "v" are treated as version 1 so
+// that pre-versioning clients keep working.
+const Version = 1
+
+// Server reply events.
+const (
+	EventAck   = "ack"
+	EventError = "error"
+)
+
+// Inbound courier commands.
+const (
+	EventLocationUpdate = "location.update"
+	EventOrderAccept    = "order.accept"
+	EventOrderDecline   = "order.decline"
+	EventOrderArrive    = "order.arrive"
+	EventOrderPickup    = "order.pickup"
+	EventOrderDeliver   = "order.deliver"
+)
+
+// Inbound customer commands.
+const (
+	EventOrderCancel = "order.cancel"
+)
+
+// Error codes returned in ErrorPayload.Code.
+const (
+	CodeBadRequest         = "bad_request"
+	CodeUnsupportedVersion = "unsupported_version"
+	CodeUnknownEvent       = "unknown_event"
+	CodeForbidden          = "forbidden"
+	CodeInvalidState       = "invalid_state"
+	CodeInternal           = "internal"
+)
+
+// ErrUnsupportedVersion is returned by Decode for frames from a newer protocol version.
+var ErrUnsupportedVersion = errors.New("unsupported protocol version")
+
+// Envelope is an outbound frame.
+type Envelope struct {
+	V     int    `json:"v"`
+	Event string `json:"event"`
+	ID    string `json:"id,omitempty"`
+	Data  any    `json:"data,omitempty"`
+}
+
+// Inbound is a frame received from a client; Data is decoded per event.
+type Inbound struct {
+	V     int             `json:"v"`
+	Event string          `json:"event"`
+	ID    string          `json:"id,omitempty"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// OrderCommand is the payload of every order.* command.
+type OrderCommand struct {
+	OrderID string `json:"order_id"`
+}
+
+// AckPayload confirms a command; Result carries the command outcome (e.g. the updated order).
+type AckPayload struct {
+	Event  string `json:"event"`
+	Result any    `json:"result,omitempty"`
+}
+
+// ErrorPayload describes why a command was rejected.
+type ErrorPayload struct {
+	Event   string `json:"event,omitempty"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// New builds a server-initiated event frame.
+func New(event string, data any) Envelope {
+	return Envelope{V: Version, Event: event, Data: data}
+}
+
+// Ack builds the success reply for the command identified by id.
+func Ack(id, event string, result any) Envelope {
+	return Envelope{V: Version, Event: EventAck, ID: id, Data: AckPayload{Event: event, Result: result}}
+}
+
+// Error builds the failure reply for the command identified by id.
+func Error(id, event, code, message string) Envelope {
+	return Envelope{V: Version, Event: EventError, ID: id, Data: ErrorPayload{Event: event, Code: code, Message: message}}
+}
+
+// Decode parses an inbound frame and checks its version.
+func Decode(b []byte) (*Inbound, error) {
+	var in Inbound
+	if err := json.Unmarshal(b, &in); err != nil {
+		return nil, err
+	}
+	if in.V == 0 {
+		in.V = Version
+	}
+	if in.V > Version {
+		return &in, ErrUnsupportedVersion
+	}
+	return &in, nil
+}
@@ -0,0 +1,179 @@
+package realtime
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mikios34/delivery-backend/realtime/protocol"
+)
+
+const (
+	// historySize bounds how many recent events are kept per customer for Last-Event-ID resume.
+	historySize = 100
+	// historyTTL drops buffered events that are too old to be worth replaying. It is also
+	// the reconnect window: events are buffered for a customer only while they have a
+	// stream open or closed their last one less than historyTTL ago.
+	historyTTL = 10 * time.Minute
+	// streamBuffer is the per-subscriber channel size; slow subscribers are disconnected
+	// (and resume via Last-Event-ID) rather than blocking the hub.
+	streamBuffer = 32
+)
+
+// StreamEvent is a customer event with its resumable id.
+type StreamEvent struct {
+	ID       string
+	Envelope protocol.Envelope
+	seq      uint64
+	at       time.Time
+}
+
+// Stream is a customer event subscription used by the SSE endpoint.
+type Stream struct {
+	C          <-chan StreamEvent
+	ch         chan StreamEvent
+	customerID string
+}
+
+// customerHistory is the replay buffer for one customer.
+type customerHistory struct {
+	events []StreamEvent
+	// evictedUpTo is the highest seq dropped from events (or published before buffering
+	// started); resuming from an older id would miss events.
+	evictedUpTo uint64
+	// closedAt is when the customer's last stream closed; zero while one is open.
+	closedAt time.Time
+}
+
+// eventID encodes the hub epoch so ids from a previous process are never mistaken as resumable.
+func (h *Hub) eventID(seq uint64) string {
+	return fmt.Sprintf("%d-%d", h.epoch, seq)
+}
+
+// parseEventID returns the seq for ids issued by this hub instance.
+func (h *Hub) parseEventID(id string) (uint64, bool) {
+	epoch, seq, ok := strings.Cut(id, "-")
+	if !ok || epoch != strconv.FormatInt(h.epoch, 10) {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// SubscribeCustomer registers an event stream for the customer.
+//
+// When lastEventID identifies an event still in the replay buffer, the events after it are
+// returned in missed and resumed is true. Otherwise resumed is false and the caller should send
+// a fresh snapshot tagged with cursor, the id of the latest event at subscription time.
+func (h *Hub) SubscribeCustomer(customerID, lastEventID string) (s *Stream, missed []StreamEvent, cursor string, resumed bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ch := make(chan StreamEvent, streamBuffer)
+	s = &Stream{C: ch, ch: ch, customerID: customerID}
+	if h.streams[customerID] == nil {
+		h.streams[customerID] = make(map[*Stream]struct{})
+	}
+	h.streams[customerID][s] = struct{}{}
+	cursor = h.eventID(h.seq)
+	hist := h.history[customerID]
+	if hist == nil {
+		// Nothing was buffered for this customer; only an id at the current seq resumes.
+		hist = &customerHistory{evictedUpTo: h.seq}
+		h.history[customerID] = hist
+	}
+	hist.closedAt = time.Time{}
+
+	if lastEventID == "" {
+		return s, nil, cursor, false
+	}
+	last, ok := h.parseEventID(lastEventID)
+	if !ok || last > h.seq || last < hist.evictedUpTo {
+		return s, nil, cursor, false
+	}
+	cutoff := time.Now().Add(-historyTTL)
+	for _, ev := range hist.events {
+		if ev.seq > last && ev.at.After(cutoff) {
+			missed = append(missed, ev)
+		}
+	}
+	return s, missed, cursor, true
+}
+
+// UnsubscribeCustomer removes the stream; it is safe to call more than once.
+func (h *Hub) UnsubscribeCustomer(s *Stream) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.dropStreamLocked(s)
+}
+
+func (h *Hub) dropStreamLocked(s *Stream) {
+	set, ok := h.streams[s.customerID]
+	if !ok {
+		return
+	}
+	if _, ok := set[s]; !ok {
+		return
+	}
+	delete(set, s)
+	close(s.ch)
+	if len(set) == 0 {
+		delete(h.streams, s.customerID)
+		if hist := h.history[s.customerID]; hist != nil {
+			hist.closedAt = time.Now()
+		}
+	}
+}
+
+// publishCustomer records the event for replay and fans it out to the customer's streams.
+// Events are only buffered for customers with a history, i.e. a stream that is open or
+// closed within the reconnect window.
+func (h *Hub) publishCustomer(customerID string, env protocol.Envelope) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.seq++
+	ev := StreamEvent{ID: h.eventID(h.seq), Envelope: env, seq: h.seq, at: time.Now()}
+
+	if hist := h.history[customerID]; hist != nil {
+		hist.events = append(hist.events, ev)
+		hist.trim(ev.at)
+	}
+
+	for s := range h.streams[customerID] {
+		select {
+		case s.ch <- ev:
+		default:
+			// Subscriber is not keeping up; disconnect it so it resumes from its last id.
+			h.dropStreamLocked(s)
+		}
+	}
+}
+
+// trim drops events beyond historySize or older than historyTTL.
+func (hist *customerHistory) trim(now time.Time) {
+	cutoff := now.Add(-historyTTL)
+	for len(hist.events) > 0 && (len(hist.events) > historySize || hist.events[0].at.Before(cutoff)) {
+		hist.evictedUpTo = hist.events[0].seq
+		hist.events = hist.events[1:]
+	}
+}
+
+// SweepStreamHistory drops expired buffered events and forgets customers whose last
+// stream closed more than historyTTL ago. It returns how many customers were forgotten.
+func (h *Hub) SweepStreamHistory(now time.Time) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	n := 0
+	for id, hist := range h.history {
+		if !hist.closedAt.IsZero() && now.Sub(hist.closedAt) > historyTTL {
+			delete(h.history, id)
+			n++
+			continue
+		}
+		hist.trim(now)
+	}
+	return n
+}
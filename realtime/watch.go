@@ -0,0 +1,51 @@
+package realtime
+
+// orderScoped is implemented by payloads that concern a single order, so watchers of that
+// order (e.g. public tracking streams) can be told to refresh.
+type orderScoped interface {
+	orderRef() string
+}
+
+func (p AssignmentPayload) orderRef() string      { return p.OrderID }
+func (p OrderAssignedPayload) orderRef() string   { return p.OrderID }
+func (p OrderStatusPayload) orderRef() string     { return p.OrderID }
+func (p CourierLocationPayload) orderRef() string { return p.OrderID }
+
+// WatchOrder returns a channel that receives a signal whenever an event about the order is
+// sent through the hub. Signals are coalesced; the caller re-reads state on each one.
+// The returned func stops the watch.
+func (h *Hub) WatchOrder(orderID string) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+	h.mu.Lock()
+	if h.watchers[orderID] == nil {
+		h.watchers[orderID] = make(map[chan struct{}]struct{})
+	}
+	h.watchers[orderID][ch] = struct{}{}
+	h.mu.Unlock()
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		delete(h.watchers[orderID], ch)
+		if len(h.watchers[orderID]) == 0 {
+			delete(h.watchers, orderID)
+		}
+	}
+}
+
+// signalOrder wakes the watchers of the order the payload refers to, if any, and reports
+// the change to the dashboard observer.
+func (h *Hub) signalOrder(payload any) {
+	scoped, ok := payload.(orderScoped)
+	if !ok {
+		return
+	}
+	h.mu.RLock()
+	for ch := range h.watchers[scoped.orderRef()] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+	h.mu.RUnlock()
+	h.changed(Change{Kind: ChangeOrder, ID: scoped.orderRef()})
+}
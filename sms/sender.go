@@ -0,0 +1,23 @@
+package sms
+
+import (
+	"context"
+	"log"
+)
+
+// Sender delivers text messages to phone numbers. Implementations wrap an SMS provider.
+type Sender interface {
+	Send(ctx context.Context, phone, message string) error
+}
+
+// LogSender is a Sender for local development: it writes messages to the log instead of
+// sending them. Never use it in production; it leaks one-time codes into the logs.
+type LogSender struct{}
+
+// NewLogSender constructs a logging Sender.
+func NewLogSender() Sender { return LogSender{} }
+
+func (LogSender) Send(_ context.Context, phone, message string) error {
+	log.Printf("sms (not sent) to %s: %s", phone, message)
+	return nil
+}
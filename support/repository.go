@@ -0,0 +1,32 @@
+package support
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/entity"
+)
+
+// Repository specifies ticket persistence.
+type Repository interface {
+	GetOrder(ctx context.Context, id uuid.UUID) (*entity.Order, error)
+	GetAdmin(ctx context.Context, id uuid.UUID) (*entity.Admin, error)
+	// FindUnresolved returns the opener's open or pending ticket on the order.
+	FindUnresolved(ctx context.Context, orderID uuid.UUID, openerRole string, openerID uuid.UUID) (*entity.SupportTicket, error)
+	// CreateTicket stores the ticket and its first message in one transaction.
+	CreateTicket(ctx context.Context, t *entity.SupportTicket, first *entity.TicketMessage) error
+	GetTicket(ctx context.Context, id uuid.UUID) (*entity.SupportTicket, error)
+	// ListTickets returns matching tickets, most recently updated first.
+	ListTickets(ctx context.Context, f TicketFilter) ([]entity.SupportTicket, int64, error)
+	UpdateTicket(ctx context.Context, id uuid.UUID, fields map[string]interface{}) error
+	// AddMessage stores the message with its attachments and applies fields to the ticket
+	// in one transaction.
+	AddMessage(ctx context.Context, m *entity.TicketMessage, fields map[string]interface{}) error
+	// ListMessages returns the ticket's messages oldest first with their attachments.
+	ListMessages(ctx context.Context, ticketID uuid.UUID) ([]entity.TicketMessage, error)
+	GetAttachment(ctx context.Context, ticketID, attachmentID uuid.UUID) (*entity.TicketAttachment, error)
+	ListAdjustments(ctx context.Context, ticketID uuid.UUID) ([]entity.TicketAdjustment, error)
+	// CreateAdjustment stores the adjustment. For refunds it locks the order and returns
+	// ErrRefundExceedsPrice when the order's refunds would exceed refundCap.
+	CreateAdjustment(ctx context.Context, a *entity.TicketAdjustment, refundCap int64) error
+}
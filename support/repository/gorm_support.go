@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/entity"
+	"github.com/mikios34/delivery-backend/support"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormSupportRepo implements support.Repository using GORM.
+type GormSupportRepo struct{ db *gorm.DB }
+
+func NewGormSupportRepo(db *gorm.DB) support.Repository { return &GormSupportRepo{db: db} }
+
+func (r *GormSupportRepo) GetOrder(ctx context.Context, id uuid.UUID) (*entity.Order, error) {
+	var o entity.Order
+	if err := r.db.WithContext(ctx).First(&o, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+func (r *GormSupportRepo) GetAdmin(ctx context.Context, id uuid.UUID) (*entity.Admin, error) {
+	var a entity.Admin
+	if err := r.db.WithContext(ctx).First(&a, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *GormSupportRepo) FindUnresolved(ctx context.Context, orderID uuid.UUID, openerRole string, openerID uuid.UUID) (*entity.SupportTicket, error) {
+	var t entity.SupportTicket
+	err := r.db.WithContext(ctx).
+		Where("order_id = ? AND opener_role = ? AND opener_id = ? AND status <> ?", orderID, openerRole, openerID, entity.TicketResolved).
+		First(&t).Error
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *GormSupportRepo) CreateTicket(ctx context.Context, t *entity.SupportTicket, first *entity.TicketMessage) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(t).Error; err != nil {
+			return err
+		}
+		first.TicketID = t.ID
+		return tx.Create(first).Error
+	})
+}
+
+func (r *GormSupportRepo) GetTicket(ctx context.Context, id uuid.UUID) (*entity.SupportTicket, error) {
+	var t entity.SupportTicket
+	if err := r.db.WithContext(ctx).First(&t, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *GormSupportRepo) ListTickets(ctx context.Context, f support.TicketFilter) ([]entity.SupportTicket, int64, error) {
+	base := func() *gorm.DB {
+		q := r.db.WithContext(ctx).Model(&entity.SupportTicket{})
+		if f.Status != "" {
+			q = q.Where("status = ?", f.Status)
+		}
+		if f.Category != "" {
+			q = q.Where("category = ?", f.Category)
+		}
+		if f.OrderID != nil {
+			q = q.Where("order_id = ?", *f.OrderID)
+		}
+		if f.OpenerRole != "" {
+			q = q.Where("opener_role = ?", f.OpenerRole)
+		}
+		if f.OpenerID != nil {
+			q = q.Where("opener_id = ?", *f.OpenerID)
+		}
+		if f.AssignedTo != nil {
+			q = q.Where("assigned_admin_id = ?", *f.AssignedTo)
+		}
+		if f.Unassigned {
+			q = q.Where("assigned_admin_id IS NULL")
+		}
+		return q
+	}
+	var total int64
+	if err := base().Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var list []entity.SupportTicket
+	err := base().Order("updated_at DESC").Limit(f.Limit).Offset(f.Offset).Find(&list).Error
+	return list, total, err
+}
+
+func (r *GormSupportRepo) UpdateTicket(ctx context.Context, id uuid.UUID, fields map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&entity.SupportTicket{}).Where("id = ?", id).Updates(fields).Error
+}
+
+func (r *GormSupportRepo) AddMessage(ctx context.Context, m *entity.TicketMessage, fields map[string]interface{}) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(m).Error; err != nil {
+			return err
+		}
+		return tx.Model(&entity.SupportTicket{}).Where("id = ?", m.TicketID).Updates(fields).Error
+	})
+}
+
+func (r *GormSupportRepo) ListMessages(ctx context.Context, ticketID uuid.UUID) ([]entity.TicketMessage, error) {
+	list := []entity.TicketMessage{}
+	err := r.db.WithContext(ctx).
+		Preload("Attachments", func(db *gorm.DB) *gorm.DB { return db.Order("created_at") }).
+		Where("ticket_id = ?", ticketID).
+		Order("created_at").
+		Find(&list).Error
+	return list, err
+}
+
+func (r *GormSupportRepo) GetAttachment(ctx context.Context, ticketID, attachmentID uuid.UUID) (*entity.TicketAttachment, error) {
+	var a entity.TicketAttachment
+	if err := r.db.WithContext(ctx).First(&a, "id = ? AND ticket_id = ?", attachmentID, ticketID).Error; err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *GormSupportRepo) ListAdjustments(ctx context.Context, ticketID uuid.UUID) ([]entity.TicketAdjustment, error) {
+	list := []entity.TicketAdjustment{}
+	err := r.db.WithContext(ctx).Where("ticket_id = ?", ticketID).Order("created_at").Find(&list).Error
+	return list, err
+}
+
+func (r *GormSupportRepo) CreateAdjustment(ctx context.Context, a *entity.TicketAdjustment, refundCap int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if a.Kind == entity.AdjustmentRefund {
+			// Lock the order so concurrent refunds cannot both pass the cap.
+			var o entity.Order
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&o, "id = ?", a.OrderID).Error; err != nil {
+				return err
+			}
+			var refunded int64
+			if err := tx.Model(&entity.TicketAdjustment{}).
+				Where("order_id = ? AND kind = ?", a.OrderID, entity.AdjustmentRefund).
+				Select("COALESCE(SUM(amount_cents), 0)").Scan(&refunded).Error; err != nil {
+				return err
+			}
+			if refunded+a.AmountCents > refundCap {
+				return support.ErrRefundExceedsPrice
+			}
+		}
+		return tx.Create(a).Error
+	})
+}
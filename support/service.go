@@ -0,0 +1,137 @@
+// Package support records complaints and disputes about orders. A customer or courier
+// opens a ticket against an order and talks to support through messages and images;
+// admins assign, answer and resolve tickets and issue refunds or adjustments.
+package support
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/entity"
+)
+
+const (
+	RoleCustomer = "customer"
+	RoleCourier  = "courier"
+	RoleAdmin    = "admin"
+)
+
+// Live events pushed through the realtime hub to the opener and to admin sockets.
+const (
+	// EventTicketUpdated carries the ticket after it was opened, assigned, answered or resolved.
+	EventTicketUpdated = "ticket.updated"
+	// EventTicketMessage carries a new message with its attachments.
+	EventTicketMessage = "ticket.message"
+	// EventTicketAdjustment carries a refund or adjustment issued on the ticket.
+	EventTicketAdjustment = "ticket.adjustment"
+)
+
+// MaxAttachmentBytes caps a single image upload.
+const MaxAttachmentBytes = 10 << 20
+
+var (
+	// ErrNotOrderParty is returned when the opener is neither the order's customer nor its assigned courier.
+	ErrNotOrderParty   = errors.New("forbidden: not the customer or courier of this order")
+	ErrInvalidCategory = errors.New("category must be damaged, late, wrong_address, payment or other")
+	// ErrInvalidBody is returned for blank or oversized messages.
+	ErrInvalidBody = errors.New("message body must be 1-4000 characters")
+	// ErrTicketExists is returned when the opener already has an unresolved ticket on the order.
+	ErrTicketExists = errors.New("an unresolved ticket for this order already exists")
+	// ErrTicketResolved is returned when writing to or resolving a resolved ticket.
+	ErrTicketResolved     = errors.New("ticket is resolved")
+	ErrResolutionRequired = errors.New("resolution is required")
+	// ErrInvalidAssignee is returned when assigning to an inactive admin or one without support.manage.
+	ErrInvalidAssignee = errors.New("assignee must be an active admin with support.manage")
+	// ErrUnsupportedImage is returned for files that are too large or not a JPEG or PNG.
+	ErrUnsupportedImage = errors.New("image must be a JPEG or PNG up to 10 MB")
+	// ErrStoreUnavailable is returned when no blob store is configured.
+	ErrStoreUnavailable = errors.New("attachment storage not configured")
+	// ErrInvalidAdjustment is returned for an unknown kind, a non-positive refund or a zero adjustment.
+	ErrInvalidAdjustment = errors.New("refunds need a positive amount_cents and adjustments a non-zero one")
+	ErrReasonRequired    = errors.New("reason is required")
+	// ErrRefundExceedsPrice is returned when the order's refunds would add up to more than its price.
+	ErrRefundExceedsPrice = errors.New("refunds would exceed the order price")
+	// ErrOrderNotServerPriced is returned for refunds on orders whose price came from the
+	// client, which cannot bound a refund.
+	ErrOrderNotServerPriced = errors.New("order has no server-computed price to cap refunds")
+)
+
+// Party identifies who is acting on a ticket.
+type Party struct {
+	Role string    // "customer", "courier" or "admin"
+	ID   uuid.UUID // customer, courier or admin profile id
+}
+
+// OpenRequest starts a ticket; Body becomes its first message.
+type OpenRequest struct {
+	Party    Party
+	OrderID  uuid.UUID
+	Category entity.TicketCategory
+	Subject  string
+	Body     string
+}
+
+// ImageUpload is one image sent to a ticket with an optional caption.
+type ImageUpload struct {
+	FileName string
+	Size     int64
+	Content  io.Reader
+	Caption  string
+}
+
+// TicketFilter selects tickets for listing. Zero fields do not filter.
+type TicketFilter struct {
+	Status     entity.TicketStatus
+	Category   entity.TicketCategory
+	OrderID    *uuid.UUID
+	OpenerRole string
+	OpenerID   *uuid.UUID
+	AssignedTo *uuid.UUID
+	Unassigned bool
+	Limit      int
+	Offset     int
+}
+
+// TicketDetail is a ticket with its conversation (oldest first) and adjustments.
+type TicketDetail struct {
+	Ticket      entity.SupportTicket      `json:"ticket"`
+	Messages    []entity.TicketMessage    `json:"messages"`
+	Adjustments []entity.TicketAdjustment `json:"adjustments"`
+}
+
+// AdjustmentRequest issues a refund or adjustment; AdminID is the issuer.
+type AdjustmentRequest struct {
+	Kind        entity.AdjustmentKind
+	AmountCents int64
+	Reason      string
+	AdminID     uuid.UUID
+}
+
+// Notifier delivers live events; the realtime hub implements it.
+type Notifier interface {
+	Notify(courierID string, event string, payload any) error
+	NotifyCustomer(customerID string, event string, payload any) error
+	BroadcastAdmins(event string, payload any)
+}
+
+// Service manages tickets. Methods taking a Party return gorm.ErrRecordNotFound for
+// tickets the party did not open, unless the party is an admin.
+type Service interface {
+	Open(ctx context.Context, req OpenRequest) (*entity.SupportTicket, error)
+	List(ctx context.Context, f TicketFilter) ([]entity.SupportTicket, int64, error)
+	Get(ctx context.Context, id uuid.UUID, p Party) (*TicketDetail, error)
+	// Reply adds a message. A reply by the opener reopens a pending ticket; a reply by an
+	// admin makes it pending and assigns the ticket to them if nobody has it.
+	Reply(ctx context.Context, id uuid.UUID, p Party, body string) (*entity.TicketMessage, error)
+	// AttachImage adds a message carrying one image, with the same effect as Reply.
+	AttachImage(ctx context.Context, id uuid.UUID, p Party, img ImageUpload) (*entity.TicketMessage, error)
+	// OpenAttachment returns an image's metadata and contents; the caller closes the reader.
+	OpenAttachment(ctx context.Context, id, attachmentID uuid.UUID, p Party) (*entity.TicketAttachment, io.ReadCloser, error)
+	Assign(ctx context.Context, id, adminID uuid.UUID) (*entity.SupportTicket, error)
+	Resolve(ctx context.Context, id, adminID uuid.UUID, resolution string) (*entity.SupportTicket, error)
+	// Adjust records a refund or adjustment. Refunds on an order may add up to at most its
+	// estimated price.
+	Adjust(ctx context.Context, id uuid.UUID, req AdjustmentRequest) (*entity.TicketAdjustment, error)
+}
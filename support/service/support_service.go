@@ -0,0 +1,379 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/admin"
+	"github.com/mikios34/delivery-backend/blob"
+	"github.com/mikios34/delivery-backend/entity"
+	"github.com/mikios34/delivery-backend/support"
+	"gorm.io/gorm"
+)
+
+// supportService implements support.Service.
+type supportService struct {
+	repo     support.Repository
+	images   blob.Store
+	notifier support.Notifier
+}
+
+// maxBodyLen caps a message in characters.
+const maxBodyLen = 4000
+
+// imageTypes are the sniffed content types accepted for ticket images.
+var imageTypes = map[string]bool{"image/jpeg": true, "image/png": true}
+
+// NewSupportService constructs a support.Service. images stores attachments (uploads fail
+// when it is nil); notifier pushes live events and may be nil.
+func NewSupportService(repo support.Repository, images blob.Store, notifier support.Notifier) support.Service {
+	return &supportService{repo: repo, images: images, notifier: notifier}
+}
+
+func (s *supportService) Open(ctx context.Context, req support.OpenRequest) (*entity.SupportTicket, error) {
+	switch req.Category {
+	case entity.TicketDamaged, entity.TicketLate, entity.TicketWrongAddress, entity.TicketPayment, entity.TicketOther:
+	default:
+		return nil, support.ErrInvalidCategory
+	}
+	body, err := checkBody(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	o, err := s.repo.GetOrder(ctx, req.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	party := req.Party
+	switch {
+	case party.Role == support.RoleCustomer && o.CustomerID == party.ID:
+	case party.Role == support.RoleCourier && o.AssignedCourier != nil && *o.AssignedCourier == party.ID:
+	default:
+		return nil, support.ErrNotOrderParty
+	}
+	if existing, err := s.repo.FindUnresolved(ctx, o.ID, party.Role, party.ID); err == nil {
+		return existing, support.ErrTicketExists
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	subject := strings.TrimSpace(req.Subject)
+	if subject == "" {
+		subject = string(req.Category)
+	}
+	t := &entity.SupportTicket{
+		OrderID:    o.ID,
+		OpenerRole: party.Role,
+		OpenerID:   party.ID,
+		Category:   req.Category,
+		Subject:    subject,
+		Status:     entity.TicketOpen,
+	}
+	first := &entity.TicketMessage{AuthorRole: party.Role, AuthorID: party.ID, Body: body}
+	if err := s.repo.CreateTicket(ctx, t, first); err != nil {
+		return nil, err
+	}
+	s.broadcast(support.EventTicketUpdated, t)
+	return t, nil
+}
+
+func (s *supportService) List(ctx context.Context, f support.TicketFilter) ([]entity.SupportTicket, int64, error) {
+	list, total, err := s.repo.ListTickets(ctx, f)
+	if err != nil {
+		return nil, 0, err
+	}
+	if list == nil {
+		list = []entity.SupportTicket{}
+	}
+	return list, total, nil
+}
+
+func (s *supportService) Get(ctx context.Context, id uuid.UUID, p support.Party) (*support.TicketDetail, error) {
+	t, err := s.ticketFor(ctx, id, p)
+	if err != nil {
+		return nil, err
+	}
+	msgs, err := s.repo.ListMessages(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	adjs, err := s.repo.ListAdjustments(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &support.TicketDetail{Ticket: *t, Messages: msgs, Adjustments: adjs}, nil
+}
+
+func (s *supportService) Reply(ctx context.Context, id uuid.UUID, p support.Party, body string) (*entity.TicketMessage, error) {
+	body, err := checkBody(body)
+	if err != nil {
+		return nil, err
+	}
+	t, err := s.ticketFor(ctx, id, p)
+	if err != nil {
+		return nil, err
+	}
+	m := &entity.TicketMessage{TicketID: t.ID, AuthorRole: p.Role, AuthorID: p.ID, Body: body}
+	if err := s.addMessage(ctx, t, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (s *supportService) AttachImage(ctx context.Context, id uuid.UUID, p support.Party, img support.ImageUpload) (*entity.TicketMessage, error) {
+	if s.images == nil {
+		return nil, support.ErrStoreUnavailable
+	}
+	caption := strings.TrimSpace(img.Caption)
+	if utf8.RuneCountInString(caption) > maxBodyLen {
+		return nil, support.ErrInvalidBody
+	}
+	if img.Size > support.MaxAttachmentBytes {
+		return nil, support.ErrUnsupportedImage
+	}
+	t, err := s.ticketFor(ctx, id, p)
+	if err != nil {
+		return nil, err
+	}
+	if t.Status == entity.TicketResolved {
+		return nil, support.ErrTicketResolved
+	}
+
+	// Trust the bytes rather than the client's Content-Type header.
+	head := make([]byte, 512)
+	n, err := io.ReadFull(img.Content, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	head = head[:n]
+	contentType := http.DetectContentType(head)
+	if !imageTypes[contentType] {
+		return nil, support.ErrUnsupportedImage
+	}
+	// Read one byte past the cap so oversized bodies with a lying size are caught.
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(io.LimitReader(io.MultiReader(bytes.NewReader(head), img.Content), support.MaxAttachmentBytes+1)); err != nil {
+		return nil, err
+	}
+	if buf.Len() > support.MaxAttachmentBytes {
+		return nil, support.ErrUnsupportedImage
+	}
+
+	m := &entity.TicketMessage{ID: uuid.New(), TicketID: t.ID, AuthorRole: p.Role, AuthorID: p.ID, Body: caption}
+	att := entity.TicketAttachment{
+		ID:          uuid.New(),
+		TicketID:    t.ID,
+		MessageID:   m.ID,
+		FileName:    strings.TrimSpace(img.FileName),
+		ContentType: contentType,
+		SizeBytes:   int64(buf.Len()),
+	}
+	att.BlobKey = fmt.Sprintf("tickets/%s/%s", t.ID, att.ID)
+	if err := s.images.Put(ctx, att.BlobKey, &buf); err != nil {
+		return nil, err
+	}
+	m.Attachments = []entity.TicketAttachment{att}
+	if err := s.addMessage(ctx, t, m); err != nil {
+		_ = s.images.Delete(ctx, att.BlobKey)
+		return nil, err
+	}
+	return m, nil
+}
+
+func (s *supportService) OpenAttachment(ctx context.Context, id, attachmentID uuid.UUID, p support.Party) (*entity.TicketAttachment, io.ReadCloser, error) {
+	if s.images == nil {
+		return nil, nil, support.ErrStoreUnavailable
+	}
+	if _, err := s.ticketFor(ctx, id, p); err != nil {
+		return nil, nil, err
+	}
+	att, err := s.repo.GetAttachment(ctx, id, attachmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	rc, err := s.images.Open(ctx, att.BlobKey)
+	if errors.Is(err, blob.ErrNotFound) {
+		return nil, nil, gorm.ErrRecordNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return att, rc, nil
+}
+
+func (s *supportService) Assign(ctx context.Context, id, adminID uuid.UUID) (*entity.SupportTicket, error) {
+	t, err := s.repo.GetTicket(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if t.Status == entity.TicketResolved {
+		return nil, support.ErrTicketResolved
+	}
+	a, err := s.repo.GetAdmin(ctx, adminID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, support.ErrInvalidAssignee
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !a.Active || !admin.HasPermission(a.Role, admin.PermManageSupport) {
+		return nil, support.ErrInvalidAssignee
+	}
+	if err := s.repo.UpdateTicket(ctx, id, map[string]interface{}{"assigned_admin_id": adminID}); err != nil {
+		return nil, err
+	}
+	return s.reload(ctx, id)
+}
+
+func (s *supportService) Resolve(ctx context.Context, id, adminID uuid.UUID, resolution string) (*entity.SupportTicket, error) {
+	resolution = strings.TrimSpace(resolution)
+	if resolution == "" {
+		return nil, support.ErrResolutionRequired
+	}
+	t, err := s.repo.GetTicket(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if t.Status == entity.TicketResolved {
+		return nil, support.ErrTicketResolved
+	}
+	fields := map[string]interface{}{
+		"status":      entity.TicketResolved,
+		"resolution":  resolution,
+		"resolved_at": time.Now(),
+		"resolved_by": adminID,
+	}
+	if t.AssignedAdminID == nil {
+		fields["assigned_admin_id"] = adminID
+	}
+	if err := s.repo.UpdateTicket(ctx, id, fields); err != nil {
+		return nil, err
+	}
+	return s.reload(ctx, id)
+}
+
+func (s *supportService) Adjust(ctx context.Context, id uuid.UUID, req support.AdjustmentRequest) (*entity.TicketAdjustment, error) {
+	switch {
+	case req.Kind == entity.AdjustmentRefund && req.AmountCents > 0:
+	case req.Kind == entity.AdjustmentCorrection && req.AmountCents != 0:
+	default:
+		return nil, support.ErrInvalidAdjustment
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return nil, support.ErrReasonRequired
+	}
+	t, err := s.repo.GetTicket(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	o, err := s.repo.GetOrder(ctx, t.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Kind == entity.AdjustmentRefund && !o.ServerPriced {
+		return nil, support.ErrOrderNotServerPriced
+	}
+	a := &entity.TicketAdjustment{
+		TicketID:    t.ID,
+		OrderID:     t.OrderID,
+		Kind:        req.Kind,
+		AmountCents: req.AmountCents,
+		Reason:      reason,
+		IssuedBy:    req.AdminID,
+	}
+	if err := s.repo.CreateAdjustment(ctx, a, o.EstimatedPriceCents); err != nil {
+		return nil, err
+	}
+	s.notifyOpener(t, support.EventTicketAdjustment, a)
+	s.broadcast(support.EventTicketAdjustment, a)
+	return a, nil
+}
+
+// ticketFor loads the ticket if p may see it.
+func (s *supportService) ticketFor(ctx context.Context, id uuid.UUID, p support.Party) (*entity.SupportTicket, error) {
+	t, err := s.repo.GetTicket(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if p.Role != support.RoleAdmin && (t.OpenerRole != p.Role || t.OpenerID != p.ID) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return t, nil
+}
+
+// addMessage stores m, moves the ticket to whoever has to answer next and pushes both.
+func (s *supportService) addMessage(ctx context.Context, t *entity.SupportTicket, m *entity.TicketMessage) error {
+	if t.Status == entity.TicketResolved {
+		return support.ErrTicketResolved
+	}
+	// updated_at is always set so the ticket sorts as recently active.
+	fields := map[string]interface{}{"updated_at": time.Now()}
+	if m.AuthorRole == support.RoleAdmin {
+		fields["status"] = entity.TicketPending
+		if t.AssignedAdminID == nil {
+			fields["assigned_admin_id"] = m.AuthorID
+		}
+	} else {
+		fields["status"] = entity.TicketOpen
+	}
+	if err := s.repo.AddMessage(ctx, m, fields); err != nil {
+		return err
+	}
+	if m.Attachments == nil {
+		m.Attachments = []entity.TicketAttachment{}
+	}
+	if m.AuthorRole == support.RoleAdmin {
+		s.notifyOpener(t, support.EventTicketMessage, m)
+	}
+	s.broadcast(support.EventTicketMessage, m)
+	if _, assigned := fields["assigned_admin_id"]; assigned || fields["status"] != t.Status {
+		_, _ = s.reload(ctx, t.ID)
+	}
+	return nil
+}
+
+// reload returns the ticket after an admin change and pushes it to the opener and admins.
+func (s *supportService) reload(ctx context.Context, id uuid.UUID) (*entity.SupportTicket, error) {
+	t, err := s.repo.GetTicket(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.notifyOpener(t, support.EventTicketUpdated, t)
+	s.broadcast(support.EventTicketUpdated, t)
+	return t, nil
+}
+
+func (s *supportService) notifyOpener(t *entity.SupportTicket, event string, payload any) {
+	if s.notifier == nil {
+		return
+	}
+	switch t.OpenerRole {
+	case support.RoleCustomer:
+		_ = s.notifier.NotifyCustomer(t.OpenerID.String(), event, payload)
+	case support.RoleCourier:
+		_ = s.notifier.Notify(t.OpenerID.String(), event, payload)
+	}
+}
+
+func (s *supportService) broadcast(event string, payload any) {
+	if s.notifier != nil {
+		s.notifier.BroadcastAdmins(event, payload)
+	}
+}
+
+func checkBody(body string) (string, error) {
+	body = strings.TrimSpace(body)
+	if body == "" || utf8.RuneCountInString(body) > maxBodyLen {
+		return "", support.ErrInvalidBody
+	}
+	return body, nil
+}
@@ -0,0 +1,153 @@
+package tracking
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/courier"
+	"github.com/mikios34/delivery-backend/entity"
+	"github.com/mikios34/delivery-backend/order"
+	"gorm.io/gorm"
+)
+
+// linkTTL is how long a shared tracking link stays valid.
+const linkTTL = 24 * time.Hour
+
+var (
+	// ErrLinkNotFound is returned for unknown tokens.
+	ErrLinkNotFound = errors.New("tracking link not found")
+	// ErrLinkExpired is returned for tokens past their expiry.
+	ErrLinkExpired = errors.New("tracking link expired")
+)
+
+// PublicLocation is the courier position shown on the public tracking page.
+type PublicLocation struct {
+	Latitude  float64    `json:"latitude"`
+	Longitude float64    `json:"longitude"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// PublicView is what an unauthenticated receiver sees. It deliberately omits the customer,
+// the pickup address and every phone number.
+type PublicView struct {
+	Status           entity.OrderStatus `json:"status"`
+	DropoffAddress   string             `json:"dropoff_address"`
+	CourierFirstName *string            `json:"courier_first_name,omitempty"`
+	// CourierLocation and ETA are only shared while the courier is on the way.
+	CourierLocation *PublicLocation `json:"courier_location,omitempty"`
+	NextStop        string          `json:"next_stop,omitempty"`
+	ETASeconds      *int64          `json:"eta_seconds,omitempty"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+	ExpiresAt       time.Time       `json:"expires_at"`
+}
+
+// LinkService issues and resolves public tracking links.
+type LinkService interface {
+	// CreateLink issues a new token for the order. The plain token is only returned here.
+	CreateLink(ctx context.Context, orderID uuid.UUID) (token string, link *entity.OrderTrackingLink, err error)
+	// Resolve returns the link for a token, rejecting unknown or expired ones.
+	Resolve(ctx context.Context, token string) (*entity.OrderTrackingLink, error)
+	// View builds the privacy-filtered tracking view for a resolved link.
+	View(ctx context.Context, link *entity.OrderTrackingLink) (*PublicView, error)
+}
+
+type linkService struct {
+	links    LinkRepository
+	orders   order.Repository
+	couriers courier.CourierRepository
+}
+
+func NewLinkService(links LinkRepository, orders order.Repository, couriers courier.CourierRepository) LinkService {
+	return &linkService{links: links, orders: orders, couriers: couriers}
+}
+
+func (s *linkService) CreateLink(ctx context.Context, orderID uuid.UUID) (string, *entity.OrderTrackingLink, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	l := &entity.OrderTrackingLink{
+		OrderID:   orderID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(linkTTL),
+	}
+	created, err := s.links.CreateLink(ctx, l)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, created, nil
+}
+
+func (s *linkService) Resolve(ctx context.Context, token string) (*entity.OrderTrackingLink, error) {
+	if token == "" {
+		return nil, ErrLinkNotFound
+	}
+	l, err := s.links.GetLinkByTokenHash(ctx, hashToken(token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrLinkNotFound
+		}
+		return nil, err
+	}
+	if time.Now().After(l.ExpiresAt) {
+		return nil, ErrLinkExpired
+	}
+	return l, nil
+}
+
+func (s *linkService) View(ctx context.Context, link *entity.OrderTrackingLink) (*PublicView, error) {
+	ord, err := s.orders.GetOrderByID(ctx, link.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	v := &PublicView{
+		Status:         ord.Status,
+		DropoffAddress: ord.DropoffAddress,
+		UpdatedAt:      ord.UpdatedAt,
+		ExpiresAt:      link.ExpiresAt,
+	}
+	if ord.AssignedCourier == nil || !showsCourier(ord.Status) {
+		return v, nil
+	}
+	if user, err := s.couriers.GetUserByCourierID(ctx, *ord.AssignedCourier); err == nil {
+		name := user.FirstName
+		v.CourierFirstName = &name
+	}
+	// Location sharing stops as soon as the order is no longer in progress.
+	if !isTrackable(ord.Status) {
+		return v, nil
+	}
+	cour, err := s.couriers.GetCourierByID(ctx, *ord.AssignedCourier)
+	if err != nil || cour.Latitude == nil || cour.Longitude == nil {
+		return v, nil
+	}
+	v.CourierLocation = &PublicLocation{Latitude: *cour.Latitude, Longitude: *cour.Longitude, UpdatedAt: cour.LocationUpdatedAt}
+	stopLat, stopLng := ord.PickupLat, ord.PickupLng
+	v.NextStop = "pickup"
+	if ord.Status == entity.OrderPickedUp {
+		stopLat, stopLng = ord.DropoffLat, ord.DropoffLng
+		v.NextStop = "dropoff"
+	}
+	if stopLat != nil && stopLng != nil {
+		eta := EstimateETA(*cour.Latitude, *cour.Longitude, *stopLat, *stopLng, nil)
+		v.ETASeconds = &eta
+	}
+	return v, nil
+}
+
+// showsCourier reports whether the courier's first name may be shown for the status.
+func showsCourier(status entity.OrderStatus) bool {
+	return isTrackable(status) || status == entity.OrderDelivered
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
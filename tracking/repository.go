@@ -0,0 +1,13 @@
+package tracking
+
+import (
+	"context"
+
+	"github.com/mikios34/delivery-backend/entity"
+)
+
+// LinkRepository persists public tracking links.
+type LinkRepository interface {
+	CreateLink(ctx context.Context, l *entity.OrderTrackingLink) (*entity.OrderTrackingLink, error)
+	GetLinkByTokenHash(ctx context.Context, tokenHash string) (*entity.OrderTrackingLink, error)
+}
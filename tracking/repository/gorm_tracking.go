@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/mikios34/delivery-backend/entity"
+	"github.com/mikios34/delivery-backend/tracking"
+	"gorm.io/gorm"
+)
+
+// GormTrackingRepo implements tracking.LinkRepository using GORM.
+type GormTrackingRepo struct {
+	db *gorm.DB
+}
+
+func NewGormTrackingRepo(db *gorm.DB) tracking.LinkRepository {
+	return &GormTrackingRepo{db: db}
+}
+
+func (r *GormTrackingRepo) CreateLink(ctx context.Context, l *entity.OrderTrackingLink) (*entity.OrderTrackingLink, error) {
+	if err := r.db.WithContext(ctx).Create(l).Error; err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (r *GormTrackingRepo) GetLinkByTokenHash(ctx context.Context, tokenHash string) (*entity.OrderTrackingLink, error) {
+	var l entity.OrderTrackingLink
+	if err := r.db.WithContext(ctx).First(&l, "token_hash = ?", tokenHash).Error; err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
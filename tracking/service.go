@@ -0,0 +1,136 @@
+package tracking
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mikios34/delivery-backend/entity"
+	"github.com/mikios34/delivery-backend/order"
+	"github.com/mikios34/delivery-backend/realtime"
+)
+
+// minForwardInterval throttles courier.location events per courier so a chatty client
+// does not flood the customer socket.
+const minForwardInterval = 2 * time.Second
+
+// defaultSpeedKmh is used for ETA when the courier does not report a usable speed.
+const defaultSpeedKmh = 25.0
+
+// LocationUpdate is a courier position sample as received from the courier app.
+// Heading is in degrees (0-360, clockwise from north) and Speed in meters per second.
+type LocationUpdate struct {
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+	Heading   *float64 `json:"heading,omitempty"`
+	Speed     *float64 `json:"speed,omitempty"`
+}
+
+// Service forwards live courier positions to the customer of the courier's active order.
+type Service interface {
+	// CourierMoved forwards a location sample to the customer when the courier has an
+	// active order in accepted/arrived/picked_up. Samples are throttled per courier.
+	CourierMoved(ctx context.Context, courierID uuid.UUID, loc LocationUpdate) error
+}
+
+type service struct {
+	orders order.Repository
+	hub    *realtime.Hub
+
+	mu       sync.Mutex
+	lastSent map[uuid.UUID]time.Time
+}
+
+func New(orders order.Repository, hub *realtime.Hub) Service {
+	return &service{orders: orders, hub: hub, lastSent: make(map[uuid.UUID]time.Time)}
+}
+
+func (s *service) CourierMoved(ctx context.Context, courierID uuid.UUID, loc LocationUpdate) error {
+	if s.hub == nil || loc.Latitude == nil || loc.Longitude == nil {
+		return nil
+	}
+	ord, err := s.orders.GetActiveOrderForCourier(ctx, courierID)
+	if err != nil {
+		return err
+	}
+	// Only share location once the courier has committed to the order and until it is completed.
+	if ord == nil || !isTrackable(ord.Status) {
+		s.forget(courierID)
+		return nil
+	}
+	if !s.allow(courierID) {
+		return nil
+	}
+
+	payload := realtime.CourierLocationPayload{
+		OrderID:   ord.ID.String(),
+		CourierID: courierID.String(),
+		Latitude:  *loc.Latitude,
+		Longitude: *loc.Longitude,
+		Heading:   loc.Heading,
+		Speed:     loc.Speed,
+		UpdatedAt: time.Now().UTC(),
+	}
+	// Next stop is the pickup until the package is picked up, then the dropoff.
+	stopLat, stopLng := ord.PickupLat, ord.PickupLng
+	payload.NextStop = "pickup"
+	if ord.Status == entity.OrderPickedUp {
+		stopLat, stopLng = ord.DropoffLat, ord.DropoffLng
+		payload.NextStop = "dropoff"
+	}
+	if stopLat != nil && stopLng != nil {
+		eta := EstimateETA(*loc.Latitude, *loc.Longitude, *stopLat, *stopLng, loc.Speed)
+		payload.ETASeconds = &eta
+	}
+	return s.hub.NotifyCustomer(ord.CustomerID.String(), "courier.location", payload)
+}
+
+// allow reports whether enough time has passed since the last forwarded sample for the courier.
+func (s *service) allow(courierID uuid.UUID) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if last, ok := s.lastSent[courierID]; ok && now.Sub(last) < minForwardInterval {
+		return false
+	}
+	s.lastSent[courierID] = now
+	return true
+}
+
+func (s *service) forget(courierID uuid.UUID) {
+	s.mu.Lock()
+	delete(s.lastSent, courierID)
+	s.mu.Unlock()
+}
+
+func isTrackable(status entity.OrderStatus) bool {
+	switch status {
+	case entity.OrderAccepted, entity.OrderArrived, entity.OrderPickedUp:
+		return true
+	}
+	return false
+}
+
+// EstimateETA returns the straight-line travel time in seconds between two points.
+// speedMps is the courier-reported speed; slow or missing speeds fall back to a city average.
+func EstimateETA(fromLat, fromLng, toLat, toLng float64, speedMps *float64) int64 {
+	distKm := HaversineKm(fromLat, fromLng, toLat, toLng)
+	speedKmh := defaultSpeedKmh
+	// Ignore near-stationary samples (e.g. waiting at a light) which would explode the ETA.
+	if speedMps != nil && *speedMps > 1 {
+		speedKmh = *speedMps * 3.6
+	}
+	return int64(math.Round(distKm / speedKmh * 3600))
+}
+
+// HaversineKm returns the great-circle distance in km between two coordinates.
+func HaversineKm(lat1, lon1, lat2, lon2 float64) float64 {
+	const R = 6371.0 // Earth radius in km
+	toRad := func(d float64) float64 { return d * (math.Pi / 180.0) }
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return R * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1.0-a))
+}